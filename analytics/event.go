@@ -0,0 +1,45 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType is the type of an analytics event.
+type EventType string
+
+const (
+	// EventJoin is sent when a player joins the proxy and connects to their first server.
+	EventJoin EventType = "join"
+	// EventQuit is sent when a player leaves the proxy.
+	EventQuit EventType = "quit"
+	// EventTransfer is sent when a player is transferred to a different server.
+	EventTransfer EventType = "transfer"
+	// EventChat is sent when a player sends a chat message.
+	EventChat EventType = "chat"
+	// EventCommand is sent when a player runs a command.
+	EventCommand EventType = "command"
+	// EventKick is sent when a player is kicked by the server they are connected to.
+	EventKick EventType = "kick"
+)
+
+// Event is a structured analytics event about a player on the proxy.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType `json:"type"`
+	// Time is the time at which the event happened.
+	Time time.Time `json:"time"`
+	// PlayerUUID is the UUID of the player the event is about.
+	PlayerUUID uuid.UUID `json:"player_uuid"`
+	// PlayerName is the name of the player the event is about.
+	PlayerName string `json:"player_name"`
+	// Server is the name of the server the player was connected to when the event happened.
+	Server string `json:"server,omitempty"`
+	// Data holds additional data specific to the type of the event, such as the chat message for EventChat or
+	// the destination server for EventTransfer.
+	Data map[string]any `json:"data,omitempty"`
+	// Experiments holds the bucket the player is assigned to for every running experiment, mapped by the names
+	// of the experiments, so that events can be compared between buckets.
+	Experiments map[string]int `json:"experiments,omitempty"`
+}
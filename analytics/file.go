@@ -0,0 +1,39 @@
+package analytics
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FileSink is a Sink that writes events to a file in the JSON lines format, meaning every event is written as a
+// JSON object on its own line.
+type FileSink struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewFileSink creates a FileSink that appends events to the file at the path passed. The file is created if it
+// does not yet exist.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Send ...
+func (s *FileSink) Send(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+// Close ...
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
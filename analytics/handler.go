@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"go.uber.org/atomic"
+)
+
+// Handler is a session.Handler that sends analytics events about a session to a Sink. It may be added to every
+// session on the proxy by passing NewHandler to portal.Handle.
+type Handler struct {
+	session.NopHandler
+
+	s    *session.Session
+	sink Sink
+
+	joined atomic.Bool
+}
+
+// NewHandler returns a function that creates a Handler for a session which sends its events to the sink passed.
+// The function returned may be passed to portal.Handle directly.
+func NewHandler(sink Sink) func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &Handler{s: s, sink: sink}
+	}
+}
+
+// HandleServerConnect ...
+func (h *Handler) HandleServerConnect(srv *server.Server, _ *minecraft.Conn) {
+	if h.joined.CAS(false, true) {
+		h.send(EventJoin, srv, nil)
+	}
+}
+
+// HandleTransfer ...
+func (h *Handler) HandleTransfer(ctx *event.Context, srv *server.Server) {
+	from := h.s.Server()
+	ctx.After(func(cancelled bool) {
+		if cancelled {
+			return
+		}
+		if dst, ok := event.Load(ctx, session.TransferServer); ok && dst != nil {
+			srv = dst
+		}
+		h.send(EventTransfer, from, map[string]any{"destination": srv.Name()})
+	})
+}
+
+// HandleServerBoundPacket ...
+func (h *Handler) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	switch pk := pk.(type) {
+	case *packet.Text:
+		if pk.TextType != packet.TextTypeChat {
+			return
+		}
+		// Messages are only recorded once every handler had the chance to cancel them, such as a chat filter, so
+		// that messages that never reached the server are left out.
+		ctx.After(func(cancelled bool) {
+			if !cancelled {
+				h.send(EventChat, h.s.Server(), map[string]any{"message": pk.Message})
+			}
+		})
+	case *packet.CommandRequest:
+		// Like chat messages, commands are only recorded if no handler cancelled them.
+		ctx.After(func(cancelled bool) {
+			if !cancelled {
+				h.send(EventCommand, h.s.Server(), map[string]any{"command": strings.TrimPrefix(pk.CommandLine, "/")})
+			}
+		})
+	}
+}
+
+// HandleServerKick ...
+func (h *Handler) HandleServerKick(_ *event.Context, srv *server.Server, message string) {
+	h.send(EventKick, srv, map[string]any{"message": message})
+}
+
+// HandleQuit ...
+func (h *Handler) HandleQuit(reason session.CloseReason) {
+	srv, _ := h.s.TryServer()
+	h.send(EventQuit, srv, map[string]any{"reason": reason.String()})
+}
+
+// send sends an event of the type passed to the sink of the handler.
+func (h *Handler) send(t EventType, srv *server.Server, data map[string]any) {
+	e := Event{
+		Type:        t,
+		Time:        time.Now(),
+		PlayerUUID:  h.s.UUID(),
+		PlayerName:  h.s.IdentityData().DisplayName,
+		Data:        data,
+		Experiments: h.s.Buckets(),
+	}
+	if srv != nil {
+		e.Server = srv.Name()
+	}
+	_ = h.sink.Send(e)
+}
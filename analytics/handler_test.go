@@ -0,0 +1,74 @@
+package analytics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paroxity/portal"
+	"github.com/paroxity/portal/analytics"
+	"github.com/paroxity/portal/portaltest"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// TestHandlerKick tests that an EventKick is sent when the server of a player disconnects them with a message.
+func TestHandlerKick(t *testing.T) {
+	lobby, err := portaltest.NewServer("lobby")
+	if err != nil {
+		t.Fatalf("start lobby: %v", err)
+	}
+	defer lobby.Close()
+	p, err := portaltest.NewProxy(portal.Options{}, lobby)
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	defer p.Close()
+	sink := make(chanSink, 16)
+	p.Handle(analytics.NewHandler(sink))
+
+	client, err := portaltest.Dial(p.Addr(), "Steve")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	conn, err := lobby.Accept(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("accept lobby: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WritePacket(&packet.Disconnect{Message: "You have been banned."}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	timeout := time.After(portaltest.Timeout)
+	for {
+		select {
+		case e := <-sink:
+			if e.Type != analytics.EventKick {
+				continue
+			}
+			if e.Server != "lobby" || e.Data["message"] != "You have been banned." {
+				t.Fatalf("expected kick from lobby with the message of the server, got %+v", e)
+			}
+			return
+		case <-timeout:
+			t.Fatalf("expected a kick event within %v", portaltest.Timeout)
+		}
+	}
+}
+
+// chanSink is an analytics.Sink that sends every event to the channel.
+type chanSink chan analytics.Event
+
+// Send ...
+func (c chanSink) Send(e analytics.Event) error {
+	select {
+	case c <- e:
+	default:
+	}
+	return nil
+}
+
+// Close ...
+func (c chanSink) Close() error {
+	return nil
+}
@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPSink is a Sink that sends events in batches to an HTTP endpoint. Each batch is sent as a POST request with
+// the events in the JSON lines format as body. This is compatible with the HTTP interface of ClickHouse, using
+// a URL such as "http://localhost:8123/?query=INSERT%20INTO%20events%20FORMAT%20JSONEachRow".
+type HTTPSink struct {
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	count int
+	size  int
+
+	closing chan struct{}
+	closed  chan struct{}
+	errf    func(err error)
+}
+
+// NewHTTPSink creates an HTTPSink that sends events to the URL passed. Events are sent once size events have
+// been buffered, or every interval, whichever comes first. The function passed is called with any error that
+// occurs while sending a batch, and may be nil.
+func NewHTTPSink(url string, size int, interval time.Duration, errf func(err error)) *HTTPSink {
+	if errf == nil {
+		errf = func(error) {}
+	}
+	s := &HTTPSink{
+		url:     url,
+		client:  &http.Client{Timeout: time.Second * 10},
+		size:    size,
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+		errf:    errf,
+	}
+	go s.run(interval)
+	return s
+}
+
+// Send ...
+func (s *HTTPSink) Send(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.buf.Write(data)
+	s.buf.WriteByte('\n')
+	s.count++
+	full := s.count >= s.size
+	s.mu.Unlock()
+
+	if full {
+		go s.flush()
+	}
+	return nil
+}
+
+// Close ...
+func (s *HTTPSink) Close() error {
+	close(s.closing)
+	<-s.closed
+	return nil
+}
+
+// run flushes the buffered events every interval until the sink is closed.
+func (s *HTTPSink) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.flush()
+		case <-s.closing:
+			s.flush()
+			close(s.closed)
+			return
+		}
+	}
+}
+
+// flush sends all buffered events to the endpoint of the sink.
+func (s *HTTPSink) flush() {
+	s.mu.Lock()
+	if s.count == 0 {
+		s.mu.Unlock()
+		return
+	}
+	body := append([]byte(nil), s.buf.Bytes()...)
+	s.buf.Reset()
+	s.count = 0
+	s.mu.Unlock()
+
+	resp, err := s.client.Post(s.url, "application/x-ndjson", bytes.NewReader(body))
+	if err != nil {
+		s.errf(err)
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		s.errf(fmt.Errorf("analytics endpoint responded with status %s", resp.Status))
+	}
+}
@@ -0,0 +1,23 @@
+package analytics
+
+// Sink receives analytics events from the proxy. Implementations may write the events to a file, send them to
+// a database or forward them to an external service. Send may be called concurrently from multiple sessions.
+type Sink interface {
+	// Send sends an event to the sink. Implementations should not block for long, as Send is called from the
+	// packet handling goroutines of sessions.
+	Send(e Event) error
+	// Close closes the sink, flushing any events that have not yet been sent.
+	Close() error
+}
+
+// NopSink is a Sink that discards all events sent to it.
+type NopSink struct{}
+
+// Compile time check to make sure NopSink implements Sink.
+var _ Sink = NopSink{}
+
+// Send ...
+func (NopSink) Send(Event) error { return nil }
+
+// Close ...
+func (NopSink) Close() error { return nil }
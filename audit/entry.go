@@ -0,0 +1,27 @@
+// Package audit implements audit logging of the commands run by players, either on the servers of the network
+// or on the proxy itself, for moderation forensics.
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry is a single command run by a player that was recorded in the audit log.
+type Entry struct {
+	// Time is the time at which the command was run.
+	Time time.Time `json:"time"`
+	// PlayerUUID is the UUID of the player that ran the command.
+	PlayerUUID uuid.UUID `json:"player_uuid"`
+	// PlayerName is the name of the player that ran the command.
+	PlayerName string `json:"player_name"`
+	// XUID is the XUID of the player that ran the command. It is empty if the player was not authenticated.
+	XUID string `json:"xuid,omitempty"`
+	// Server is the name of the server the player was connected to when running the command.
+	Server string `json:"server"`
+	// Command is the full command line that was run, without the leading slash.
+	Command string `json:"command"`
+	// Proxy is true if the command was handled by the proxy rather than forwarded to the server.
+	Proxy bool `json:"proxy"`
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"strings"
+	"time"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// NewHandler returns a function that creates a session.Handler which records every command sent by the session
+// to its server in the log passed. Commands cancelled by a handler, such as those handled by the proxy itself,
+// are not sent to the server and therefore not recorded by it. Errors that occur while recording are logged to the logger passed. The
+// function returned may be passed to portal.Handle directly.
+func NewHandler(l *Log, log internal.Logger) func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{l: l, log: log, s: s}
+	}
+}
+
+// handler is a session.Handler that records the commands of a single session.
+type handler struct {
+	session.NopHandler
+	l   *Log
+	log internal.Logger
+	s   *session.Session
+}
+
+// HandleServerBoundPacket ...
+func (h *handler) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	if pk, ok := pk.(*packet.CommandRequest); ok {
+		ctx.After(func(cancelled bool) {
+			if cancelled {
+				return
+			}
+			if err := h.l.Record(NewEntry(h.s, pk.CommandLine, false)); err != nil {
+				h.log.Errorf("failed to record command in audit log: %v", err)
+			}
+		})
+	}
+}
+
+// NewEntry returns an Entry for the command line passed run by the session passed. Proxy should be true if the
+// command is handled by the proxy itself. Proxy commands may be recorded using Log.Record with the entry
+// returned.
+func NewEntry(s *session.Session, command string, proxy bool) Entry {
+	identity := s.IdentityData()
+	e := Entry{
+		Time:       time.Now(),
+		PlayerUUID: s.UUID(),
+		PlayerName: identity.DisplayName,
+		XUID:       identity.XUID,
+		Command:    strings.TrimPrefix(command, "/"),
+		Proxy:      proxy,
+	}
+	if srv := s.Server(); srv != nil {
+		e.Server = srv.Name()
+	}
+	return e
+}
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Log records audit entries. Every entry is written to a writer in the JSON lines format, and the most recent
+// entries are kept in memory so that they can be queried.
+type Log struct {
+	mu      sync.Mutex
+	w       io.Writer
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewLog creates a new Log that writes entries to the writer passed, which may be nil to not write them
+// anywhere. At most capacity of the most recent entries are kept in memory for querying.
+func NewLog(w io.Writer, capacity int) *Log {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Log{w: w, entries: make([]Entry, capacity)}
+}
+
+// Record records the entry passed in the log.
+func (l *Log) Record(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+	if l.w == nil {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
+
+// Query returns up to limit of the most recent entries kept in memory, from newest to oldest. If player is not
+// empty, only entries of the player with that name are returned. If limit is zero or less, all matching entries
+// are returned.
+func (l *Log) Query(player string, limit int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	var entries []Entry
+	for i := 1; i <= n; i++ {
+		e := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if player != "" && !strings.EqualFold(e.PlayerName, player) {
+			continue
+		}
+		entries = append(entries, e)
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+	}
+	return entries
+}
@@ -0,0 +1,33 @@
+package portal
+
+import (
+	"github.com/paroxity/portal/broadcast"
+)
+
+// Broadcaster returns the broadcaster used by the proxy to send announcements to its players.
+func (p *Portal) Broadcaster() *broadcast.Broadcaster {
+	return p.broadcaster
+}
+
+// Broadcast sends a chat message to every player on the proxy. broadcast.ErrRateLimited is returned if too many
+// broadcasts were sent recently.
+func (p *Portal) Broadcast(message string) error {
+	return p.broadcaster.Message(broadcast.Target{}, message)
+}
+
+// BroadcastTitle shows a title with the subtitle passed to every player on the proxy. The subtitle may be empty.
+// broadcast.ErrRateLimited is returned if too many broadcasts were sent recently.
+func (p *Portal) BroadcastTitle(title, subtitle string) error {
+	return p.broadcaster.Title(broadcast.Target{}, title, subtitle)
+}
+
+// BroadcastTo sends a chat message to the players in the group or on the server selected by the target passed.
+func (p *Portal) BroadcastTo(t broadcast.Target, message string) error {
+	return p.broadcaster.Message(t, message)
+}
+
+// BroadcastTitleTo shows a title with the subtitle passed to the players in the group or on the server selected
+// by the target passed.
+func (p *Portal) BroadcastTitleTo(t broadcast.Target, title, subtitle string) error {
+	return p.broadcaster.Title(t, title, subtitle)
+}
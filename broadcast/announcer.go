@@ -0,0 +1,74 @@
+package broadcast
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/paroxity/portal/session"
+)
+
+// Mode is the way an announcement is shown to players.
+type Mode string
+
+const (
+	// ModeChat shows announcements as chat messages.
+	ModeChat Mode = "chat"
+	// ModeActionBar shows announcements above the hotbar.
+	ModeActionBar Mode = "actionbar"
+	// ModeTitle shows announcements as titles. The first line of a message is the title, and the remaining lines
+	// are the subtitle.
+	ModeTitle Mode = "title"
+)
+
+// Announcement is a list of messages that are announced one after another at an interval.
+type Announcement struct {
+	// Interval is the interval at which the next message is announced.
+	Interval time.Duration
+	// Messages holds the messages announced, in order. After the last message, the first is announced again.
+	Messages []string
+	// Target selects the players the messages are announced to.
+	Target Target
+	// Mode is the way the messages are shown. If empty, ModeChat is used.
+	Mode Mode
+}
+
+// Announce announces the messages of the announcement passed at its interval, until the stop channel passed is
+// closed. Announcements are not subject to the rate limit of the broadcaster. Announce blocks until it is
+// stopped, so it should be called in a separate goroutine. An error is returned if the announcement is invalid.
+func (b *Broadcaster) Announce(a Announcement, stop <-chan struct{}) error {
+	if len(a.Messages) == 0 {
+		return fmt.Errorf("announcement has no messages")
+	}
+	if a.Interval <= 0 {
+		return fmt.Errorf("announcement has an invalid interval %v", a.Interval)
+	}
+	var show func(s *session.Session, message string)
+	switch a.Mode {
+	case ModeChat, "":
+		show = (*session.Session).SendMessage
+	case ModeActionBar:
+		show = (*session.Session).SendActionBar
+	case ModeTitle:
+		show = func(s *session.Session, message string) {
+			title, subtitle, _ := strings.Cut(message, "\n")
+			s.SendTitle(title, subtitle)
+		}
+	default:
+		return fmt.Errorf("unknown announcement mode %q", a.Mode)
+	}
+
+	t := time.NewTicker(a.Interval)
+	defer t.Stop()
+	for i := 0; ; i = (i + 1) % len(a.Messages) {
+		select {
+		case <-t.C:
+		case <-stop:
+			return nil
+		}
+		message := a.Messages[i]
+		b.each(a.Target, func(s *session.Session) {
+			show(s, message)
+		})
+	}
+}
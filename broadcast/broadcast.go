@@ -0,0 +1,118 @@
+// Package broadcast implements sending announcements to many players on the proxy at once.
+package broadcast
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/session"
+)
+
+// ErrRateLimited is returned when a broadcast is refused because too many broadcasts were sent recently.
+var ErrRateLimited = errors.New("too many broadcasts sent recently")
+
+// Target selects the players a broadcast is sent to. The zero value selects every player on the proxy.
+type Target struct {
+	// Group is the name of the group whose players receive the broadcast. It may be empty.
+	Group string
+	// Server is the name of the server whose players receive the broadcast. It may be empty.
+	Server string
+}
+
+// matches returns if the session passed is selected by the target.
+func (t Target) matches(s *session.Session) bool {
+	if t.Group == "" && t.Server == "" {
+		return true
+	}
+	srv, ok := s.TryServer()
+	if !ok || srv == nil {
+		return false
+	}
+	if t.Server != "" && !strings.EqualFold(srv.Name(), t.Server) {
+		return false
+	}
+	return t.Group == "" || strings.EqualFold(srv.Group(), t.Group)
+}
+
+// Broadcaster sends announcements to the players of a session store, limiting the number of broadcasts that
+// may be sent within a window.
+type Broadcaster struct {
+	store *session.Store
+
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   []time.Time
+}
+
+// New returns a new Broadcaster which sends announcements to the sessions in the store passed. At most limit
+// broadcasts may be sent within the window passed. If limit is zero, broadcasts are not limited.
+func New(store *session.Store, limit int, window time.Duration) *Broadcaster {
+	return &Broadcaster{store: store, limit: limit, window: window}
+}
+
+// Message sends a chat message to every player selected by the target passed.
+func (b *Broadcaster) Message(t Target, message string) error {
+	return b.broadcast(t, func(s *session.Session) {
+		s.SendMessage(message)
+	})
+}
+
+// Title shows a title with the subtitle passed to every player selected by the target passed. The subtitle may
+// be empty.
+func (b *Broadcaster) Title(t Target, title, subtitle string) error {
+	return b.broadcast(t, func(s *session.Session) {
+		s.SendTitle(title, subtitle)
+	})
+}
+
+// ActionBar shows a message above the hotbar of every player selected by the target passed.
+func (b *Broadcaster) ActionBar(t Target, message string) error {
+	return b.broadcast(t, func(s *session.Session) {
+		s.SendActionBar(message)
+	})
+}
+
+// broadcast calls the function passed for every player selected by the target passed, unless the rate limit
+// was reached.
+func (b *Broadcaster) broadcast(t Target, f func(s *session.Session)) error {
+	if !b.allow() {
+		return ErrRateLimited
+	}
+	b.each(t, f)
+	return nil
+}
+
+// each calls the function passed for every player selected by the target passed, regardless of the rate limit.
+func (b *Broadcaster) each(t Target, f func(s *session.Session)) {
+	for _, s := range b.store.All() {
+		if s.LoggedIn() && t.matches(s) {
+			f(s)
+		}
+	}
+}
+
+// allow records a broadcast and returns if it is allowed by the rate limit.
+func (b *Broadcaster) allow() bool {
+	if b.limit <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	n := 0
+	for _, t := range b.sent {
+		if now.Sub(t) < b.window {
+			b.sent[n] = t
+			n++
+		}
+	}
+	b.sent = b.sent[:n]
+	if len(b.sent) >= b.limit {
+		return false
+	}
+	b.sent = append(b.sent, now)
+	return true
+}
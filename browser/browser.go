@@ -0,0 +1,168 @@
+// Package browser implements a server browser served by the proxy, which lets players pick a server to play on
+// from a form.
+package browser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/paroxity/portal/command"
+	"github.com/paroxity/portal/form"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+const (
+	// iconOnline is the icon of servers that are healthy and accept players.
+	iconOnline = "textures/ui/confirm"
+	// iconOffline is the icon of servers that are unhealthy or do not accept players.
+	iconOffline = "textures/ui/cancel"
+)
+
+// Browser lists the groups and servers of the proxy with their player counts and status in a form. Players may
+// select a server or group in it to be transferred to it.
+type Browser struct {
+	registry *server.Registry
+	forms    *form.Manager
+}
+
+// New returns a new Browser which lists the servers in the registry passed and sends its forms using the form
+// manager passed.
+func New(registry *server.Registry, forms *form.Manager) *Browser {
+	return &Browser{registry: registry, forms: forms}
+}
+
+// Command returns a command with the name passed which opens the browser.
+func (b *Browser) Command(name string) command.Command {
+	return command.Command{
+		Name:        name,
+		Description: "Opens the server browser",
+		Run: func(s *session.Session, _ []string) {
+			b.Open(s)
+		},
+	}
+}
+
+// Open opens the browser for the session passed, showing all groups and the servers that are not in a group.
+func (b *Browser) Open(s *session.Session) {
+	groups := make(map[string][]*server.Server)
+	var buttons []form.Button
+	for _, srv := range b.servers(b.registry.Servers()) {
+		if srv.Group() == "" {
+			buttons = append(buttons, b.serverButton(srv))
+			continue
+		}
+		groups[srv.Group()] = append(groups[srv.Group()], srv)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groupButtons := make([]form.Button, 0, len(names))
+	for _, name := range names {
+		name, servers := name, groups[name]
+		players, icon := 0, iconOffline
+		for _, srv := range servers {
+			players += srv.PlayerCount()
+			if available(srv) {
+				icon = iconOnline
+			}
+		}
+		groupButtons = append(groupButtons, form.Button{
+			Text:  fmt.Sprintf("%s\n%d playing", name, players),
+			Image: icon,
+			Press: func(s *session.Session) {
+				b.openGroup(s, name, servers)
+			},
+		})
+	}
+	b.send(s, form.Menu{
+		Title:   "Servers",
+		Content: "Select a server to play on.",
+		Buttons: append(groupButtons, buttons...),
+	})
+}
+
+// openGroup opens a menu listing the servers of a group for the session passed. The first button transfers the
+// session to the server in the group with the fewest players.
+func (b *Browser) openGroup(s *session.Session, name string, servers []*server.Server) {
+	buttons := []form.Button{{
+		Text:  "Quick join",
+		Image: iconOnline,
+		Press: func(s *session.Session) {
+			b.transfer(s, func() error {
+				return s.TransferTo(name)
+			})
+		},
+	}}
+	for _, srv := range servers {
+		buttons = append(buttons, b.serverButton(srv))
+	}
+	b.send(s, form.Menu{
+		Title:   name,
+		Content: "Select a server to play on.",
+		Buttons: buttons,
+		Close:   b.Open,
+	})
+}
+
+// serverButton returns a button which shows the status of the server passed and transfers players to it.
+func (b *Browser) serverButton(srv *server.Server) form.Button {
+	icon := iconOffline
+	if available(srv) {
+		icon = iconOnline
+	}
+	return form.Button{
+		Text:  fmt.Sprintf("%s\n%d playing", srv.Name(), srv.PlayerCount()),
+		Image: icon,
+		Press: func(s *session.Session) {
+			b.transfer(s, func() error {
+				if !available(srv) {
+					return fmt.Errorf("%s is not available right now", srv.Name())
+				}
+				if s.Server() == srv {
+					return fmt.Errorf("you are already playing on %s", srv.Name())
+				}
+				return s.Transfer(srv)
+			})
+		},
+	}
+}
+
+// transfer calls the transfer function passed in a new goroutine, so that the packets of the session keep being
+// handled during the transfer, and tells the session passed if it failed.
+func (b *Browser) transfer(s *session.Session, f func() error) {
+	go func() {
+		if err := f(); err != nil {
+			s.SendMessage(text.Colourf("<red>%v</red>", err))
+		}
+	}()
+}
+
+// send sends the menu passed to the session passed.
+func (b *Browser) send(s *session.Session, m form.Menu) {
+	_ = b.forms.Send(s, m)
+}
+
+// servers returns the servers passed that are in rotation, sorted by their names.
+func (b *Browser) servers(all []*server.Server) []*server.Server {
+	servers := make([]*server.Server, 0, len(all))
+	for _, srv := range all {
+		if srv.InRotation() {
+			servers = append(servers, srv)
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return strings.ToLower(servers[i].Name()) < strings.ToLower(servers[j].Name())
+	})
+	return servers
+}
+
+// available returns if players can be transferred to the server passed.
+func available(srv *server.Server) bool {
+	return srv.Healthy() && srv.InRotation() && !srv.Full()
+}
@@ -0,0 +1,169 @@
+// Package chat implements moderation of the chat messages sent by players before they reach the server that
+// the players are connected to.
+package chat
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/punishment"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// Config holds the settings of a Moderator. Limits that are zero are not enforced.
+type Config struct {
+	// RateLimit is the maximum number of messages a player may send within the RateWindow.
+	RateLimit int
+	// RateWindow is the window in which at most RateLimit messages may be sent.
+	RateWindow time.Duration
+	// MaxLength is the maximum length of a message. Longer messages are blocked.
+	MaxLength int
+	// RepeatLimit is the maximum number of times in a row a player may send the same message.
+	RepeatLimit int
+	// Violations is the number of blocked messages after which a player is muted.
+	Violations int
+	// MuteDuration is the duration a player is muted for after reaching the number of violations.
+	MuteDuration time.Duration
+	// Filter is called for every message that passed all the other checks. It returns the message that should
+	// be sent instead, or false if the message should be blocked. It may be nil.
+	Filter func(s *session.Session, message string) (string, bool)
+}
+
+// Moderator applies the moderation of a Config to the chat messages sent by players. Players that repeatedly
+// violate the limits are muted using a punishment.Store.
+type Moderator struct {
+	conf  Config
+	mutes punishment.Store
+	log   internal.Logger
+}
+
+// NewModerator creates a new Moderator with the config passed, which mutes players in the store passed. Errors
+// that occur while muting players are logged to the logger passed.
+func NewModerator(conf Config, mutes punishment.Store, log internal.Logger) *Moderator {
+	return &Moderator{conf: conf, mutes: mutes, log: log}
+}
+
+// Handler returns a function that creates a session.Handler which moderates the chat messages of the session.
+// The function returned may be passed to portal.Handle directly.
+func (m *Moderator) Handler() func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{m: m, s: s}
+	}
+}
+
+// handler is the session.Handler of a Moderator for a single session.
+type handler struct {
+	session.NopHandler
+	m *Moderator
+	s *session.Session
+
+	mu         sync.Mutex
+	sent       []time.Time
+	last       string
+	repeats    int
+	violations int
+}
+
+// HandleServerBoundPacket ...
+func (h *handler) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	t, ok := pk.(*packet.Text)
+	if !ok || t.TextType != packet.TextTypeChat || ctx.Cancelled() {
+		return
+	}
+	if mute, ok := h.m.mutes.Muted(h.s.UUID()); ok {
+		ctx.Cancel()
+		h.s.SendMessage(mute.Message())
+		return
+	}
+	if reason, ok := h.check(t.Message); !ok {
+		ctx.Cancel()
+		h.s.SendMessage(text.Colourf("<red>%s</red>", reason))
+		h.violation()
+		return
+	}
+	if h.m.conf.Filter != nil {
+		msg, ok := h.m.conf.Filter(h.s, t.Message)
+		if !ok {
+			ctx.Cancel()
+			return
+		}
+		t.Message = msg
+	}
+}
+
+// check checks the message passed against the limits of the moderator. If the message violates any of them,
+// false is returned together with the reason shown to the player.
+func (h *handler) check(message string) (string, bool) {
+	conf := h.m.conf
+	if conf.MaxLength > 0 && len(message) > conf.MaxLength {
+		return "Your message is too long.", false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if conf.RateLimit > 0 {
+		n := 0
+		for _, sent := range h.sent {
+			if now.Sub(sent) < conf.RateWindow {
+				h.sent[n] = sent
+				n++
+			}
+		}
+		h.sent = h.sent[:n]
+		if len(h.sent) >= conf.RateLimit {
+			return "You are sending messages too quickly.", false
+		}
+		h.sent = append(h.sent, now)
+	}
+	if conf.RepeatLimit > 0 {
+		normalised := strings.ToLower(strings.TrimSpace(message))
+		if normalised == h.last {
+			h.repeats++
+		} else {
+			h.last, h.repeats = normalised, 1
+		}
+		if h.repeats > conf.RepeatLimit {
+			return "You may not repeat the same message.", false
+		}
+	}
+	return "", true
+}
+
+// violation records a blocked message of the session and mutes the player once they reach the number of
+// violations of the config.
+func (h *handler) violation() {
+	conf := h.m.conf
+	if conf.Violations <= 0 || conf.MuteDuration <= 0 {
+		return
+	}
+	h.mu.Lock()
+	h.violations++
+	reached := h.violations >= conf.Violations
+	if reached {
+		h.violations = 0
+	}
+	h.mu.Unlock()
+	if !reached {
+		return
+	}
+
+	now := time.Now()
+	if err := h.m.mutes.Mute(punishment.Mute{
+		UUID:    h.s.UUID(),
+		Reason:  "Spamming",
+		Source:  "anti-spam",
+		Created: now,
+		Expiry:  now.Add(conf.MuteDuration),
+	}); err != nil {
+		h.m.log.Errorf("failed to mute %s: %v", h.s.IdentityData().DisplayName, err)
+		return
+	}
+	h.s.SendMessage(text.Colourf("<red>You have been muted for %s for spamming.</red>", conf.MuteDuration))
+}
@@ -0,0 +1,99 @@
+package chat
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/permission"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// SlowModeBypass is the permission of players that may chat regardless of slow mode.
+const SlowModeBypass = "portal.chat.slowmode.bypass"
+
+// SlowMode limits players to one chat message per interval, which may be set per group of servers and changed at
+// any time, such as to handle a raid. Players with the SlowModeBypass permission are not limited.
+type SlowMode struct {
+	ranks permission.Provider
+
+	mu        sync.RWMutex
+	intervals map[string]time.Duration
+}
+
+// NewSlowMode returns a SlowMode without any intervals set. The permissions of players are checked using the
+// provider passed, which may be nil to let no player bypass slow mode.
+func NewSlowMode(ranks permission.Provider) *SlowMode {
+	return &SlowMode{ranks: ranks, intervals: make(map[string]time.Duration)}
+}
+
+// Set sets the interval between the chat messages of players in the group passed. Servers without a group may
+// be passed by their name. If the interval is zero, slow mode is disabled for the group.
+func (m *SlowMode) Set(group string, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	group = strings.ToLower(group)
+	if interval <= 0 {
+		delete(m.intervals, group)
+		return
+	}
+	m.intervals[group] = interval
+}
+
+// Interval returns the interval between the chat messages of players in the group passed, or zero if slow mode is
+// disabled for the group.
+func (m *SlowMode) Interval(group string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.intervals[strings.ToLower(group)]
+}
+
+// Handler returns a function that creates a session.Handler which applies slow mode to the chat messages of the
+// session. The function returned may be passed to portal.Handle directly.
+func (m *SlowMode) Handler() func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &slowModeHandler{m: m, s: s}
+	}
+}
+
+// slowModeHandler is the session.Handler of a SlowMode for a single session.
+type slowModeHandler struct {
+	session.NopHandler
+	m *SlowMode
+	s *session.Session
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// HandleServerBoundPacket ...
+func (h *slowModeHandler) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	t, ok := pk.(*packet.Text)
+	if !ok || t.TextType != packet.TextTypeChat || ctx.Cancelled() {
+		return
+	}
+	srv, ok := h.s.TryServer()
+	if !ok || srv == nil {
+		return
+	}
+	interval := h.m.Interval(srv.Group())
+	if interval == 0 {
+		interval = h.m.Interval(srv.Name())
+	}
+	if interval == 0 || permission.Has(h.m.ranks, h.s, SlowModeBypass) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	if wait := h.last.Add(interval).Sub(now); wait > 0 {
+		ctx.Cancel()
+		h.s.SendMessage(text.Colourf("<red>Slow mode is enabled, you may chat again in %s.</red>", wait.Round(time.Second)))
+		return
+	}
+	h.last = now
+}
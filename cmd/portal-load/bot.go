@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/socket/packet"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	mcpacket "github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sirupsen/logrus"
+)
+
+// timeout is the maximum time a client waits to join the proxy, and to receive its own chat messages.
+const timeout = time.Second * 30
+
+// bot is a synthetic client connected to the proxy.
+type bot struct {
+	name      string
+	conf      config
+	servers   []string
+	transfers *transferrer
+	metrics   *metrics
+	log       *logrus.Logger
+
+	mu sync.Mutex
+	// chat holds the chat messages sent by the bot that were not yet received back, with the time they were
+	// sent at.
+	chat map[string]time.Time
+}
+
+// run connects the bot to the proxy and makes it chat and transfer until the stop channel is closed or the bot
+// is disconnected.
+func (b *bot) run(stop <-chan struct{}) {
+	start := time.Now()
+	conn, err := minecraft.Dialer{
+		IdentityData: login.IdentityData{DisplayName: b.name},
+	}.DialTimeout(b.conf.network, b.conf.address, timeout)
+	if err != nil {
+		b.metrics.fail(metricJoin)
+		b.log.Errorf("%s failed to connect: %v", b.name, err)
+		return
+	}
+	defer conn.Close()
+	if err := conn.DoSpawnTimeout(timeout); err != nil {
+		b.metrics.fail(metricJoin)
+		b.log.Errorf("%s failed to spawn: %v", b.name, err)
+		return
+	}
+	b.metrics.observe(metricJoin, time.Since(start))
+
+	b.chat = make(map[string]time.Time)
+	closed := make(chan struct{})
+	go b.read(conn, closed)
+
+	transferInterval := b.conf.transferInterval
+	if b.transfers == nil {
+		transferInterval = 0
+	}
+	chat, transfer := newTimer(b.conf.chatInterval), newTimer(transferInterval)
+	defer chat.Stop()
+	defer transfer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-closed:
+			b.metrics.fail(metricSession)
+			b.log.Errorf("%s was disconnected", b.name)
+			return
+		case <-chat.C:
+			b.sendChat(conn)
+			chat.Reset(jitter(b.conf.chatInterval))
+		case <-transfer.C:
+			b.transfer()
+			transfer.Reset(jitter(b.conf.transferInterval))
+		}
+	}
+}
+
+// read reads packets from the connection passed until it is closed, after which the channel passed is closed.
+// Chat messages sent by the bot itself are matched to the time they were sent at to measure their latency.
+func (b *bot) read(conn *minecraft.Conn, closed chan<- struct{}) {
+	defer close(closed)
+	for {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			return
+		}
+		text, ok := pk.(*mcpacket.Text)
+		if !ok {
+			continue
+		}
+		b.mu.Lock()
+		for msg, sent := range b.chat {
+			if time.Since(sent) > timeout {
+				delete(b.chat, msg)
+				b.metrics.fail(metricChat)
+				continue
+			}
+			if text.Message == msg || contains(text.Parameters, msg) {
+				delete(b.chat, msg)
+				b.metrics.observe(metricChat, time.Since(sent))
+			}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// sendChat sends a chat message with a random number to the server, so that it can be recognised once it is
+// broadcast back to the bot.
+func (b *bot) sendChat(conn *minecraft.Conn) {
+	msg := fmt.Sprintf("portal-load %d", rand.Int63())
+	b.mu.Lock()
+	b.chat[msg] = time.Now()
+	b.mu.Unlock()
+	if err := conn.WritePacket(&mcpacket.Text{TextType: mcpacket.TextTypeChat, SourceName: b.name, Message: msg}); err != nil {
+		b.metrics.fail(metricChat)
+	}
+}
+
+// transfer requests the transfer of the bot to a random server and waits until it is done.
+func (b *bot) transfer() {
+	srv := b.servers[rand.Intn(len(b.servers))]
+	start := time.Now()
+	status, msg, err := b.transfers.transfer(session.OfflineUUID(b.name), srv)
+	switch {
+	case err != nil:
+		b.metrics.fail(metricTransfer)
+		b.log.Errorf("%s could not be transferred to %s: %v", b.name, srv, err)
+	case status == packet.TransferResponseAlreadyOnServer:
+		// The bot was already on the server picked, so nothing was measured.
+	case status != packet.TransferResponseSuccess:
+		b.metrics.fail(metricTransfer)
+		b.log.Errorf("%s could not be transferred to %s: status %d %s", b.name, srv, status, msg)
+	default:
+		b.metrics.observe(metricTransfer, time.Since(start))
+	}
+}
+
+// newTimer returns a timer that fires after a random duration around the interval passed. If the interval is
+// zero, the timer never fires.
+func newTimer(interval time.Duration) *time.Timer {
+	if interval <= 0 {
+		t := time.NewTimer(time.Hour)
+		t.Stop()
+		return t
+	}
+	return time.NewTimer(jitter(interval))
+}
+
+// contains returns if the strings passed contain s.
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
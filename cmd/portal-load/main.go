@@ -0,0 +1,110 @@
+// Command portal-load connects a number of synthetic clients to a proxy, which transfer between servers and
+// chat at random, and reports the latency of joins, transfers and chat messages once it is done. It is meant
+// to find the capacity of a proxy before an event, and should never be run against a production proxy.
+//
+// The proxy must run in offline mode, as the clients are not authenticated with Xbox Live. Transfers are
+// requested through the socket API of the proxy, so its address and secret must be passed for players to be
+// transferred.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/paroxity/portal/socket"
+	"github.com/paroxity/portal/transport"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	var conf config
+	flag.StringVar(&conf.address, "address", "127.0.0.1:19132", "address of the proxy")
+	flag.StringVar(&conf.network, "network", transport.NetworkRakNet, "network used to connect to the proxy, such as raknet or tcp")
+	flag.IntVar(&conf.clients, "clients", 50, "number of clients to connect")
+	flag.DurationVar(&conf.ramp, "ramp", time.Millisecond*100, "time between the connections of two clients")
+	flag.DurationVar(&conf.duration, "duration", time.Minute, "time the clients stay connected after all of them joined")
+	flag.DurationVar(&conf.chatInterval, "chat", time.Second*5, "average time between two chat messages of a client, or 0 to disable chat")
+	flag.DurationVar(&conf.transferInterval, "transfer", time.Second*15, "average time between two transfers of a client, or 0 to disable transfers")
+	flag.StringVar(&conf.servers, "servers", "", "comma separated names of the servers to transfer clients between")
+	flag.StringVar(&conf.socketAddress, "socket", "127.0.0.1:19131", "address of the socket server of the proxy, used to request transfers")
+	flag.StringVar(&conf.secret, "secret", "", "secret of the socket server of the proxy")
+	flag.Parse()
+
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true, TimestampFormat: "15:04:05"})
+
+	var servers []string
+	for _, name := range strings.Split(conf.servers, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			servers = append(servers, name)
+		}
+	}
+	var transfers *transferrer
+	if conf.transferInterval > 0 && len(servers) > 0 {
+		c, err := socket.Dial(conf.socketAddress, conf.secret, "portal-load", log)
+		if err != nil {
+			log.Fatalf("unable to connect to socket server: %v", err)
+		}
+		transfers = newTransferrer(c)
+		defer c.Close()
+	}
+
+	m := newMetrics()
+	stop := make(chan struct{})
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-c:
+			log.Infof("interrupted, disconnecting clients")
+		case <-time.After(conf.ramp*time.Duration(conf.clients) + conf.duration):
+		}
+		close(stop)
+	}()
+
+	var wg sync.WaitGroup
+	started := time.Now()
+spawn:
+	for i := 0; i < conf.clients; i++ {
+		if i > 0 {
+			select {
+			case <-stop:
+				break spawn
+			case <-time.After(conf.ramp):
+			}
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := &bot{name: fmt.Sprintf("load%d", i), conf: conf, servers: servers, transfers: transfers, metrics: m, log: log}
+			b.run(stop)
+		}(i)
+	}
+	wg.Wait()
+
+	fmt.Printf("ran %d clients for %v\n\n", conf.clients, time.Since(started).Round(time.Second))
+	m.report(os.Stdout)
+}
+
+// config holds the flags passed to the command.
+type config struct {
+	address, network      string
+	clients               int
+	ramp, duration        time.Duration
+	chatInterval          time.Duration
+	transferInterval      time.Duration
+	servers               string
+	socketAddress, secret string
+}
+
+// jitter returns a random duration around the average passed, so that clients do not act in lockstep.
+func jitter(avg time.Duration) time.Duration {
+	return avg/2 + time.Duration(rand.Int63n(int64(avg)))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	metricJoin     = "join"
+	metricTransfer = "transfer"
+	metricChat     = "chat"
+	metricSession  = "session"
+)
+
+// metrics records the latency of the actions of all bots.
+type metrics struct {
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series holds the latencies measured for a single action, and the number of times it failed.
+type series struct {
+	durations []time.Duration
+	failures  int
+}
+
+// newMetrics returns an empty metrics.
+func newMetrics() *metrics {
+	return &metrics{series: make(map[string]*series)}
+}
+
+// observe records a successful action with the latency passed.
+func (m *metrics) observe(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.get(name)
+	s.durations = append(s.durations, d)
+}
+
+// fail records a failed action.
+func (m *metrics) fail(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(name).failures++
+}
+
+// get returns the series with the name passed, creating it if it does not yet exist. The mutex of the metrics
+// must be held.
+func (m *metrics) get(name string) *series {
+	s, ok := m.series[name]
+	if !ok {
+		s = &series{}
+		m.series[name] = s
+	}
+	return s
+}
+
+// report writes a table with the percentiles of the latency of every action to the writer passed.
+func (m *metrics) report(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ACTION\tOK\tFAILED\tP50\tP90\tP99\tMAX")
+	for _, name := range []string{metricJoin, metricTransfer, metricChat, metricSession} {
+		s, ok := m.series[name]
+		if !ok {
+			continue
+		}
+		sort.Slice(s.durations, func(i, j int) bool {
+			return s.durations[i] < s.durations[j]
+		})
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\n", name, len(s.durations), s.failures,
+			percentile(s.durations, 0.5), percentile(s.durations, 0.9), percentile(s.durations, 0.99), percentile(s.durations, 1))
+	}
+	_ = tw.Flush()
+}
+
+// percentile returns the pth percentile of the sorted durations passed, rounded to a millisecond.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	i := int(float64(len(durations)-1) * p)
+	return durations[i].Round(time.Millisecond)
+}
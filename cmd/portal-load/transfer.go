@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/socket"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// transferrer requests transfers of players through the socket API of the proxy and passes the responses back
+// to the bots waiting for them.
+type transferrer struct {
+	c *socket.Client
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]chan *packet.TransferResponse
+	err     error
+}
+
+// newTransferrer returns a transferrer that requests transfers using the client passed, and starts reading
+// responses from it.
+func newTransferrer(c *socket.Client) *transferrer {
+	t := &transferrer{c: c, pending: make(map[uuid.UUID]chan *packet.TransferResponse)}
+	go t.read()
+	return t
+}
+
+// transfer requests the transfer of the player with the UUID passed to the server passed, and waits for the
+// response of the proxy.
+func (t *transferrer) transfer(player uuid.UUID, srv string) (status byte, msg string, err error) {
+	resp := make(chan *packet.TransferResponse, 1)
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return 0, "", t.err
+	}
+	t.pending[player] = resp
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, player)
+		t.mu.Unlock()
+	}()
+
+	if err := t.c.WritePacket(&packet.TransferRequest{PlayerUUID: player, Server: srv}); err != nil {
+		return 0, "", err
+	}
+	select {
+	case pk, ok := <-resp:
+		if !ok {
+			return 0, "", errors.New("socket connection closed")
+		}
+		return pk.Status, pk.Error, nil
+	case <-time.After(timeout * 2):
+		return 0, "", errors.New("no response received")
+	}
+}
+
+// read reads packets from the socket connection until it is closed, passing transfer responses to the bots
+// waiting for them.
+func (t *transferrer) read() {
+	for {
+		pk, err := t.c.ReadPacket()
+		if err != nil {
+			t.mu.Lock()
+			t.err = err
+			for _, c := range t.pending {
+				close(c)
+			}
+			t.pending = map[uuid.UUID]chan *packet.TransferResponse{}
+			t.mu.Unlock()
+			return
+		}
+		resp, ok := pk.(*packet.TransferResponse)
+		if !ok {
+			continue
+		}
+		t.mu.Lock()
+		if c, ok := t.pending[resp.PlayerUUID]; ok {
+			c <- resp
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,501 @@
+// Command portalctl administers a running proxy through its socket API. It is able to show information about the
+// proxy, list the players and servers on the proxy, transfer, reconnect and kick players, test if servers accept
+// players, put servers in maintenance, register servers, change the canary servers of groups, set the slow mode of
+// the chat, export and import the state of the proxy, show what the proxy tracks for players, list the packs
+// servers send and inspect the packets of players.
+//
+// Usage:
+//
+//	portalctl [flags] info
+//	portalctl [flags] players [server]
+//	portalctl [flags] servers
+//	portalctl [flags] transfer <player> <server>
+//	portalctl [flags] reconnect <player>
+//	portalctl [flags] shadow <player> <server>
+//	portalctl [flags] kick <player> [message]
+//	portalctl [flags] maintenance <server> <on|off>
+//	portalctl [flags] register <name> <address> [network]
+//	portalctl [flags] canary <group> <server> <percentage> [xuid...]
+//	portalctl [flags] canary <group> off
+//	portalctl [flags] slowmode <group> <seconds|off>
+//	portalctl [flags] state export
+//	portalctl [flags] state import <file|->
+//	portalctl [flags] footprint [limit]
+//	portalctl [flags] packs [server]
+//	portalctl [flags] debug <player> [both|clientbound|serverbound] [packet id...]
+//
+// The address and secret of the socket server are passed using the -address and -secret flags, or the
+// PORTAL_ADDRESS and PORTAL_SECRET environment variables.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paroxity/portal/socket"
+	"github.com/paroxity/portal/socket/packet"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	address := flag.String("address", env("PORTAL_ADDRESS", "127.0.0.1:19131"), "address of the socket server of the proxy")
+	secret := flag.String("secret", env("PORTAL_SECRET", ""), "secret of the socket server of the proxy")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	name := fmt.Sprintf("portalctl-%d", os.Getpid())
+	if args[0] == "register" && (len(args) == 3 || len(args) == 4) {
+		// The server is registered under the name of the connection, so that it is removed once portalctl
+		// exits.
+		name = args[1]
+	}
+	c, err := socket.Dial(*address, *secret, name, log)
+	if err != nil {
+		fail("unable to connect to %s: %v", *address, err)
+	}
+	defer c.Close()
+
+	switch cmd, args := args[0], args[1:]; {
+	case cmd == "info" && len(args) == 0:
+		info(c)
+	case cmd == "players" && len(args) <= 1:
+		var srv string
+		if len(args) == 1 {
+			srv = args[0]
+		}
+		players(c, srv)
+	case cmd == "servers" && len(args) == 0:
+		servers(c)
+	case cmd == "transfer" && len(args) == 2:
+		transfer(c, args[0], args[1])
+	case cmd == "reconnect" && len(args) == 1:
+		reconnect(c, args[0])
+	case cmd == "shadow" && len(args) == 2:
+		shadow(c, args[0], args[1])
+	case cmd == "kick" && len(args) >= 1:
+		kick(c, args[0], strings.Join(args[1:], " "))
+	case cmd == "maintenance" && len(args) == 2 && (args[1] == "on" || args[1] == "off"):
+		maintenance(c, args[0], args[1] == "on")
+	case cmd == "register" && (len(args) == 2 || len(args) == 3):
+		var network string
+		if len(args) == 3 {
+			network = args[2]
+		}
+		register(c, args[0], args[1], network)
+	case cmd == "canary" && len(args) == 2 && args[1] == "off":
+		canary(c, &packet.CanaryRequest{Group: args[0], Remove: true})
+	case cmd == "canary" && len(args) >= 3:
+		percentage, err := strconv.ParseFloat(args[2], 32)
+		if err != nil {
+			fail("invalid percentage %q", args[2])
+		}
+		canary(c, &packet.CanaryRequest{Group: args[0], Server: args[1], Percentage: float32(percentage), XUIDs: args[3:]})
+	case cmd == "slowmode" && len(args) == 2:
+		var interval int64
+		if args[1] != "off" {
+			if interval, err = strconv.ParseInt(args[1], 10, 32); err != nil || interval < 0 {
+				fail("invalid interval %q", args[1])
+			}
+		}
+		slowMode(c, args[0], int32(interval))
+	case cmd == "state" && len(args) == 1 && args[0] == "export":
+		state(c, &packet.StateRequest{})
+	case cmd == "state" && len(args) == 2 && args[0] == "import":
+		var data []byte
+		if args[1] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[1])
+		}
+		if err != nil {
+			fail("unable to read state: %v", err)
+		}
+		state(c, &packet.StateRequest{Import: true, State: data})
+	case cmd == "footprint" && len(args) <= 1:
+		var limit int64
+		if len(args) == 1 {
+			if limit, err = strconv.ParseInt(args[0], 10, 32); err != nil || limit < 0 {
+				fail("invalid limit %q", args[0])
+			}
+		}
+		footprint(c, int32(limit))
+	case cmd == "packs" && len(args) <= 1:
+		var srv string
+		if len(args) == 1 {
+			srv = args[0]
+		}
+		packs(c, srv)
+	case cmd == "debug" && len(args) >= 1:
+		pk, ids := &packet.DebugRequest{PlayerName: args[0]}, args[1:]
+		if len(args) >= 2 {
+			ids = args[2:]
+			switch args[1] {
+			case "both":
+			case "clientbound":
+				pk.Direction = packet.DebugDirectionClientBound
+			case "serverbound":
+				pk.Direction = packet.DebugDirectionServerBound
+			default:
+				fail("unknown direction %q", args[1])
+			}
+		}
+		for _, arg := range ids {
+			id, err := strconv.ParseUint(arg, 10, 32)
+			if err != nil {
+				fail("invalid packet id %q", arg)
+			}
+			pk.PacketIDs = append(pk.PacketIDs, uint32(id))
+		}
+		debug(c, pk)
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// info prints information about the proxy, such as its version and uptime.
+func info(c *socket.Client) {
+	resp := request[*packet.ProxyInfoResponse](c, &packet.ProxyInfoRequest{})
+	started := time.Unix(resp.StartTime, 0)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "VERSION\t%s\n", resp.Version)
+	_, _ = fmt.Fprintf(w, "COMMIT\t%s\n", resp.Commit)
+	_, _ = fmt.Fprintf(w, "GO\t%s\n", resp.GoVersion)
+	_, _ = fmt.Fprintf(w, "STARTED\t%s (up %s)\n", started.Format(time.RFC3339), time.Since(started).Round(time.Second))
+	_, _ = fmt.Fprintf(w, "MINECRAFT\t%s (protocol %d)\n", resp.MinecraftVersion, resp.Protocol)
+	_, _ = fmt.Fprintf(w, "PLAYERS\t%d\n", resp.Players)
+	_, _ = fmt.Fprintf(w, "SERVERS\t%d\n", resp.Servers)
+	_ = w.Flush()
+}
+
+// players prints the players on the proxy, or only those on the server passed if it is not empty.
+func players(c *socket.Client, srv string) {
+	resp := request[*packet.PlayerListResponse](c, &packet.PlayerListRequest{Server: srv})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tUUID\tSERVER")
+	for _, p := range resp.Players {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, p.UUID, p.Server)
+	}
+	_ = w.Flush()
+}
+
+// servers prints the servers on the proxy with their player counts.
+func servers(c *socket.Client) {
+	resp := request[*packet.ServerListResponse](c, &packet.ServerListRequest{})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tPLAYERS")
+	for _, s := range resp.Servers {
+		_, _ = fmt.Fprintf(w, "%s\t%d\n", s.Name, s.PlayerCount)
+	}
+	_ = w.Flush()
+}
+
+// transfer transfers the player with the name passed to the server passed.
+func transfer(c *socket.Client, player, srv string) {
+	found := request[*packet.FindPlayerResponse](c, &packet.FindPlayerRequest{PlayerName: player})
+	if !found.Online {
+		fail("%s is not online", player)
+	}
+	resp := request[*packet.TransferResponse](c, &packet.TransferRequest{PlayerUUID: found.PlayerUUID, Server: srv})
+	switch resp.Status {
+	case packet.TransferResponseSuccess:
+		fmt.Printf("transferred %s to %s\n", found.PlayerName, srv)
+	case packet.TransferResponseServerNotFound:
+		fail("server %s not found", srv)
+	case packet.TransferResponseAlreadyOnServer:
+		fail("%s is already on %s", found.PlayerName, srv)
+	case packet.TransferResponsePlayerNotFound:
+		fail("%s is not online", player)
+	default:
+		fail("unable to transfer %s: %s", found.PlayerName, resp.Error)
+	}
+}
+
+// reconnect reconnects the player with the name passed to the server they are on.
+func reconnect(c *socket.Client, player string) {
+	resp := request[*packet.ReconnectResponse](c, &packet.ReconnectRequest{PlayerName: player})
+	switch resp.Status {
+	case packet.ReconnectResponseSuccess:
+		fmt.Printf("reconnected %s\n", player)
+	case packet.ReconnectResponsePlayerNotFound:
+		fail("%s is not online", player)
+	default:
+		fail("unable to reconnect %s: %s", player, resp.Error)
+	}
+}
+
+// shadow dials the server passed and spawns on it as the player with the name passed, without moving the player.
+func shadow(c *socket.Client, player, srv string) {
+	resp := request[*packet.ShadowTransferResponse](c, &packet.ShadowTransferRequest{PlayerName: player, Server: srv})
+	switch resp.Status {
+	case packet.ShadowTransferResponseSuccess:
+		fmt.Printf("%s accepted %s (dial %dms, spawn %dms)\n", resp.Server, player, resp.DialTime, resp.SpawnTime)
+	case packet.ShadowTransferResponsePlayerNotFound:
+		fail("player %s not found", player)
+	case packet.ShadowTransferResponseServerNotFound:
+		fail("server %s not found", srv)
+	default:
+		fail("%s did not accept %s: %s", resp.Server, player, resp.Error)
+	}
+}
+
+// kick disconnects the player with the name passed from the proxy with the message passed.
+func kick(c *socket.Client, player, message string) {
+	resp := request[*packet.KickResponse](c, &packet.KickRequest{PlayerName: player, Message: message})
+	if resp.Status == packet.KickResponsePlayerNotFound {
+		fail("%s is not online", player)
+	}
+	fmt.Printf("kicked %s\n", player)
+}
+
+// maintenance puts the server passed in or out of maintenance.
+func maintenance(c *socket.Client, srv string, enabled bool) {
+	resp := request[*packet.MaintenanceResponse](c, &packet.MaintenanceRequest{Server: srv, Enabled: enabled})
+	if resp.Status == packet.MaintenanceResponseServerNotFound {
+		fail("server %s not found", srv)
+	}
+	if enabled {
+		fmt.Printf("%s is now in maintenance\n", resp.Server)
+	} else {
+		fmt.Printf("%s is no longer in maintenance\n", resp.Server)
+	}
+}
+
+// canary sets or removes the canary server of a group.
+func canary(c *socket.Client, pk *packet.CanaryRequest) {
+	resp := request[*packet.CanaryResponse](c, pk)
+	switch resp.Status {
+	case packet.CanaryResponseSuccess:
+		if pk.Remove {
+			fmt.Printf("removed the canary of %s\n", pk.Group)
+		} else {
+			fmt.Printf("%s is now the canary of %s at %g%%\n", pk.Server, pk.Group, pk.Percentage)
+		}
+	case packet.CanaryResponseUnavailable:
+		fail("the proxy does not support canaries")
+	case packet.CanaryResponseServerNotFound:
+		fail("server %s not found in group %s", pk.Server, pk.Group)
+	case packet.CanaryResponseInvalidPercentage:
+		fail("percentage must be between 0 and 100")
+	}
+}
+
+// slowMode sets the interval in seconds between the chat messages of players in a group, or disables slow mode
+// if it is zero.
+func slowMode(c *socket.Client, group string, interval int32) {
+	resp := request[*packet.SlowModeResponse](c, &packet.SlowModeRequest{Group: group, Interval: interval})
+	switch resp.Status {
+	case packet.SlowModeResponseSuccess:
+		if interval == 0 {
+			fmt.Printf("disabled slow mode of %s\n", group)
+		} else {
+			fmt.Printf("set slow mode of %s to %d seconds\n", group, interval)
+		}
+	case packet.SlowModeResponseUnavailable:
+		fail("the proxy has no slow mode")
+	default:
+		fail("invalid interval %d", interval)
+	}
+}
+
+// state exports the state of the proxy, or imports the state of the request passed, and prints the state of the
+// proxy as JSON.
+func state(c *socket.Client, pk *packet.StateRequest) {
+	resp := request[*packet.StateResponse](c, pk)
+	switch resp.Status {
+	case packet.StateResponseSuccess:
+		fmt.Println(string(resp.State))
+	case packet.StateResponseUnavailable:
+		fail("the proxy does not support exporting its state")
+	default:
+		fail("%s", resp.Error)
+	}
+}
+
+// footprint prints the number of entries the proxy tracks for the sessions with the largest footprints, and for
+// all sessions together.
+func footprint(c *socket.Client, limit int32) {
+	resp := request[*packet.FootprintResponse](c, &packet.FootprintRequest{Limit: limit})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tSERVER\tENTITIES\tPLAYERLIST\tEFFECTS\tBOSSBARS\tSCOREBOARDS\tSOUNDS\tBLOBS\tDIMENSIONS")
+	row := func(name, srv string, f packet.Footprint) {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", name, srv, f.Entities, f.PlayerList, f.Effects, f.BossBars, f.Scoreboards, f.Sounds, f.BlobHashes, f.Dimensions)
+	}
+	for _, s := range resp.Sessions {
+		row(s.PlayerName, s.Server, s.Footprint)
+	}
+	row("TOTAL", "", resp.Total)
+	_ = w.Flush()
+}
+
+// packs prints the resource and behaviour packs sent by the server passed, or by all servers if empty.
+func packs(c *socket.Client, srv string) {
+	resp := request[*packet.ServerPacksResponse](c, &packet.ServerPacksRequest{Server: srv})
+	if resp.Status == packet.ServerPacksResponseServerNotFound {
+		fail("server %s not found", srv)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVER	UUID	VERSION	TYPE	SIZE	NAME")
+	for _, s := range resp.Servers {
+		switch {
+		case !s.Known:
+			_, _ = fmt.Fprintf(w, "%s	(unknown)				\n", s.Server)
+		case len(s.Packs) == 0:
+			_, _ = fmt.Fprintf(w, "%s	(none)				\n", s.Server)
+		}
+		for _, p := range s.Packs {
+			kind := "resources"
+			if p.Behaviours {
+				kind = "behaviours"
+			}
+			_, _ = fmt.Fprintf(w, "%s	%s	%s	%s	%d	%s\n", s.Server, p.UUID, p.Version, kind, p.Size, p.Name)
+		}
+	}
+	_ = w.Flush()
+}
+
+// register registers a server with the name, address and network passed, and keeps it registered until portalctl
+// is interrupted, as the proxy removes the server once the connection is closed. If the network is empty, the
+// proxy connects to the server over RakNet.
+func register(c *socket.Client, name, address, network string) {
+	if err := c.WritePacket(&packet.RegisterServer{Address: address, Network: network}); err != nil {
+		fail("unable to register server: %v", err)
+	}
+	fmt.Printf("registered %s at %s, press Ctrl+C to unregister it\n", name, address)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, err := c.ReadPacket(); err != nil {
+				return
+			}
+		}
+	}()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-sig:
+	case <-closed:
+		fail("connection to the proxy was closed")
+	}
+}
+
+// debug attaches to the player of the request passed and prints their packets as JSON lines until the player
+// leaves or portalctl is interrupted.
+func debug(c *socket.Client, pk *packet.DebugRequest) {
+	resp := request[*packet.DebugResponse](c, pk)
+	if resp.Status == packet.DebugResponsePlayerNotFound {
+		fail("%s is not online", pk.PlayerName)
+	}
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		os.Exit(0)
+	}()
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		pk, err := c.ReadPacket()
+		if err != nil {
+			fail("connection to the proxy was closed")
+		}
+		switch pk := pk.(type) {
+		case *packet.DebugPacket:
+			direction := "serverbound"
+			if pk.ClientBound {
+				direction = "clientbound"
+			}
+			_ = enc.Encode(map[string]any{
+				"direction": direction,
+				"id":        pk.PacketID,
+				"name":      pk.Name,
+				"packet":    json.RawMessage(pk.Payload),
+			})
+		case *packet.DebugResponse:
+			if pk.Status == packet.DebugResponseSessionClosed {
+				return
+			}
+		}
+	}
+}
+
+// request sends the packet passed to the proxy and waits for a response of the type T. Other packets received
+// in the meantime are ignored.
+func request[T packet.Packet](c *socket.Client, pk packet.Packet) T {
+	if err := c.WritePacket(pk); err != nil {
+		fail("unable to send request: %v", err)
+	}
+	for {
+		resp, err := c.ReadPacket()
+		if err != nil {
+			fail("unable to read response: %v", err)
+		}
+		if resp, ok := resp.(T); ok {
+			return resp
+		}
+	}
+}
+
+// env returns the value of the environment variable passed, or def if it is not set.
+func env(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+// fail prints the error passed and exits.
+func fail(format string, a ...any) {
+	_, _ = fmt.Fprintf(os.Stderr, "portalctl: "+format+"\n", a...)
+	os.Exit(1)
+}
+
+// usage prints the usage of portalctl.
+func usage() {
+	_, _ = fmt.Fprint(os.Stderr, `usage: portalctl [flags] <command> [arguments]
+
+commands:
+  info                           show the version, uptime and player count of the proxy
+  players [server]               list the players on the proxy or on a server
+  servers                        list the servers on the proxy
+  transfer <player> <server>     transfer a player to a server
+  reconnect <player>             reconnect a player to the server they are on
+  shadow <player> <server>       test if a server accepts a player without moving them
+  kick <player> [message]        kick a player from the proxy
+  maintenance <server> <on|off>  put a server in or out of maintenance
+  register <name> <address> [network]
+                                 register a server until interrupted
+  canary <group> <server> <percentage> [xuid...]
+                                 send a percentage of the players of a group to a canary server
+  canary <group> off             remove the canary of a group
+  slowmode <group> <seconds|off> set or disable the slow mode of the chat of a group
+  state export                   print the servers, canaries and whitelist of the proxy as JSON
+  state import <file|->          change the servers, canaries and whitelist of the proxy to a state
+  footprint [limit]              show what the proxy tracks for the players with the largest footprints
+  packs [server]                 list the packs servers send to players
+  debug <player> [both|clientbound|serverbound] [packet id...]
+                                 print the packets of a player as JSON lines
+
+flags:
+`)
+	flag.PrintDefaults()
+}
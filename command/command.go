@@ -0,0 +1,86 @@
+// Package command implements commands that are handled by the proxy itself rather than by the server that the
+// player is connected to.
+package command
+
+import (
+	"strings"
+
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// Command is a command handled by the proxy. Commands run by players are intercepted before they reach the
+// server the player is on.
+type Command struct {
+	// Name is the name of the command, without the leading slash.
+	Name string
+	// Description is the description of the command shown to players in the command list.
+	Description string
+	// Aliases holds other names the command may be run with.
+	Aliases []string
+	// Parameters holds the parameters of the command in the order they are entered, which are shown to players
+	// in the command list. The client refuses to run a command with arguments its parameters do not accept, so
+	// commands taking arguments must declare them.
+	Parameters []Parameter
+	// Run runs the command for the session passed with the arguments that followed the name of the command.
+	Run func(s *session.Session, args []string)
+	// Allow returns if the session passed may run the command. Commands a session may not run are left out of
+	// its command list and passed on to the server it is on. If nil, every session may run the command.
+	Allow func(s *session.Session) bool
+}
+
+// Parameter is a parameter of a Command shown to players in the command list.
+type Parameter struct {
+	// Name is the name of the parameter, such as "player".
+	Name string
+	// Type is the type of the value the parameter accepts.
+	Type ParameterType
+	// Optional specifies if the parameter may be left out. Parameters following an optional parameter must also
+	// be optional.
+	Optional bool
+}
+
+// ParameterType is the type of the value accepted by a Parameter.
+type ParameterType uint32
+
+const (
+	// ParameterString accepts a single word.
+	ParameterString ParameterType = protocol.CommandArgTypeString
+	// ParameterInt accepts a whole number.
+	ParameterInt ParameterType = protocol.CommandArgTypeInt
+	// ParameterTarget accepts the name of a player or a target selector.
+	ParameterTarget ParameterType = protocol.CommandArgTypeTarget
+	// ParameterRawText accepts all text that follows, including spaces. It must be the last parameter.
+	ParameterRawText ParameterType = protocol.CommandArgTypeRawText
+)
+
+// allowed returns if the session passed may run the command.
+func (c Command) allowed(s *session.Session) bool {
+	return c.Allow == nil || c.Allow(s)
+}
+
+// Players returns a function that may be used as the Allow function of a command, which only allows the players
+// with one of the names or XUIDs passed to run it. Names are matched regardless of case.
+func Players(names ...string) func(s *session.Session) bool {
+	allowed := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		allowed[strings.ToLower(name)] = struct{}{}
+	}
+	return func(s *session.Session) bool {
+		id := s.IdentityData()
+		if _, ok := allowed[strings.ToLower(id.DisplayName)]; ok {
+			return true
+		}
+		_, ok := allowed[id.XUID]
+		return ok && id.XUID != ""
+	}
+}
+
+// parse splits a command line into the name of the command and its arguments.
+func parse(line string) (name string, args []string) {
+	fields := strings.Fields(strings.TrimPrefix(line, "/"))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), fields[1:]
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// Diagnose returns a command with the name passed which shows the internal state of the session of the player
+// with the name passed as argument, such as its transfer state, buffered packets and the last packets read, to
+// find out why a player is stuck. Only the players allowed by the function passed may run the command, such as
+// the function returned by Players.
+func Diagnose(name string, store *session.Store, allow func(s *session.Session) bool) Command {
+	return Command{
+		Name:        name,
+		Description: "Shows the internal state of the session of a player",
+		Parameters:  []Parameter{{Name: "player", Type: ParameterRawText}},
+		Allow:       allow,
+		Run: func(s *session.Session, args []string) {
+			if len(args) == 0 {
+				s.SendMessage(text.Colourf("<red>Usage: /%s <player></red>", name))
+				return
+			}
+			// The name is taken from all arguments, as the names of players may contain spaces.
+			player := strings.Join(args, " ")
+			target, ok := store.LoadFromName(player)
+			if !ok {
+				s.SendMessage(text.Colourf("<red>%s is not online.</red>", player))
+				return
+			}
+			s.SendMessage(Diagnostics(target))
+		},
+	}
+}
+
+// Diagnostics formats the internal state of the session passed as shown by the command returned by Diagnose.
+// The text is coloured, which may be removed using text.Clean, such as to write it to the console.
+func Diagnostics(s *session.Session) string {
+	d := s.Diagnostics()
+	now := s.Clock().Now()
+	var b strings.Builder
+	b.WriteString(text.Colourf("<yellow>%s</yellow> <grey>(%s)</grey>", s.IdentityData().DisplayName, s.CorrelationID()))
+	login := "logging in"
+	if d.LoggedIn {
+		login = "logged in"
+	}
+	b.WriteString(text.Colourf("\n<yellow>Login:</yellow> %s", login))
+	if d.Closed {
+		b.WriteString(text.Colourf("\n<yellow>Closed:</yellow> %s", s.CloseReason()))
+	} else if d.Detached {
+		b.WriteString(text.Colourf("\n<yellow>Connection:</yellow> detached"))
+	}
+	server := d.Server
+	if server == "" {
+		server = "none"
+	}
+	b.WriteString(text.Colourf("\n<yellow>Server:</yellow> %s", server))
+	b.WriteString(text.Colourf("\n<yellow>Transfer:</yellow> %s", d.TransferState))
+	if d.Reconnecting {
+		b.WriteString(text.Colourf(" <grey>(reconnecting)</grey>"))
+	}
+	if d.Held {
+		b.WriteString(text.Colourf(" <grey>(held)</grey>"))
+	}
+	b.WriteString(text.Colourf("\n<yellow>Buffered:</yellow> %d/%d packets", d.Buffered, d.BufferSize))
+	b.WriteString(text.Colourf("\n<yellow>Handlers:</yellow> %d", d.Handlers))
+	f := d.Footprint
+	b.WriteString(text.Colourf("\n<yellow>Tracked:</yellow> %d entities, %d player list entries, %d effects, %d boss bars, %d scoreboards, %d sounds, %d blobs",
+		f.Entities, f.PlayerList, f.Effects, f.BossBars, f.Scoreboards, f.Sounds, f.BlobHashes))
+	writePackets(&b, "Last client packets", d.ClientPackets, packet.NewClientPool(), now)
+	writePackets(&b, "Last server packets", d.ServerPackets, packet.NewServerPool(), now)
+	return b.String()
+}
+
+// writePackets writes the packets passed under the title passed, naming them using the packet pool passed.
+func writePackets(b *strings.Builder, title string, packets []session.PacketRecord, pool packet.Pool, now time.Time) {
+	if len(packets) == 0 {
+		b.WriteString(text.Colourf("\n<yellow>%s:</yellow> none", title))
+		return
+	}
+	b.WriteString(text.Colourf("\n<yellow>%s:</yellow>", title))
+	for _, pk := range packets {
+		b.WriteString(text.Colourf("\n<grey>-</grey> %s <grey>(%d, %s ago)</grey>", packetName(pool, pk.ID), pk.ID, now.Sub(pk.Time).Round(time.Millisecond)))
+	}
+}
+
+// packetName returns the name of the packet with the ID passed in the pool passed, or "Unknown" if the pool has
+// no packet with the ID.
+func packetName(pool packet.Pool, id uint32) string {
+	f, ok := pool[id]
+	if !ok {
+		return "Unknown"
+	}
+	return reflect.TypeOf(f()).Elem().Name()
+}
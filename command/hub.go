@@ -0,0 +1,46 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// Hub returns a command with the name passed which sends the player to a hub. If the group passed is not empty,
+// the player is sent to the healthy server of that group with the fewest players. Otherwise, the server is found
+// using the load balancer passed. The server the player is on is never chosen, so that players are never sent
+// back to the server they are leaving.
+func Hub(name string, registry *server.Registry, group string, lb session.LoadBalancer) Command {
+	return Command{
+		Name:        name,
+		Description: "Sends you to the hub",
+		Run: func(s *session.Session, _ []string) {
+			current, _ := s.TryServer()
+			if group != "" && current != nil && strings.EqualFold(current.Group(), group) {
+				s.SendMessage(text.Colourf("<red>You are already in the hub.</red>"))
+				return
+			}
+			var srv *server.Server
+			if group != "" {
+				srv = server.Least(registry.Group(group), func(srv *server.Server) bool {
+					return srv.Healthy()
+				})
+			} else {
+				srv = session.FindServerExcluding(lb, s, current)
+			}
+			if srv == nil {
+				s.SendMessage(text.Colourf("<red>No hub is available right now.</red>"))
+				return
+			}
+			// The transfer is done in a new goroutine, so that the packets of the session keep being handled
+			// during the transfer.
+			go func() {
+				if err := s.Transfer(srv); err != nil {
+					s.SendMessage(text.Colourf("<red>Unable to send you to the hub: %v</red>", err))
+				}
+			}()
+		},
+	}
+}
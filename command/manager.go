@@ -0,0 +1,183 @@
+package command
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/paroxity/portal/audit"
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Manager holds the commands handled by the proxy. Its handler intercepts the commands run by players and adds
+// the commands of the proxy to the command list sent by servers, so that players see them.
+type Manager struct {
+	mu       sync.RWMutex
+	commands map[string]Command
+	names    map[string]string
+
+	audit *audit.Log
+	log   internal.Logger
+}
+
+// NewManager returns a new Manager without any commands.
+func NewManager() *Manager {
+	return &Manager{commands: make(map[string]Command), names: make(map[string]string)}
+}
+
+// Register registers the command passed. A command registered earlier with the same name is replaced.
+func (m *Manager) Register(c Command) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := strings.ToLower(c.Name)
+	m.commands[name] = c
+	m.names[name] = name
+	for _, alias := range c.Aliases {
+		m.names[strings.ToLower(alias)] = name
+	}
+}
+
+// SetAudit sets the audit log in which every command run by players is recorded as a proxy command. Errors
+// that occur while recording are logged to the logger passed. If the log passed is nil, commands are no longer
+// recorded.
+func (m *Manager) SetAudit(l *audit.Log, log internal.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audit, m.log = l, log
+}
+
+// Command returns the command registered with the name or alias passed.
+func (m *Manager) Command(name string) (Command, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.commands[m.names[strings.ToLower(name)]]
+	return c, ok
+}
+
+// Commands returns all commands registered, sorted by their names.
+func (m *Manager) Commands() []Command {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	commands := make([]Command, 0, len(m.commands))
+	for _, c := range m.commands {
+		commands = append(commands, c)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// Handler returns a function that creates a session.Handler which runs the commands of the manager for the
+// session. The function returned may be passed to portal.Handle directly.
+func (m *Manager) Handler() func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{m: m, s: s}
+	}
+}
+
+// handler is the session.Handler of a Manager for a single session.
+type handler struct {
+	session.NopHandler
+	m *Manager
+	s *session.Session
+}
+
+// HandleServerBoundPacket ...
+func (h *handler) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	req, ok := pk.(*packet.CommandRequest)
+	if !ok {
+		return
+	}
+	name, args := parse(req.CommandLine)
+	c, ok := h.m.Command(name)
+	if !ok || !c.allowed(h.s) {
+		return
+	}
+	ctx.Cancel()
+	h.m.record(h.s, req.CommandLine)
+	c.Run(h.s, args)
+}
+
+// record records the command line passed, run by the session passed, in the audit log of the manager, if it has
+// one.
+func (m *Manager) record(s *session.Session, commandLine string) {
+	m.mu.RLock()
+	l, log := m.audit, m.log
+	m.mu.RUnlock()
+	if l == nil {
+		return
+	}
+	if err := l.Record(audit.NewEntry(s, commandLine, true)); err != nil {
+		log.Errorf("failed to record command in audit log: %v", err)
+	}
+}
+
+// HandleClientBoundPacket ...
+func (h *handler) HandleClientBoundPacket(_ *event.Context, pk packet.Packet) {
+	available, ok := pk.(*packet.AvailableCommands)
+	if !ok {
+		return
+	}
+	// Commands of the server that have the same name as a command of the proxy are removed, as the proxy
+	// intercepts them before they reach the server.
+	n := 0
+	for _, c := range available.Commands {
+		if cmd, ok := h.m.Command(c.Name); !ok || !cmd.allowed(h.s) {
+			available.Commands[n] = c
+			n++
+		}
+	}
+	available.Commands = available.Commands[:n]
+	for _, c := range h.m.Commands() {
+		if !c.allowed(h.s) {
+			continue
+		}
+		params := make([]protocol.CommandParameter, 0, len(c.Parameters))
+		for _, p := range c.Parameters {
+			params = append(params, protocol.CommandParameter{
+				Name:     p.Name,
+				Type:     protocol.CommandArgValid | uint32(p.Type),
+				Optional: p.Optional,
+			})
+		}
+		available.Commands = append(available.Commands, protocol.Command{
+			Name:          strings.ToLower(c.Name),
+			Description:   c.Description,
+			AliasesOffset: aliasEnum(available, c),
+			Overloads:     []protocol.CommandOverload{{Parameters: params}},
+		})
+	}
+}
+
+// aliasEnum adds an enum holding the name and aliases of the command passed to the packet passed and returns its
+// offset, which is used as the AliasesOffset of the command. If the command has no aliases, no enum is added and
+// math.MaxUint32 is returned.
+func aliasEnum(available *packet.AvailableCommands, c Command) uint32 {
+	if len(c.Aliases) == 0 {
+		return math.MaxUint32
+	}
+	enum := protocol.CommandEnum{Type: strings.ToLower(c.Name) + "Aliases"}
+	for _, name := range append([]string{c.Name}, c.Aliases...) {
+		enum.ValueIndices = append(enum.ValueIndices, enumValue(available, strings.ToLower(name)))
+	}
+	available.Enums = append(available.Enums, enum)
+	return uint32(len(available.Enums) - 1)
+}
+
+// enumValue returns the index of the enum value passed in the packet passed, adding it if the packet does not
+// hold it yet.
+func enumValue(available *packet.AvailableCommands, value string) uint {
+	for i, v := range available.EnumValues {
+		if v == value {
+			return uint(i)
+		}
+	}
+	available.EnumValues = append(available.EnumValues, value)
+	return uint(len(available.EnumValues) - 1)
+}
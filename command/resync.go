@@ -0,0 +1,20 @@
+package command
+
+import (
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// Resync returns a command with the name passed which rejoins the server the player is on, to recover their
+// client from visual desyncs such as invisible blocks or players missing from the player list.
+func Resync(name string) Command {
+	return Command{
+		Name:        name,
+		Description: "Reloads the world around you",
+		Run: func(s *session.Session, _ []string) {
+			if err := s.Resync(); err != nil {
+				s.SendMessage(text.Colourf("<red>Unable to reload the world: %v</red>", err))
+			}
+		},
+	}
+}
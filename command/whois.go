@@ -0,0 +1,105 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/paroxity/portal/audit"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// whoisCommands is the number of recent commands of a player shown by the whois command.
+const whoisCommands = 5
+
+// Whois returns a command with the name passed which shows information about the player with the name passed
+// as argument, such as their server, ping, device and recent transfers. The recent commands of the player are
+// shown from the audit log passed, which may be nil. If maskIP is true, the last part of the IP address of the
+// player is hidden. Only the players allowed by the function passed may run the command, such as the function
+// returned by Players.
+func Whois(name string, store *session.Store, log *audit.Log, maskIP bool, allow func(s *session.Session) bool) Command {
+	return Command{
+		Name:        name,
+		Description: "Shows information about a player",
+		Parameters:  []Parameter{{Name: "player", Type: ParameterRawText}},
+		Allow:       allow,
+		Run: func(s *session.Session, args []string) {
+			if len(args) == 0 {
+				s.SendMessage(text.Colourf("<red>Usage: /%s <player></red>", name))
+				return
+			}
+			// The name is taken from all arguments, as the names of players may contain spaces.
+			player := strings.Join(args, " ")
+			target, ok := store.LoadFromName(player)
+			if !ok {
+				s.SendMessage(text.Colourf("<red>%s is not online.</red>", player))
+				return
+			}
+			s.SendMessage(whois(target, log, maskIP))
+		},
+	}
+}
+
+// whois formats the information about the session passed shown by the whois command.
+func whois(s *session.Session, log *audit.Log, maskIP bool) string {
+	id := s.IdentityData()
+	var b strings.Builder
+	b.WriteString(text.Colourf("<yellow>%s</yellow> <grey>(%s)</grey>", id.DisplayName, s.UUID()))
+	if id.XUID != "" {
+		b.WriteString(text.Colourf("\n<yellow>XUID:</yellow> %s", id.XUID))
+	}
+	b.WriteString(text.Colourf("\n<yellow>Correlation ID:</yellow> %s", s.CorrelationID()))
+	if name := s.DisplayName(); name != id.DisplayName {
+		b.WriteString(text.Colourf("\n<yellow>Display name:</yellow> %s", name))
+	}
+	if srv, ok := s.TryServer(); ok {
+		b.WriteString(text.Colourf("\n<yellow>Server:</yellow> %s", srv.Name()))
+		if s.Transferring() {
+			b.WriteString(text.Colourf(" <grey>(%s)</grey>", s.TransferState()))
+		}
+	}
+	b.WriteString(text.Colourf("\n<yellow>Ping:</yellow> %dms", s.Latency().Milliseconds()))
+	b.WriteString(text.Colourf("\n<yellow>Device:</yellow> %s", session.DeviceName(s.DeviceOS())))
+	b.WriteString(text.Colourf("\n<yellow>Locale:</yellow> %s", s.Locale()))
+	b.WriteString(text.Colourf("\n<yellow>IP:</yellow> %s", address(s.RemoteAddr(), maskIP)))
+	f := s.Footprint()
+	b.WriteString(text.Colourf("\n<yellow>Tracked:</yellow> %d entities, %d player list entries, %d effects, %d boss bars, %d scoreboards",
+		f.Entities, f.PlayerList, f.Effects, f.BossBars, f.Scoreboards))
+	if joined := s.JoinTime(); !joined.IsZero() {
+		b.WriteString(text.Colourf("\n<yellow>Joined:</yellow> %s <grey>(%s ago)</grey>", joined.UTC().Format("2006-01-02 15:04:05 MST"), s.Playtime().Round(time.Second)))
+	}
+	if history := s.TransferHistory(); len(history) > 0 {
+		b.WriteString(text.Colourf("\n<yellow>Recent transfers:</yellow>"))
+		for _, r := range history {
+			b.WriteString(text.Colourf("\n<grey>-</grey> %s -> %s <grey>(%s)</grey>", r.From, r.To, r.Time.UTC().Format("15:04:05")))
+		}
+	}
+	if log != nil {
+		if entries := log.Query(id.DisplayName, whoisCommands); len(entries) > 0 {
+			b.WriteString(text.Colourf("\n<yellow>Recent commands:</yellow>"))
+			for _, e := range entries {
+				b.WriteString(text.Colourf("\n<grey>-</grey> /%s <grey>(%s, %s)</grey>", e.Command, e.Server, e.Time.UTC().Format("15:04:05")))
+			}
+		}
+	}
+	return b.String()
+}
+
+// address returns the IP address of the network address passed. If mask is true, the last octet of IPv4
+// addresses is replaced with an x and IPv6 addresses are reduced to their /48 prefix.
+func address(addr net.Addr, mask bool) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	ip := net.ParseIP(host)
+	if !mask || ip == nil {
+		return host
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.x", ip4[0], ip4[1], ip4[2])
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
@@ -0,0 +1,230 @@
+// Package compass implements a boss bar or scoreboard sidebar owned by the proxy, which shows players the server
+// they are on, the number of players online and their ping.
+package compass
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// Style is the way the compass is shown to a player.
+type Style int
+
+const (
+	// StyleBossBar shows the compass as a boss bar at the top of the screen. Only the first line of the compass
+	// is shown. The boss bar is bound to the player itself, so it conflicts with boss bars that servers bind to
+	// the player.
+	StyleBossBar Style = iota
+	// StyleSidebar shows the compass as a scoreboard sidebar. It replaces any sidebar shown by the server.
+	StyleSidebar
+)
+
+// objective is the name of the scoreboard objective used for the sidebar of the compass.
+const objective = "portal:compass"
+
+// Info holds the information shown in the compass of a player.
+type Info struct {
+	// Server is the name of the server the player is on.
+	Server string
+	// Online is the number of players online on the proxy.
+	Online int
+	// Ping is the latency of the player.
+	Ping time.Duration
+}
+
+// Compass shows a boss bar or sidebar with the information of a player to the players it is shown to, and
+// refreshes it at an interval. Its handler must be added to sessions using portal.Handle.
+type Compass struct {
+	store *session.Store
+
+	mu      sync.Mutex
+	format  func(s *session.Session, info Info) []string
+	viewers map[*session.Session]*viewer
+}
+
+// viewer holds the state of the compass of a single player.
+type viewer struct {
+	style Style
+	// shown is false if the compass must be shown from scratch, for example after the player was transferred.
+	shown bool
+	// lines holds the number of lines of the sidebar last sent.
+	lines int
+}
+
+// New returns a new Compass which counts the players online in the store passed.
+func New(store *session.Store) *Compass {
+	return &Compass{store: store, format: DefaultFormat, viewers: make(map[*session.Session]*viewer)}
+}
+
+// DefaultFormat is the default format of the lines of a compass.
+func DefaultFormat(_ *session.Session, info Info) []string {
+	return []string{
+		text.Colourf("<aqua>%s</aqua> <grey>|</grey> <green>%d online</green> <grey>|</grey> <yellow>%dms</yellow>", info.Server, info.Online, info.Ping.Milliseconds()),
+	}
+}
+
+// SetFormat sets the function that returns the lines of the compass of a player. If nil, DefaultFormat is used.
+func (c *Compass) SetFormat(f func(s *session.Session, info Info) []string) {
+	if f == nil {
+		f = DefaultFormat
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.format = f
+}
+
+// Show shows the compass to the session passed in the style passed. The compass is shown on the next refresh.
+func (c *Compass) Show(s *session.Session, style Style) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.viewers[s]; ok {
+		if v.style == style {
+			return
+		}
+		c.hide(s, v)
+	}
+	c.viewers[s] = &viewer{style: style}
+}
+
+// Hide hides the compass from the session passed.
+func (c *Compass) Hide(s *session.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.viewers[s]; ok {
+		c.hide(s, v)
+		delete(c.viewers, s)
+	}
+}
+
+// Run refreshes the compass of every player it is shown to at the interval passed, until the stop channel passed
+// is closed. Run blocks until it is stopped, so it should be called in a separate goroutine.
+func (c *Compass) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			c.refresh()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// refresh sends the current information to every player the compass is shown to.
+func (c *Compass) refresh() {
+	online := len(c.store.All())
+
+	c.mu.Lock()
+	sessions := make([]*session.Session, 0, len(c.viewers))
+	for s := range c.viewers {
+		sessions = append(sessions, s)
+	}
+	c.mu.Unlock()
+
+	// The servers of the sessions are looked up without holding the lock of the compass, as sessions hold their
+	// own lock while calling the handler of the compass when changing servers.
+	servers := make(map[*session.Session]string, len(sessions))
+	for _, s := range sessions {
+		srv, ok := s.TryServer()
+		if !ok || srv == nil || s.Transferring() {
+			continue
+		}
+		servers[s] = srv.Name()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for s, name := range servers {
+		v, ok := c.viewers[s]
+		if !ok {
+			continue
+		}
+		lines := c.format(s, Info{Server: name, Online: online, Ping: s.Conn().Latency()})
+		if v.style == StyleBossBar {
+			c.sendBossBar(s, v, lines)
+		} else {
+			c.sendSidebar(s, v, lines)
+		}
+	}
+}
+
+// sendBossBar shows the first of the lines passed in the boss bar of the session passed.
+func (c *Compass) sendBossBar(s *session.Session, v *viewer, lines []string) {
+	title := ""
+	if len(lines) > 0 {
+		title = lines[0]
+	}
+	event := uint32(packet.BossEventTitle)
+	if !v.shown {
+		event, v.shown = packet.BossEventShow, true
+	}
+	_ = s.Conn().WritePacket(&packet.BossEvent{
+		BossEntityUniqueID: s.OriginalUniqueID(),
+		EventType:          event,
+		BossBarTitle:       title,
+		HealthPercentage:   1,
+	})
+}
+
+// sendSidebar shows the lines passed in the sidebar of the session passed.
+func (c *Compass) sendSidebar(s *session.Session, v *viewer, lines []string) {
+	conn := s.Conn()
+	if !v.shown {
+		_ = conn.WritePacket(&packet.SetDisplayObjective{
+			DisplaySlot:   "sidebar",
+			ObjectiveName: objective,
+			DisplayName:   text.Colourf("<bold>%s</bold>", "Network"),
+			CriteriaName:  "dummy",
+		})
+		v.shown = true
+	}
+	removeLines(conn, v.lines)
+
+	entries := make([]protocol.ScoreboardEntry, 0, len(lines))
+	for i, line := range lines {
+		entries = append(entries, protocol.ScoreboardEntry{
+			EntryID:       int64(i),
+			ObjectiveName: objective,
+			Score:         int32(i),
+			IdentityType:  protocol.ScoreboardIdentityFakePlayer,
+			// Lines of a scoreboard must be unique, so invisible colour codes are appended to equal lines.
+			DisplayName: line + fmt.Sprintf("§%x", i%16),
+		})
+	}
+	_ = conn.WritePacket(&packet.SetScore{ActionType: packet.ScoreboardActionModify, Entries: entries})
+	v.lines = len(lines)
+}
+
+// hide hides the compass of the viewer passed from the session passed.
+func (c *Compass) hide(s *session.Session, v *viewer) {
+	if !v.shown {
+		return
+	}
+	conn := s.Conn()
+	if v.style == StyleBossBar {
+		_ = conn.WritePacket(&packet.BossEvent{BossEntityUniqueID: s.OriginalUniqueID(), EventType: packet.BossEventHide})
+		return
+	}
+	removeLines(conn, v.lines)
+	_ = conn.WritePacket(&packet.RemoveObjective{ObjectiveName: objective})
+}
+
+// removeLines removes the number of lines passed from the sidebar of the connection passed.
+func removeLines(conn *minecraft.Conn, n int) {
+	if n == 0 {
+		return
+	}
+	entries := make([]protocol.ScoreboardEntry, n)
+	for i := range entries {
+		entries[i] = protocol.ScoreboardEntry{EntryID: int64(i), ObjectiveName: objective}
+	}
+	_ = conn.WritePacket(&packet.SetScore{ActionType: packet.ScoreboardActionRemove, Entries: entries})
+}
@@ -0,0 +1,40 @@
+package compass
+
+import (
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// Handler returns a function that creates a session.Handler which keeps the compass of the session shown after it
+// is transferred and forgets about the session once it quits. The function returned may be passed to
+// portal.Handle directly.
+func (c *Compass) Handler() func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{c: c, s: s}
+	}
+}
+
+// handler is the session.Handler of a Compass for a single session.
+type handler struct {
+	session.NopHandler
+	c *Compass
+	s *session.Session
+}
+
+// HandleChangeConn ...
+func (h *handler) HandleChangeConn(*minecraft.Conn) {
+	h.c.mu.Lock()
+	defer h.c.mu.Unlock()
+	if v, ok := h.c.viewers[h.s]; ok {
+		// The boss bars and scoreboards shown to the player may have been cleared or replaced during the
+		// transfer, so the compass is shown from scratch on the next refresh.
+		v.shown, v.lines = false, 0
+	}
+}
+
+// HandleQuit ...
+func (h *handler) HandleQuit(session.CloseReason) {
+	h.c.mu.Lock()
+	defer h.c.mu.Unlock()
+	delete(h.c.viewers, h.s)
+}
@@ -1,6 +1,9 @@
 package portal
 
 import (
+	"fmt"
+
+	"github.com/paroxity/portal/server"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
 	"os"
 	"path/filepath"
@@ -14,6 +17,18 @@ type Config struct {
 		// Address is the address on which the proxy should listen. Players may connect to this address in
 		// order to join. It should be in the format of "ip:port".
 		Address string `json:"address"`
+		// Listeners holds additional networks and addresses on which the proxy accepts connections from
+		// players, next to the RakNet listener at Address. This may be used to accept players over networks
+		// such as "tcp", or any other network registered with gophertunnel.
+		Listeners []struct {
+			// Network is the name of the network to listen on.
+			Network string `json:"network"`
+			// Address is the address to listen on in the format of "ip:port".
+			Address string `json:"address"`
+			// PacksRequired, if set, overrides ResourcePacks.Required for players connecting to this listener,
+			// so that players such as staff may join without being forced to download the resource packs.
+			PacksRequired *bool `json:"packs_required,omitempty"`
+		} `json:"listeners"`
 		// Communication holds settings related to the communication aspects of the proxy.
 		Communication struct {
 			// Address is the address on which the communication service should listen. External connections
@@ -28,7 +43,501 @@ type Config struct {
 		// It is recommended that this is always set to true in order to prevent possible attack vectors, however if any
 		// non-malicious clients are reaching these limits, you may want to disable it.
 		ReaderLimits bool `json:"reader_limits"`
+		// Pool holds settings related to the pool of RakNet connections kept for servers using the
+		// "raknet_pooled" network.
+		Pool struct {
+			// Size is the number of idle connections kept for every server.
+			Size int `json:"size"`
+			// MaxIdle is the time in seconds after which an idle connection is replaced.
+			MaxIdle int `json:"max_idle"`
+		} `json:"pool"`
 	} `json:"network"`
+	// Servers holds a list of servers that are registered on the proxy when it starts, next to servers that
+	// register themselves through the communication service.
+	Servers []struct {
+		// Name is the name of the server. It is used to transfer players to the server.
+		Name string `json:"name"`
+		// Address is the address of the server in the format of "ip:port".
+		Address string `json:"address"`
+		// Network is the network used to connect to the server. It may be "raknet", "raknet_pooled" or "tcp", or
+		// any other network registered with gophertunnel. If empty, "raknet" is used.
+		Network string `json:"network"`
+		// Group is the name of the group the server is part of, such as "lobby". Players may be transferred to
+		// any server of a group by the name of the group.
+		Group string `json:"group"`
+		// SoftCap is the player count from which the server is only chosen by load balancers if no other server
+		// is below its soft cap. If zero, the server has no soft cap.
+		SoftCap int `json:"soft_cap"`
+		// MaxPlayers is the maximum player count of the server. If zero, the server has no maximum.
+		MaxPlayers int `json:"max_players"`
+		// Metadata holds arbitrary attributes of the server, such as "region" or "tier", which load balancers and
+		// handlers may use to decide where to send players.
+		Metadata map[string]string `json:"metadata"`
+		// Filter holds the packet filter policies for packets sent between the server and its players.
+		Filter struct {
+			// ClientBound is the policy for packets sent by the server to players.
+			ClientBound FilterPolicy `json:"client_bound"`
+			// ServerBound is the policy for packets sent by players to the server.
+			ServerBound FilterPolicy `json:"server_bound"`
+		} `json:"filter"`
+	} `json:"servers"`
+	// Fallback holds settings related to sending players to another server when their server closes the
+	// connection unexpectedly.
+	Fallback struct {
+		// Enabled is if players should be sent to another server instead of being disconnected when their
+		// server closes the connection unexpectedly.
+		Enabled bool `json:"enabled"`
+		// Expiry is the time in seconds a server that failed for a player is excluded when finding a fallback
+		// server for them.
+		Expiry int `json:"expiry"`
+	} `json:"fallback"`
+	// Kick holds settings related to players being disconnected by the server they are on.
+	Kick struct {
+		// Policy is the policy applied when a server disconnects a player with a message. It may be "forward",
+		// which shows the message of the server, "fallback", which sends the player to a fallback server if
+		// fallback is enabled, or "message", which shows Message instead.
+		Policy string `json:"policy"`
+		// Message is the message shown to kicked players if Policy is "message". Any "{server}" in it is replaced
+		// with the name of the server and any "{reason}" with the message of the server.
+		Message string `json:"message"`
+		// Rewrites holds rules that replace the messages of servers matching their pattern, so that for example
+		// internal errors are not shown to players verbatim. The first rule that matches a message is applied.
+		Rewrites []struct {
+			// Pattern is the regular expression the message must match.
+			Pattern string `json:"pattern"`
+			// Template is the message shown instead. It may refer to submatches of the pattern, such as $1.
+			Template string `json:"template"`
+		} `json:"rewrites"`
+		// Brand is applied to every message of a server after it is rewritten. Any "{message}" in it is replaced
+		// with the message and any "{server}" with the name of the server. If empty, messages are not branded.
+		Brand string `json:"brand"`
+	} `json:"kick"`
+	// Limbo holds settings related to the limbo world hosted by the proxy.
+	Limbo struct {
+		// Enabled is if the proxy should host a limbo world that players may be parked in.
+		Enabled bool `json:"enabled"`
+		// Name is the name of the server the limbo is registered as.
+		Name string `json:"name"`
+		// Address is the loopback address the limbo listens on over TCP.
+		Address string `json:"address"`
+		// Block is the block the platform of the limbo is made of.
+		Block string `json:"block"`
+		// Fallback is if players should be parked in the limbo when no server can be joined, instead of being
+		// disconnected.
+		Fallback bool `json:"fallback"`
+		// Message is the message shown in a boss bar to players in the limbo.
+		Message string `json:"message"`
+		// RetryGroup is the group of servers players in the limbo are sent to once one is available. If empty,
+		// any server may be chosen.
+		RetryGroup string `json:"retry_group"`
+		// RetryInterval is the interval in seconds at which the proxy attempts to move players out of the limbo.
+		RetryInterval int `json:"retry_interval"`
+	} `json:"limbo"`
+	// ServerBrowser holds settings related to the server browser served by the proxy.
+	ServerBrowser struct {
+		// Enabled is if players may open the server browser using its command.
+		Enabled bool `json:"enabled"`
+		// Command is the name of the command that opens the server browser.
+		Command string `json:"command"`
+	} `json:"server_browser"`
+	// Resync holds settings related to the command players may use to rejoin their server after a desync.
+	Resync struct {
+		// Enabled is if players may rejoin the server they are on using the command.
+		Enabled bool `json:"enabled"`
+		// Command is the name of the command that rejoins the server.
+		Command string `json:"command"`
+	} `json:"resync"`
+	// Hub holds settings related to the command players may use to return to a hub.
+	Hub struct {
+		// Enabled is if players may return to a hub using the command.
+		Enabled bool `json:"enabled"`
+		// Command is the name of the command that sends players to a hub.
+		Command string `json:"command"`
+		// Group is the group of servers players are sent to. If empty, the server is found using the load
+		// balancer of the proxy. Players are never sent to the server they are on.
+		Group string `json:"group"`
+	} `json:"hub"`
+	// Staff holds the names or XUIDs of the staff members of the proxy, who may run the commands of the proxy
+	// meant for staff, such as the command showing information about the proxy.
+	Staff []string `json:"staff"`
+	// Ranks holds the ranks players may be given, of which the prefixes are shown in front of their names.
+	Ranks []struct {
+		// Name is the name of the rank.
+		Name string `json:"name"`
+		// Prefix is shown in front of the names of the players with the rank, such as "§c[Admin] ".
+		Prefix string `json:"prefix"`
+		// Colour is the colour of the names of the players with the rank, such as "§c".
+		Colour string `json:"colour"`
+		// Permissions holds the permissions of the players with the rank. The permission "*" grants every
+		// permission.
+		Permissions []string `json:"permissions"`
+		// Players holds the names or XUIDs of the players with the rank.
+		Players []string `json:"players"`
+	} `json:"ranks"`
+	// DefaultRank is the name of the rank of players that are not given any other rank. It may be empty.
+	DefaultRank string `json:"default_rank"`
+	// DecorateRanks is if the prefixes and colours of the ranks of players are shown in front of their names in
+	// the chat and player list of every player, so that servers do not need to format ranks themselves.
+	DecorateRanks bool `json:"decorate_ranks"`
+	// ProxyInfo holds settings related to the command staff may use to show information about the proxy.
+	ProxyInfo struct {
+		// Enabled is if staff may show information about the proxy using the command.
+		Enabled bool `json:"enabled"`
+		// Command is the name of the command that shows information about the proxy.
+		Command string `json:"command"`
+	} `json:"proxy_info"`
+	// Whois holds settings related to the command staff may use to show information about a player.
+	Whois struct {
+		// Enabled is if staff may show information about players using the command.
+		Enabled bool `json:"enabled"`
+		// Command is the name of the command that shows information about a player.
+		Command string `json:"command"`
+		// MaskIP is if the last part of the IP address of the player is hidden.
+		MaskIP bool `json:"mask_ip"`
+	} `json:"whois"`
+	// Diagnose holds settings related to the command staff may use to show the internal state of the session of a
+	// player, such as to find out why a player is stuck on a loading screen.
+	Diagnose struct {
+		// Enabled is if staff may show the state of sessions using the command.
+		Enabled bool `json:"enabled"`
+		// Command is the name of the command that shows the state of the session of a player.
+		Command string `json:"command"`
+	} `json:"diagnose"`
+	// Broadcast holds settings related to announcements sent to many players at once.
+	Broadcast struct {
+		// Limit is the maximum number of broadcasts that may be sent within the window. If zero, broadcasts are not
+		// limited.
+		Limit int `json:"limit"`
+		// Window is the window in seconds in which at most Limit broadcasts may be sent.
+		Window int `json:"window"`
+	} `json:"broadcast"`
+	// Announcements holds lists of messages that are announced to players at an interval.
+	Announcements []struct {
+		// Interval is the interval in seconds at which the next message is announced.
+		Interval int `json:"interval"`
+		// Messages holds the messages announced, one after another.
+		Messages []string `json:"messages"`
+		// Group is the group whose players receive the messages. If empty, players in every group receive them.
+		Group string `json:"group"`
+		// Server is the server whose players receive the messages. If empty, players on every server receive them.
+		Server string `json:"server"`
+		// Mode is the way the messages are shown. It may be "chat", "actionbar" or "title".
+		Mode string `json:"mode"`
+	} `json:"announcements"`
+	// JoinMessages holds settings related to the messages shown when players join or leave.
+	JoinMessages struct {
+		// Enabled is if the proxy should show join and quit messages.
+		Enabled bool `json:"enabled"`
+		// Scope is either "network", to show the messages when players join or leave the proxy, or "server", to
+		// show them to the players on a server when players join or leave that server.
+		Scope string `json:"scope"`
+		// Join and Quit are the templates of the messages shown when a player joins or leaves. They may use
+		// {{.Name}}, {{.Prefix}} and {{.Server}}. If empty, no message is shown.
+		Join string `json:"join"`
+		Quit string `json:"quit"`
+		// Suppress is if the join and leave messages of the servers themselves are hidden.
+		Suppress bool `json:"suppress"`
+		// Servers holds the messages of specific servers by their names, which replace the messages above for
+		// those servers.
+		Servers map[string]struct {
+			Join     string `json:"join"`
+			Quit     string `json:"quit"`
+			Suppress bool   `json:"suppress"`
+		} `json:"servers"`
+	} `json:"join_messages"`
+	// Compass holds settings related to the compass shown to players by the proxy.
+	Compass struct {
+		// Enabled is if every player should be shown the server they are on, the number of players online and
+		// their ping.
+		Enabled bool `json:"enabled"`
+		// Style is the way the compass is shown. It may be "bossbar" or "sidebar".
+		Style string `json:"style"`
+		// Interval is the interval in seconds at which the compass is refreshed.
+		Interval int `json:"interval"`
+	} `json:"compass"`
+	// Transfer holds settings related to transferring players between servers.
+	Transfer struct {
+		// SpawnHold is the maximum time in seconds players are held on a loading screen after being transferred,
+		// until the destination server releases them through the socket API. If zero, players are not held.
+		SpawnHold int `json:"spawn_hold"`
+		// Buffer is the maximum number of chat messages and commands sent by a player during a transfer that are
+		// sent to the server once the transfer is done, instead of being dropped. If zero, none are buffered.
+		Buffer int `json:"buffer"`
+		// MaxConcurrent is the maximum number of transfers that may dial their destination server at the same
+		// time. Transfers over the limit are queued until another transfer finishes. If zero, transfers are not
+		// limited.
+		MaxConcurrent int `json:"max_concurrent"`
+		// EntityTTL is the time in seconds after which entities spawned for players that did not appear in any
+		// packet are forgotten by the proxy, so that servers that never remove their entities do not grow the
+		// memory used by the proxy. If zero, entities are never forgotten.
+		EntityTTL int `json:"entity_ttl"`
+		// PaletteMismatch is the policy applied when a player is transferred to a server of which the custom
+		// blocks or items differ from those the client received when it joined. It may be "ignore", which
+		// transfers the player anyway, "reject", which fails the transfer, or "reconnect", which transfers the
+		// player with a full reconnect if Reconnect is enabled.
+		PaletteMismatch string `json:"palette_mismatch"`
+		// Reconnect holds settings related to transferring players with a full reconnect, which sends the client
+		// back to the proxy to join the destination server from scratch.
+		Reconnect struct {
+			// Enabled is if players may be transferred with a full reconnect.
+			Enabled bool `json:"enabled"`
+			// Address is the address at which players are able to reach the proxy, in the format of
+			// "host:port", which clients are sent back to.
+			Address string `json:"address"`
+			// Window is the time in seconds within which players must join again to be sent to the server they
+			// were transferred to. Players are recognised by a route token stored under their XUID, which is
+			// stored in the database if a storage driver is set, so that proxies sharing the database are able
+			// to hand off players to each other.
+			Window int `json:"window"`
+		} `json:"reconnect"`
+	} `json:"transfer"`
+	// Startup holds settings related to the startup of the proxy.
+	Startup struct {
+		// Validate is if the proxy should validate its configuration and attempt to reach every server when
+		// it starts, logging any problems found.
+		Validate bool `json:"validate"`
+		// RefuseOnFatal is if the proxy should refuse to start when a fatal problem is found during validation.
+		RefuseOnFatal bool `json:"refuse_on_fatal"`
+	} `json:"startup"`
+	// Handoff holds settings related to handing off sessions to a proxy started after this one. This is an
+	// experimental feature.
+	Handoff struct {
+		// Enabled is if the state of sessions should be stored when the proxy shuts down, so that players
+		// rejoining a restarted proxy are routed straight back to their previous server.
+		Enabled bool `json:"enabled"`
+		// File is the path to the file in which the states of sessions are stored.
+		File string `json:"file"`
+		// Expiry is the time in seconds after which a stored state is no longer used.
+		Expiry int `json:"expiry"`
+	} `json:"handoff"`
+	// Queue holds settings related to the login queue of the proxy.
+	Queue struct {
+		// Enabled is if players should wait in a queue when no server is available for them to join, instead
+		// of being disconnected.
+		Enabled bool `json:"enabled"`
+		// Interval is the interval in seconds at which the proxy attempts to find servers for the players
+		// at the front of the queue.
+		Interval int `json:"interval"`
+		// Priority holds the permissions that give players priority in the queue, from the lowest priority tier
+		// to the highest. Players with none of the permissions are let through after all other players.
+		Priority []string `json:"priority"`
+		// Message is shown to players waiting in the queue every time their position is updated. Any
+		// "{position}" in it is replaced with the position of the player and any "{total}" with the number of
+		// players in the queue. If empty, no message is shown.
+		Message string `json:"message"`
+	} `json:"queue"`
+	// Analytics holds settings related to sending analytics events about players to an external sink.
+	Analytics struct {
+		// Sink is the type of sink analytics events are sent to. It may be "none", "file" or "http".
+		Sink string `json:"sink"`
+		// File is the path to the file events are written to in the JSON lines format if Sink is "file".
+		File string `json:"file"`
+		// URL is the URL events are sent to in batches if Sink is "http". This may be the HTTP interface of a
+		// ClickHouse database.
+		URL string `json:"url"`
+	} `json:"analytics"`
+	// PlayerCount holds settings related to the player count shown in the server list.
+	PlayerCount struct {
+		// Network is if the player count of all proxies sharing the storage database is shown, rather than only
+		// that of this proxy. It requires a storage driver to be set.
+		Network bool `json:"network"`
+		// ID identifies the proxy among the proxies sharing the database. If empty, the hostname and address of
+		// the proxy are used.
+		ID string `json:"id"`
+		// Interval is the interval in seconds at which the player count of the proxy is shared and the player
+		// count of the network is updated.
+		Interval int `json:"interval"`
+	} `json:"player_count"`
+	// Heartbeat holds settings related to periodically reporting the status of the proxy to a master server, such
+	// as a server list or the orchestration service of a network running many proxies.
+	Heartbeat struct {
+		// Enabled is if heartbeats should be sent.
+		Enabled bool `json:"enabled"`
+		// URL is the URL of the master server heartbeats are sent to.
+		URL string `json:"url"`
+		// Secret is the secret heartbeats are signed with, so that the master server is able to verify them. If
+		// empty, heartbeats are not signed.
+		Secret string `json:"secret"`
+		// ID identifies the proxy among the proxies reporting to the master server.
+		ID string `json:"id"`
+		// Address is the address at which players are able to reach the proxy, in the format of "host:port". If
+		// empty, the address the proxy listens on is reported.
+		Address string `json:"address"`
+		// Interval is the interval in seconds at which heartbeats are sent.
+		Interval int `json:"interval"`
+	} `json:"heartbeat"`
+	// ErrorReporting holds settings related to reporting errors, such as panics and failed dials, to Sentry.
+	ErrorReporting struct {
+		// Enabled is if errors should be reported.
+		Enabled bool `json:"enabled"`
+		// DSN is the DSN of the Sentry project errors are reported to. Any service compatible with the store API
+		// of Sentry may be used.
+		DSN string `json:"dsn"`
+		// Environment is the environment reported with every error, such as "production".
+		Environment string `json:"environment"`
+		// Release is the release reported with every error, such as the version of the proxy.
+		Release string `json:"release"`
+	} `json:"error_reporting"`
+	// Health holds settings related to checking the health of servers.
+	Health struct {
+		// Interval is the interval in seconds at which the health of all servers is checked.
+		Interval int `json:"interval"`
+		// Timeout is the time in seconds after which a server that has not responded is marked as unhealthy.
+		Timeout int `json:"timeout"`
+		// LatencyRouting holds settings related to sending players to the servers with the lowest latency, as
+		// measured by the health checks.
+		LatencyRouting struct {
+			// Enabled is if players should be sent to the servers with the lowest latency instead of being
+			// split evenly across all servers.
+			Enabled bool `json:"enabled"`
+			// PlayerWeight is the number of milliseconds of latency each player on a server is worth, so that
+			// players are still spread across servers with a similar latency.
+			PlayerWeight float64 `json:"player_weight"`
+		} `json:"latency_routing"`
+	} `json:"health"`
+	// Routes holds settings related to sending players to a preferred group of servers based on their locale
+	// or region.
+	Routes struct {
+		// Rules is a list of rules, of which the first one a player matches decides their preferred group.
+		Rules []struct {
+			// Group is the group of servers players matching the rule are sent to.
+			Group string `json:"group"`
+			// Locales holds the language codes the rule matches, such as "pt_BR" or "pt".
+			Locales []string `json:"locales"`
+			// Regions holds the regions the rule matches, as defined in Regions.
+			Regions []string `json:"regions"`
+			// Devices holds the devices the rule matches, such as "android", "win10", "xbox", "ps4" or "switch".
+			Devices []string `json:"devices"`
+			// InputModes holds the input modes the rule matches, which may be "mouse", "touch", "gamepad" or
+			// "motion_controller".
+			InputModes []string `json:"input_modes"`
+		} `json:"rules"`
+		// Regions maps the names of regions to the IP ranges in the CIDR notation that are part of them.
+		Regions map[string][]string `json:"regions"`
+	} `json:"routes"`
+	// Canaries holds a list of canary servers, which only a part of the players sent to their group are sent
+	// to, so that new builds of servers can be tested safely. Canaries may also be changed through the
+	// communication service while the proxy is running.
+	Canaries []struct {
+		// Group is the group the canary server is part of.
+		Group string `json:"group"`
+		// Server is the name of the canary server.
+		Server string `json:"server"`
+		// Percentage is the percentage of players sent to the group, between 0 and 100, that are sent to the
+		// canary server.
+		Percentage float64 `json:"percentage"`
+		// XUIDs holds the XUIDs of players that are always sent to the canary server.
+		XUIDs []string `json:"xuids"`
+	} `json:"canaries"`
+	// Experiments maps the names of gameplay experiments to the number of buckets players are split into for
+	// them. The bucket of every player is included in analytics events.
+	Experiments map[string]int `json:"experiments"`
+	// StartGame holds experiments and features that are forced in the game data sent to players when they join,
+	// regardless of the settings of the server they join first, as servers with different experiments may crash
+	// the client after a transfer.
+	StartGame struct {
+		// Experiments maps the names of experiments, such as "gametest", to whether they are forced to be enabled
+		// or disabled.
+		Experiments map[string]bool `json:"experiments"`
+		// ClientSideGeneration, PersonaDisabled, CustomSkinsDisabled and DisablePlayerInteractions, if set, force
+		// the feature with the same name to be enabled or disabled.
+		ClientSideGeneration      *bool `json:"client_side_generation"`
+		PersonaDisabled           *bool `json:"persona_disabled"`
+		CustomSkinsDisabled       *bool `json:"custom_skins_disabled"`
+		DisablePlayerInteractions *bool `json:"disable_player_interactions"`
+	} `json:"start_game"`
+	// Webhooks holds settings related to sending operational events of the proxy to webhooks.
+	Webhooks struct {
+		// Hooks is a list of webhooks that are notified of events.
+		Hooks []struct {
+			// URL is the URL that events are sent to.
+			URL string `json:"url"`
+			// Format is the format of the payload sent to the webhook. It may be "json" or "discord".
+			Format string `json:"format"`
+			// Events is a list of events the webhook is notified of. If empty, it is notified of all events.
+			Events []string `json:"events"`
+			// Template is a text/template used to produce the payload instead of the default of the format.
+			Template string `json:"template"`
+		} `json:"hooks"`
+		// PlayerThresholds is a list of player counts which cause an event to be sent when the player count of
+		// the proxy rises to or drops below them.
+		PlayerThresholds []int `json:"player_thresholds"`
+		// TransferFailures holds settings related to events about repeatedly failing transfers.
+		TransferFailures struct {
+			// Limit is the number of transfers to a server that must fail within the window to send an event.
+			Limit int `json:"limit"`
+			// Window is the window in seconds in which the transfers must fail.
+			Window int `json:"window"`
+		} `json:"transfer_failures"`
+	} `json:"webhooks"`
+	// Chat holds settings related to the moderation of chat messages sent by players.
+	Chat struct {
+		// Enabled is if chat messages should be moderated by the proxy.
+		Enabled bool `json:"enabled"`
+		// RateLimit is the maximum number of messages a player may send within the rate window.
+		RateLimit int `json:"rate_limit"`
+		// RateWindow is the window in seconds in which at most RateLimit messages may be sent.
+		RateWindow int `json:"rate_window"`
+		// MaxLength is the maximum length of a message.
+		MaxLength int `json:"max_length"`
+		// RepeatLimit is the maximum number of times in a row a player may send the same message.
+		RepeatLimit int `json:"repeat_limit"`
+		// Violations is the number of blocked messages after which a player is muted.
+		Violations int `json:"violations"`
+		// MuteDuration is the duration in seconds a player is muted for after reaching the number of violations.
+		MuteDuration int `json:"mute_duration"`
+		// SlowMode holds the interval in seconds between the chat messages of players per group of servers, or per
+		// server for servers without a group. Players with the permission "portal.chat.slowmode.bypass" are not
+		// limited. Slow mode is applied even if chat moderation is disabled.
+		SlowMode map[string]int `json:"slow_mode"`
+	} `json:"chat"`
+	// Storage holds settings related to the database shared by the whitelist, punishments, statistics, handoff
+	// states, route tokens and player counts. If no driver is set, each of them uses the file configured in its own section, and
+	// route tokens are kept in memory.
+	Storage struct {
+		// Driver is the database/sql driver used to connect to the database. It may be "sqlite", "sqlite3",
+		// "postgres" or "pgx". The driver must be registered by importing it in the program running the proxy,
+		// which the example proxy does for "sqlite" and "pgx".
+		Driver string `json:"driver"`
+		// DSN is the data source name passed to the driver, such as the path to an SQLite database or the
+		// connection string of a PostgreSQL database.
+		DSN string `json:"dsn"`
+	} `json:"storage"`
+	// Punishments holds settings related to the storage of punishments such as mutes.
+	Punishments struct {
+		// File is the path to the file in which punishments are stored.
+		File string `json:"file"`
+		// MutedCommands holds the names of the commands that muted players may not run, such as "tell" or "me".
+		MutedCommands []string `json:"muted_commands"`
+	} `json:"punishments"`
+	// Stats holds settings related to tracking the statistics of players.
+	Stats struct {
+		// Enabled is if the playtime and number of joins of players should be tracked.
+		Enabled bool `json:"enabled"`
+		// File is the path to the file in which statistics are stored.
+		File string `json:"file"`
+	} `json:"stats"`
+	// Audit holds settings related to the audit log of commands run by players.
+	Audit struct {
+		// Enabled is if commands run by players should be recorded in the audit log.
+		Enabled bool `json:"enabled"`
+		// File is the path to the file in which commands are recorded in the JSON lines format.
+		File string `json:"file"`
+		// MaxSize is the size in megabytes after which the file is rotated.
+		MaxSize int `json:"max_size"`
+		// Capacity is the number of most recent entries kept in memory to be queried through the socket API.
+		Capacity int `json:"capacity"`
+	} `json:"audit"`
+	// SessionLogs holds settings related to the logs of the lifecycle of every session.
+	SessionLogs struct {
+		// Enabled is if the join, transfers, errors and quit of every session should be recorded in a file of
+		// its own.
+		Enabled bool `json:"enabled"`
+		// Directory is the directory in which the files are stored, in a directory per day.
+		Directory string `json:"directory"`
+		// Retention is the number of days after which the files are removed. If zero, they are never removed.
+		Retention int `json:"retention"`
+	} `json:"session_logs"`
 	// Logger holds settings related to the logging aspects of the proxy.
 	Logger struct {
 		// File is the path to the file in which logs should be stored. If the path is empty then logs will
@@ -36,6 +545,26 @@ type Config struct {
 		File string `json:"file"`
 		// Level is the required level logs should have to be shown in console or in the file above.
 		Level string `json:"level"`
+		// Hexdump is if packets that fail to decode should be logged as a hexdump and forwarded as they are
+		// instead of being dropped. Packets of which the entity IDs would need to be translated are still
+		// dropped. Every player holds two 16 MiB buffers in this mode.
+		Hexdump bool `json:"hexdump"`
+		// Rotation holds settings related to the rotation of the file above.
+		Rotation struct {
+			// MaxSize is the size in megabytes after which the file is rotated. If zero, the file is not rotated
+			// by size.
+			MaxSize int `json:"max_size"`
+			// Interval is the interval in hours at which the file is rotated. If zero, the file is not rotated by
+			// time.
+			Interval int `json:"interval"`
+			// Compress is if rotated files should be compressed using gzip.
+			Compress bool `json:"compress"`
+			// MaxAge is the number of days after which rotated files are removed. If zero, they are not removed
+			// by age.
+			MaxAge int `json:"max_age"`
+			// MaxBackups is the maximum number of rotated files kept. If zero, they are not removed by count.
+			MaxBackups int `json:"max_backups"`
+		} `json:"rotation"`
 	} `json:"logger"`
 	// PlayerLatency holds settings related to the latency reporting aspects of the proxy.
 	PlayerLatency struct {
@@ -44,6 +573,94 @@ type Config struct {
 		// UpdateInterval is the interval to report a player's ping if Report is true.
 		UpdateInterval int `json:"update_interval"`
 	} `json:"player_latency"`
+	// Authentication holds settings related to the authentication of players with Xbox Live.
+	Authentication struct {
+		// RequireXUID is if players that are not authenticated with Xbox Live should be rejected.
+		RequireXUID bool `json:"require_xuid"`
+		// OfflineMode disables authentication with Xbox Live for local testing. Players are given a UUID
+		// derived from their name.
+		OfflineMode bool `json:"offline_mode"`
+		// DuplicateLogin is the policy applied when a player connects while they are still connected. It may be
+		// "kick_old", "reject_new" or "handoff", which kicks the old session and sends the player to the server
+		// it was on.
+		DuplicateLogin string `json:"duplicate_login"`
+		// Takeover holds settings related to keeping the sessions of players that lost their connection open.
+		Takeover struct {
+			// Enabled is if players reconnecting within the grace period should be attached to their session
+			// again instead of joining their server from scratch. Experimental.
+			Enabled bool `json:"enabled"`
+			// Grace is the time in seconds the session of a player that lost their connection is kept open.
+			Grace int `json:"grace"`
+		} `json:"takeover"`
+		// MinVersion is the minimum version of Minecraft players must join with, such as "1.20.40". If empty,
+		// there is no minimum version.
+		MinVersion string `json:"min_version"`
+		// MaxVersion is the maximum version of Minecraft players may join with, such as "1.21.x". If empty,
+		// there is no maximum version.
+		MaxVersion string `json:"max_version"`
+		// VersionMessage is the message shown to players joining with a version that is not allowed. It is a
+		// text/template which may use {{.Version}}, {{.Min}}, {{.Max}} and {{.Outdated}}.
+		VersionMessage string `json:"version_message"`
+	} `json:"authentication"`
+	// Throttle holds settings related to banning IP addresses that repeatedly fail to log in.
+	Throttle struct {
+		// Enabled is if IP addresses that fail to log in too often should be banned.
+		Enabled bool `json:"enabled"`
+		// Limit is the number of failed logins from an IP address within the window after which it is banned.
+		Limit int `json:"limit"`
+		// Window is the window in seconds in which at most Limit logins from an IP address may fail.
+		Window int `json:"window"`
+		// Timeout is the time in seconds within which a connection must reach the resource pack stage of the
+		// login. Connections that do not, for example because their login chain is invalid, count as failed logins.
+		Timeout int `json:"timeout"`
+		// BanDuration is the duration in seconds an IP address is banned for. If zero, bans never expire.
+		BanDuration int `json:"ban_duration"`
+		// Firewalls holds external firewalls that are notified of banned IP addresses, so that their traffic is
+		// dropped before it reaches the proxy.
+		Firewalls []struct {
+			// Driver is the type of the firewall. It may be "exec", which runs a command, or "http", which sends a
+			// POST request to a URL.
+			Driver string `json:"driver"`
+			// Block is the command run to block an IP address if Driver is "exec". Any "{ip}" in its arguments is
+			// replaced with the IP address and any "{timeout}" with the number of seconds it is blocked for.
+			Block []string `json:"block"`
+			// Unblock is the command run to unblock an IP address if Driver is "exec". If empty, IP addresses are
+			// not unblocked by the proxy.
+			Unblock []string `json:"unblock"`
+			// URL is the URL requests are sent to if Driver is "http".
+			URL string `json:"url"`
+			// Headers holds headers added to requests if Driver is "http", such as an authorization header.
+			Headers map[string]string `json:"headers"`
+		} `json:"firewalls"`
+	} `json:"throttle"`
+	// Bandwidth holds settings related to counting and capping the bandwidth of players.
+	Bandwidth struct {
+		// Enabled is if the bandwidth of players should be counted and capped.
+		Enabled bool `json:"enabled"`
+		// In is the maximum number of kilobytes per second a player may send to the proxy. If zero, it is not capped.
+		In int `json:"in"`
+		// Out is the maximum number of kilobytes per second the proxy may send to a player. If zero, it is not
+		// capped.
+		Out int `json:"out"`
+		// Policy is the policy applied when a player exceeds their cap. It may be "throttle", which slows down
+		// their connection, or "kick", which disconnects them.
+		Policy string `json:"policy"`
+	} `json:"bandwidth"`
+	// Devices holds settings related to restricting the devices players may join with.
+	Devices struct {
+		// BlockImpossible is if players reporting a device that no official client reports, such as a console
+		// with a touch screen, should be prevented from joining.
+		BlockImpossible bool `json:"block_impossible"`
+		// Restrictions is a list of restrictions that prevent players on specific devices from joining.
+		Restrictions []struct {
+			// Devices holds the devices the restriction matches.
+			Devices []string `json:"devices"`
+			// InputModes holds the input modes the restriction matches.
+			InputModes []string `json:"input_modes"`
+			// Message is the message shown to players matching the restriction.
+			Message string `json:"message"`
+		} `json:"restrictions"`
+	} `json:"devices"`
 	// Whitelist holds settings related to the proxy whitelist.
 	Whitelist struct {
 		// Enabled is if the whitelist is enabled.
@@ -53,12 +670,21 @@ type Config struct {
 	} `json:"whitelist"`
 	// ResourcePacks holds settings related to sending resource packs to players.
 	ResourcePacks struct {
-		// Required is if players are required to download the resource packs before connecting.
+		// Required is if players are required to download the resource packs before connecting. Players are unable
+		// to decline the packs if true. It may be overridden for each of the additional listeners.
 		Required bool `json:"required"`
 		// Directory is the directory to load resource packs from. They can be directories, .zip files or .mcpack files.
 		Directory string `json:"directory"`
+		// URLs holds the URLs of resource packs hosted over HTTP, such as on a CDN. The proxy downloads them once
+		// when it starts, and players download them from the URL rather than through the proxy, which reduces the
+		// bandwidth of the proxy when players join.
+		URLs []string `json:"urls,omitempty"`
 		// EncryptionKeys is a map of resource pack UUIDs to their encryption key.
 		EncryptionKeys map[string]string `json:"encryption_keys,omitempty"`
+		// KeyDirectory is a directory holding the encryption keys of resource packs in files named after the UUID
+		// of the pack with the .key extension. Keys in EncryptionKeys take precedence. If empty, keys are only
+		// read from EncryptionKeys.
+		KeyDirectory string `json:"key_directory,omitempty"`
 	} `json:"resource_packs"`
 }
 
@@ -67,14 +693,103 @@ func DefaultConfig() (c Config) {
 	c.Network.Address = ":19132"
 	c.Network.Communication.Address = ":19131"
 	c.Network.ReaderLimits = true
+	c.Startup.Validate = true
+	c.Handoff.File = "handoff.json"
+	c.Handoff.Expiry = 60
+	c.Queue.Interval = 2
+	c.Queue.Message = "§7You are in position §e{position}§7 of §e{total}§7 in the queue."
+	c.Analytics.Sink = "none"
+	c.Analytics.File = "analytics.jsonl"
+	c.Heartbeat.Interval = 30
+	c.PlayerCount.Interval = 10
+	c.Health.Interval = 10
+	c.Health.Timeout = 5
+	c.Health.LatencyRouting.PlayerWeight = 1
+	c.Webhooks.TransferFailures.Limit = 5
+	c.Webhooks.TransferFailures.Window = 60
+	c.Chat.RateLimit = 5
+	c.Chat.RateWindow = 5
+	c.Chat.MaxLength = 256
+	c.Chat.RepeatLimit = 3
+	c.Chat.Violations = 5
+	c.Chat.MuteDuration = 300
+	c.Punishments.File = "punishments.json"
+	c.Punishments.MutedCommands = []string{"tell", "msg", "w", "me"}
+	c.Stats.File = "stats.json"
+	c.Audit.File = "audit.jsonl"
+	c.Audit.MaxSize = 10
+	c.Audit.Capacity = 1000
+	c.SessionLogs.Directory = "sessions"
+	c.SessionLogs.Retention = 30
+	c.Fallback.Enabled = true
+	c.Fallback.Expiry = 30
+	c.Authentication.DuplicateLogin = "kick_old"
+	c.Bandwidth.In = 512
+	c.Bandwidth.Out = 4096
+	c.Bandwidth.Policy = "throttle"
+	c.Throttle.Limit = 10
+	c.Throttle.Window = 60
+	c.Throttle.Timeout = 60
+	c.Throttle.BanDuration = 600
+	c.Authentication.Takeover.Grace = 15
+	c.Network.Pool.Size = 2
+	c.Kick.Policy = "fallback"
+	c.Kick.Message = "§cYou were disconnected from {server}.\n§7{reason}"
+	c.Limbo.Name = "limbo"
+	c.Limbo.Address = "127.0.0.1:19134"
+	c.Limbo.Block = "minecraft:glass"
+	c.Limbo.Fallback = true
+	c.Limbo.Message = "All servers are currently unavailable. You will be moved once one is back."
+	c.Limbo.RetryInterval = 10
+	c.ServerBrowser.Enabled = true
+	c.ServerBrowser.Command = "play"
+	c.Resync.Command = "resync"
+	c.Hub.Command = "hub"
+	c.ProxyInfo.Command = "proxy"
+	c.Whois.Command = "whois"
+	c.Whois.MaskIP = true
+	c.Diagnose.Command = "diagnose"
+	c.Transfer.Buffer = 32
+	c.Transfer.PaletteMismatch = "ignore"
+	c.Transfer.Reconnect.Window = 30
+	c.Broadcast.Limit = 10
+	c.Broadcast.Window = 60
+	c.JoinMessages.Scope = "network"
+	c.JoinMessages.Join = "§e{{.Prefix}}{{.Name}}§r§e joined the network"
+	c.JoinMessages.Quit = "§e{{.Prefix}}{{.Name}}§r§e left the network"
+	c.JoinMessages.Suppress = true
+	c.Compass.Style = "bossbar"
+	c.Compass.Interval = 5
+	c.Network.Pool.MaxIdle = 10
 	c.Logger.File = "proxy.log"
 	c.Logger.Level = "debug"
+	c.Logger.Rotation.MaxSize = 100
+	c.Logger.Rotation.Compress = true
+	c.Logger.Rotation.MaxAge = 30
+	c.Logger.Rotation.MaxBackups = 10
 	c.PlayerLatency.Report = true
 	c.PlayerLatency.UpdateInterval = 5
 	c.ResourcePacks.Directory = "resource_packs"
 	return
 }
 
+// FilterPolicy is the configuration of a packet filter policy in a single direction.
+type FilterPolicy struct {
+	// Mode is the mode of the policy. If "allow", only the packets listed are allowed. If "block" or empty, the
+	// packets listed are blocked and all other packets are allowed.
+	Mode string `json:"mode"`
+	// Packets is a list of the IDs of the packets that are allowed or blocked.
+	Packets []uint32 `json:"packets"`
+}
+
+// Policy returns the server.Policy described by the configuration.
+func (f FilterPolicy) Policy() server.Policy {
+	if f.Mode == "allow" {
+		return server.AllowList(f.Packets...)
+	}
+	return server.BlockList(f.Packets...)
+}
+
 // LoadResourcePacks attempts to load all the resource packs in the provided directory. If the directory does not exist,
 // it will be created. If any pack fails to compile, the error will be returned.
 func LoadResourcePacks(dir string) ([]*resource.Pack, error) {
@@ -98,3 +813,17 @@ func LoadResourcePacks(dir string) ([]*resource.Pack, error) {
 	}
 	return packs, nil
 }
+
+// LoadResourcePackURLs downloads the resource packs at the URLs passed. Players download packs loaded this way
+// from their URL instead of from the proxy. If any pack fails to download, the error will be returned.
+func LoadResourcePackURLs(urls []string) ([]*resource.Pack, error) {
+	packs := make([]*resource.Pack, 0, len(urls))
+	for _, url := range urls {
+		pack, err := resource.ReadURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", url, err)
+		}
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
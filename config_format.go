@@ -0,0 +1,136 @@
+package portal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig loads the configuration from the file at the path passed. The format of the file is detected by
+// its extension and may be JSON (.json), YAML (.yaml or .yml) or TOML (.toml). Values not present in the file
+// are left at their defaults, as returned by DefaultConfig.
+func LoadConfig(path string) (Config, error) {
+	c := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	data, err = toJSON(filepath.Ext(path), data)
+	if err != nil {
+		return c, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// WriteConfig writes the configuration passed to the file at the path passed. The format of the file is
+// detected by its extension, just like LoadConfig.
+func WriteConfig(path string, c Config) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	data, err = fromJSON(filepath.Ext(path), data)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", path, err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ConvertConfig converts the configuration file at the path src to the format of the path dst, which is
+// detected by its extension. Values missing in the source file are filled out with their defaults.
+func ConvertConfig(src, dst string) error {
+	c, err := LoadConfig(src)
+	if err != nil {
+		return err
+	}
+	return WriteConfig(dst, c)
+}
+
+// toJSON converts data in the format belonging to the file extension passed to JSON. The JSON names of the
+// fields of the configuration are used as keys in all formats, so converting the data to JSON first allows
+// the same struct tags to be used for every format.
+func toJSON(ext string, data []byte) ([]byte, error) {
+	var m map[string]any
+	switch strings.ToLower(ext) {
+	case ".json":
+		return data, nil
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	return json.Marshal(m)
+}
+
+// fromJSON converts JSON data to the format belonging to the file extension passed.
+func fromJSON(ext string, data []byte) ([]byte, error) {
+	if strings.ToLower(ext) == ".json" {
+		return data, nil
+	}
+	var m map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	m = normalise(m)
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		return yaml.Marshal(m)
+	case ".toml":
+		buf := bytes.NewBuffer(nil)
+		if err := toml.NewEncoder(buf).Encode(m); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported config format %q", ext)
+}
+
+// normalise prepares a map decoded from JSON to be encoded in a different format. It removes all nil values,
+// as TOML has no way of representing them, and turns numbers into integers where possible so that they are
+// not encoded as floats.
+func normalise(m map[string]any) map[string]any {
+	for k, v := range m {
+		if v == nil {
+			delete(m, k)
+			continue
+		}
+		m[k] = normaliseValue(v)
+	}
+	return m
+}
+
+// normaliseValue normalises a single value decoded from JSON. See normalise.
+func normaliseValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		return normalise(v)
+	case []any:
+		for i, e := range v {
+			v[i] = normaliseValue(e)
+		}
+	}
+	return v
+}
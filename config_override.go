@@ -0,0 +1,134 @@
+package portal
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The configuration of the proxy is built up in layers, where each layer overrides the values set by the
+// layers before it:
+//
+//  1. The defaults returned by DefaultConfig.
+//  2. The configuration file.
+//  3. Environment variables, applied using Config.ApplyEnv.
+//  4. Command line flags, applied using Config.ApplyFlags.
+//
+// Every field of the configuration may be overridden by an environment variable or flag. The name of the
+// environment variable is the prefix passed to ApplyEnv followed by the JSON names of the field and its
+// parents, in upper case and separated by underscores. For example, the address of the communication service
+// may be set using PORTAL_NETWORK_COMMUNICATION_ADDRESS. The name of the flag is made up of the same JSON
+// names separated by dots, such as -network.communication.address. Lists and maps, such as the list of
+// servers, are set using their JSON representation.
+
+// ApplyEnv overrides the values of the configuration with the values of environment variables with the prefix
+// passed. An error is returned if any of the environment variables holds a value that is not valid for its
+// field.
+func (c *Config) ApplyEnv(prefix string) error {
+	return walkConfig(reflect.ValueOf(c).Elem(), nil, func(path []string, v reflect.Value) error {
+		name := strings.ToUpper(strings.Join(append([]string{prefix}, path...), "_"))
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		if err := setConfigValue(v, val); err != nil {
+			return fmt.Errorf("environment variable %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// ApplyFlags parses the command line arguments passed and overrides the values of the configuration with the
+// values of any flags set. An error is returned if the arguments could not be parsed or if a flag holds a
+// value that is not valid for its field.
+func (c *Config) ApplyFlags(args []string) error {
+	fs := flag.NewFlagSet("portal", flag.ContinueOnError)
+	fields := map[string]reflect.Value{}
+	_ = walkConfig(reflect.ValueOf(c).Elem(), nil, func(path []string, v reflect.Value) error {
+		name := strings.Join(path, ".")
+		fields[name] = v
+		fs.String(name, "", fmt.Sprintf("overrides %s in the configuration", name))
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		if setErr := setConfigValue(fields[f.Name], f.Value.String()); setErr != nil {
+			err = fmt.Errorf("flag -%s: %w", f.Name, setErr)
+		}
+	})
+	return err
+}
+
+// walkConfig calls f for every field in the struct value passed that is not a struct itself, together with
+// the JSON names of the field and its parents.
+func walkConfig(v reflect.Value, path []string, f func(path []string, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldPath := append(append([]string(nil), path...), name)
+		if field.Type.Kind() == reflect.Struct {
+			if err := walkConfig(v.Field(i), fieldPath, f); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f(fieldPath, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setConfigValue parses the string passed and sets it to the value v.
+func setConfigValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		ptr := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(s), ptr.Interface()); err != nil {
+			return err
+		}
+		v.Set(ptr.Elem())
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+// Package console implements an interactive console that reads commands from the standard input of the proxy.
+// If the input is a terminal, the line being typed is kept below the log output, commands and their arguments
+// are completed with tab and previous commands are recalled with the arrow keys.
+package console
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Command is a command that may be run from the console.
+type Command struct {
+	// Name is the name of the command.
+	Name string
+	// Usage describes the arguments of the command, such as "<player> [message]".
+	Usage string
+	// Description is a short description of the command shown by the help command.
+	Description string
+	// Complete returns the possible values of the last argument in args, which may be partially typed. If nil,
+	// arguments of the command are not completed.
+	Complete func(args []string) []string
+	// Run runs the command with the arguments passed. Output of the command should be written to the writer
+	// passed.
+	Run func(w io.Writer, args []string) error
+}
+
+// prompt is the prompt shown in front of the line being typed.
+const prompt = "> "
+
+// Console reads commands from an input and runs them.
+type Console struct {
+	in  *os.File
+	out io.Writer
+
+	// mu guards the output and the line being typed, so that log output does not interleave with the line.
+	mu       sync.Mutex
+	commands map[string]Command
+	line     []rune
+	history  []string
+	// terminal holds the state of the terminal before the console changed it, or nil if the input is not a
+	// terminal.
+	terminal *terminalState
+}
+
+// New returns a Console that reads commands from the input passed and writes their output to out. A help
+// command is registered by default.
+func New(in *os.File, out io.Writer) *Console {
+	c := &Console{in: in, out: out, commands: make(map[string]Command)}
+	c.Register(Command{
+		Name:        "help",
+		Description: "Lists all commands.",
+		Run:         c.help,
+	})
+	return c
+}
+
+// Register registers a command, replacing any command with the same name.
+func (c *Console) Register(cmd Command) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.commands[strings.ToLower(cmd.Name)] = cmd
+}
+
+// Writer returns a writer that writes to the writer passed, such as the output of a logger, while keeping the
+// line being typed below the output.
+func (c *Console) Writer(w io.Writer) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.terminal == nil {
+			return w.Write(p)
+		}
+		_, _ = io.WriteString(c.out, "\r\x1b[K")
+		n, err := w.Write(p)
+		c.redraw()
+		return n, err
+	})
+}
+
+// Run reads and runs commands until the input is closed. If the input is a terminal, it is put in a mode in
+// which keys are read as they are pressed, until Restore is called or Run returns.
+func (c *Console) Run() error {
+	state, err := makeRaw(c.in)
+	if err != nil {
+		return c.runLines()
+	}
+	c.mu.Lock()
+	c.terminal = state
+	c.redraw()
+	c.mu.Unlock()
+	defer c.Restore()
+
+	r := bufio.NewReader(c.in)
+	historyIndex := 0
+	for {
+		ch, _, err := r.ReadRune()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		c.mu.Lock()
+		switch ch {
+		case '\r', '\n':
+			line := string(c.line)
+			c.line = c.line[:0]
+			_, _ = io.WriteString(c.out, "\n")
+			if strings.TrimSpace(line) != "" {
+				c.history = append(c.history, line)
+			}
+			historyIndex = len(c.history)
+			c.mu.Unlock()
+			c.exec(line)
+			c.mu.Lock()
+			c.redraw()
+		case '\t':
+			c.complete()
+		case 127, '\b':
+			if len(c.line) > 0 {
+				c.line = c.line[:len(c.line)-1]
+				c.redraw()
+			}
+		case 0x15:
+			// Ctrl+U clears the line.
+			c.line = c.line[:0]
+			c.redraw()
+		case 0x1b:
+			// Escape sequences of arrow keys have the form ESC [ X.
+			if b, _ := r.ReadByte(); b != '[' {
+				break
+			}
+			switch b, _ := r.ReadByte(); b {
+			case 'A':
+				if historyIndex > 0 {
+					historyIndex--
+					c.line = []rune(c.history[historyIndex])
+				}
+			case 'B':
+				if historyIndex < len(c.history) {
+					historyIndex++
+					c.line = c.line[:0]
+					if historyIndex < len(c.history) {
+						c.line = []rune(c.history[historyIndex])
+					}
+				}
+			}
+			c.redraw()
+		default:
+			if ch >= ' ' {
+				c.line = append(c.line, ch)
+				_, _ = io.WriteString(c.out, string(ch))
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Restore restores the terminal to the state it was in before Run was called. It should be called before the
+// process exits.
+func (c *Console) Restore() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.terminal != nil {
+		restore(c.in, c.terminal)
+		c.terminal = nil
+		_, _ = io.WriteString(c.out, "\r\x1b[K")
+	}
+}
+
+// runLines reads and runs commands line by line, for inputs that are not a terminal.
+func (c *Console) runLines() error {
+	s := bufio.NewScanner(c.in)
+	for s.Scan() {
+		c.exec(s.Text())
+	}
+	return s.Err()
+}
+
+// exec runs the command line passed.
+func (c *Console) exec(line string) {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return
+	}
+	c.mu.Lock()
+	cmd, ok := c.commands[strings.ToLower(args[0])]
+	c.mu.Unlock()
+
+	w := c.Writer(c.out)
+	if !ok {
+		_, _ = fmt.Fprintf(w, "Unknown command %q, run \"help\" for a list of commands.\n", args[0])
+		return
+	}
+	if err := cmd.Run(w, args[1:]); err != nil {
+		_, _ = fmt.Fprintf(w, "%s: %v\n", cmd.Name, err)
+	}
+}
+
+// complete completes the last word of the line being typed. If multiple completions are possible, the
+// longest common prefix is completed and all of them are listed. The mutex of the console must be held.
+func (c *Console) complete() {
+	line := string(c.line)
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	last := words[len(words)-1]
+
+	var candidates []string
+	if len(words) == 1 {
+		for name := range c.commands {
+			candidates = append(candidates, name)
+		}
+	} else if cmd, ok := c.commands[strings.ToLower(words[0])]; ok && cmd.Complete != nil {
+		candidates = cmd.Complete(words[1:])
+	}
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(last)) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	sort.Strings(matches)
+
+	prefix := strings.TrimSuffix(line, last)
+	if len(matches) == 1 {
+		c.line = []rune(prefix + matches[0] + " ")
+		c.redraw()
+		return
+	}
+	common := commonPrefix(matches)
+	if len(common) > len(last) {
+		c.line = []rune(prefix + common)
+		c.redraw()
+		return
+	}
+	_, _ = io.WriteString(c.out, "\r\x1b[K"+strings.Join(matches, "  ")+"\n")
+	c.redraw()
+}
+
+// redraw redraws the prompt and the line being typed. The mutex of the console must be held.
+func (c *Console) redraw() {
+	_, _ = io.WriteString(c.out, "\r\x1b[K"+prompt+string(c.line))
+}
+
+// help lists all commands registered.
+func (c *Console) help(w io.Writer, _ []string) error {
+	c.mu.Lock()
+	commands := make([]Command, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		commands = append(commands, cmd)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "%s - %s\n", strings.TrimSpace(cmd.Name+" "+cmd.Usage), cmd.Description)
+	}
+	return nil
+}
+
+// commonPrefix returns the longest prefix shared by all strings passed, ignoring case.
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// writerFunc is an io.Writer implemented by a function.
+type writerFunc func(p []byte) (int, error)
+
+// Write ...
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package console
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalState holds the state of a terminal before it was changed by makeRaw.
+type terminalState struct {
+	termios unix.Termios
+}
+
+// makeRaw disables line buffering and echoing of the terminal passed, so that keys are read as they are
+// pressed. Signals such as Ctrl+C are still handled by the terminal. An error is returned if the file passed
+// is not a terminal.
+func makeRaw(f *os.File) (*terminalState, error) {
+	termios, err := unix.IoctlGetTermios(int(f.Fd()), ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	state := &terminalState{termios: *termios}
+
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(int(f.Fd()), ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// restore restores the terminal passed to the state passed.
+func restore(f *os.File, state *terminalState) {
+	_ = unix.IoctlSetTermios(int(f.Fd()), ioctlSetTermios, &state.termios)
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package console
+
+import (
+	"errors"
+	"os"
+)
+
+// terminalState is unused on platforms on which the terminal cannot be changed.
+type terminalState struct{}
+
+// makeRaw always returns an error, as the terminal cannot be changed on this platform. Commands are read line
+// by line instead.
+func makeRaw(*os.File) (*terminalState, error) {
+	return nil, errors.New("console: terminal mode not supported on this platform")
+}
+
+// restore ...
+func restore(*os.File, *terminalState) {}
@@ -0,0 +1,127 @@
+// Package control implements the administrative actions of the proxy, such as kicking and transferring
+// players, so that they behave the same regardless of whether they are requested through the socket API or
+// the console of the proxy.
+package control
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+)
+
+var (
+	// ErrPlayerNotFound is returned if the player acted upon is not connected to the proxy.
+	ErrPlayerNotFound = errors.New("player not found")
+	// ErrServerNotFound is returned if the server acted upon is not registered with the proxy.
+	ErrServerNotFound = errors.New("server not found")
+	// ErrAlreadyOnServer is returned if a player is transferred to the server they are already on.
+	ErrAlreadyOnServer = errors.New("player is already on the server")
+)
+
+// Player represents a player connected to the proxy, as listed by Players.
+type Player struct {
+	// UUID is the UUID of the player.
+	UUID uuid.UUID
+	// Name is the name of the player.
+	Name string
+	// Server is the name of the server the player is on. It is empty if the player is still logging in.
+	Server string
+}
+
+// Players returns the players connected to the proxy. If srv is not empty, only the players on the server
+// with that name are returned.
+func Players(store *session.Store, srv string) []Player {
+	players := make([]Player, 0)
+	for _, s := range store.All() {
+		var name string
+		if sessionServer, ok := s.TryServer(); ok && sessionServer != nil {
+			name = sessionServer.Name()
+		}
+		if srv != "" && !strings.EqualFold(srv, name) {
+			continue
+		}
+		players = append(players, Player{UUID: s.UUID(), Name: s.IdentityData().DisplayName, Server: name})
+	}
+	return players
+}
+
+// Find finds the session of a player by their UUID, or by their name if no session with the UUID exists.
+func Find(store *session.Store, id uuid.UUID, name string) (*session.Session, error) {
+	if s, ok := store.Load(id); ok {
+		return s, nil
+	}
+	if name != "" {
+		if s, ok := store.LoadFromName(name); ok {
+			return s, nil
+		}
+	}
+	return nil, ErrPlayerNotFound
+}
+
+// Kick disconnects the player with the UUID or name passed from the proxy, showing them the message passed.
+// The session of the player is returned.
+func Kick(store *session.Store, id uuid.UUID, name, message string) (*session.Session, error) {
+	s, err := Find(store, id, name)
+	if err != nil {
+		return nil, err
+	}
+	s.Disconnect(message)
+	return s, nil
+}
+
+// Transfer transfers the player with the UUID or name passed to the server with the name passed, or to a
+// server of the group with that name. The session of the player is returned.
+func Transfer(store *session.Store, registry *server.Registry, id uuid.UUID, name, srv string) (*session.Session, error) {
+	target, ok := registry.Find(srv)
+	if !ok {
+		return nil, ErrServerNotFound
+	}
+	s, err := Find(store, id, name)
+	if err != nil {
+		return nil, err
+	}
+	if s.Server().Address() == target.Address() {
+		return s, ErrAlreadyOnServer
+	}
+	return s, s.Transfer(target)
+}
+
+// Reconnect reconnects the player with the UUID or name passed to the server they are on, using a fresh
+// connection. The session of the player is returned.
+func Reconnect(store *session.Store, id uuid.UUID, name string) (*session.Session, error) {
+	s, err := Find(store, id, name)
+	if err != nil {
+		return nil, err
+	}
+	return s, s.Reconnect()
+}
+
+// ShadowTransfer dials the server with the name passed, or a server of the group with that name, and spawns on
+// it as the player with the UUID or name passed, without moving the player. The session of the player and the
+// server tested are returned.
+func ShadowTransfer(store *session.Store, registry *server.Registry, id uuid.UUID, name, srv string) (*session.Session, *server.Server, session.ShadowResult, error) {
+	target, ok := registry.Find(srv)
+	if !ok {
+		return nil, nil, session.ShadowResult{}, ErrServerNotFound
+	}
+	s, err := Find(store, id, name)
+	if err != nil {
+		return nil, target, session.ShadowResult{}, err
+	}
+	res, err := s.ShadowTransfer(target)
+	return s, target, res, err
+}
+
+// SetMaintenance puts the server with the name passed in or out of maintenance. Servers in maintenance are
+// taken out of rotation, so that load balancers do not send players to them.
+func SetMaintenance(registry *server.Registry, name string, enabled bool) (*server.Server, error) {
+	srv, ok := registry.Server(name)
+	if !ok {
+		return nil, ErrServerNotFound
+	}
+	srv.SetInRotation(!enabled)
+	return srv, nil
+}
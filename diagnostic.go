@@ -0,0 +1,321 @@
+package portal
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/broadcast"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/storage"
+	"github.com/paroxity/portal/transport"
+)
+
+// Severity is the severity of a problem found while validating the proxy.
+type Severity int
+
+const (
+	// SeverityWarning is the severity of problems that do not prevent the proxy from functioning, but that
+	// may lead to unexpected behaviour.
+	SeverityWarning Severity = iota
+	// SeverityFatal is the severity of problems that prevent the proxy from functioning correctly.
+	SeverityFatal
+)
+
+// String ...
+func (s Severity) String() string {
+	if s == SeverityFatal {
+		return "fatal"
+	}
+	return "warning"
+}
+
+// Problem is a problem found while validating the configuration or the state of the proxy.
+type Problem struct {
+	// Severity is the severity of the problem.
+	Severity Severity
+	// Message is a human-readable description of the problem.
+	Message string
+}
+
+// Diagnostic is the result of validating the configuration or the state of the proxy. It holds all the problems
+// that were found.
+type Diagnostic struct {
+	mu       sync.Mutex
+	problems []Problem
+}
+
+// Problems returns all the problems found.
+func (d *Diagnostic) Problems() []Problem {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Problem(nil), d.problems...)
+}
+
+// Fatal returns true if any of the problems found has the severity SeverityFatal.
+func (d *Diagnostic) Fatal() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, p := range d.problems {
+		if p.Severity == SeverityFatal {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge adds all the problems of the diagnostic passed to the diagnostic.
+func (d *Diagnostic) Merge(o *Diagnostic) {
+	for _, p := range o.Problems() {
+		d.add(p.Severity, "%s", p.Message)
+	}
+}
+
+// add adds a problem with the severity passed to the diagnostic.
+func (d *Diagnostic) add(severity Severity, format string, a ...any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.problems = append(d.problems, Problem{Severity: severity, Message: fmt.Sprintf(format, a...)})
+}
+
+// Validate validates the configuration and returns a diagnostic holding any problems found, such as duplicate
+// server names, malformed addresses or conflicting ports.
+func (c Config) Validate() *Diagnostic {
+	d := &Diagnostic{}
+
+	listen := map[string]string{}
+	checkListen := func(network, address, name string) {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			d.add(SeverityFatal, "%s has an invalid address %q: %v", name, address, err)
+			return
+		}
+		key := listenKey(network, address)
+		if other, ok := listen[key]; ok {
+			d.add(SeverityFatal, "%s and %s both listen on %s", other, name, address)
+			return
+		}
+		listen[key] = name
+	}
+	checkListen(transport.NetworkRakNet, c.Network.Address, "the proxy")
+	checkListen("tcp", c.Network.Communication.Address, "the communication service")
+	for _, l := range c.Network.Listeners {
+		checkListen(l.Network, l.Address, fmt.Sprintf("the %s listener", l.Network))
+	}
+	if c.Network.Communication.Secret == "" {
+		d.add(SeverityWarning, "the communication service has no secret set")
+	}
+	switch DuplicatePolicy(c.Authentication.DuplicateLogin) {
+	case DuplicateKickOld, DuplicateRejectNew, DuplicateHandoff, "":
+	default:
+		d.add(SeverityFatal, "unknown duplicate login policy %q", c.Authentication.DuplicateLogin)
+	}
+	if _, err := NewVersionGate(c.Authentication.MinVersion, c.Authentication.MaxVersion, c.Authentication.VersionMessage); err != nil {
+		d.add(SeverityFatal, "the version gate is invalid: %v", err)
+	}
+	if c.Authentication.OfflineMode {
+		if c.Authentication.RequireXUID {
+			d.add(SeverityFatal, "offline mode is enabled while XUIDs are required, so no player is able to join")
+		} else {
+			d.add(SeverityWarning, "offline mode is enabled, so players are not authenticated with Xbox Live")
+		}
+	}
+	switch session.KickPolicy(c.Kick.Policy) {
+	case session.KickForward, session.KickFallback, session.KickMessage, "":
+	default:
+		d.add(SeverityFatal, "unknown kick policy %q", c.Kick.Policy)
+	}
+	switch session.PalettePolicy(c.Transfer.PaletteMismatch) {
+	case session.PaletteIgnore, session.PaletteReject, "":
+	case session.PaletteReconnect:
+		if !c.Transfer.Reconnect.Enabled {
+			d.add(SeverityFatal, "palette mismatches are handled by reconnecting, but reconnect transfers are disabled")
+		}
+	default:
+		d.add(SeverityFatal, "unknown palette mismatch policy %q", c.Transfer.PaletteMismatch)
+	}
+	if c.Transfer.Reconnect.Enabled {
+		if _, _, err := net.SplitHostPort(c.Transfer.Reconnect.Address); err != nil {
+			d.add(SeverityFatal, "reconnect transfers have an invalid address %q: %v", c.Transfer.Reconnect.Address, err)
+		}
+	}
+	for i, rw := range c.Kick.Rewrites {
+		if _, err := regexp.Compile(rw.Pattern); err != nil {
+			d.add(SeverityFatal, "kick rewrite %d has an invalid pattern %q: %v", i+1, rw.Pattern, err)
+		}
+	}
+	if c.Health.LatencyRouting.Enabled && c.Health.LatencyRouting.PlayerWeight < 0 {
+		d.add(SeverityFatal, "latency routing has a negative player weight of %g", c.Health.LatencyRouting.PlayerWeight)
+	}
+	checkDevices := func(name string, devices, inputModes []string) {
+		for _, device := range devices {
+			if _, ok := session.ParseDeviceOS(device); !ok {
+				d.add(SeverityFatal, "%s has an unknown device %q", name, device)
+			}
+		}
+		for _, mode := range inputModes {
+			if _, ok := session.ParseInputMode(mode); !ok {
+				d.add(SeverityFatal, "%s has an unknown input mode %q", name, mode)
+			}
+		}
+	}
+	for i, r := range c.Routes.Rules {
+		if r.Group == "" {
+			d.add(SeverityFatal, "route rule %d has no group", i+1)
+		}
+		checkDevices(fmt.Sprintf("route rule %d", i+1), r.Devices, r.InputModes)
+	}
+	for i, r := range c.Devices.Restrictions {
+		if len(r.Devices) == 0 && len(r.InputModes) == 0 {
+			d.add(SeverityWarning, "device restriction %d has no devices or input modes, so it matches no player", i+1)
+		}
+		checkDevices(fmt.Sprintf("device restriction %d", i+1), r.Devices, r.InputModes)
+	}
+	for region, ranges := range c.Routes.Regions {
+		for _, cidr := range ranges {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				d.add(SeverityFatal, "region %s has an invalid IP range %q", region, cidr)
+			}
+		}
+	}
+	for i, canary := range c.Canaries {
+		switch {
+		case canary.Group == "" || canary.Server == "":
+			d.add(SeverityFatal, "canary %d must have a group and a server", i+1)
+		case canary.Percentage < 0 || canary.Percentage > 100:
+			d.add(SeverityFatal, "canary %s has an invalid percentage of %g", canary.Server, canary.Percentage)
+		}
+	}
+	for name, buckets := range c.Experiments {
+		if buckets < 1 {
+			d.add(SeverityFatal, "experiment %s must have at least one bucket, but has %d", name, buckets)
+		}
+	}
+	if c.Throttle.Enabled && c.Throttle.Limit <= 0 {
+		d.add(SeverityFatal, "the throttle has an invalid limit of %d failed logins", c.Throttle.Limit)
+	}
+	for i, f := range c.Throttle.Firewalls {
+		switch f.Driver {
+		case "exec":
+			if len(f.Block) == 0 {
+				d.add(SeverityFatal, "firewall %d has no block command", i+1)
+			}
+		case "http":
+			if f.URL == "" {
+				d.add(SeverityFatal, "firewall %d has no URL", i+1)
+			}
+		default:
+			d.add(SeverityFatal, "firewall %d has an unknown driver %q", i+1, f.Driver)
+		}
+	}
+	if c.ErrorReporting.Enabled && c.ErrorReporting.DSN == "" {
+		d.add(SeverityFatal, "error reporting is enabled without a DSN")
+	}
+	if c.PlayerCount.Network {
+		if c.Storage.Driver == "" {
+			d.add(SeverityFatal, "the network player count is enabled without a storage driver to share it through")
+		}
+		if c.PlayerCount.Interval <= 0 {
+			d.add(SeverityFatal, "the network player count has an invalid interval of %d seconds", c.PlayerCount.Interval)
+		}
+	}
+	if c.Heartbeat.Enabled {
+		if c.Heartbeat.URL == "" {
+			d.add(SeverityFatal, "heartbeats are enabled without a URL")
+		}
+		if c.Heartbeat.Interval <= 0 {
+			d.add(SeverityFatal, "heartbeats have an invalid interval of %d seconds", c.Heartbeat.Interval)
+		}
+		if c.Heartbeat.Secret == "" {
+			d.add(SeverityWarning, "heartbeats have no secret set, so the master server is unable to verify them")
+		}
+	}
+	switch transport.CapPolicy(c.Bandwidth.Policy) {
+	case transport.CapThrottle, transport.CapKick, "":
+	default:
+		d.add(SeverityFatal, "unknown bandwidth policy %q", c.Bandwidth.Policy)
+	}
+	if c.JoinMessages.Scope != "network" && c.JoinMessages.Scope != "server" && c.JoinMessages.Enabled {
+		d.add(SeverityFatal, "unknown join message scope %q", c.JoinMessages.Scope)
+	}
+	if c.Compass.Style != "bossbar" && c.Compass.Style != "sidebar" && c.Compass.Enabled {
+		d.add(SeverityFatal, "unknown compass style %q", c.Compass.Style)
+	}
+	if _, ok := storage.DialectOf(c.Storage.Driver); !ok && c.Storage.Driver != "" {
+		d.add(SeverityFatal, "unsupported storage driver %q", c.Storage.Driver)
+	}
+	for i, a := range c.Announcements {
+		switch broadcast.Mode(a.Mode) {
+		case broadcast.ModeChat, broadcast.ModeActionBar, broadcast.ModeTitle, "":
+		default:
+			d.add(SeverityFatal, "announcement %d has an unknown mode %q", i+1, a.Mode)
+		}
+		if a.Interval <= 0 {
+			d.add(SeverityFatal, "announcement %d has an invalid interval of %d seconds", i+1, a.Interval)
+		}
+	}
+
+	names := map[string]struct{}{}
+	for _, srv := range c.Servers {
+		if srv.Name == "" {
+			d.add(SeverityFatal, "a server with the address %q has no name", srv.Address)
+			continue
+		}
+		if _, ok := names[strings.ToLower(srv.Name)]; ok {
+			d.add(SeverityFatal, "the server name %q is used more than once", srv.Name)
+		}
+		names[strings.ToLower(srv.Name)] = struct{}{}
+		if _, _, err := net.SplitHostPort(srv.Address); err != nil {
+			d.add(SeverityFatal, "server %s has an invalid address %q: %v", srv.Name, srv.Address, err)
+		}
+		for _, policy := range []FilterPolicy{srv.Filter.ClientBound, srv.Filter.ServerBound} {
+			if policy.Mode != "" && policy.Mode != "allow" && policy.Mode != "block" {
+				d.add(SeverityFatal, "server %s has an invalid packet filter mode %q", srv.Name, policy.Mode)
+			}
+		}
+	}
+	return d
+}
+
+// listenKey returns a key that is equal for two network addresses that would conflict when listened on.
+func listenKey(network, address string) string {
+	// RakNet runs on top of UDP, whereas all the other networks we know of run on top of TCP.
+	protocol := "tcp"
+	if network == transport.NetworkRakNet || network == transport.NetworkPooledRakNet || network == "" {
+		protocol = "udp"
+	}
+	_, port, _ := net.SplitHostPort(address)
+	return protocol + ":" + port
+}
+
+// Validate validates the state of the proxy and returns a diagnostic holding any problems found. It checks
+// that none of the listeners of the proxy conflict and attempts to reach every server in the server registry.
+// Validate should be called before Listen.
+func (p *Portal) Validate() *Diagnostic {
+	d := &Diagnostic{}
+
+	listen := map[string]struct{}{}
+	for _, addr := range p.addresses {
+		key := listenKey(addr.Network, addr.Address)
+		if _, ok := listen[key]; ok {
+			d.add(SeverityFatal, "multiple listeners are listening on %s", addr.Address)
+		}
+		listen[key] = struct{}{}
+	}
+
+	var wg sync.WaitGroup
+	for _, srv := range p.serverRegistry.Servers() {
+		wg.Add(1)
+		go func(srv *server.Server) {
+			defer wg.Done()
+			if err := server.Ping(srv, time.Second*5); err != nil {
+				d.add(SeverityWarning, "server %s at %s is not reachable: %v", srv.Name(), srv.Address(), err)
+			}
+		}(srv)
+	}
+	wg.Wait()
+	return d
+}
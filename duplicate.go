@@ -0,0 +1,36 @@
+package portal
+
+import (
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+)
+
+// DuplicatePolicy is the policy applied when a player connects to the proxy while a session with the same UUID
+// is still open.
+type DuplicatePolicy string
+
+const (
+	// DuplicateKickOld disconnects the open session and lets the new connection join as usual.
+	DuplicateKickOld DuplicatePolicy = "kick_old"
+	// DuplicateRejectNew keeps the open session and disconnects the new connection.
+	DuplicateRejectNew DuplicatePolicy = "reject_new"
+	// DuplicateHandoff disconnects the open session and sends the new connection to the server the open session
+	// was connected to.
+	DuplicateHandoff DuplicatePolicy = "handoff"
+)
+
+// pinnedLoadBalancer is a load balancer that returns a specific server if it is still registered, and falls back
+// to another load balancer otherwise.
+type pinnedLoadBalancer struct {
+	srv      *server.Server
+	registry *server.Registry
+	fallback session.LoadBalancer
+}
+
+// FindServer ...
+func (b pinnedLoadBalancer) FindServer(s *session.Session) *server.Server {
+	if srv, ok := b.registry.Server(b.srv.Name()); ok && srv == b.srv && !srv.Full() {
+		return srv
+	}
+	return b.fallback.FindServer(s)
+}
@@ -5,6 +5,7 @@ package event
 type Context struct {
 	cancel bool
 	after  []func(bool)
+	values map[any]any
 }
 
 // C returns a new event context.
@@ -17,6 +18,11 @@ func (ctx *Context) Cancel() {
 	ctx.cancel = true
 }
 
+// Cancelled returns whether the context has been cancelled by any of the handlers of the event.
+func (ctx *Context) Cancelled() bool {
+	return ctx.cancel
+}
+
 // Continue calls the function f if the context is not cancelled. If it is cancelled, Continue will return
 // immediately.
 // These functions are not generally useful for handling events. See After() for executing code after the
@@ -0,0 +1,39 @@
+package event
+
+// Key is a key of a value carried by a Context. The type parameter T is the type of the value stored under the
+// key, so that values can be loaded and stored in a type safe way. Keys are compared by identity, meaning two
+// keys created with the same name are still different keys.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a new Key with the name passed. The name is only used for debugging purposes.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Load loads the value stored under the key passed in the context. If no value was stored, the zero value of T
+// and false are returned.
+func Load[T any](ctx *Context, k *Key[T]) (T, bool) {
+	v, ok := ctx.values[k]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return v.(T), true
+}
+
+// Store stores a value under the key passed in the context. Handlers of an event may use Store to change the
+// values the event was called with, such as the server a session is being transferred to. Any value previously
+// stored under the key is overwritten.
+func Store[T any](ctx *Context, k *Key[T], v T) {
+	if ctx.values == nil {
+		ctx.values = make(map[any]any)
+	}
+	ctx.values[k] = v
+}
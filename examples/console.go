@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal"
+	"github.com/paroxity/portal/command"
+	"github.com/paroxity/portal/console"
+	"github.com/paroxity/portal/control"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// newConsole creates the console of the proxy, which reads commands from the standard input. The commands act
+// on the proxy through the control package, like the socket API.
+func newConsole(p *portal.Portal, stop func(), reload func() error) *console.Console {
+	c := console.New(os.Stdin, os.Stdout)
+	c.Register(console.Command{
+		Name:        "list",
+		Usage:       "[server]",
+		Description: "Lists the players on the proxy or on a server.",
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return serverNames(p)
+			}
+			return nil
+		},
+		Run: func(w io.Writer, args []string) error {
+			var srv string
+			if len(args) > 0 {
+				srv = args[0]
+			}
+			players := control.Players(p.SessionStore(), srv)
+			_, _ = fmt.Fprintf(w, "There are %d players online:\n", len(players))
+			for _, player := range players {
+				_, _ = fmt.Fprintf(w, "- %s (%s)\n", player.Name, player.Server)
+			}
+			return nil
+		},
+	})
+	c.Register(console.Command{
+		Name:        "kick",
+		Usage:       "<player> [message]",
+		Description: "Kicks a player from the proxy.",
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return playerNames(p)
+			}
+			return nil
+		},
+		Run: func(w io.Writer, args []string) error {
+			if len(args) == 0 {
+				return errors.New("usage: kick <player> [message]")
+			}
+			message := strings.Join(args[1:], " ")
+			if message == "" {
+				message = "You were kicked from the proxy."
+			}
+			s, err := control.Kick(p.SessionStore(), uuid.Nil, args[0], message)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(w, "Kicked %s.\n", s.IdentityData().DisplayName)
+			return nil
+		},
+	})
+	c.Register(console.Command{
+		Name:        "transfer",
+		Usage:       "<player> <server>",
+		Description: "Transfers a player to a server or group.",
+		Complete: func(args []string) []string {
+			switch len(args) {
+			case 1:
+				return playerNames(p)
+			case 2:
+				return serverNames(p)
+			}
+			return nil
+		},
+		Run: func(w io.Writer, args []string) error {
+			if len(args) != 2 {
+				return errors.New("usage: transfer <player> <server>")
+			}
+			s, err := control.Transfer(p.SessionStore(), p.ServerRegistry(), uuid.Nil, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(w, "Transferred %s to %s.\n", s.IdentityData().DisplayName, args[1])
+			return nil
+		},
+	})
+	c.Register(console.Command{
+		Name:        "reconnect",
+		Usage:       "<player>",
+		Description: "Reconnects a player to the server they are on.",
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return playerNames(p)
+			}
+			return nil
+		},
+		Run: func(w io.Writer, args []string) error {
+			if len(args) != 1 {
+				return errors.New("usage: reconnect <player>")
+			}
+			s, err := control.Reconnect(p.SessionStore(), uuid.Nil, args[0])
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(w, "Reconnected %s to %s.\n", s.IdentityData().DisplayName, s.Server().Name())
+			return nil
+		},
+	})
+	c.Register(console.Command{
+		Name:        "diagnose",
+		Usage:       "<player>",
+		Description: "Shows the internal state of the session of a player.",
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return playerNames(p)
+			}
+			return nil
+		},
+		Run: func(w io.Writer, args []string) error {
+			if len(args) != 1 {
+				return errors.New("usage: diagnose <player>")
+			}
+			s, ok := p.SessionStore().LoadFromName(args[0])
+			if !ok {
+				return fmt.Errorf("%s is not online", args[0])
+			}
+			_, _ = fmt.Fprintln(w, text.Clean(command.Diagnostics(s)))
+			return nil
+		},
+	})
+	c.Register(console.Command{
+		Name:        "info",
+		Description: "Shows information about the proxy.",
+		Run: func(w io.Writer, _ []string) error {
+			i := p.Info()
+			_, _ = fmt.Fprintf(w, "Portal %s (commit %s, %s)\n", i.Version, i.Commit, i.GoVersion)
+			_, _ = fmt.Fprintf(w, "Started at %s, up for %s\n", i.Started.Format("2006-01-02 15:04:05"), i.Uptime().Round(time.Second))
+			_, _ = fmt.Fprintf(w, "Minecraft %s (protocol %d)\n", i.MinecraftVersion, i.Protocol)
+			_, _ = fmt.Fprintf(w, "%d players on %d servers\n", i.Players, i.Servers)
+			return nil
+		},
+	})
+	c.Register(console.Command{
+		Name:        "end",
+		Description: "Stops the proxy.",
+		Run: func(io.Writer, []string) error {
+			stop()
+			return nil
+		},
+	})
+	c.Register(console.Command{
+		Name:        "reload",
+		Description: "Reloads the servers from the config.",
+		Run: func(io.Writer, []string) error {
+			return reload()
+		},
+	})
+	return c
+}
+
+// playerNames returns the names of all players on the proxy.
+func playerNames(p *portal.Portal) []string {
+	var names []string
+	for _, s := range p.SessionStore().All() {
+		names = append(names, s.IdentityData().DisplayName)
+	}
+	return names
+}
+
+// serverNames returns the names of all servers and groups on the proxy.
+func serverNames(p *portal.Portal) []string {
+	var names []string
+	groups := make(map[string]bool)
+	for _, srv := range p.ServerRegistry().Servers() {
+		names = append(names, srv.Name())
+		if g := srv.Group(); g != "" && !groups[g] {
+			groups[g] = true
+			names = append(names, g)
+		}
+	}
+	return names
+}
@@ -1,18 +1,54 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/paroxity/portal"
+	"github.com/paroxity/portal/analytics"
+	"github.com/paroxity/portal/audit"
+	"github.com/paroxity/portal/broadcast"
+	"github.com/paroxity/portal/browser"
+	"github.com/paroxity/portal/chat"
+	"github.com/paroxity/portal/command"
+	"github.com/paroxity/portal/compass"
+	"github.com/paroxity/portal/firewall"
+	"github.com/paroxity/portal/form"
+	"github.com/paroxity/portal/heartbeat"
 	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/limbo"
 	portallog "github.com/paroxity/portal/log"
+	"github.com/paroxity/portal/permission"
+	"github.com/paroxity/portal/presence"
+	"github.com/paroxity/portal/punishment"
+	"github.com/paroxity/portal/report"
+	"github.com/paroxity/portal/restart"
+	"github.com/paroxity/portal/server"
 	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/sessionlog"
 	"github.com/paroxity/portal/socket"
+	"github.com/paroxity/portal/socket/packet"
+	"github.com/paroxity/portal/stats"
+	"github.com/paroxity/portal/storage"
+	"github.com/paroxity/portal/transport"
+	"github.com/paroxity/portal/webhook"
 	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/text"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+
+	// The drivers of the databases that may be set in the storage section of the config.
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
@@ -22,9 +58,25 @@ func main() {
 		FullTimestamp:   true,
 		TimestampFormat: "15:04:05",
 	})
+	if runCommand(logger, os.Args[1:]) {
+		return
+	}
 	conf := readConfig(logger)
+	if err := conf.ApplyEnv("PORTAL"); err != nil {
+		logger.Fatalf("error applying environment variables to config: %v", err)
+	}
+	if err := conf.ApplyFlags(os.Args[1:]); err != nil {
+		logger.Fatalf("error applying flags to config: %v", err)
+	}
 	if conf.Logger.File != "" {
-		fileLogger, err := portallog.New(conf.Logger.File)
+		rotation := conf.Logger.Rotation
+		fileLogger, err := portallog.NewWithRotation(conf.Logger.File, portallog.Rotation{
+			MaxSize:    int64(rotation.MaxSize) * 1024 * 1024,
+			Interval:   time.Hour * time.Duration(rotation.Interval),
+			Compress:   rotation.Compress,
+			MaxAge:     time.Hour * 24 * time.Duration(rotation.MaxAge),
+			MaxBackups: rotation.MaxBackups,
+		})
 		if err != nil {
 			logger.Fatalf("unable to create file logger: %v", err)
 		}
@@ -41,42 +93,603 @@ func main() {
 	if err != nil {
 		logger.Fatalf("unable to load resource packs: %v", err)
 	}
-	for i, pack := range resourcePacks {
-		key, ok := conf.ResourcePacks.EncryptionKeys[pack.UUID()]
-		if ok {
-			resourcePacks[i] = pack.WithContentKey(key)
+	remotePacks, err := portal.LoadResourcePackURLs(conf.ResourcePacks.URLs)
+	if err != nil {
+		logger.Fatalf("unable to download resource packs: %v", err)
+	}
+	resourcePacks = append(resourcePacks, remotePacks...)
+	keys := []portal.PackKeyProvider{portal.StaticPackKeys(conf.ResourcePacks.EncryptionKeys)}
+	if conf.ResourcePacks.KeyDirectory != "" {
+		keys = append(keys, portal.DirectoryPackKeys(conf.ResourcePacks.KeyDirectory))
+	}
+	if resourcePacks, err = portal.ApplyPackKeys(resourcePacks, keys...); err != nil {
+		logger.Fatalf("unable to load resource pack keys: %v", err)
+	}
+
+	var provider storage.Provider
+	var whitelist session.Whitelist = session.NewSimpleWhitelist(conf.Whitelist.Enabled, conf.Whitelist.Players)
+	if conf.Storage.Driver != "" {
+		db, err := storage.Open(conf.Storage.Driver, conf.Storage.DSN)
+		if err != nil {
+			logger.Fatalf("unable to open storage: %v", err)
+		}
+		provider = db
+		w := session.NewProviderWhitelist(conf.Whitelist.Enabled, provider)
+		for _, name := range conf.Whitelist.Players {
+			if err := w.Add(name); err != nil {
+				logger.Fatalf("unable to add %s to the whitelist: %v", name, err)
+			}
+		}
+		whitelist = w
+	}
+
+	var restrictions []session.DeviceRestriction
+	for _, r := range conf.Devices.Restrictions {
+		devices, inputModes, err := parseDevices(r.Devices, r.InputModes)
+		if err != nil {
+			logger.Fatalf("unable to parse device restriction: %v", err)
+		}
+		restrictions = append(restrictions, session.DeviceRestriction{Devices: devices, InputModes: inputModes, Message: r.Message})
+	}
+
+	var versionGate *portal.VersionGate
+	if conf.Authentication.MinVersion != "" || conf.Authentication.MaxVersion != "" {
+		versionGate, err = portal.NewVersionGate(conf.Authentication.MinVersion, conf.Authentication.MaxVersion, conf.Authentication.VersionMessage)
+		if err != nil {
+			logger.Fatalf("unable to create version gate: %v", err)
+		}
+	}
+
+	var punishments punishment.Store = punishment.NewProviderStore(provider)
+	if provider == nil {
+		punishments, err = punishment.NewFileStore(conf.Punishments.File)
+		if err != nil {
+			logger.Fatalf("unable to load punishments: %v", err)
+		}
+	}
+	// The networks players connect over are wrapped so that the bandwidth and failed logins of connections are
+	// handled before they reach the proxy.
+	var raknet, tcp minecraft.Network = minecraft.RakNet{}, transport.TCP{}
+	var meter *transport.Meter
+	if conf.Bandwidth.Enabled {
+		meter = transport.NewMeter(transport.MeterConfig{
+			In:     conf.Bandwidth.In * 1024,
+			Out:    conf.Bandwidth.Out * 1024,
+			Policy: transport.CapPolicy(conf.Bandwidth.Policy),
+		})
+		raknet, tcp = meter.Wrap(raknet), meter.Wrap(tcp)
+	}
+	var throttle *firewall.Throttle
+	if conf.Throttle.Enabled {
+		var drivers []firewall.Driver
+		for _, f := range conf.Throttle.Firewalls {
+			if f.Driver == "exec" {
+				drivers = append(drivers, firewall.NewExecDriver(f.Block, f.Unblock))
+				continue
+			}
+			header := make(http.Header)
+			for k, v := range f.Headers {
+				header.Set(k, v)
+			}
+			drivers = append(drivers, firewall.NewHTTPDriver(f.URL, header))
+		}
+		throttle = firewall.NewThrottle(firewall.Config{
+			Limit:       conf.Throttle.Limit,
+			Window:      time.Second * time.Duration(conf.Throttle.Window),
+			Timeout:     time.Second * time.Duration(conf.Throttle.Timeout),
+			BanDuration: time.Second * time.Duration(conf.Throttle.BanDuration),
+			Drivers:     drivers,
+		}, punishments, logger)
+		raknet, tcp = throttle.Wrap(raknet), throttle.Wrap(tcp)
+		go throttle.Run(time.Second, nil)
+	}
+	minecraft.RegisterNetwork(transport.NetworkRakNet, raknet)
+	minecraft.RegisterNetwork(transport.NetworkTCP, tcp)
+
+	var rewrites []session.KickRewrite
+	for _, rw := range conf.Kick.Rewrites {
+		pattern, err := regexp.Compile(rw.Pattern)
+		if err != nil {
+			logger.Fatalf("invalid kick rewrite pattern %q: %v", rw.Pattern, err)
+		}
+		rewrites = append(rewrites, session.KickRewrite{Pattern: pattern, Template: rw.Template})
+	}
+
+	var transfers *session.TransferLimiter
+	if conf.Transfer.MaxConcurrent > 0 {
+		transfers = session.NewTransferLimiter(conf.Transfer.MaxConcurrent)
+	}
+	var rejoins *session.Rejoins
+	if conf.Transfer.Reconnect.Enabled {
+		// Route tokens are stored in the database if there is one, so that proxies sharing it are able to hand
+		// off players to each other.
+		var tokens session.RouteTokenStore
+		if provider != nil {
+			tokens = session.NewProviderRouteTokenStore(provider)
+		}
+		rejoins, err = session.NewRejoins(conf.Transfer.Reconnect.Address, time.Second*time.Duration(conf.Transfer.Reconnect.Window), tokens)
+		if err != nil {
+			logger.Fatalf("unable to enable reconnect transfers: %v", err)
 		}
 	}
 
+	var reporter report.Reporter
+	if conf.ErrorReporting.Enabled {
+		sentry, err := report.NewSentry(conf.ErrorReporting.DSN, conf.ErrorReporting.Environment, conf.ErrorReporting.Release, func(err error) {
+			logger.Errorf("failed to report error: %v", err)
+		})
+		if err != nil {
+			logger.Fatalf("unable to create error reporter: %v", err)
+		}
+		reporter = sentry
+	}
+
+	var takeover *session.Takeover
+	if conf.Authentication.Takeover.Enabled {
+		takeover = session.NewTakeover(time.Second * time.Duration(conf.Authentication.Takeover.Grace))
+	}
+	var listeners []portal.ListenAddress
+	for _, l := range conf.Network.Listeners {
+		listeners = append(listeners, portal.ListenAddress{Network: l.Network, Address: l.Address, TexturePacksRequired: l.PacksRequired})
+	}
+	status := portal.NewMOTDStatusProvider("Portal")
 	p := portal.New(portal.Options{
 		Logger: logger,
 
-		Address: conf.Network.Address,
+		Address:   conf.Network.Address,
+		Listeners: listeners,
 		ListenConfig: minecraft.ListenConfig{
-			StatusProvider: portal.NewMOTDStatusProvider("Portal"),
+			StatusProvider: status,
 
 			ResourcePacks:        resourcePacks,
 			TexturePacksRequired: conf.ResourcePacks.Required,
 		},
 
-		Whitelist: session.NewSimpleWhitelist(conf.Whitelist.Enabled, conf.Whitelist.Players),
+		RequireXUID:    conf.Authentication.RequireXUID,
+		OfflineMode:    conf.Authentication.OfflineMode,
+		DuplicateLogin: portal.DuplicatePolicy(conf.Authentication.DuplicateLogin),
+		Takeover:       takeover,
+
+		SpawnHold:       time.Second * time.Duration(conf.Transfer.SpawnHold),
+		TransferBuffer:  conf.Transfer.Buffer,
+		EntityTTL:       time.Second * time.Duration(conf.Transfer.EntityTTL),
+		TransferLimiter: transfers,
+		Rejoins:         rejoins,
+
+		KickPolicy:    session.KickPolicy(conf.Kick.Policy),
+		PalettePolicy: session.PalettePolicy(conf.Transfer.PaletteMismatch),
+		KickMessage:   conf.Kick.Message,
+		KickRewriter:  session.NewKickRewriter(rewrites, conf.Kick.Brand),
+
+		BroadcastLimit:  conf.Broadcast.Limit,
+		BroadcastWindow: time.Second * time.Duration(conf.Broadcast.Window),
+
+		Throttle:  throttle,
+		Meter:     meter,
+		Whitelist: whitelist,
+
+		VersionGate:            versionGate,
+		DeviceRestrictions:     restrictions,
+		BlockImpossibleDevices: conf.Devices.BlockImpossible,
+
+		HexdumpPackets: conf.Logger.Hexdump,
+
+		Experiments: session.NewExperiments(conf.Experiments),
+		Reporter:    reporter,
+
+		GameOverrides: &session.GameOverrides{
+			Experiments:               conf.StartGame.Experiments,
+			ClientSideGeneration:      conf.StartGame.ClientSideGeneration,
+			PersonaDisabled:           conf.StartGame.PersonaDisabled,
+			CustomSkinsDisabled:       conf.StartGame.CustomSkinsDisabled,
+			DisablePlayerInteractions: conf.StartGame.DisablePlayerInteractions,
+		},
+	})
+	if takeover != nil {
+		p.Handle(takeover.Handler())
+	}
+	pool := transport.NewPool(conf.Network.Pool.Size, time.Second*time.Duration(conf.Network.Pool.MaxIdle))
+	minecraft.RegisterNetwork(transport.NetworkPooledRakNet, pool)
+	// configured holds the servers registered from the config by their name, so that they can be updated when
+	// the config is reloaded.
+	configured := make(map[string]*server.Server)
+	registerServers(p, pool, conf, configured)
+	if conf.Health.LatencyRouting.Enabled {
+		p.SetLoadBalancer(session.NewLatencyLoadBalancer(p.ServerRegistry(), conf.Health.LatencyRouting.PlayerWeight))
+	}
+	if len(conf.Routes.Rules) > 0 {
+		regions, err := session.NewCIDRRegions(conf.Routes.Regions)
+		if err != nil {
+			logger.Fatalf("unable to parse regions: %v", err)
+		}
+		rules := make([]session.RouteRule, 0, len(conf.Routes.Rules))
+		for _, r := range conf.Routes.Rules {
+			devices, inputModes, err := parseDevices(r.Devices, r.InputModes)
+			if err != nil {
+				logger.Fatalf("unable to parse route rule for %s: %v", r.Group, err)
+			}
+			rules = append(rules, session.RouteRule{Group: r.Group, Locales: r.Locales, Regions: r.Regions, Devices: devices, InputModes: inputModes})
+		}
+		p.SetLoadBalancer(session.NewRouteLoadBalancer(p.LoadBalancer(), p.ServerRegistry(), rules, regions))
+	}
+	canary := session.NewCanaryLoadBalancer(p.LoadBalancer(), p.ServerRegistry())
+	for _, c := range conf.Canaries {
+		canary.SetCanary(c.Group, session.Canary{Server: c.Server, Percentage: c.Percentage, XUIDs: c.XUIDs})
+	}
+	p.SetLoadBalancer(canary)
+	var lim *limbo.Limbo
+	if conf.Limbo.Enabled {
+		lim = limbo.New(logger, conf.Limbo.Name, conf.Limbo.Block)
+		lim.SetMessage(conf.Limbo.Message)
+		if err := lim.Listen(transport.NetworkTCP, conf.Limbo.Address); err != nil {
+			logger.Fatalf("unable to start limbo: %v", err)
+		}
+		p.ServerRegistry().AddServer(lim.Server())
+		go lim.RetryJoin(p.SessionStore(), p.ServerRegistry(), conf.Limbo.RetryGroup, time.Second*time.Duration(conf.Limbo.RetryInterval))
+	}
+	// onStop holds functions that are called when the proxy receives a signal to shut down.
+	var onStop []func()
+	onStop = append(onStop, func() {
+		_ = pool.Close()
+	})
+	if conf.Handoff.Enabled {
+		var handoff session.HandoffStore = session.NewProviderHandoffStore(provider)
+		if provider == nil {
+			handoff, err = session.NewFileHandoffStore(conf.Handoff.File)
+			if err != nil {
+				logger.Fatalf("unable to load handoff file: %v", err)
+			}
+		}
+		p.SetLoadBalancer(session.NewHandoffLoadBalancer(handoff, p.ServerRegistry(), p.LoadBalancer(), time.Second*time.Duration(conf.Handoff.Expiry)))
+		onStop = append(onStop, func() {
+			if err := p.SaveHandoff(handoff); err != nil {
+				logger.Errorf("unable to save handoff file: %v", err)
+			}
+		})
+	}
+	notifier, err := webhookNotifier(logger, conf)
+	if err != nil {
+		logger.Fatalf("unable to create webhooks: %v", err)
+	}
+	onStop = append(onStop, func() {
+		notifier.Notify(webhook.EventStop, "Proxy is shutting down", nil)
+		notifier.Wait()
 	})
+	if conf.PlayerCount.Network && provider != nil {
+		id := conf.PlayerCount.ID
+		if id == "" {
+			host, _ := os.Hostname()
+			id = host + "/" + conf.Network.Address
+		}
+		count := portal.NewNetworkPlayerCount(provider, id, func() (int, int) {
+			return len(p.SessionStore().All()), 0
+		}, func(err error) {
+			logger.Errorf("failed to update network player count: %v", err)
+		})
+		status.SetNetworkPlayerCount(count)
+		go count.Run(time.Second * time.Duration(conf.PlayerCount.Interval))
+		onStop = append(onStop, func() {
+			if err := count.Close(); err != nil {
+				logger.Errorf("failed to remove player count: %v", err)
+			}
+		})
+	}
+	if conf.Heartbeat.Enabled {
+		address := conf.Heartbeat.Address
+		if address == "" {
+			address = conf.Network.Address
+		}
+		hb := heartbeat.NewSender(conf.Heartbeat.URL, conf.Heartbeat.Secret, func() heartbeat.Status {
+			i := p.Info()
+			return heartbeat.Status{
+				ID:               conf.Heartbeat.ID,
+				Address:          address,
+				Version:          i.Version,
+				Commit:           i.Commit,
+				MinecraftVersion: i.MinecraftVersion,
+				Protocol:         i.Protocol,
+				Players:          i.Players,
+				Servers:          i.Servers,
+				Started:          i.Started,
+			}
+		}, func(err error) {
+			logger.Errorf("failed to send heartbeat: %v", err)
+		})
+		go hb.Run(time.Second * time.Duration(conf.Heartbeat.Interval))
+		onStop = append(onStop, func() {
+			if err := hb.Close(); err != nil {
+				logger.Errorf("failed to send last heartbeat: %v", err)
+			}
+		})
+	}
+	onStop = append(onStop, func() {
+		// The listeners are closed first, so that no players join while the proxy is shutting down.
+		if err := p.Close(); err != nil {
+			logger.Errorf("unable to close listeners: %v", err)
+		}
+		for _, s := range p.SessionStore().All() {
+			s.DisconnectWithReason("Proxy is shutting down.", session.CloseProxyShutdown)
+		}
+	})
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() {
+			for _, f := range onStop {
+				f()
+			}
+			os.Exit(0)
+		})
+	}
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		<-c
+		stop()
+	}()
+	p.Handle(webhook.NewTransferFailures(notifier, conf.Webhooks.TransferFailures.Limit, time.Second*time.Duration(conf.Webhooks.TransferFailures.Window)).Handler())
+	health := server.NewHealthChecker(p.ServerRegistry(), time.Second*time.Duration(conf.Health.Timeout))
+	health.OnChange(notifier.ServerHealthChanged)
+	health.OnPacksChange(func(srv *server.Server, previous, current []server.Pack) {
+		added, removed := server.DiffPacks(previous, current)
+		logger.Errorf("packs of server %s changed: added %v, removed %v", srv.Name(), added, removed)
+	})
+	go health.Run(time.Second * time.Duration(conf.Health.Interval))
+	if len(conf.Webhooks.PlayerThresholds) > 0 {
+		go notifier.WatchPlayerCount(func() int {
+			return len(p.SessionStore().All())
+		}, conf.Webhooks.PlayerThresholds, time.Second*5)
+	}
+	p.Handle(punishment.NewMuteHandler(punishments, conf.Punishments.MutedCommands...))
+	if conf.Chat.Enabled {
+		p.Handle(chat.NewModerator(chat.Config{
+			RateLimit:    conf.Chat.RateLimit,
+			RateWindow:   time.Second * time.Duration(conf.Chat.RateWindow),
+			MaxLength:    conf.Chat.MaxLength,
+			RepeatLimit:  conf.Chat.RepeatLimit,
+			Violations:   conf.Chat.Violations,
+			MuteDuration: time.Second * time.Duration(conf.Chat.MuteDuration),
+		}, punishments, logger).Handler())
+	}
+	var tracker *stats.Tracker
+	if conf.Stats.Enabled {
+		var store stats.Store = stats.NewProviderStore(provider)
+		if provider == nil {
+			store, err = stats.NewFileStore(conf.Stats.File)
+			if err != nil {
+				logger.Fatalf("unable to load statistics: %v", err)
+			}
+		}
+		tracker = stats.NewTracker(store, logger)
+		p.Handle(tracker.Handler())
+		onStop = append(onStop, func() {
+			if err := tracker.Close(); err != nil {
+				logger.Errorf("unable to save statistics: %v", err)
+			}
+		})
+	}
+	var auditLog *audit.Log
+	if conf.Audit.Enabled {
+		f, err := audit.NewRotatingFile(conf.Audit.File, int64(conf.Audit.MaxSize)*1024*1024)
+		if err != nil {
+			logger.Fatalf("unable to open audit log: %v", err)
+		}
+		auditLog = audit.NewLog(f, conf.Audit.Capacity)
+		p.Handle(audit.NewHandler(auditLog, logger))
+		onStop = append(onStop, func() {
+			if err := f.Close(); err != nil {
+				logger.Errorf("unable to close audit log: %v", err)
+			}
+		})
+	}
+	if conf.SessionLogs.Enabled {
+		sessionLog, err := sessionlog.NewLog(conf.SessionLogs.Directory, time.Hour*24*time.Duration(conf.SessionLogs.Retention), logger)
+		if err != nil {
+			logger.Fatalf("unable to open session logs: %v", err)
+		}
+		p.Handle(sessionlog.NewHandler(sessionLog, logger))
+		pruneStop := make(chan struct{})
+		go sessionLog.Run(time.Hour, pruneStop)
+		onStop = append(onStop, func() {
+			close(pruneStop)
+		})
+	}
+	sink, err := analyticsSink(logger, conf)
+	if err != nil {
+		logger.Fatalf("unable to create analytics sink: %v", err)
+	}
+	p.Handle(analytics.NewHandler(sink))
+	onStop = append(onStop, func() {
+		if err := sink.Close(); err != nil {
+			logger.Errorf("unable to close analytics sink: %v", err)
+		}
+	})
+	if provider != nil {
+		// The provider is closed after the sinks above, as they may still store data when they are closed.
+		onStop = append(onStop, func() {
+			if err := provider.Close(); err != nil {
+				logger.Errorf("unable to close storage: %v", err)
+			}
+		})
+	}
+
+	ranks := rankProvider(conf)
+	var queue *session.Queue
+	if conf.Queue.Enabled {
+		queue = session.NewQueue(p.LoadBalancer(), permission.NewQueuePriority(ranks, conf.Queue.Priority...), time.Second*time.Duration(conf.Queue.Interval))
+		if conf.Queue.Message != "" {
+			queue.OnUpdate(func(e session.QueueEntry, total int) {
+				e.Session.SendToast("Queue", strings.NewReplacer("{position}", strconv.Itoa(e.Position), "{total}", strconv.Itoa(total)).Replace(conf.Queue.Message))
+			})
+		}
+		p.SetLoadBalancer(queue)
+	}
+	if lim != nil && conf.Limbo.Fallback {
+		p.SetLoadBalancer(limbo.NewLoadBalancer(p.LoadBalancer(), lim))
+	}
+	if conf.DecorateRanks {
+		p.SessionStore().SetDecorator(permission.NewDecorator(ranks))
+	}
+	if conf.JoinMessages.Enabled {
+		servers := make(map[string]presence.Messages, len(conf.JoinMessages.Servers))
+		for name, m := range conf.JoinMessages.Servers {
+			servers[name] = presence.Messages{Join: m.Join, Quit: m.Quit, Suppress: m.Suppress}
+		}
+		announcer, err := presence.New(p.SessionStore(), presence.Config{
+			Scope:    presence.Scope(conf.JoinMessages.Scope),
+			Messages: presence.Messages{Join: conf.JoinMessages.Join, Quit: conf.JoinMessages.Quit, Suppress: conf.JoinMessages.Suppress},
+			Servers:  servers,
+			Ranks:    ranks,
+		})
+		if err != nil {
+			logger.Fatalf("unable to create join messages: %v", err)
+		}
+		p.Handle(announcer.Handler())
+	}
+	slowMode := chat.NewSlowMode(ranks)
+	for group, interval := range conf.Chat.SlowMode {
+		slowMode.Set(group, time.Second*time.Duration(interval))
+	}
+	p.Handle(slowMode.Handler())
+	commands := command.NewManager()
+	if auditLog != nil {
+		commands.SetAudit(auditLog, logger)
+	}
+	p.Handle(commands.Handler())
+	forms := form.NewManager(logger)
+	p.Handle(forms.Handler())
+	if conf.ServerBrowser.Enabled {
+		commands.Register(browser.New(p.ServerRegistry(), forms).Command(conf.ServerBrowser.Command))
+	}
+	if conf.Resync.Enabled {
+		commands.Register(command.Resync(conf.Resync.Command))
+	}
+	if conf.Hub.Enabled {
+		commands.Register(command.Hub(conf.Hub.Command, p.ServerRegistry(), conf.Hub.Group, p.LoadBalancer()))
+	}
+	if conf.ProxyInfo.Enabled {
+		commands.Register(p.InfoCommand(conf.ProxyInfo.Command, command.Players(conf.Staff...)))
+	}
+	if conf.Whois.Enabled {
+		commands.Register(command.Whois(conf.Whois.Command, p.SessionStore(), auditLog, conf.Whois.MaskIP, command.Players(conf.Staff...)))
+	}
+	if conf.Diagnose.Enabled {
+		commands.Register(command.Diagnose(conf.Diagnose.Command, p.SessionStore(), command.Players(conf.Staff...)))
+	}
+	if conf.Compass.Enabled {
+		c := compass.New(p.SessionStore())
+		style := compass.StyleBossBar
+		if conf.Compass.Style == "sidebar" {
+			style = compass.StyleSidebar
+		}
+		h := c.Handler()
+		p.Handle(func(s *session.Session) session.Handler {
+			c.Show(s, style)
+			return h(s)
+		})
+		go c.Run(time.Second*time.Duration(conf.Compass.Interval), nil)
+	}
+	if conf.Fallback.Enabled {
+		p.Handle(session.NewFallbackHandler(p.LoadBalancer(), time.Second*time.Duration(conf.Fallback.Expiry)))
+	}
+	printBanner(logger, conf)
+	if conf.Startup.Validate {
+		d := conf.Validate()
+		d.Merge(p.Validate())
+		for _, problem := range d.Problems() {
+			if problem.Severity == portal.SeverityFatal {
+				logger.Errorf("startup check: %s", problem.Message)
+			} else {
+				logger.Warnf("startup check: %s", problem.Message)
+			}
+		}
+		if d.Fatal() && conf.Startup.RefuseOnFatal {
+			logger.Fatalf("refusing to start: fatal problems were found during the startup check")
+		}
+	}
 	if err := p.Listen(); err != nil {
 		logger.Fatalf("failed to listen on %s: %v", conf.Network.Address, err)
 	}
 
 	socketServer := socket.NewDefaultServer(conf.Network.Communication.Address, conf.Network.Communication.Secret, p.SessionStore(), p.ServerRegistry(), logger, conf.Network.ReaderLimits)
+	if queue != nil {
+		socketServer.SetQueue(queue)
+	}
+	if auditLog != nil {
+		socketServer.SetAudit(auditLog)
+	}
+	socketServer.SetRestarts(restart.NewScheduler(p.SessionStore(), p.ServerRegistry(), health, logger))
+	p.Handle(socketServer.TransferEvents())
+	p.Handle(socketServer.QuitEvents())
+	p.Handle(socketServer.CorrelationIDs())
+	socketServer.SetBroadcaster(p.Broadcaster())
+	socketServer.SetCanary(canary)
+	socketServer.SetSlowMode(slowMode)
+	socketServer.SetState(p)
+	socketServer.SetProxyInfo(func() *packet.ProxyInfoResponse {
+		i := p.Info()
+		return &packet.ProxyInfoResponse{
+			Version:          i.Version,
+			Commit:           i.Commit,
+			GoVersion:        i.GoVersion,
+			StartTime:        i.Started.Unix(),
+			Protocol:         i.Protocol,
+			MinecraftVersion: i.MinecraftVersion,
+			Players:          int32(i.Players),
+			Servers:          int32(i.Servers),
+		}
+	})
+	if tracker != nil {
+		socketServer.SetStats(tracker)
+	}
+	for _, a := range conf.Announcements {
+		a := broadcast.Announcement{
+			Interval: time.Second * time.Duration(a.Interval),
+			Messages: a.Messages,
+			Target:   broadcast.Target{Group: a.Group, Server: a.Server},
+			Mode:     broadcast.Mode(a.Mode),
+		}
+		go func() {
+			if err := p.Broadcaster().Announce(a, nil); err != nil {
+				logger.Errorf("unable to run announcement: %v", err)
+			}
+		}()
+	}
 	if err := socketServer.Listen(); err != nil {
 		p.Logger().Fatalf("socket server failed to listen: %v", err)
 	}
+	notifier.Notify(webhook.EventStart, fmt.Sprintf("Proxy started on %s", conf.Network.Address), map[string]any{"address": conf.Network.Address})
 	if conf.PlayerLatency.Report {
 		go socketServer.ReportPlayerLatency(time.Second * time.Duration(conf.PlayerLatency.UpdateInterval))
 	}
+	cons := newConsole(p, stop, func() error {
+		c, err := reloadConfig()
+		if err != nil {
+			return err
+		}
+		registerServers(p, pool, c, configured)
+		logger.Infof("reloaded %d servers from the config", len(c.Servers))
+		return nil
+	})
+	logger.SetOutput(cons.Writer(logger.Out))
+	// The terminal is restored last, after any output of the other functions.
+	onStop = append(onStop, cons.Restore)
+	go func() {
+		if err := cons.Run(); err != nil {
+			logger.Errorf("console stopped: %v", err)
+		}
+	}()
 
 	for {
 		s, err := p.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			// Every listener is closed, so the proxy is shut down. If it is already shutting down, stop waits
+			// for it to finish.
+			stop()
+		}
 		if err != nil {
-			s.Disconnect(text.Colourf("<red>%v</red>", err))
+			if s != nil {
+				s.Disconnect(text.Colourf("<red>%v</red>", err))
+			}
 			p.Logger().Errorf("failed to accept connection: %v", err)
 			continue
 		}
@@ -84,28 +697,179 @@ func main() {
 	}
 }
 
+// analyticsSink creates the analytics sink set in the config.
+func analyticsSink(logger internal.Logger, conf portal.Config) (analytics.Sink, error) {
+	switch conf.Analytics.Sink {
+	case "file":
+		return analytics.NewFileSink(conf.Analytics.File)
+	case "http":
+		return analytics.NewHTTPSink(conf.Analytics.URL, 1000, time.Second*10, func(err error) {
+			logger.Errorf("failed to send analytics events: %v", err)
+		}), nil
+	case "none", "":
+		return analytics.NopSink{}, nil
+	}
+	return nil, fmt.Errorf("unknown analytics sink %q", conf.Analytics.Sink)
+}
+
+// rankProvider creates a provider of the ranks set in the config.
+func rankProvider(conf portal.Config) *permission.StaticProvider {
+	ranks := make([]permission.Rank, 0, len(conf.Ranks))
+	for _, r := range conf.Ranks {
+		ranks = append(ranks, permission.Rank{Name: r.Name, Prefix: r.Prefix, Colour: r.Colour, Permissions: r.Permissions})
+	}
+	provider := permission.NewStaticProvider(conf.DefaultRank, ranks...)
+	for _, r := range conf.Ranks {
+		for _, player := range r.Players {
+			provider.Assign(player, r.Name)
+		}
+	}
+	return provider
+}
+
+// webhookNotifier creates a notifier for the webhooks set in the config.
+func webhookNotifier(logger internal.Logger, conf portal.Config) (*webhook.Notifier, error) {
+	var hooks []*webhook.Webhook
+	for _, h := range conf.Webhooks.Hooks {
+		events := make([]webhook.EventType, 0, len(h.Events))
+		for _, e := range h.Events {
+			events = append(events, webhook.EventType(e))
+		}
+		w, err := webhook.New(h.URL, h.Format, events, h.Template)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, w)
+	}
+	return webhook.NewNotifier(logger, hooks...), nil
+}
+
+// printBanner logs a short overview of the proxy's configuration when it starts.
+func printBanner(logger internal.Logger, conf portal.Config) {
+	logger.Infof("starting portal on %s (communication on %s)", conf.Network.Address, conf.Network.Communication.Address)
+	for _, l := range conf.Network.Listeners {
+		logger.Infof("additionally listening on %s %s", l.Network, l.Address)
+	}
+	logger.Infof("%d server(s) configured", len(conf.Servers))
+}
+
+// configFiles holds the paths of the configuration files that are looked for, in order of preference. If
+// none of them exist, a default configuration is written to the first path.
+var configFiles = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
 func readConfig(logger internal.Logger) portal.Config {
+	for _, path := range configFiles {
+		if _, err := os.Stat(path); err == nil {
+			c, err := portal.LoadConfig(path)
+			if err != nil {
+				logger.Fatalf("error reading config: %v", err)
+			}
+			return c
+		}
+	}
 	c := portal.DefaultConfig()
-	if _, err := os.Stat("config.json"); os.IsNotExist(err) {
-		f, err := os.Create("config.json")
-		if err != nil {
-			logger.Fatalf("error creating config: %v", err)
+	if err := portal.WriteConfig(configFiles[0], c); err != nil {
+		logger.Fatalf("error writing default config: %v", err)
+	}
+	return c
+}
+
+// reloadConfig reads the config file again and applies the environment variables and flags to it, in the same
+// way as the config is read when the proxy starts.
+func reloadConfig() (portal.Config, error) {
+	for _, path := range configFiles {
+		if _, err := os.Stat(path); err != nil {
+			continue
 		}
-		data, err := json.MarshalIndent(c, "", "\t")
+		c, err := portal.LoadConfig(path)
 		if err != nil {
-			logger.Fatalf("error encoding default config: %v", err)
+			return c, err
+		}
+		if err := c.ApplyEnv("PORTAL"); err != nil {
+			return c, err
 		}
-		if _, err := f.Write(data); err != nil {
-			logger.Fatalf("error writing encoded default config: %v", err)
+		if err := c.ApplyFlags(os.Args[1:]); err != nil {
+			return c, err
 		}
-		_ = f.Close()
+		return c, nil
 	}
-	data, err := ioutil.ReadFile("config.json")
-	if err != nil {
-		logger.Fatalf("error reading config: %v", err)
+	return portal.Config{}, errors.New("no config file found")
+}
+
+// registerServers registers the servers in the config with the proxy. Servers that were registered before are
+// updated, and servers in configured that are no longer in the config are removed, so that the function may
+// be called again when the config is reloaded. Players on removed servers are not moved.
+func registerServers(p *portal.Portal, pool *transport.Pool, conf portal.Config, configured map[string]*server.Server) {
+	seen := make(map[string]bool)
+	for _, srv := range conf.Servers {
+		name, network := strings.ToLower(srv.Name), srv.Network
+		if network == "" {
+			network = transport.NetworkRakNet
+		}
+		seen[name] = true
+
+		s, ok := configured[name]
+		if ok && (s.Network() != network || s.Address() != srv.Address) {
+			p.ServerRegistry().RemoveServer(s)
+			ok = false
+		}
+		if !ok {
+			if network == transport.NetworkPooledRakNet {
+				pool.Warm(srv.Address)
+			}
+			s = server.NewWithNetwork(srv.Name, network, srv.Address)
+		}
+		s.SetGroup(srv.Group)
+		s.SetCapacity(srv.SoftCap, srv.MaxPlayers)
+		s.ReplaceMetadata(srv.Metadata)
+		s.SetFilter(server.PacketFilter{
+			ClientBound: srv.Filter.ClientBound.Policy(),
+			ServerBound: srv.Filter.ServerBound.Policy(),
+		})
+		if !ok {
+			configured[name] = s
+			p.ServerRegistry().AddServer(s)
+		}
 	}
-	if err := json.Unmarshal(data, &c); err != nil {
-		logger.Fatalf("error decoding config: %v", err)
+	for name, s := range configured {
+		if !seen[name] {
+			p.ServerRegistry().RemoveServer(s)
+			delete(configured, name)
+		}
 	}
-	return c
+}
+
+// parseDevices parses the names of devices and input modes passed, as used in the config.
+func parseDevices(deviceNames, inputModeNames []string) (devices []protocol.DeviceOS, inputModes []int, err error) {
+	for _, name := range deviceNames {
+		os, ok := session.ParseDeviceOS(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown device %q", name)
+		}
+		devices = append(devices, os)
+	}
+	for _, name := range inputModeNames {
+		mode, ok := session.ParseInputMode(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown input mode %q", name)
+		}
+		inputModes = append(inputModes, mode)
+	}
+	return devices, inputModes, nil
+}
+
+// runCommand runs a command passed as command line arguments to the program, such as "config convert", and
+// returns true if a command was run.
+func runCommand(logger internal.Logger, args []string) bool {
+	if len(args) < 2 || args[0] != "config" || args[1] != "convert" {
+		return false
+	}
+	if len(args) != 4 {
+		logger.Fatalf("usage: portal config convert <source> <destination>")
+	}
+	if err := portal.ConvertConfig(args[2], args[3]); err != nil {
+		logger.Fatalf("error converting config: %v", err)
+	}
+	logger.Infof("converted %s to %s", args[2], args[3])
+	return true
 }
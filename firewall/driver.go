@@ -0,0 +1,132 @@
+package firewall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Driver notifies an external firewall, such as an nftables set or the firewall of a cloud provider, of IP
+// addresses banned by the proxy, so that their traffic is dropped before it reaches the proxy at all.
+type Driver interface {
+	// Block blocks the traffic of the IP address passed until the time passed. If the time is zero, the IP
+	// address is blocked until Unblock is called.
+	Block(ip string, until time.Time) error
+	// Unblock stops blocking the traffic of the IP address passed.
+	Unblock(ip string) error
+}
+
+// ExecDriver is a Driver that runs a command to block and unblock IP addresses, such as a script adding them to
+// an ipset or nftables set. Any "{ip}" in the arguments of the commands is replaced with the IP address and any
+// "{timeout}" with the number of seconds the IP address is blocked for, which is zero if it is blocked forever.
+type ExecDriver struct {
+	block, unblock []string
+}
+
+// NewExecDriver returns an ExecDriver running the commands passed, each consisting of the path of the program
+// and its arguments. If the unblock command is empty, IP addresses are never unblocked by the driver, which
+// is useful if the firewall expires entries itself.
+func NewExecDriver(block, unblock []string) *ExecDriver {
+	return &ExecDriver{block: block, unblock: unblock}
+}
+
+// Block ...
+func (d *ExecDriver) Block(ip string, until time.Time) error {
+	timeout := 0
+	if !until.IsZero() {
+		timeout = int(time.Until(until).Seconds())
+	}
+	return run(d.block, strings.NewReplacer("{ip}", ip, "{timeout}", strconv.Itoa(timeout)))
+}
+
+// Unblock ...
+func (d *ExecDriver) Unblock(ip string) error {
+	return run(d.unblock, strings.NewReplacer("{ip}", ip, "{timeout}", "0"))
+}
+
+// run runs the command passed after replacing its arguments using the replacer passed.
+func run(command []string, r *strings.Replacer) error {
+	if len(command) == 0 {
+		return nil
+	}
+	args := make([]string, len(command)-1)
+	for i, arg := range command[1:] {
+		args[i] = r.Replace(arg)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, command[0], args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("run %s: %w: %s", command[0], err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// HTTPDriver is a Driver that sends a POST request to an HTTP endpoint to block and unblock IP addresses, such
+// as the API of a cloud firewall or a small service managing one. The body of each request is a JSON object
+// holding the "action", which is either "block" or "unblock", the "ip" and, when blocking, the "until" time.
+type HTTPDriver struct {
+	url    string
+	header http.Header
+	client *http.Client
+}
+
+// NewHTTPDriver returns an HTTPDriver sending requests to the URL passed. The header passed, which may hold an
+// authorization token, is added to every request and may be nil.
+func NewHTTPDriver(url string, header http.Header) *HTTPDriver {
+	return &HTTPDriver{url: url, header: header, client: &http.Client{Timeout: time.Second * 10}}
+}
+
+// httpRequest is the body of a request sent by an HTTPDriver.
+type httpRequest struct {
+	Action string     `json:"action"`
+	IP     string     `json:"ip"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// Block ...
+func (d *HTTPDriver) Block(ip string, until time.Time) error {
+	req := httpRequest{Action: "block", IP: ip}
+	if !until.IsZero() {
+		req.Until = &until
+	}
+	return d.send(req)
+}
+
+// Unblock ...
+func (d *HTTPDriver) Unblock(ip string) error {
+	return d.send(httpRequest{Action: "unblock", IP: ip})
+}
+
+// send sends the request passed to the endpoint of the driver.
+func (d *HTTPDriver) send(r httpRequest) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range d.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("firewall endpoint responded with status %s", resp.Status)
+	}
+	return nil
+}
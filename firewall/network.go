@@ -0,0 +1,49 @@
+package firewall
+
+import (
+	"net"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// Wrap returns a minecraft.Network wrapping around the network passed, whose listeners close connections from
+// IP addresses banned by the throttle and track the logins of other connections. The network returned should be
+// registered using minecraft.RegisterNetwork under the name of the network the proxy listens on.
+func (t *Throttle) Wrap(n minecraft.Network) minecraft.Network {
+	return network{Network: n, t: t}
+}
+
+// network is a minecraft.Network whose listeners are wrapped by a Throttle.
+type network struct {
+	minecraft.Network
+	t *Throttle
+}
+
+// Listen ...
+func (n network) Listen(address string) (minecraft.NetworkListener, error) {
+	l, err := n.Network.Listen(address)
+	if err != nil {
+		return nil, err
+	}
+	return listener{NetworkListener: l, t: n.t}, nil
+}
+
+// listener is a minecraft.NetworkListener that closes connections rejected by a Throttle.
+type listener struct {
+	minecraft.NetworkListener
+	t *Throttle
+}
+
+// Accept ...
+func (l listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.NetworkListener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.t.accept(conn.RemoteAddr()) {
+			return conn, nil
+		}
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,218 @@
+// Package firewall implements protection of the listeners of the proxy against abusive connections, such as
+// clients repeatedly failing to log in, and notifies external firewalls of the IP addresses it bans.
+package firewall
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/punishment"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"go.uber.org/atomic"
+)
+
+// Config holds the settings of a Throttle.
+type Config struct {
+	// Limit is the number of failed logins from an IP address within the window after which it is banned.
+	Limit int
+	// Window is the window in which at most Limit logins from an IP address may fail.
+	Window time.Duration
+	// Timeout is the time within which a connection must reach the resource pack stage of the login sequence.
+	// Connections that do not, for example because their login chain is invalid or because they stopped
+	// responding, count as failed logins.
+	Timeout time.Duration
+	// BanDuration is the duration an IP address is banned for. If zero, bans never expire.
+	BanDuration time.Duration
+	// Drivers are notified of every IP address banned, so that external firewalls drop its traffic until the
+	// ban expires.
+	Drivers []Driver
+}
+
+// Metrics holds the number of connections handled by a Throttle since it was created.
+type Metrics struct {
+	// Failed is the number of logins that failed.
+	Failed uint64
+	// Blocked is the number of connections that were closed because their IP address was banned.
+	Blocked uint64
+	// Bans is the number of IP addresses that were banned.
+	Bans uint64
+}
+
+// Throttle tracks the failed logins of IP addresses and bans those that fail to log in too often. Connections
+// from banned IP addresses are closed as soon as they are accepted by a listener wrapped using Wrap, before any
+// of the login sequence is handled. Loopback addresses are never banned.
+type Throttle struct {
+	conf Config
+	bans punishment.Store
+	log  internal.Logger
+
+	mu       sync.Mutex
+	pending  map[string]pendingLogin
+	failures map[string][]time.Time
+
+	failed, blocked, banned atomic.Uint64
+}
+
+// pendingLogin is a connection that was accepted, but has not yet finished logging in.
+type pendingLogin struct {
+	ip       string
+	accepted time.Time
+}
+
+// NewThrottle returns a Throttle using the settings passed, which stores the IP bans it creates in the store
+// passed. Bans created by other means in the store are enforced too.
+func NewThrottle(conf Config, bans punishment.Store, log internal.Logger) *Throttle {
+	if conf.Timeout <= 0 {
+		conf.Timeout = time.Minute
+	}
+	return &Throttle{
+		conf:     conf,
+		bans:     bans,
+		log:      log,
+		pending:  make(map[string]pendingLogin),
+		failures: make(map[string][]time.Time),
+	}
+}
+
+// Succeed marks the login of the connection with the remote address passed as successful. It must be called
+// for every connection that finishes logging in, as connections that do not are counted as failed logins.
+func (t *Throttle) Succeed(addr net.Addr) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, addr.String())
+}
+
+// PacketFunc returns a function that may be used as the PacketFunc of a minecraft.ListenConfig, which stops the
+// timeout of a connection as soon as it reaches the resource pack stage of the login sequence. Only clients with
+// a valid login reach it, and downloading resource packs may take longer than the timeout, so connections that
+// reach it are never counted as failed logins. The function passed, which may be nil, is called for every packet.
+func (t *Throttle) PacketFunc(f func(header packet.Header, payload []byte, src, dst net.Addr)) func(header packet.Header, payload []byte, src, dst net.Addr) {
+	return func(header packet.Header, payload []byte, src, dst net.Addr) {
+		if header.PacketID == packet.IDResourcePacksInfo {
+			// The packet is written by the proxy, so the destination is the address of the client.
+			t.Succeed(dst)
+		}
+		if f != nil {
+			f(header, payload, src, dst)
+		}
+	}
+}
+
+// Run counts the connections that did not reach the resource pack stage within the timeout as failed logins
+// every interval, until the channel passed is closed. Without Run, they are only counted when another connection
+// is accepted. It blocks until the channel is closed, so it should generally be called in a separate goroutine.
+func (t *Throttle) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.sweep()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Metrics returns the number of connections handled by the throttle since it was created.
+func (t *Throttle) Metrics() Metrics {
+	return Metrics{Failed: t.failed.Load(), Blocked: t.blocked.Load(), Bans: t.banned.Load()}
+}
+
+// accept handles a connection with the remote address passed being accepted by a listener. It returns false if
+// the connection must be closed.
+func (t *Throttle) accept(addr net.Addr) bool {
+	ip := addressIP(addr)
+	if ip == nil || ip.IsLoopback() {
+		return true
+	}
+	t.sweep()
+	if _, ok := t.bans.IPBanned(ip.String()); ok {
+		t.blocked.Inc()
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[addr.String()] = pendingLogin{ip: ip.String(), accepted: time.Now()}
+	return true
+}
+
+// sweep counts the connections that did not finish logging in within the timeout as failed logins, and bans
+// the IP addresses that exceeded the limit.
+func (t *Throttle) sweep() {
+	now := time.Now()
+
+	t.mu.Lock()
+	var exceeded []string
+	for addr, p := range t.pending {
+		if now.Sub(p.accepted) < t.conf.Timeout {
+			continue
+		}
+		delete(t.pending, addr)
+		t.failed.Inc()
+
+		failures := t.failures[p.ip][:0]
+		for _, f := range t.failures[p.ip] {
+			if now.Sub(f) < t.conf.Window {
+				failures = append(failures, f)
+			}
+		}
+		failures = append(failures, now)
+		if len(failures) >= t.conf.Limit {
+			delete(t.failures, p.ip)
+			exceeded = append(exceeded, p.ip)
+			continue
+		}
+		t.failures[p.ip] = failures
+	}
+	t.mu.Unlock()
+
+	for _, ip := range exceeded {
+		t.ban(ip, now)
+	}
+}
+
+// ban bans the IP address passed for the ban duration of the throttle.
+func (t *Throttle) ban(ip string, now time.Time) {
+	b := punishment.IPBan{IP: ip, Reason: "Too many failed logins", Source: "throttle", Created: now}
+	if t.conf.BanDuration > 0 {
+		b.Expiry = now.Add(t.conf.BanDuration)
+	}
+	if err := t.bans.BanIP(b); err != nil {
+		t.log.Errorf("failed to ban %s after too many failed logins: %v", ip, err)
+		return
+	}
+	t.banned.Inc()
+	t.log.Infof("banned %s for %s after too many failed logins", ip, t.conf.BanDuration)
+	for _, d := range t.conf.Drivers {
+		go t.block(d, b)
+	}
+}
+
+// block blocks the IP address of the ban passed using the driver passed, and unblocks it once the ban expires.
+func (t *Throttle) block(d Driver, b punishment.IPBan) {
+	if err := d.Block(b.IP, b.Expiry); err != nil {
+		t.log.Errorf("failed to block %s in firewall: %v", b.IP, err)
+		return
+	}
+	if b.Expiry.IsZero() {
+		return
+	}
+	time.AfterFunc(time.Until(b.Expiry), func() {
+		if err := d.Unblock(b.IP); err != nil {
+			t.log.Errorf("failed to unblock %s in firewall: %v", b.IP, err)
+		}
+	})
+}
+
+// addressIP returns the IP of the address passed, or nil if it has none.
+func addressIP(addr net.Addr) net.IP {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
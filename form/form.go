@@ -0,0 +1,85 @@
+// Package form implements forms that are sent by the proxy itself rather than by the server that the player is
+// connected to. Responses to these forms are intercepted before they reach the server.
+package form
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/paroxity/portal/session"
+)
+
+// Form is a form that may be sent to a player using a Manager.
+type Form interface {
+	json.Marshaler
+	// Submit handles the response of the player to the form. The response is nil if the player closed the form
+	// without responding.
+	Submit(s *session.Session, response []byte) error
+}
+
+// Menu is a form with a list of buttons, of which the player may press one.
+type Menu struct {
+	// Title is the title shown at the top of the menu.
+	Title string
+	// Content is the text shown above the buttons of the menu.
+	Content string
+	// Buttons holds the buttons of the menu.
+	Buttons []Button
+	// Close is called if the player closes the menu without pressing a button. It may be nil.
+	Close func(s *session.Session)
+}
+
+// Button is a button in a Menu.
+type Button struct {
+	// Text is the text shown on the button.
+	Text string
+	// Image is the path to a texture in a resource pack, such as "textures/ui/confirm", or a URL to an image.
+	// It may be empty.
+	Image string
+	// Press is called when the player presses the button.
+	Press func(s *session.Session)
+}
+
+// MarshalJSON ...
+func (m Menu) MarshalJSON() ([]byte, error) {
+	buttons := make([]map[string]any, 0, len(m.Buttons))
+	for _, b := range m.Buttons {
+		button := map[string]any{"text": b.Text}
+		if b.Image != "" {
+			t := "path"
+			if strings.HasPrefix(b.Image, "http") {
+				t = "url"
+			}
+			button["image"] = map[string]any{"type": t, "data": b.Image}
+		}
+		buttons = append(buttons, button)
+	}
+	return json.Marshal(map[string]any{
+		"type":    "form",
+		"title":   m.Title,
+		"content": m.Content,
+		"buttons": buttons,
+	})
+}
+
+// Submit ...
+func (m Menu) Submit(s *session.Session, response []byte) error {
+	if response == nil {
+		if m.Close != nil {
+			m.Close(s)
+		}
+		return nil
+	}
+	var index int
+	if err := json.Unmarshal(response, &index); err != nil {
+		return err
+	}
+	if index < 0 || index >= len(m.Buttons) {
+		return fmt.Errorf("button index %d out of range", index)
+	}
+	if press := m.Buttons[index].Press; press != nil {
+		press(s)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package form
+
+import (
+	"sync"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"go.uber.org/atomic"
+)
+
+// idOffset is the ID of the first form sent by the proxy. Forms sent by servers usually have low IDs, so the
+// IDs of the proxy start high enough not to collide with them.
+const idOffset = 1 << 30
+
+// Manager sends forms to players and handles their responses. Its handler must be added to sessions using
+// portal.Handle for responses to be handled.
+type Manager struct {
+	log internal.Logger
+	id  atomic.Uint32
+
+	mu      sync.Mutex
+	pending map[*session.Session]map[uint32]Form
+}
+
+// NewManager returns a new Manager which logs errors that occur while handling responses to the logger passed.
+func NewManager(log internal.Logger) *Manager {
+	m := &Manager{log: log, pending: make(map[*session.Session]map[uint32]Form)}
+	m.id.Store(idOffset)
+	return m
+}
+
+// Send sends the form passed to the session passed.
+func (m *Manager) Send(s *session.Session, f Form) error {
+	data, err := f.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	id := m.id.Inc()
+
+	m.mu.Lock()
+	if m.pending[s] == nil {
+		m.pending[s] = make(map[uint32]Form)
+	}
+	m.pending[s][id] = f
+	m.mu.Unlock()
+
+	return s.Conn().WritePacket(&packet.ModalFormRequest{FormID: id, FormData: data})
+}
+
+// Handler returns a function that creates a session.Handler which handles the responses of the session to the
+// forms sent by the manager. The function returned may be passed to portal.Handle directly.
+func (m *Manager) Handler() func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{m: m, s: s}
+	}
+}
+
+// take removes and returns the form with the ID passed that was sent to the session passed.
+func (m *Manager) take(s *session.Session, id uint32) (Form, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.pending[s][id]
+	delete(m.pending[s], id)
+	return f, ok
+}
+
+// handler is the session.Handler of a Manager for a single session.
+type handler struct {
+	session.NopHandler
+	m *Manager
+	s *session.Session
+}
+
+// HandleServerBoundPacket ...
+func (h *handler) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	resp, ok := pk.(*packet.ModalFormResponse)
+	if !ok {
+		return
+	}
+	f, ok := h.m.take(h.s, resp.FormID)
+	if !ok {
+		return
+	}
+	ctx.Cancel()
+
+	var data []byte
+	if d, ok := resp.ResponseData.Value(); ok && string(d) != "null" {
+		data = d
+	}
+	if err := f.Submit(h.s, data); err != nil {
+		h.m.log.Debugf("invalid response of %s to form %d: %v", h.s.IdentityData().DisplayName, resp.FormID, err)
+	}
+}
+
+// HandleQuit ...
+func (h *handler) HandleQuit(session.CloseReason) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	delete(h.m.pending, h.s)
+}
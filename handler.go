@@ -0,0 +1,65 @@
+package portal
+
+import (
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// Handler handles events that are called by the proxy itself, rather than by a specific session. These events
+// are generally called before a session is created for a connection.
+type Handler interface {
+	// HandleAccept handles a connection being accepted by one of the listeners of the proxy. At this point the
+	// login data of the connection, such as its identity and client data, has been parsed, but no session has
+	// been created yet. ctx.Cancel() may be called to disconnect the connection, in which case the message
+	// stored under the DisconnectMessage key using event.Store is shown to the player.
+	HandleAccept(ctx *event.Context, conn *minecraft.Conn)
+	// HandleVerify handles the proxy deciding if a connection is verified, which is the case if the player is
+	// authenticated with Xbox Live. The decision is stored under the Verified key and may be changed using
+	// event.Store. If the proxy requires XUIDs and the connection is not verified, or if ctx.Cancel() is
+	// called, the connection is disconnected with the message stored under the DisconnectMessage key.
+	HandleVerify(ctx *event.Context, conn *minecraft.Conn)
+	// HandleDuplicateLogin handles a connection of a player whose UUID is already used by an open session. The
+	// policy that is applied is stored under the DuplicateLogin key and may be changed using event.Store.
+	// ctx.Cancel() may be called to disconnect the new connection regardless of the policy.
+	HandleDuplicateLogin(ctx *event.Context, conn *minecraft.Conn, existing *session.Session)
+	// HandleDevice handles the proxy deciding if the device of a connection is allowed to join, based on the
+	// device restrictions of the proxy. The decision is stored under the DeviceAllowed key and may be changed
+	// using event.Store. If the device is not allowed, or if ctx.Cancel() is called, the connection is
+	// disconnected with the message stored under the DisconnectMessage key.
+	HandleDevice(ctx *event.Context, conn *minecraft.Conn)
+}
+
+// DisconnectMessage is the key of the message shown to a player when an event that results in the player being
+// disconnected is cancelled, such as HandleAccept.
+var DisconnectMessage = event.NewKey[string]("disconnect message")
+
+// Verified is the key of the decision if a connection is verified in the context passed to HandleVerify.
+var Verified = event.NewKey[bool]("verified")
+
+// DeviceAllowed is the key of the decision if the device of a connection is allowed in the context passed to
+// HandleDevice.
+var DeviceAllowed = event.NewKey[bool]("device allowed")
+
+// DuplicateLogin is the key of the DuplicatePolicy applied in the context passed to HandleDuplicateLogin.
+var DuplicateLogin = event.NewKey[DuplicatePolicy]("duplicate login policy")
+
+// NopHandler implements the Handler interface but does not execute any code when an event is called. The
+// default handler of the proxy is set to NopHandler.
+// Users may embed NopHandler to avoid having to implement each method.
+type NopHandler struct{}
+
+// Compile time check to make sure NopHandler implements Handler.
+var _ Handler = (*NopHandler)(nil)
+
+// HandleAccept ...
+func (NopHandler) HandleAccept(*event.Context, *minecraft.Conn) {}
+
+// HandleVerify ...
+func (NopHandler) HandleVerify(*event.Context, *minecraft.Conn) {}
+
+// HandleDuplicateLogin ...
+func (NopHandler) HandleDuplicateLogin(*event.Context, *minecraft.Conn, *session.Session) {}
+
+// HandleDevice ...
+func (NopHandler) HandleDevice(*event.Context, *minecraft.Conn) {}
@@ -0,0 +1,190 @@
+// Package heartbeat periodically reports the status of the proxy to a master server, such as a server list or
+// the orchestration service of a network running many proxies, so that proxies that stopped reporting can be
+// taken out of rotation.
+package heartbeat
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is the status of the proxy sent in a heartbeat.
+type Status struct {
+	// ID identifies the proxy among the proxies reporting to the same master server.
+	ID string `json:"id"`
+	// Address is the address at which players are able to reach the proxy, in the format of "host:port".
+	Address string `json:"address"`
+	// Online is false in the last heartbeat sent when the proxy stops.
+	Online bool `json:"online"`
+	// Version and Commit are the version and git commit of the proxy.
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	// MinecraftVersion and Protocol are the version of Minecraft supported by the proxy and its protocol version.
+	MinecraftVersion string `json:"minecraft_version"`
+	Protocol         int32  `json:"protocol"`
+	// Players is the number of players connected to the proxy, and Servers the number of servers registered.
+	Players int `json:"players"`
+	Servers int `json:"servers"`
+	// Started is the time the proxy was started at.
+	Started time.Time `json:"started"`
+	// Time is the time at which the heartbeat was sent.
+	Time time.Time `json:"time"`
+}
+
+// Header names of the signature of heartbeats.
+const (
+	// TimestampHeader holds the time at which a heartbeat was signed as a Unix timestamp.
+	TimestampHeader = "X-Portal-Timestamp"
+	// SignatureHeader holds the signature of a heartbeat, in the format of "sha256=<hex>".
+	SignatureHeader = "X-Portal-Signature"
+)
+
+// Sender sends heartbeats with the status of the proxy to a master server. Each heartbeat is sent as a POST
+// request with the Status in the JSON format as body. If a secret is set, heartbeats are signed using an
+// HMAC-SHA256 of the timestamp in the TimestampHeader, a dot and the body, so that the master server is able to
+// verify them using Verify.
+type Sender struct {
+	url    string
+	secret []byte
+	client *http.Client
+	status func() Status
+	errf   func(err error)
+
+	// mu serialises heartbeats, so that no heartbeat is sent after the last one sent by Close.
+	mu      sync.Mutex
+	stopped bool
+	stop    chan struct{}
+}
+
+// NewSender creates a Sender that sends heartbeats to the URL passed, signed with the secret passed if it is not
+// empty. The status sent is returned by the function passed. The function passed is called with any error that
+// occurs while sending a heartbeat, and may be nil.
+func NewSender(url, secret string, status func() Status, errf func(err error)) *Sender {
+	if errf == nil {
+		errf = func(error) {}
+	}
+	return &Sender{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: time.Second * 10},
+		status: status,
+		errf:   errf,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Run sends a heartbeat immediately and then every interval, until the Sender is closed. It blocks until then,
+// so it should generally be called in a separate goroutine.
+func (s *Sender) Run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := s.Send(); err != nil {
+			s.errf(err)
+		}
+		select {
+		case <-t.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Send sends a single heartbeat with the current status of the proxy. No heartbeat is sent once the Sender is
+// closed.
+func (s *Sender) Send() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil
+	}
+	st := s.status()
+	st.Online = true
+	return s.send(st)
+}
+
+// Close stops the Sender and sends a last heartbeat with Online set to false, so that the master server is able
+// to remove the proxy immediately rather than waiting for its heartbeats to time out.
+func (s *Sender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil
+	}
+	s.stopped = true
+	close(s.stop)
+
+	st := s.status()
+	st.Online = false
+	return s.send(st)
+}
+
+// send sends a heartbeat with the status passed.
+func (s *Sender) send(st Status) error {
+	st.Time = time.Now()
+	body, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.secret) > 0 {
+		timestamp := strconv.FormatInt(st.Time.Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(sign(s.secret, timestamp, body)))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send heartbeat: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("master server responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// Verify verifies the signature of a heartbeat with the body passed, received with the values of the
+// TimestampHeader and SignatureHeader passed, using the secret passed. Heartbeats signed longer than maxAge ago
+// are rejected, so that heartbeats cannot be replayed. Master servers written in Go may use it to verify the
+// heartbeats they receive.
+func Verify(secret, timestamp, signature string, body []byte, maxAge time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("heartbeat was signed %v ago", age.Round(time.Second))
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		return fmt.Errorf("invalid signature %q", signature)
+	}
+	actual, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !hmac.Equal(actual, sign([]byte(secret), timestamp, body)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// sign returns the HMAC-SHA256 of the timestamp and body passed using the secret passed.
+func sign(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
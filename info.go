@@ -0,0 +1,101 @@
+package portal
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/paroxity/portal/command"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// Version and Commit are the version and git commit of the proxy. If not set at build time, for example using
+// -ldflags "-X github.com/paroxity/portal.Version=v1.2.0", they are read from the build info of the binary.
+var Version, Commit string
+
+// Info holds information about the proxy, such as its version and how long it has been running.
+type Info struct {
+	// Version is the version of the proxy, such as "v1.2.0", or "(devel)" if it was built from a checkout without
+	// a version.
+	Version string
+	// Commit is the git commit the proxy was built from. It is empty if unknown.
+	Commit string
+	// GoVersion is the version of Go the proxy was built with.
+	GoVersion string
+	// Started is the time the proxy was started at.
+	Started time.Time
+	// Protocol is the protocol version of Minecraft supported by the proxy, and MinecraftVersion the version of
+	// Minecraft it belongs to, such as "1.20.40".
+	Protocol         int32
+	MinecraftVersion string
+	// Players is the number of players connected to the proxy, and Servers the number of servers registered.
+	Players, Servers int
+}
+
+// Uptime returns the time that has passed since the proxy was started.
+func (i Info) Uptime() time.Duration {
+	return time.Since(i.Started)
+}
+
+// build holds the version, commit and Go version of the binary, read once from its build info.
+var build = readBuild()
+
+// readBuild returns the Info of the proxy with only the fields about the binary set.
+func readBuild() Info {
+	i := Info{Version: Version, Commit: Commit}
+	b, ok := debug.ReadBuildInfo()
+	if !ok {
+		return i
+	}
+	i.GoVersion = b.GoVersion
+	if i.Version == "" {
+		i.Version = b.Main.Version
+		for _, dep := range b.Deps {
+			if dep.Path == "github.com/paroxity/portal" {
+				i.Version = dep.Version
+			}
+		}
+	}
+	if i.Commit == "" {
+		for _, s := range b.Settings {
+			if s.Key == "vcs.revision" {
+				i.Commit = s.Value
+			}
+		}
+	}
+	return i
+}
+
+// Info returns information about the proxy, such as its version, the time it was started at and the number of
+// players connected to it.
+func (p *Portal) Info() Info {
+	i := build
+	i.Started = p.started
+	i.Protocol, i.MinecraftVersion = protocol.CurrentProtocol, protocol.CurrentVersion
+	i.Players, i.Servers = len(p.sessionStore.All()), len(p.serverRegistry.Servers())
+	return i
+}
+
+// InfoCommand returns a command with the name passed which shows the Info of the proxy to the player running it.
+// Only the players allowed by the function passed may run the command, such as the function returned by
+// command.Players.
+func (p *Portal) InfoCommand(name string, allow func(s *session.Session) bool) command.Command {
+	return command.Command{
+		Name:        name,
+		Description: "Shows information about the proxy",
+		Allow:       allow,
+		Run: func(s *session.Session, _ []string) {
+			i := p.Info()
+			commit := i.Commit
+			if len(commit) > 12 {
+				commit = commit[:12]
+			}
+			s.SendMessage(text.Colourf("<yellow>Portal %s</yellow> <grey>(%s, %s)</grey>\n"+
+				"<yellow>Uptime:</yellow> %s\n"+
+				"<yellow>Minecraft:</yellow> %s (protocol %d)\n"+
+				"<yellow>Players:</yellow> %d on %d servers",
+				i.Version, commit, i.GoVersion, i.Uptime().Round(time.Second), i.MinecraftVersion, i.Protocol, i.Players, i.Servers))
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package limbo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	// minY is the lowest Y position of the overworld.
+	minY = -64
+	// subChunks is the number of sub chunks in a chunk of the overworld.
+	subChunks = 24
+	// biomePlains is the ID of the plains biome, which is used for every chunk in the limbo.
+	biomePlains = 1
+)
+
+// blockHash returns the network ID of a block with the name passed and no properties, as used by clients when
+// UseBlockNetworkIDHashes is enabled in the StartGame packet.
+func blockHash(name string) uint32 {
+	data, err := nbt.MarshalEncoding(map[string]any{"name": name, "states": map[string]any{}}, nbt.LittleEndian)
+	if err != nil {
+		panic(err)
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(data)
+	return h.Sum32()
+}
+
+// chunkPayload encodes the payload of a chunk in the network format. If platform is true, the chunk contains a
+// platform of the block passed with the size passed at the Y position passed, starting at the 0, 0 corner of
+// the chunk. Otherwise, the chunk is empty.
+func chunkPayload(platform bool, block string, size, y int) []byte {
+	buf := new(bytes.Buffer)
+	index := (y - minY) >> 4
+	count := 0
+	if platform {
+		count = index + 1
+	}
+	for i := 0; i < count; i++ {
+		// Every sub chunk uses version 8 of the sub chunk format.
+		buf.WriteByte(8)
+		if i != index {
+			// No block storages, meaning the sub chunk is filled with air.
+			buf.WriteByte(0)
+			continue
+		}
+		buf.WriteByte(1)
+		// One bit per block with network IDs in the palette.
+		buf.WriteByte(1<<1 | 1)
+		words := make([]uint32, 4096/32)
+		localY := (y - minY) & 15
+		for x := 0; x < size; x++ {
+			for z := 0; z < size; z++ {
+				i := x<<8 | z<<4 | localY
+				words[i/32] |= 1 << (i % 32)
+			}
+		}
+		_ = binary.Write(buf, binary.LittleEndian, words)
+		_ = protocol.WriteVarint32(buf, 2)
+		_ = protocol.WriteVarint32(buf, int32(blockHash("minecraft:air")))
+		_ = protocol.WriteVarint32(buf, int32(blockHash(block)))
+	}
+	for i := 0; i < subChunks; i++ {
+		// A biome storage with zero bits per entry holds a single biome for the whole sub chunk.
+		buf.WriteByte(0<<1 | 1)
+		_ = protocol.WriteVarint32(buf, biomePlains)
+	}
+	// The border block count, which is always zero.
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
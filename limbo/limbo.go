@@ -0,0 +1,209 @@
+package limbo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"go.uber.org/atomic"
+)
+
+const (
+	// platformY is the Y position of the platform players stand on in the limbo.
+	platformY = 64
+	// platformSize is the length of each side of the platform.
+	platformSize = 8
+	// chunkRadius is the radius of chunks sent around the platform.
+	chunkRadius = 2
+)
+
+// Limbo is a world hosted by the proxy itself, consisting of a small platform in a void. Sessions may be parked in
+// it indefinitely, for example while all servers are down or while waiting in a queue. It listens for
+// connections like any other server and is added to the server registry, so that sessions are transferred to
+// and from it as usual.
+type Limbo struct {
+	log  internal.Logger
+	name string
+
+	listener *minecraft.Listener
+	srv      *server.Server
+
+	spawn   mgl32.Vec3
+	chunks  map[protocol.ChunkPos][]byte
+	message atomic.String
+
+	mu    sync.Mutex
+	conns map[*minecraft.Conn]struct{}
+}
+
+// New creates a new Limbo with the name passed, which is the name of the server it is registered as. Its
+// platform is made of the block passed, such as "minecraft:glass".
+func New(log internal.Logger, name, block string) *Limbo {
+	l := &Limbo{
+		log:    log,
+		name:   name,
+		spawn:  mgl32.Vec3{platformSize / 2, platformY + 1.62, platformSize / 2},
+		chunks: make(map[protocol.ChunkPos][]byte),
+		conns:  make(map[*minecraft.Conn]struct{}),
+	}
+	empty := chunkPayload(false, block, platformSize, platformY)
+	for x := int32(-chunkRadius); x <= chunkRadius; x++ {
+		for z := int32(-chunkRadius); z <= chunkRadius; z++ {
+			l.chunks[protocol.ChunkPos{x, z}] = empty
+		}
+	}
+	l.chunks[protocol.ChunkPos{}] = chunkPayload(true, block, platformSize, platformY)
+	return l
+}
+
+// Listen starts listening for connections from the proxy on the network and address passed. The limbo is
+// intended to be listened on a loopback address, such as "127.0.0.1:19134" over "tcp".
+func (l *Limbo) Listen(network, address string) error {
+	listener, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	l.listener = listener
+	l.srv = server.NewWithNetwork(l.name, network, listener.Addr().String())
+	// The limbo should never be chosen by load balancers, only by transferring sessions to it directly.
+	l.srv.SetInRotation(false)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				l.log.Errorf("limbo failed to accept connection: %v", err)
+				continue
+			}
+			go l.handleConn(conn.(*minecraft.Conn))
+		}
+	}()
+	return nil
+}
+
+// SetMessage sets the message shown in a boss bar to players that join the limbo, for example to tell them why
+// they are in it. If empty, no boss bar is shown.
+func (l *Limbo) SetMessage(message string) {
+	l.message.Store(message)
+}
+
+// Server returns the server of the limbo, which may be added to the server registry of the proxy. It returns
+// nil if the limbo is not listening.
+func (l *Limbo) Server() *server.Server {
+	return l.srv
+}
+
+// Park transfers the session passed to the limbo, where it stays until it is transferred to another server.
+func (l *Limbo) Park(s *session.Session) error {
+	if l.srv == nil {
+		return fmt.Errorf("limbo is not listening")
+	}
+	return s.Transfer(l.srv)
+}
+
+// Close closes the limbo and disconnects all sessions in it.
+func (l *Limbo) Close() error {
+	l.mu.Lock()
+	for conn := range l.conns {
+		_ = conn.Close()
+	}
+	l.mu.Unlock()
+	if l.listener == nil {
+		return nil
+	}
+	return l.listener.Close()
+}
+
+// handleConn spawns the connection passed in the limbo and handles its packets until it is closed.
+func (l *Limbo) handleConn(conn *minecraft.Conn) {
+	l.mu.Lock()
+	l.conns[conn] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	data := minecraft.GameData{
+		WorldName:               "Limbo",
+		EntityUniqueID:          1,
+		EntityRuntimeID:         1,
+		PlayerGameMode:          2,
+		WorldGameMode:           2,
+		BaseGameVersion:         "*",
+		PlayerPosition:          l.spawn,
+		WorldSpawn:              protocol.BlockPos{platformSize / 2, platformY + 1, platformSize / 2},
+		Dimension:               packet.DimensionOverworld,
+		Time:                    6000,
+		UseBlockNetworkIDHashes: true,
+	}
+	if err := conn.StartGame(data); err != nil {
+		l.log.Debugf("limbo failed to spawn %s: %v", conn.IdentityData().DisplayName, err)
+		return
+	}
+	l.sendChunks(conn)
+	if m := l.message.Load(); m != "" {
+		_ = conn.WritePacket(&packet.BossEvent{
+			BossEntityUniqueID: data.EntityUniqueID,
+			EventType:          packet.BossEventShow,
+			BossBarTitle:       m,
+			HealthPercentage:   1,
+		})
+	}
+
+	for {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			return
+		}
+		var pos mgl32.Vec3
+		switch pk := pk.(type) {
+		case *packet.PlayerAuthInput:
+			pos = pk.Position
+		case *packet.MovePlayer:
+			pos = pk.Position
+		default:
+			continue
+		}
+		if pos.Y() < minY {
+			// The player fell off the platform, so they are put back on it.
+			_ = conn.WritePacket(&packet.MovePlayer{
+				EntityRuntimeID: data.EntityRuntimeID,
+				Position:        l.spawn,
+				Mode:            packet.MoveModeReset,
+			})
+		}
+	}
+}
+
+// sendChunks sends the chunks of the limbo to the connection passed.
+func (l *Limbo) sendChunks(conn *minecraft.Conn) {
+	_ = conn.WritePacket(&packet.NetworkChunkPublisherUpdate{
+		Position: protocol.BlockPos{int32(l.spawn.X()), int32(l.spawn.Y()), int32(l.spawn.Z())},
+		Radius:   chunkRadius * 16,
+	})
+	for pos, payload := range l.chunks {
+		count := uint32(0)
+		if pos == (protocol.ChunkPos{}) {
+			count = uint32((platformY-minY)>>4) + 1
+		}
+		_ = conn.WritePacket(&packet.LevelChunk{
+			Position:      pos,
+			SubChunkCount: count,
+			RawPayload:    payload,
+		})
+	}
+}
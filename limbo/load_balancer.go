@@ -0,0 +1,38 @@
+package limbo
+
+import (
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+)
+
+// LoadBalancer is a session.LoadBalancer which sends players to the limbo when the load balancer it wraps does
+// not return a server, or when none of the servers it returns can be dialed. This keeps players connected during
+// a total outage of the servers, instead of disconnecting them.
+type LoadBalancer struct {
+	lb    session.LoadBalancer
+	limbo *Limbo
+}
+
+// NewLoadBalancer returns a new LoadBalancer which falls back to the limbo passed.
+func NewLoadBalancer(lb session.LoadBalancer, limbo *Limbo) *LoadBalancer {
+	return &LoadBalancer{lb: lb, limbo: limbo}
+}
+
+// FindServer ...
+func (b *LoadBalancer) FindServer(s *session.Session) *server.Server {
+	return b.FindServerExcluding(s)
+}
+
+// FindServerExcluding ...
+func (b *LoadBalancer) FindServerExcluding(s *session.Session, exclude ...*server.Server) *server.Server {
+	if srv := session.FindServerExcluding(b.lb, s, exclude...); srv != nil {
+		return srv
+	}
+	srv := b.limbo.Server()
+	for _, e := range exclude {
+		if srv == e {
+			return nil
+		}
+	}
+	return srv
+}
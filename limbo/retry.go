@@ -0,0 +1,45 @@
+package limbo
+
+import (
+	"time"
+
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+)
+
+// RetryJoin attempts to transfer every session parked in the limbo to the healthy server with the fewest players
+// in the group passed, at the interval passed. If the group is empty, any server may be chosen. RetryJoin blocks
+// forever, so it should be called in a separate goroutine. If the interval is not positive, ten seconds is used.
+func (l *Limbo) RetryJoin(store *session.Store, registry *server.Registry, group string, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second * 10
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		if l.srv == nil {
+			continue
+		}
+		for _, s := range store.All() {
+			if srv, ok := s.TryServer(); !ok || srv != l.srv || s.Transferring() {
+				continue
+			}
+			servers := registry.Servers()
+			if group != "" {
+				servers = registry.Group(group)
+			}
+			srv := server.Least(servers, func(srv *server.Server) bool {
+				return srv.Healthy()
+			})
+			if srv == nil {
+				// No server is available, so there is no use trying for the other sessions either.
+				break
+			}
+			go func(s *session.Session) {
+				if err := s.Transfer(srv); err != nil {
+					l.log.Debugf("failed to move %s out of the limbo: %v", s.IdentityData().DisplayName, err)
+				}
+			}(s)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 )
 
@@ -12,22 +13,35 @@ var cleaner = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
 // Logger represents a Writer which writes the log to the provided file as well as stdout.
 type Logger struct {
+	path     string
+	rotation Rotation
+	stdout   io.Writer
+
+	mu     sync.Mutex
 	file   *os.File
-	stdout io.Writer
+	size   int64
+	opened time.Time
+
+	// cleanupMu prevents backups from being compressed or removed by more than one goroutine at a time.
+	cleanupMu sync.Mutex
 }
 
 // New creates a new logger to be used with any log package. It is designed to write to a log file as well as
-// stdout to allow you to store logs from the proxy.
+// stdout to allow you to store logs from the proxy. The log file is never rotated.
 func New(path string) (*Logger, error) {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
+	return NewWithRotation(path, Rotation{})
+}
+
+// NewWithRotation creates a new logger like New, of which the log file is rotated according to the rotation
+// passed.
+func NewWithRotation(path string, rotation Rotation) (*Logger, error) {
+	l := &Logger{path: path, rotation: rotation, stdout: colorable.NewColorableStdout()}
+	if err := l.open(); err != nil {
 		return nil, err
 	}
-
-	return &Logger{
-		file:   f,
-		stdout: colorable.NewColorableStdout(),
-	}, nil
+	// Backups left behind by a previous run are compressed and pruned right away.
+	go l.cleanup()
+	return l, nil
 }
 
 // Write ...
@@ -36,6 +50,16 @@ func (l *Logger) Write(p []byte) (int, error) {
 		return n, err
 	}
 
-	cleaned := cleaner.ReplaceAllString(string(p), "")
-	return l.file.WriteString(time.Now().Format("2006-1-2") + " " + cleaned)
+	cleaned := time.Now().Format("2006-1-2") + " " + cleaner.ReplaceAllString(string(p), "")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rotation.due(l.size, int64(len(cleaned)), l.opened, time.Now()) {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.file.WriteString(cleaned)
+	l.size += int64(n)
+	return n, err
 }
@@ -0,0 +1,168 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupLayout is the layout of the time at which a log file was rotated, which is added to the name of the
+// backup it is rotated to.
+const backupLayout = "20060102-150405"
+
+// Rotation holds the settings used to rotate the log file of a Logger. The zero value never rotates the file.
+type Rotation struct {
+	// MaxSize is the size in bytes after which the log file is rotated. If zero, the file is not rotated by size.
+	MaxSize int64
+	// Interval is the interval at which the log file is rotated, such as every 24 hours. If zero, the file is not
+	// rotated by time.
+	Interval time.Duration
+	// Compress specifies if rotated log files are compressed using gzip.
+	Compress bool
+	// MaxAge is the age after which rotated log files are removed. If zero, they are not removed by age.
+	MaxAge time.Duration
+	// MaxBackups is the maximum number of rotated log files kept. If zero, they are not removed by count.
+	MaxBackups int
+}
+
+// due returns if a log file that was opened at the time passed and has the size passed must be rotated before
+// n more bytes are written to it.
+func (r Rotation) due(size, n int64, opened, now time.Time) bool {
+	if r.MaxSize > 0 && size > 0 && size+n > r.MaxSize {
+		return true
+	}
+	return r.Interval > 0 && now.Sub(opened) >= r.Interval
+}
+
+// open opens the log file of the logger and reads its current size.
+func (l *Logger) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.file, l.size, l.opened = f, info.Size(), time.Now()
+	return nil
+}
+
+// rotate closes the current log file, renames it to a backup and opens a new file at the path of the logger.
+// Backups are compressed and pruned in the background.
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path, l.backupName(time.Now())); err != nil {
+		return err
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	go l.cleanup()
+	return nil
+}
+
+// backupName returns the path of the backup of the log file rotated at the time passed, such as
+// "proxy-20240131-150405.log" for "proxy.log".
+func (l *Logger) backupName(t time.Time) string {
+	ext := filepath.Ext(l.path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(l.path, ext), t.Format(backupLayout), ext)
+}
+
+// backup is a rotated log file.
+type backup struct {
+	path    string
+	rotated time.Time
+}
+
+// backups returns the rotated log files of the logger, from newest to oldest.
+func (l *Logger) backups() ([]backup, error) {
+	ext := filepath.Ext(l.path)
+	prefix := filepath.Base(strings.TrimSuffix(l.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(l.path))
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		t, err := time.ParseInLocation(backupLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(filepath.Dir(l.path), name), rotated: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotated.After(backups[j].rotated)
+	})
+	return backups, nil
+}
+
+// cleanup compresses the backups of the logger that are not yet compressed and removes the backups that are
+// too old or exceed the maximum number of backups. Errors are written to stdout, as writing them to the logger
+// could rotate the file again.
+func (l *Logger) cleanup() {
+	l.cleanupMu.Lock()
+	defer l.cleanupMu.Unlock()
+
+	backups, err := l.backups()
+	if err != nil {
+		_, _ = fmt.Fprintf(l.stdout, "unable to list rotated log files: %v\n", err)
+		return
+	}
+	for i, b := range backups {
+		if (l.rotation.MaxBackups > 0 && i >= l.rotation.MaxBackups) || (l.rotation.MaxAge > 0 && time.Since(b.rotated) > l.rotation.MaxAge) {
+			if err := os.Remove(b.path); err != nil {
+				_, _ = fmt.Fprintf(l.stdout, "unable to remove rotated log file: %v\n", err)
+			}
+			continue
+		}
+		if l.rotation.Compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := compress(b.path); err != nil {
+				_, _ = fmt.Fprintf(l.stdout, "unable to compress rotated log file: %v\n", err)
+			}
+		}
+	}
+}
+
+// compress compresses the file at the path passed using gzip, replacing it with a file with the same name
+// followed by ".gz".
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(dst)
+	if _, err := io.Copy(w, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := w.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,119 @@
+package portal
+
+import (
+	"time"
+
+	"github.com/paroxity/portal/storage"
+	"go.uber.org/atomic"
+)
+
+// playerCountBucket is the bucket of the storage.Provider in which the player counts of proxies are stored.
+const playerCountBucket = "player_counts"
+
+// proxyPlayerCount is the player count of a single proxy as stored in the storage.Provider.
+type proxyPlayerCount struct {
+	// Players is the number of players on the proxy, and MaxPlayers the maximum, or zero if it has none.
+	Players    int `json:"players"`
+	MaxPlayers int `json:"max_players"`
+	// Expires is the time after which the count is ignored, as the proxy stopped updating it.
+	Expires time.Time `json:"expires"`
+}
+
+// NetworkPlayerCount shares the player count of the proxy with the other proxies of a network through a
+// storage.Provider shared by them, such as a database, and sums the player counts of all proxies. It may be set
+// to a MOTDStatusProvider, so that the server list shows the player count of the whole network rather than only
+// that of the proxy a player pings.
+type NetworkPlayerCount struct {
+	p     storage.Provider
+	id    string
+	local func() (players, maxPlayers int)
+	errf  func(err error)
+
+	// players and maxPlayers are the counts of the network as of the last update. known is false until the first
+	// update succeeded.
+	players, maxPlayers atomic.Int64
+	known               atomic.Bool
+
+	stop chan struct{}
+}
+
+// NewNetworkPlayerCount creates a NetworkPlayerCount that stores the player count of the proxy under the ID
+// passed, which must be unique among the proxies sharing the provider passed. The player count and maximum of
+// the proxy are returned by the function passed, where a maximum of zero means that the proxy has none. The
+// function passed is called with any error that occurs while updating the counts, and may be nil.
+func NewNetworkPlayerCount(p storage.Provider, id string, local func() (players, maxPlayers int), errf func(err error)) *NetworkPlayerCount {
+	if errf == nil {
+		errf = func(error) {}
+	}
+	return &NetworkPlayerCount{p: p, id: id, local: local, errf: errf, stop: make(chan struct{})}
+}
+
+// Run updates the counts immediately and then every interval, until the NetworkPlayerCount is closed. The count of
+// the proxy expires after three intervals, so that proxies that stopped without removing their count are no
+// longer counted. It blocks until closed, so it should generally be called in a separate goroutine.
+func (n *NetworkPlayerCount) Run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := n.Update(interval * 3); err != nil {
+			n.errf(err)
+		}
+		select {
+		case <-t.C:
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+// Update stores the current player count of the proxy, which expires after the duration passed, and sums the
+// counts of all proxies that did not expire. Expired counts are removed.
+func (n *NetworkPlayerCount) Update(ttl time.Duration) error {
+	now := time.Now()
+	players, maxPlayers := n.local()
+	if err := storage.Save(n.p, playerCountBucket, n.id, proxyPlayerCount{Players: players, MaxPlayers: maxPlayers, Expires: now.Add(ttl)}); err != nil {
+		return err
+	}
+	ids, err := n.p.Keys(playerCountBucket)
+	if err != nil {
+		return err
+	}
+	var total, totalMax int
+	unlimited := false
+	for _, id := range ids {
+		var c proxyPlayerCount
+		if ok, err := storage.Load(n.p, playerCountBucket, id, &c); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		if now.After(c.Expires) {
+			_ = n.p.Delete(playerCountBucket, id)
+			continue
+		}
+		total += c.Players
+		totalMax += c.MaxPlayers
+		unlimited = unlimited || c.MaxPlayers == 0
+	}
+	if unlimited {
+		// A single proxy without a maximum means the network has none either.
+		totalMax = 0
+	}
+	n.players.Store(int64(total))
+	n.maxPlayers.Store(int64(totalMax))
+	n.known.Store(true)
+	return nil
+}
+
+// Counts returns the player count and the maximum player count of the network as of the last update, where a
+// maximum of zero means that the network has none. If no update succeeded yet, false is returned.
+func (n *NetworkPlayerCount) Counts() (players, maxPlayers int, ok bool) {
+	return int(n.players.Load()), int(n.maxPlayers.Load()), n.known.Load()
+}
+
+// Close stops updating the counts and removes the count of the proxy, so that its players are no longer counted
+// by the other proxies.
+func (n *NetworkPlayerCount) Close() error {
+	close(n.stop)
+	return n.p.Delete(playerCountBucket, n.id)
+}
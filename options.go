@@ -1,9 +1,13 @@
 package portal
 
 import (
+	"github.com/paroxity/portal/firewall"
 	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/report"
 	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/transport"
 	"github.com/sandertv/gophertunnel/minecraft"
+	"time"
 )
 
 // Options represents the options that control how the proxy should be set up. After the proxy has been
@@ -14,6 +18,12 @@ type Options struct {
 
 	// Address is the address that the proxy should run on. It should be in the format of "address:port".
 	Address string
+	// Network is the network the proxy listens on at Address. It may be any network registered with
+	// gophertunnel, such as "raknet" or "tcp". If left empty, "raknet" is used.
+	Network string
+	// Listeners holds additional networks and addresses on which the proxy accepts connections from clients,
+	// next to the network at Address. Sessions accepted over any of these listeners behave identically.
+	Listeners []ListenAddress
 	// ListenConfig contains settings that can be changed for the listener. It can be used to change the MOTD
 	// and add resource packs etc.
 	ListenConfig minecraft.ListenConfig
@@ -22,6 +32,122 @@ type Options struct {
 	// change which servers players connect to when they join the proxy.
 	LoadBalancer session.LoadBalancer
 
+	// RequireXUID makes the proxy reject connections of players that are not authenticated with Xbox Live,
+	// which have no XUID. Handlers may override the decision in HandleVerify.
+	RequireXUID bool
+	// OfflineMode disables authentication with Xbox Live for local testing. Players that are not authenticated
+	// are given a UUID derived from their name, so that they keep the same UUID across joins.
+	OfflineMode bool
+
+	// DuplicateLogin is the policy applied when a player connects while a session with the same UUID is still
+	// open. If left empty, DuplicateKickOld is used.
+	DuplicateLogin DuplicatePolicy
+
+	// Takeover, if set, attaches players that reconnect after losing their connection to their session that was
+	// kept open, instead of creating a new session for them. Its handler must also be added using Handle.
+	Takeover *session.Takeover
+
+	// SpawnHold is the maximum time players are held on a loading screen after being transferred, until the
+	// destination server releases them. If zero, players are not held.
+	SpawnHold time.Duration
+	// TransferBuffer is the maximum number of chat messages and commands sent by players during a transfer that
+	// are sent to the server once the transfer is done, instead of being dropped. If zero, none are buffered.
+	TransferBuffer int
+	// TransferLimiter, if set, limits the number of transfers dialing their destination server at the same time,
+	// queueing the transfers over the limit.
+	TransferLimiter *session.TransferLimiter
+
+	// EntityTTL is the time after which entities spawned for players that did not appear in any packet are
+	// forgotten by the proxy, so that servers that never remove their entities do not grow the memory used by
+	// sessions. Forgotten entities are no longer removed from the client when a player is transferred, so it
+	// should be long enough for idle entities, such as NPCs, to appear. If zero, entities are never forgotten.
+	EntityTTL time.Duration
+
+	// PalettePolicy is the policy applied when a player is transferred to a server of which the custom blocks or
+	// items differ from those the client received when it joined. If left empty, session.PaletteIgnore is used.
+	PalettePolicy session.PalettePolicy
+
+	// Rejoins, if set, allows players to be transferred with a full reconnect using Session.TransferReconnect, and
+	// sends players that join again after such a transfer to the server they were transferred to.
+	Rejoins *session.Rejoins
+
+	// GameOverrides, if set, holds the experiments and features forced in the game data sent to players when they
+	// join, so that every player has the same set regardless of the first server they join.
+	GameOverrides *session.GameOverrides
+
+	// KickPolicy is the policy applied when a server disconnects a player with a message. If left empty,
+	// session.KickFallback is used.
+	KickPolicy session.KickPolicy
+	// KickMessage is the message shown to kicked players if KickPolicy is session.KickMessage. Any "{server}" in
+	// it is replaced with the name of the server and any "{reason}" with the message of the server.
+	KickMessage string
+	// KickRewriter, if set, rewrites the messages of players kicked by their server before they are shown.
+	KickRewriter *session.KickRewriter
+
+	// BroadcastLimit is the maximum number of broadcasts that may be sent within the BroadcastWindow. If zero,
+	// broadcasts are not limited.
+	BroadcastLimit int
+	// BroadcastWindow is the window in which at most BroadcastLimit broadcasts may be sent.
+	BroadcastWindow time.Duration
+
+	// Throttle, if set, is notified of every connection that reaches the resource pack stage or finishes logging
+	// in, so that it is able to ban IP addresses that repeatedly fail to. The networks the proxy listens on must
+	// be wrapped using Throttle.Wrap.
+	Throttle *firewall.Throttle
+
+	// Meter, if set, counts and caps the bandwidth of players. The networks the proxy listens on must be
+	// wrapped using Meter.Wrap. Players exceeding their cap are disconnected if the policy of the meter is
+	// transport.CapKick.
+	Meter *transport.Meter
+
+	// Clock is the clock used for the timeouts and times of sessions. If nil, session.SystemClock is used. It
+	// is mainly useful to trigger timeouts deterministically in tests.
+	Clock session.Clock
+	// Dial is the function used by sessions to dial servers. If nil, session.DefaultDial is used. It is mainly
+	// useful to simulate slow or failing servers in tests.
+	Dial session.DialFunc
+
+	// Experiments holds the gameplay experiments sessions are assigned to buckets of, which may be read using
+	// Session.Bucket. If nil, no experiments are running.
+	Experiments *session.Experiments
+
+	// VersionGate, if set, rejects players of which the version of the client is outside the range of the
+	// gate, before any server is dialed for them.
+	VersionGate *VersionGate
+
+	// DeviceRestrictions holds restrictions that prevent players on specific devices from joining the proxy.
+	// The first restriction a player matches decides the message they are disconnected with.
+	DeviceRestrictions []session.DeviceRestriction
+	// BlockImpossibleDevices specifies if players reporting a device that no official client reports, as
+	// decided by session.ImpossibleDevice, are prevented from joining the proxy.
+	BlockImpossibleDevices bool
+
+	// HexdumpPackets specifies if packets that fail to decode are logged as a hexdump together with the player
+	// and server they were sent between, and forwarded as they are instead of being dropped.
+	HexdumpPackets bool
+
+	// Reporter, if set, is reported errors such as panics in handlers, failed dials and failed logins, tagged with
+	// the player and server they concern.
+	Reporter report.Reporter
+
 	// Whitelist is used to limit the proxy to only allow certain players to join.
 	Whitelist session.Whitelist
+
+	// Handler is the handler of the proxy, which handles events called before a session is created for a
+	// connection. If nil, a NopHandler is used.
+	Handler Handler
+}
+
+// ListenAddress represents an address on a specific network on which the proxy listens for connections.
+type ListenAddress struct {
+	// Network is the name of the network, such as "raknet". The network must be registered with gophertunnel
+	// using minecraft.RegisterNetwork.
+	Network string
+	// Address is the address to listen on in the format of "address:port".
+	Address string
+	// TexturePacksRequired, if set, overrides ListenConfig.TexturePacksRequired for the connections of this
+	// listener. Packs are negotiated with every connection of a listener in the same way, before the rank of the
+	// player or the server they join is known, so players that should not be forced to download the packs, such
+	// as staff or players joining a server without custom content, may instead be given a separate listener.
+	TexturePacksRequired *bool
 }
@@ -0,0 +1,120 @@
+package portal
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+)
+
+// PackKeyProvider provides the content keys of encrypted resource packs, so that the proxy is able to send the
+// keys to players downloading the packs.
+type PackKeyProvider interface {
+	// ContentKey returns the content key of the pack with the UUID passed, or false if the provider has no key
+	// for the pack.
+	ContentKey(uuid string) (string, bool, error)
+}
+
+// StaticPackKeys is a PackKeyProvider that provides the keys in a map of pack UUIDs to their content key, such as
+// those in the configuration file.
+type StaticPackKeys map[string]string
+
+// ContentKey ...
+func (k StaticPackKeys) ContentKey(uuid string) (string, bool, error) {
+	key, ok := k[uuid]
+	return key, ok, nil
+}
+
+// DirectoryPackKeys is a PackKeyProvider that reads the content key of a pack from a file named after the UUID of
+// the pack with the .key extension, such as "5ac4f3c1-4f2a-4f43-8a3e-1d6c2e0b7a91.key", in a directory.
+type DirectoryPackKeys string
+
+// ContentKey ...
+func (d DirectoryPackKeys) ContentKey(uuid string) (string, bool, error) {
+	data, err := os.ReadFile(filepath.Join(string(d), uuid+".key"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// contentKeyLength is the length of the content keys of encrypted packs, which are AES-256 keys.
+const contentKeyLength = 32
+
+// ApplyPackKeys returns the packs passed with the content keys of the packs provided by the first of the providers
+// passed that has a key for them. An error is returned if a key does not have the length of a content key, or if
+// a pack is encrypted but none of the providers has a key for it, as players would be unable to use the pack.
+func ApplyPackKeys(packs []*resource.Pack, providers ...PackKeyProvider) ([]*resource.Pack, error) {
+	keyed := make([]*resource.Pack, 0, len(packs))
+	for _, pack := range packs {
+		key, err := packKey(pack.UUID(), providers)
+		if err != nil {
+			return nil, fmt.Errorf("content key of pack %s (%s): %w", pack.Name(), pack.UUID(), err)
+		}
+		if key == "" {
+			if packEncrypted(pack) {
+				return nil, fmt.Errorf("pack %s (%s) is encrypted, but no content key is configured for it", pack.Name(), pack.UUID())
+			}
+			keyed = append(keyed, pack)
+			continue
+		}
+		keyed = append(keyed, pack.WithContentKey(key))
+	}
+	return keyed, nil
+}
+
+// packKey returns the content key of the pack with the UUID passed from the first provider that has one, or an
+// empty string if none of them do.
+func packKey(uuid string, providers []PackKeyProvider) (string, error) {
+	for _, p := range providers {
+		if p == nil {
+			continue
+		}
+		key, ok, err := p.ContentKey(uuid)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		if len(key) != contentKeyLength {
+			return "", fmt.Errorf("key has a length of %d, but must have a length of %d", len(key), contentKeyLength)
+		}
+		return key, nil
+	}
+	return "", nil
+}
+
+// encryptedContentsMagic is the magic number in the header of the contents.json file of encrypted packs.
+const encryptedContentsMagic = 0x9bcfb9fc
+
+// packEncrypted checks if the pack passed is encrypted, which is the case if its contents.json file starts with
+// the header of encrypted packs.
+func packEncrypted(pack *resource.Pack) bool {
+	r, err := zip.NewReader(io.NewSectionReader(pack, 0, int64(pack.Len())), int64(pack.Len()))
+	if err != nil {
+		return false
+	}
+	for _, f := range r.File {
+		if f.Name != "contents.json" && !strings.HasSuffix(f.Name, "/contents.json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return false
+		}
+		header := make([]byte, 8)
+		_, err = io.ReadFull(rc, header)
+		_ = rc.Close()
+		return err == nil && binary.LittleEndian.Uint32(header[4:]) == encryptedContentsMagic
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// Package permission implements the ranks and permissions of players on the proxy, which features of the proxy
+// use to decorate players and to decide what they are allowed to do.
+package permission
+
+import (
+	"github.com/paroxity/portal/session"
+)
+
+// Rank is a rank of players, such as "admin" or "vip".
+type Rank struct {
+	// Name is the name of the rank.
+	Name string
+	// Prefix is shown in front of the names of the players with the rank, such as "§c[Admin] ". It may be
+	// empty.
+	Prefix string
+	// Colour is the colour of the names of the players with the rank, such as "§c". It may be empty.
+	Colour string
+	// Permissions holds the permissions of the players with the rank.
+	Permissions []string
+}
+
+// Has returns if the rank has the permission passed. A rank with the permission "*" has every permission.
+func (r Rank) Has(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission || p == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Provider provides the ranks of players, such as from the configuration of the proxy or from a database
+// shared with the permissions plugins of the servers.
+type Provider interface {
+	// Rank returns the rank of the player of the session passed. The zero Rank is returned if the player has no
+	// rank.
+	Rank(s *session.Session) Rank
+}
+
+// NopProvider is a Provider which gives no player a rank.
+type NopProvider struct{}
+
+// Rank ...
+func (NopProvider) Rank(*session.Session) Rank {
+	return Rank{}
+}
+
+// Has returns if the player of the session passed has the permission passed according to the provider passed.
+// If the provider is nil, no player has any permission.
+func Has(p Provider, s *session.Session, permission string) bool {
+	if p == nil {
+		return false
+	}
+	return p.Rank(s).Has(permission)
+}
+
+// Prefix returns the prefix of the rank of the player of the session passed according to the provider passed.
+// If the provider is nil, an empty prefix is returned.
+func Prefix(p Provider, s *session.Session) string {
+	if p == nil {
+		return ""
+	}
+	return p.Rank(s).Prefix
+}
+
+// Decorator is a session.Decorator which shows the prefix and colour of the rank of players in front of their
+// names in the player list and chat.
+type Decorator struct {
+	p Provider
+}
+
+// Compile time check to make sure Decorator implements session.Decorator.
+var _ session.Decorator = Decorator{}
+
+// NewDecorator returns a Decorator which decorates names with the ranks of the provider passed.
+func NewDecorator(p Provider) Decorator {
+	return Decorator{p: p}
+}
+
+// DecorateName ...
+func (d Decorator) DecorateName(s *session.Session, name string) string {
+	r := d.p.Rank(s)
+	if r.Colour != "" {
+		name = r.Colour + name + "§r"
+	}
+	return r.Prefix + name
+}
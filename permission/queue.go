@@ -0,0 +1,34 @@
+package permission
+
+import (
+	"github.com/paroxity/portal/session"
+)
+
+// QueuePriority is a session.PriorityProvider which gives players a priority tier in the login queue based on
+// the permissions of their rank, so that ranks such as "vip" are let through the queue first.
+type QueuePriority struct {
+	p     Provider
+	tiers []string
+}
+
+// Compile time check to make sure QueuePriority implements session.PriorityProvider.
+var _ session.PriorityProvider = QueuePriority{}
+
+// NewQueuePriority returns a QueuePriority which finds the ranks of players using the provider passed. The
+// permissions passed give the priority tiers from lowest to highest: players with the first permission have a
+// priority of 1, players with the second a priority of 2 and so on. Players with none of the permissions have a
+// priority of 0.
+func NewQueuePriority(p Provider, tiers ...string) QueuePriority {
+	return QueuePriority{p: p, tiers: tiers}
+}
+
+// Priority ...
+func (q QueuePriority) Priority(s *session.Session) int {
+	r := q.p.Rank(s)
+	for i := len(q.tiers) - 1; i >= 0; i-- {
+		if r.Has(q.tiers[i]) {
+			return i + 1
+		}
+	}
+	return 0
+}
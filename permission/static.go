@@ -0,0 +1,55 @@
+package permission
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/paroxity/portal/session"
+)
+
+// StaticProvider is a Provider which assigns ranks to players by their names or XUIDs, such as ranks set in the
+// configuration of the proxy. Players may be assigned another rank at any time.
+type StaticProvider struct {
+	mu      sync.RWMutex
+	ranks   map[string]Rank
+	players map[string]string
+	def     string
+}
+
+// NewStaticProvider returns a StaticProvider with the ranks passed. Players without a rank assigned are given
+// the rank with the name def, which may be empty to give them no rank.
+func NewStaticProvider(def string, ranks ...Rank) *StaticProvider {
+	p := &StaticProvider{ranks: make(map[string]Rank, len(ranks)), players: make(map[string]string), def: strings.ToLower(def)}
+	for _, r := range ranks {
+		p.ranks[strings.ToLower(r.Name)] = r
+	}
+	return p
+}
+
+// Assign assigns the rank with the name passed to the player with the name or XUID passed. Names are matched
+// regardless of case. If the rank is empty, the player is given the default rank again.
+func (p *StaticProvider) Assign(player, rank string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	player = strings.ToLower(player)
+	if rank == "" {
+		delete(p.players, player)
+		return
+	}
+	p.players[player] = strings.ToLower(rank)
+}
+
+// Rank ...
+func (p *StaticProvider) Rank(s *session.Session) Rank {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	id := s.IdentityData()
+	name, ok := p.players[strings.ToLower(id.DisplayName)]
+	if !ok && id.XUID != "" {
+		name, ok = p.players[id.XUID]
+	}
+	if !ok {
+		name = p.def
+	}
+	return p.ranks[name]
+}
@@ -1,26 +1,64 @@
 package portal
 
 import (
+	"errors"
 	"fmt"
+	"github.com/paroxity/portal/broadcast"
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/firewall"
 	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/report"
 	"github.com/paroxity/portal/server"
 	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/transport"
 	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/sirupsen/logrus"
+	"net"
+	"runtime/debug"
+	"sync"
+	"time"
 )
 
 // Portal represents the proxy and controls its functionality.
 type Portal struct {
-	log internal.Logger
+	log     internal.Logger
+	started time.Time
 
-	address      string
+	addresses    []ListenAddress
 	listenConfig minecraft.ListenConfig
-	listener     *minecraft.Listener
+	listeners    []*minecraft.Listener
+	incoming     chan *minecraft.Conn
+	// listening is done once every listener stopped accepting connections, after which stopped is closed.
+	// closing is closed once Close is called, so that connections accepted afterwards are not passed on.
+	listening sync.WaitGroup
+	stopped   chan struct{}
+	closing   chan struct{}
+	closeOnce sync.Once
 
 	sessionStore   *session.Store
 	serverRegistry *server.Registry
 	loadBalancer   session.LoadBalancer
 	whitelist      session.Whitelist
+	throttle       *firewall.Throttle
+	meter          *transport.Meter
+	// metered holds the metered sessions by the remote addresses of their connections, so that the session of a
+	// connection exceeding its bandwidth cap is found without waiting for sessions to log in.
+	meteredMu      sync.Mutex
+	metered        map[string]*session.Session
+	requireXUID    bool
+	duplicateLogin DuplicatePolicy
+	takeover       *session.Takeover
+	env            session.Env
+	broadcaster    *broadcast.Broadcaster
+
+	versionGate            *VersionGate
+	deviceRestrictions     []session.DeviceRestriction
+	blockImpossibleDevices bool
+
+	hMutex    sync.RWMutex
+	h         Handler
+	factories []func(s *session.Session) session.Handler
 }
 
 // New instantiates portal using the provided options and returns it. If some options are not set, default
@@ -36,17 +74,69 @@ func New(opts Options) *Portal {
 	if opts.Whitelist == nil {
 		opts.Whitelist = session.NewSimpleWhitelist(false, []string{})
 	}
-	return &Portal{
-		log: opts.Logger,
+	if opts.Handler == nil {
+		opts.Handler = NopHandler{}
+	}
+	if opts.Network == "" {
+		opts.Network = transport.NetworkRakNet
+	}
+	if opts.DuplicateLogin == "" {
+		opts.DuplicateLogin = DuplicateKickOld
+	}
+	if opts.OfflineMode {
+		opts.ListenConfig.AuthenticationDisabled = true
+	}
+	addresses := append([]ListenAddress{{Network: opts.Network, Address: opts.Address}}, opts.Listeners...)
+	sessionStore := session.NewDefaultStore()
+	p := &Portal{
+		log:     opts.Logger,
+		started: time.Now(),
 
-		address:      opts.Address,
+		addresses:    addresses,
 		listenConfig: opts.ListenConfig,
+		incoming:     make(chan *minecraft.Conn),
+		stopped:      make(chan struct{}),
+		closing:      make(chan struct{}),
 
-		sessionStore:   session.NewDefaultStore(),
+		sessionStore:   sessionStore,
 		serverRegistry: serverRegistry,
 		loadBalancer:   opts.LoadBalancer,
 		whitelist:      opts.Whitelist,
+		throttle:       opts.Throttle,
+		meter:          opts.Meter,
+		metered:        make(map[string]*session.Session),
+		requireXUID:    opts.RequireXUID,
+		duplicateLogin: opts.DuplicateLogin,
+		takeover:       opts.Takeover,
+		env: session.Env{
+			Clock:          opts.Clock,
+			Dial:           opts.Dial,
+			Experiments:    opts.Experiments,
+			Transfers:      opts.TransferLimiter,
+			Reporter:       opts.Reporter,
+			EntityTTL:      opts.EntityTTL,
+			GameOverrides:  opts.GameOverrides,
+			Rejoins:        opts.Rejoins,
+			SpawnHold:      opts.SpawnHold,
+			KickPolicy:     opts.KickPolicy,
+			KickMessage:    opts.KickMessage,
+			KickRewriter:   opts.KickRewriter,
+			PalettePolicy:  opts.PalettePolicy,
+			Hexdump:        opts.HexdumpPackets,
+			TransferBuffer: opts.TransferBuffer,
+		},
+		broadcaster: broadcast.New(sessionStore, opts.BroadcastLimit, opts.BroadcastWindow),
+
+		versionGate:            opts.VersionGate,
+		deviceRestrictions:     opts.DeviceRestrictions,
+		blockImpossibleDevices: opts.BlockImpossibleDevices,
+
+		h: opts.Handler,
+	}
+	if p.meter != nil {
+		p.meter.OnExceeded(p.bandwidthExceeded)
 	}
+	return p
 }
 
 // Logger returns the global logger used by the proxy.
@@ -64,6 +154,13 @@ func (p *Portal) ServerRegistry() *server.Registry {
 	return p.serverRegistry
 }
 
+// Server attempts to find a server by its name, or by the name of its group if no server has the name passed. If a
+// group is found, the healthy server with the fewest players in it is returned. Names are matched without regard
+// to case.
+func (p *Portal) Server(name string) (*server.Server, bool) {
+	return p.serverRegistry.Find(name)
+}
+
 // LoadBalancer returns the load balancer that handles the server a player joins when they first connect to the proxy.
 func (p *Portal) LoadBalancer() session.LoadBalancer {
 	return p.loadBalancer
@@ -74,44 +171,324 @@ func (p *Portal) SetLoadBalancer(loadBalancer session.LoadBalancer) {
 	p.loadBalancer = loadBalancer
 }
 
-// Listen starts to listen on the set address and allows connections from minecraft clients. An error is
-// returned if the listener failed to listen.
+// acceptResult holds the result of accepting a connection on one of the listeners of the proxy.
+// SetHandler sets the handler of the proxy, which handles events called before a session is created for a
+// connection. If the handler is nil, a NopHandler is used instead.
+func (p *Portal) SetHandler(h Handler) {
+	p.hMutex.Lock()
+	defer p.hMutex.Unlock()
+
+	if h == nil {
+		h = NopHandler{}
+	}
+	p.h = h
+}
+
+// Handle registers a function that is called for every new session on the proxy to create a handler for it.
+// The handler returned is added to the session before it starts connecting to a server, so that it receives
+// every event of the session. If the function returns nil, no handler is added.
+func (p *Portal) Handle(factory func(s *session.Session) session.Handler) {
+	p.hMutex.Lock()
+	defer p.hMutex.Unlock()
+
+	p.factories = append(p.factories, factory)
+}
+
+// handler returns the handler of the proxy.
+func (p *Portal) handler() Handler {
+	p.hMutex.RLock()
+	defer p.hMutex.RUnlock()
+	return p.h
+}
+
+// handlerFactories returns all the functions registered using Handle.
+func (p *Portal) handlerFactories() []func(s *session.Session) session.Handler {
+	p.hMutex.RLock()
+	defer p.hMutex.RUnlock()
+	return append([]func(s *session.Session) session.Handler(nil), p.factories...)
+}
+
+// SaveHandoff stores the state of every session on the proxy in the HandoffStore passed. It should be called
+// when the proxy shuts down, so that a proxy started afterwards is able to route returning players to their
+// previous server using a session.HandoffLoadBalancer.
+func (p *Portal) SaveHandoff(store session.HandoffStore) error {
+	return session.SaveHandoff(p.sessionStore, store)
+}
+
+// Listen starts to listen on the set addresses and allows connections from minecraft clients. An error is
+// returned if any of the listeners failed to listen.
 func (p *Portal) Listen() error {
-	l, err := p.listenConfig.Listen("raknet", p.address)
-	if err != nil {
-		return err
+	for _, addr := range p.addresses {
+		cfg := p.listenConfig
+		if addr.TexturePacksRequired != nil {
+			cfg.TexturePacksRequired = *addr.TexturePacksRequired
+		}
+		if p.throttle != nil {
+			cfg.PacketFunc = p.throttle.PacketFunc(cfg.PacketFunc)
+		}
+		l, err := cfg.Listen(addr.Network, addr.Address)
+		if err != nil {
+			for _, l := range p.listeners {
+				_ = l.Close()
+			}
+			p.listeners = nil
+			return fmt.Errorf("listen on %s %s: %w", addr.Network, addr.Address, err)
+		}
+		p.listeners = append(p.listeners, l)
+		p.Logger().Debugf("listening for connections on %s %s", addr.Network, addr.Address)
+	}
+	p.listening.Add(len(p.listeners))
+	for _, l := range p.listeners {
+		go p.accept(l)
 	}
-	p.listener = l
+	go func() {
+		p.listening.Wait()
+		close(p.stopped)
+	}()
 	return nil
 }
 
+// Addrs returns the addresses the proxy is listening on, in the order the networks were listened on. It returns
+// nothing until Listen is called.
+func (p *Portal) Addrs() []net.Addr {
+	addrs := make([]net.Addr, 0, len(p.listeners))
+	for _, l := range p.listeners {
+		addrs = append(addrs, l.Addr())
+	}
+	return addrs
+}
+
+// Close closes all listeners of the proxy, so that no new connections are accepted. Sessions that are already
+// open are not closed.
+func (p *Portal) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
+	var err error
+	for _, l := range p.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// accept continuously accepts connections from the listener passed and passes them on to Accept. It returns
+// once the listener is closed. Connections accepted after the proxy is closed are closed instead of waiting
+// for Accept to be called.
+func (p *Portal) accept(l *minecraft.Listener) {
+	defer p.listening.Done()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				p.log.Debugf("stopped listening for connections on %s", l.Addr())
+			} else {
+				p.log.Errorf("stopped listening for connections on %s: %v", l.Addr(), err)
+			}
+			return
+		}
+		select {
+		case p.incoming <- conn.(*minecraft.Conn):
+		case <-p.closing:
+			_ = conn.Close()
+		}
+	}
+}
+
 // Accept accepts a fully connected (on Minecraft layer) connection which is ready to receive and send packets. If the
-// listener is closed or the player failed to spawn in then an error will be returned. When an error is returned the
-// session is also returned, but it may be incomplete and contain nil values.
-func (p *Portal) Accept() (*session.Session, error) {
+// player failed to spawn in then an error will be returned. When an error is returned the session is also returned,
+// but it may be incomplete and contain nil values. Once every listener of the proxy is closed, Accept returns an
+// error wrapping net.ErrClosed.
+func (p *Portal) Accept() (s *session.Session, err error) {
 	p.Logger().Debugf("waiting to accept...")
-	if p.listener == nil {
+	if len(p.listeners) == 0 {
 		return nil, fmt.Errorf("no active listener")
 	}
-	conn, err := p.listener.Accept()
+	var c *minecraft.Conn
+	select {
+	case c = <-p.incoming:
+	case <-p.stopped:
+		return nil, fmt.Errorf("all listeners are closed: %w", net.ErrClosed)
+	}
 	p.Logger().Debugf("accepted connection")
-	if err != nil {
-		return nil, err
+	if p.throttle != nil {
+		p.throttle.Succeed(c.RemoteAddr())
+	}
+	defer func() {
+		// A panic in one of the handlers or handler factories is recovered, so that a faulty handler does not
+		// crash the entire proxy.
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			p.log.Errorf("panic while accepting %s: %v\n%s", c.IdentityData().DisplayName, r, stack)
+			if rep := p.env.Reporter; rep != nil {
+				rep.Report(report.Event{
+					Time:    time.Now(),
+					Kind:    report.KindPanic,
+					Message: fmt.Sprintf("panic while accepting: %v", r),
+					Stack:   stack,
+					Tags:    map[string]string{"player": c.IdentityData().DisplayName, "xuid": c.IdentityData().XUID},
+				})
+			}
+			_ = p.Disconnect(c, "An internal error occurred.")
+			s, err = nil, fmt.Errorf("panic while accepting %s: %v", c.IdentityData().DisplayName, r)
+		}
+	}()
+
+	ctx := event.C()
+	p.handler().HandleAccept(ctx, c)
+	if ctx.Cancelled() {
+		m, _ := event.Load(ctx, DisconnectMessage)
+		_ = p.Disconnect(c, m)
+		return nil, fmt.Errorf("connection of %s was cancelled by the handler", c.IdentityData().DisplayName)
+	}
+
+	if p.versionGate != nil {
+		if ok, m := p.versionGate.Allow(c.ClientData().GameVersion); !ok {
+			_ = p.Disconnect(c, m)
+			return nil, fmt.Errorf("version %s of %s is not allowed", c.ClientData().GameVersion, c.IdentityData().DisplayName)
+		}
 	}
-	c := conn.(*minecraft.Conn)
+
+	ctx = event.C()
+	event.Store(ctx, Verified, c.IdentityData().XUID != "")
+	p.handler().HandleVerify(ctx, c)
+	if verified, _ := event.Load(ctx, Verified); ctx.Cancelled() || (p.requireXUID && !verified) {
+		m, ok := event.Load(ctx, DisconnectMessage)
+		if !ok {
+			m = "You must be signed in to Xbox Live to join."
+		}
+		_ = p.Disconnect(c, m)
+		return nil, fmt.Errorf("connection of %s was not verified", c.IdentityData().DisplayName)
+	}
+
+	ctx = event.C()
+	allowed, m := p.deviceAllowed(c)
+	event.Store(ctx, DeviceAllowed, allowed)
+	if !allowed {
+		event.Store(ctx, DisconnectMessage, m)
+	}
+	p.handler().HandleDevice(ctx, c)
+	if allowed, _ := event.Load(ctx, DeviceAllowed); ctx.Cancelled() || !allowed {
+		m, ok := event.Load(ctx, DisconnectMessage)
+		if !ok || m == "" {
+			m = "Your device is not allowed to join."
+		}
+		_ = p.Disconnect(c, m)
+		return nil, fmt.Errorf("device of %s is not allowed", c.IdentityData().DisplayName)
+	}
+
 	if ok, m := p.whitelist.Authorize(c); !ok {
 		_ = p.Disconnect(c, m)
 		return nil, fmt.Errorf("player is not whitelisted: %s", m)
 	}
-	return session.New(c, p.sessionStore, p.loadBalancer, p.log)
+	if xuid := c.IdentityData().XUID; p.takeover != nil && xuid != "" {
+		if s, ok := p.takeover.Claim(xuid); ok {
+			if err := s.Reattach(c); err != nil {
+				s.Close()
+				return nil, fmt.Errorf("failed to reattach %s to their session: %w", c.IdentityData().DisplayName, err)
+			}
+			p.meterSession(s, c)
+			return s, nil
+		}
+	}
+	loadBalancer := p.loadBalancer
+	if p.env.Rejoins != nil {
+		// Players transferred with a full reconnect, or handed off by another proxy, are sent to the server of
+		// their route token.
+		if name, ok := p.env.Rejoins.Redeem(session.RouteKey(c.IdentityData().XUID, session.ConnUUID(c))); ok {
+			if srv, ok := p.serverRegistry.Server(name); ok {
+				loadBalancer = pinnedLoadBalancer{srv: srv, registry: p.serverRegistry, fallback: loadBalancer}
+			}
+		}
+	}
+	if existing, ok := p.sessionStore.Load(session.ConnUUID(c)); ok {
+		ctx = event.C()
+		event.Store(ctx, DuplicateLogin, p.duplicateLogin)
+		p.handler().HandleDuplicateLogin(ctx, c, existing)
+		policy, _ := event.Load(ctx, DuplicateLogin)
+		if ctx.Cancelled() || policy == DuplicateRejectNew {
+			m, ok := event.Load(ctx, DisconnectMessage)
+			if !ok {
+				m = "You are already connected to this network."
+			}
+			_ = p.Disconnect(c, m)
+			return nil, fmt.Errorf("%s is already connected", c.IdentityData().DisplayName)
+		}
+		if srv, ok := existing.TryServer(); ok && policy == DuplicateHandoff {
+			loadBalancer = pinnedLoadBalancer{srv: srv, registry: p.serverRegistry, fallback: loadBalancer}
+		}
+		p.log.Infof("%s connected again, disconnecting their previous session", c.IdentityData().DisplayName)
+		existing.Disconnect("You logged in from another location.")
+	}
+	s, err = session.NewWithEnv(p.env, c, p.sessionStore, p.serverRegistry, loadBalancer, p.log, p.handlerFactories()...)
+	if err != nil {
+		return nil, err
+	}
+	p.meterSession(s, c)
+	return s, nil
+}
+
+// deviceAllowed returns if the device of the connection passed is allowed to join the proxy, and the message
+// the connection is disconnected with if it is not.
+func (p *Portal) deviceAllowed(c *minecraft.Conn) (bool, string) {
+	d := c.ClientData()
+	if p.blockImpossibleDevices && session.ImpossibleDevice(d) {
+		return false, ""
+	}
+	for _, r := range p.deviceRestrictions {
+		if r.Matches(d) {
+			return false, r.Message
+		}
+	}
+	return true, ""
+}
+
+// meterSession sets the bandwidth counter of the session passed to the counter of the connection passed, if the
+// proxy meters bandwidth.
+func (p *Portal) meterSession(s *session.Session, c *minecraft.Conn) {
+	if p.meter == nil {
+		return
+	}
+	if counter, ok := p.meter.Counter(c.RemoteAddr()); ok {
+		s.SetBandwidthCounter(counter)
+	}
+	addr := c.RemoteAddr().String()
+	p.meteredMu.Lock()
+	p.metered[addr] = s
+	p.meteredMu.Unlock()
+	s.OnClose(func() {
+		p.meteredMu.Lock()
+		defer p.meteredMu.Unlock()
+		if p.metered[addr] == s {
+			delete(p.metered, addr)
+		}
+	})
+}
+
+// bandwidthExceeded disconnects the session of the connection with the remote address passed, which exceeded
+// its bandwidth cap.
+func (p *Portal) bandwidthExceeded(addr net.Addr) {
+	p.meteredMu.Lock()
+	s, ok := p.metered[addr.String()]
+	p.meteredMu.Unlock()
+	if !ok {
+		return
+	}
+	p.log.Infof("[%s] %s exceeded the bandwidth cap, disconnecting them", s.CorrelationID(), s.IdentityData().DisplayName)
+	s.Disconnect("You exceeded the bandwidth limit.")
 }
 
 // Disconnect disconnects a Minecraft Conn passed by first sending a disconnect with the message passed, and
 // closing the connection after. If the message passed is empty, the client will be immediately sent to the
 // player list instead of a disconnect screen.
 func (p *Portal) Disconnect(conn *minecraft.Conn, message string) error {
-	if p.listener == nil {
+	if len(p.listeners) == 0 {
 		return fmt.Errorf("no listener to disconnect connection")
 	}
-	return p.listener.Disconnect(conn, message)
+	_ = conn.WritePacket(&packet.Disconnect{
+		HideDisconnectionScreen: message == "",
+		Message:                 message,
+	})
+	return conn.Close()
 }
@@ -0,0 +1,30 @@
+package portaltest
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/transport"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+)
+
+// Dial connects a fake client with the name passed to the proxy listening on the address passed over TCP, and
+// waits until it is spawned on a server. The proxy must run in offline mode, as the client is not
+// authenticated with Xbox Live.
+func Dial(address, name string) (*Conn, error) {
+	conn, err := minecraft.Dialer{
+		IdentityData: login.IdentityData{
+			DisplayName: name,
+			Identity:    uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String(),
+		},
+	}.DialTimeout(transport.NetworkTCP, address, Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	if err := conn.DoSpawnTimeout(Timeout); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("spawn: %w", err)
+	}
+	return newConn(conn), nil
+}
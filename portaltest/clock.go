@@ -0,0 +1,114 @@
+package portaltest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// Clock is a session.Clock of which the time only changes when it is advanced using Advance. It may be passed
+// to portal.Options to trigger timeouts of sessions deterministically.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+// timer is a function scheduled to be called by a Clock.
+type timer struct {
+	at time.Time
+	f  func()
+}
+
+// NewClock returns a Clock set to the time passed.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now ...
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc ...
+func (c *Clock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, other := range c.timers {
+			if other == t {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Advance moves the time of the clock forward by the duration passed. The functions of all timers that expire
+// are called in the order they expire, on the goroutine calling Advance, before Advance returns.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var expired, pending []*timer
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		expired = append(expired, t)
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].at.Before(expired[j].at)
+	})
+	for _, t := range expired {
+		t.f()
+	}
+}
+
+// SlowDial returns a session.DialFunc that waits until the duration passed has elapsed on the clock passed
+// before dialing the server using session.DefaultDial. It may be used to simulate slow servers.
+func SlowDial(c session.Clock, d time.Duration) session.DialFunc {
+	return func(ctx context.Context, s *session.Session, srv *server.Server) (*minecraft.Conn, error) {
+		elapsed := make(chan struct{})
+		stop := c.AfterFunc(d, func() { close(elapsed) })
+		defer stop()
+		select {
+		case <-elapsed:
+			return session.DefaultDial(ctx, s, srv)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// FailDial returns a session.DialFunc that fails to dial the servers passed with the error passed, and dials
+// any other server using session.DefaultDial. If no servers are passed, dialing any server fails.
+func FailDial(err error, servers ...*server.Server) session.DialFunc {
+	return func(ctx context.Context, s *session.Session, srv *server.Server) (*minecraft.Conn, error) {
+		if len(servers) == 0 {
+			return nil, err
+		}
+		for _, failed := range servers {
+			if failed == srv {
+				return nil, err
+			}
+		}
+		return session.DefaultDial(ctx, s, srv)
+	}
+}
@@ -0,0 +1,96 @@
+package portaltest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Conn is a connection of a fake client to the proxy, or of the proxy to a fake server. Packets received on
+// the connection are queued, so that they can be waited for using Expect or ExpectPacket.
+type Conn struct {
+	*minecraft.Conn
+
+	packets chan packet.Packet
+	closed  chan struct{}
+	err     error
+}
+
+// newConn wraps the connection passed and starts reading packets from it.
+func newConn(conn *minecraft.Conn) *Conn {
+	c := &Conn{Conn: conn, packets: make(chan packet.Packet, 256), closed: make(chan struct{})}
+	go c.read()
+	return c
+}
+
+// read reads packets from the connection until it is closed. Packets are dropped if the queue is full, so that
+// a test that does not read every packet does not block the connection.
+func (c *Conn) read() {
+	defer close(c.closed)
+	for {
+		pk, err := c.Conn.ReadPacket()
+		if err != nil {
+			c.err = err
+			return
+		}
+		select {
+		case c.packets <- pk:
+		default:
+		}
+	}
+}
+
+// Expect waits until a packet for which f returns true is received and returns it. Packets received before it
+// for which f returns false are discarded. If no such packet is received within the timeout, or if the
+// connection is closed, an error is returned.
+func (c *Conn) Expect(timeout time.Duration, f func(pk packet.Packet) bool) (packet.Packet, error) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for {
+		select {
+		case pk := <-c.packets:
+			if f(pk) {
+				return pk, nil
+			}
+		case <-c.closed:
+			// Packets received before the connection was closed may still be queued.
+			select {
+			case pk := <-c.packets:
+				if f(pk) {
+					return pk, nil
+				}
+				continue
+			default:
+			}
+			return nil, fmt.Errorf("connection closed: %w", c.err)
+		case <-t.C:
+			return nil, fmt.Errorf("no matching packet received within %v", timeout)
+		}
+	}
+}
+
+// ExpectPacket waits until a packet of the type T is received on the connection passed and returns it. Packets
+// of other types received before it are discarded.
+func ExpectPacket[T packet.Packet](c *Conn, timeout time.Duration) (T, error) {
+	pk, err := c.Expect(timeout, func(pk packet.Packet) bool {
+		_, ok := pk.(T)
+		return ok
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return pk.(T), nil
+}
+
+// Closed returns a channel that is closed once the connection is closed.
+func (c *Conn) Closed() <-chan struct{} {
+	return c.closed
+}
+
+// ReadPacket panics, as packets are read by the Conn itself. Expect or ExpectPacket should be used instead.
+func (c *Conn) ReadPacket() (packet.Packet, error) {
+	panic("portaltest: ReadPacket must not be called on a Conn, use Expect instead")
+}
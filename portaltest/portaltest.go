@@ -0,0 +1,25 @@
+// Package portaltest implements a fake downstream server and a fake client that run in-process, so that the
+// proxy can be tested end-to-end without real game servers. A typical test starts one or more servers using
+// NewServer, a proxy using NewProxy, and connects a client to it using Dial:
+//
+//	srv, _ := portaltest.NewServer("lobby")
+//	defer srv.Close()
+//	p, _ := portaltest.NewProxy(portal.Options{}, srv)
+//	defer p.Close()
+//	client, _ := portaltest.Dial(p.Addr(), "Steve")
+//	defer client.Close()
+//	conn, _ := srv.Accept(portaltest.Timeout)
+//
+// The client and the connection of the proxy to the server may then be used to send packets to each other
+// and to wait for them using Expect or ExpectPacket. All connections are made over TCP on the loopback
+// interface.
+//
+// Time and dialing may be controlled by passing a Clock and a session.DialFunc, such as SlowDial or FailDial,
+// in the portal.Options of the proxy, so that timeouts and failing transfers can be tested deterministically.
+package portaltest
+
+import "time"
+
+// Timeout is the timeout used for dialing and spawning connections. It may also be used as timeout for Expect
+// and Accept.
+const Timeout = time.Second * 10
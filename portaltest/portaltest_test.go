@@ -0,0 +1,143 @@
+package portaltest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paroxity/portal"
+	"github.com/paroxity/portal/portaltest"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// TestJoin tests that a client joining the proxy is connected to a server and that packets are forwarded in
+// both directions.
+func TestJoin(t *testing.T) {
+	lobby := startServer(t, "lobby")
+	p := startProxy(t, lobby)
+	client := dial(t, p, "Steve")
+	conn, err := lobby.Accept(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer conn.Close()
+
+	s, err := p.Session(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("session: %v", err)
+	}
+	waitLogin(t, s)
+	if srv, ok := s.TryServer(); !ok || srv.Name() != "lobby" {
+		t.Fatalf("expected session to be on lobby")
+	}
+
+	sendChat(t, client, "hello server")
+	expectChat(t, conn, "hello server")
+	if err := conn.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "hello client"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	expectChat(t, client, "hello client")
+}
+
+// TestTransfer tests that a session is transferred to another server once the client finished the dimension
+// change, after which packets are forwarded to the new server.
+func TestTransfer(t *testing.T) {
+	lobby, game := startServer(t, "lobby"), startServer(t, "game")
+	p := startProxy(t, lobby)
+	client := dial(t, p, "Steve")
+	lobbyConn, err := lobby.Accept(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("accept lobby: %v", err)
+	}
+	defer lobbyConn.Close()
+	// The game server is only added once the client joined, so that the client always joins the lobby.
+	p.ServerRegistry().AddServer(game.Server())
+	s, err := p.Session(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("session: %v", err)
+	}
+
+	if err := s.Transfer(game.Server()); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	gameConn, err := game.Accept(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("accept game: %v", err)
+	}
+	defer gameConn.Close()
+	if _, err := portaltest.ExpectPacket[*packet.ChangeDimension](client, portaltest.Timeout); err != nil {
+		t.Fatalf("expect dimension change: %v", err)
+	}
+	if err := client.WritePacket(&packet.PlayerAction{ActionType: protocol.PlayerActionDimensionChangeDone}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	sendChat(t, client, "hello game")
+	expectChat(t, gameConn, "hello game")
+	if srv := s.Server(); srv != game.Server() {
+		t.Fatalf("expected session to be on game, got %s", srv.Name())
+	}
+}
+
+// waitLogin waits until the session passed finished logging in.
+func waitLogin(t *testing.T, s *session.Session) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), portaltest.Timeout)
+	defer cancel()
+	if err := s.WaitLogin(ctx); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+}
+
+// startServer starts a fake server with the name passed, which is closed when the test ends.
+func startServer(t *testing.T, name string) *portaltest.Server {
+	t.Helper()
+	srv, err := portaltest.NewServer(name)
+	if err != nil {
+		t.Fatalf("start server %s: %v", name, err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	return srv
+}
+
+// startProxy starts a proxy with the servers passed, which is closed when the test ends.
+func startProxy(t *testing.T, servers ...*portaltest.Server) *portaltest.Proxy {
+	t.Helper()
+	p, err := portaltest.NewProxy(portal.Options{}, servers...)
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+// dial connects a client with the name passed to the proxy passed, which is closed when the test ends.
+func dial(t *testing.T, p *portaltest.Proxy, name string) *portaltest.Conn {
+	t.Helper()
+	client, err := portaltest.Dial(p.Addr(), name)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// sendChat sends a chat message from the client passed.
+func sendChat(t *testing.T, client *portaltest.Conn, message string) {
+	t.Helper()
+	if err := client.WritePacket(&packet.Text{TextType: packet.TextTypeChat, SourceName: client.IdentityData().DisplayName, Message: message}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// expectChat waits for a text packet with the message passed on the connection passed.
+func expectChat(t *testing.T, conn *portaltest.Conn, message string) {
+	t.Helper()
+	if _, err := conn.Expect(portaltest.Timeout, func(pk packet.Packet) bool {
+		text, ok := pk.(*packet.Text)
+		return ok && text.Message == message
+	}); err != nil {
+		t.Fatalf("expect %q: %v", message, err)
+	}
+}
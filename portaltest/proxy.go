@@ -0,0 +1,94 @@
+package portaltest
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/paroxity/portal"
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/transport"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/atomic"
+)
+
+// Proxy is a proxy running in-process, which accepts fake clients over TCP on a random local port.
+type Proxy struct {
+	*portal.Portal
+
+	sessions chan *session.Session
+	closed   atomic.Bool
+	done     chan struct{}
+}
+
+// NewProxy creates a proxy using the options passed, adds the servers passed to its server registry and starts
+// accepting connections. The address and network of the options are overwritten, and the proxy always runs in
+// offline mode. If no logger is set, logs are discarded.
+func NewProxy(opts portal.Options, servers ...*Server) (*Proxy, error) {
+	opts.Network, opts.Address = transport.NetworkTCP, "127.0.0.1:0"
+	opts.Listeners = nil
+	opts.OfflineMode = true
+	if opts.Logger == nil {
+		log := logrus.New()
+		log.SetOutput(io.Discard)
+		opts.Logger = log
+	}
+	p := &Proxy{Portal: portal.New(opts), sessions: make(chan *session.Session, 16), done: make(chan struct{})}
+	for _, srv := range servers {
+		p.ServerRegistry().AddServer(srv.Server())
+	}
+	if err := p.Listen(); err != nil {
+		return nil, err
+	}
+	go p.accept()
+	return p, nil
+}
+
+// Addr returns the address fake clients may connect to using Dial.
+func (p *Proxy) Addr() string {
+	return p.Addrs()[0].String()
+}
+
+// Session waits for the next session to be accepted by the proxy and returns it. If no session is accepted
+// within the timeout, an error is returned.
+func (p *Proxy) Session(timeout time.Duration) (*session.Session, error) {
+	select {
+	case s := <-p.sessions:
+		return s, nil
+	case <-p.done:
+		return nil, fmt.Errorf("proxy closed")
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no session accepted within %v", timeout)
+	}
+}
+
+// Close stops the proxy from accepting connections and closes all of its sessions.
+func (p *Proxy) Close() error {
+	p.closed.Store(true)
+	err := p.Portal.Close()
+	for _, s := range p.SessionStore().All() {
+		s.CloseWithReason(session.CloseProxyShutdown)
+	}
+	return err
+}
+
+// accept accepts sessions until the proxy is closed.
+func (p *Proxy) accept() {
+	defer close(p.done)
+	for {
+		s, err := p.Accept()
+		if err != nil {
+			if p.closed.Load() {
+				return
+			}
+			if s != nil {
+				s.Close()
+			}
+			continue
+		}
+		select {
+		case p.sessions <- s:
+		default:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package portaltest
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/transport"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"go.uber.org/atomic"
+)
+
+// serverCount is the number of fake servers started, used to give each of them different entity IDs so that
+// the translation of entity IDs by the proxy is exercised by transfers.
+var serverCount atomic.Int64
+
+// Server is a fake downstream server running in-process. Players connecting to it are spawned in an empty
+// world, after which their connections are queued so that a test can accept them using Accept.
+type Server struct {
+	srv  *server.Server
+	l    *minecraft.Listener
+	data minecraft.GameData
+
+	conns chan *Conn
+	done  chan struct{}
+}
+
+// NewServer starts a fake server with the name passed, listening on a random local port over TCP. The server
+// returned by Server may be added to the server registry of the proxy.
+func NewServer(name string) (*Server, error) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true, ErrorLog: log.New(io.Discard, "", 0)}.Listen(transport.NetworkTCP, "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	id := serverCount.Add(1)
+	s := &Server{
+		srv: server.NewWithNetwork(name, transport.NetworkTCP, l.Addr().String()),
+		l:   l,
+		data: minecraft.GameData{
+			WorldName:       name,
+			EntityUniqueID:  id,
+			EntityRuntimeID: uint64(id),
+			PlayerPosition:  mgl32.Vec3{0, 64, 0},
+			WorldSpawn:      [3]int32{0, 64, 0},
+		},
+		conns: make(chan *Conn, 16),
+		done:  make(chan struct{}),
+	}
+	go s.accept()
+	return s, nil
+}
+
+// Server returns the server.Server pointing to the fake server, which may be added to the server registry of
+// the proxy.
+func (s *Server) Server() *server.Server {
+	return s.srv
+}
+
+// Accept waits for the next player to spawn on the server and returns their connection. If no player spawns
+// within the timeout, an error is returned.
+func (s *Server) Accept(timeout time.Duration) (*Conn, error) {
+	select {
+	case c := <-s.conns:
+		return c, nil
+	case <-s.done:
+		return nil, fmt.Errorf("server closed")
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no connection accepted within %v", timeout)
+	}
+}
+
+// Close closes the server and the connections of all players on it.
+func (s *Server) Close() error {
+	return s.l.Close()
+}
+
+// accept accepts connections until the listener is closed, spawning each of them.
+func (s *Server) accept() {
+	defer close(s.done)
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		go s.spawn(conn.(*minecraft.Conn))
+	}
+}
+
+// spawn spawns the connection passed and queues it to be accepted.
+func (s *Server) spawn(conn *minecraft.Conn) {
+	if err := conn.StartGameTimeout(s.data, Timeout); err != nil {
+		_ = conn.Close()
+		return
+	}
+	select {
+	case s.conns <- newConn(conn):
+	case <-s.done:
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,94 @@
+package presence
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Handler returns a function that creates a session.Handler which shows the join and quit messages of the
+// session and suppresses those of its servers. The function returned may be passed to portal.Handle directly.
+func (a *Announcer) Handler() func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{a: a, s: s}
+	}
+}
+
+// handler is the session.Handler of an Announcer for a single session.
+type handler struct {
+	session.NopHandler
+	a *Announcer
+	s *session.Session
+
+	// srv is the name of the server the session is on, or empty if it has not joined any server yet.
+	mu  sync.Mutex
+	srv string
+}
+
+// HandleClientBoundPacket ...
+func (h *handler) HandleClientBoundPacket(ctx *event.Context, pk packet.Packet) {
+	t, ok := pk.(*packet.Text)
+	if !ok || t.TextType != packet.TextTypeTranslation {
+		return
+	}
+	if !strings.Contains(t.Message, "multiplayer.player.joined") && !strings.Contains(t.Message, "multiplayer.player.left") {
+		return
+	}
+	h.mu.Lock()
+	srv := h.srv
+	h.mu.Unlock()
+	if h.a.templates(srv).suppress {
+		ctx.Cancel()
+	}
+}
+
+// HandleServerConnect ...
+func (h *handler) HandleServerConnect(srv *server.Server, _ *minecraft.Conn) {
+	h.mu.Lock()
+	previous := h.srv
+	h.srv = srv.Name()
+	h.mu.Unlock()
+
+	switch h.a.scope {
+	case ScopeNetwork:
+		if previous == "" {
+			h.a.announce(h.a.templates(srv.Name()).join, h.s, srv.Name(), everyone)
+		}
+	case ScopeServer:
+		if previous != "" {
+			h.a.announce(h.a.templates(previous).quit, h.s, previous, on(previous))
+		}
+		h.a.announce(h.a.templates(srv.Name()).join, h.s, srv.Name(), on(srv.Name()))
+	}
+}
+
+// HandleTransferFailure ...
+func (h *handler) HandleTransferFailure(*server.Server, error) {
+	// The session stays on the server it was on, even if it spawned on the server passed before the transfer
+	// failed.
+	if cur, ok := h.s.TryServer(); ok && cur != nil {
+		h.mu.Lock()
+		h.srv = cur.Name()
+		h.mu.Unlock()
+	}
+}
+
+// HandleQuit ...
+func (h *handler) HandleQuit(session.CloseReason) {
+	h.mu.Lock()
+	srv := h.srv
+	h.mu.Unlock()
+	if srv == "" {
+		return
+	}
+	match := everyone
+	if h.a.scope == ScopeServer {
+		match = on(srv)
+	}
+	h.a.announce(h.a.templates(srv).quit, h.s, srv, match)
+}
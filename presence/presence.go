@@ -0,0 +1,153 @@
+// Package presence implements the messages shown to players when other players join or leave, either the
+// network or a single server. The join and leave messages of the servers themselves may be suppressed, so that
+// the messages are consistent across the network.
+package presence
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/paroxity/portal/permission"
+	"github.com/paroxity/portal/session"
+)
+
+// Scope determines when join and quit messages are shown.
+type Scope string
+
+const (
+	// ScopeNetwork shows a join message to every player when a player joins the proxy, and a quit message when
+	// they leave it. Transfers between servers are not announced.
+	ScopeNetwork Scope = "network"
+	// ScopeServer shows a join message to the players on a server when a player joins it, either from another
+	// server or by joining the proxy, and a quit message when they leave it.
+	ScopeServer Scope = "server"
+)
+
+// Messages holds the templates of the join and quit messages. The templates are executed with Data.
+type Messages struct {
+	// Join is the message shown when a player joins. If empty, no message is shown.
+	Join string
+	// Quit is the message shown when a player leaves. If empty, no message is shown.
+	Quit string
+	// Suppress is if the join and leave messages sent by servers are hidden from players.
+	Suppress bool
+}
+
+// Data is the data the templates of Messages are executed with.
+type Data struct {
+	// Name is the display name of the player joining or leaving.
+	Name string
+	// Prefix is the prefix of the rank of the player, which may be empty.
+	Prefix string
+	// Server is the name of the server the player joined or left. In ScopeNetwork, it is the first or last server
+	// the player was on.
+	Server string
+}
+
+// Config holds the settings of an Announcer.
+type Config struct {
+	// Scope determines when join and quit messages are shown. If empty, ScopeNetwork is used.
+	Scope Scope
+	// Messages holds the messages shown for servers that have no messages of their own.
+	Messages Messages
+	// Servers holds the messages of specific servers by their names. In ScopeNetwork, only Suppress is used.
+	Servers map[string]Messages
+	// Ranks provides the ranks of which the prefixes are shown in the messages. It may be nil.
+	Ranks permission.Provider
+}
+
+// templates holds the parsed join and quit templates of a Messages.
+type templates struct {
+	join, quit *template.Template
+	suppress   bool
+}
+
+// Announcer shows join and quit messages to the players of a session store.
+type Announcer struct {
+	store   *session.Store
+	scope   Scope
+	ranks   permission.Provider
+	def     templates
+	servers map[string]templates
+}
+
+// New returns an Announcer which shows the messages of the config passed to the players in the store passed. An
+// error is returned if any of the templates could not be parsed.
+func New(store *session.Store, conf Config) (*Announcer, error) {
+	if conf.Scope == "" {
+		conf.Scope = ScopeNetwork
+	}
+	if conf.Scope != ScopeNetwork && conf.Scope != ScopeServer {
+		return nil, fmt.Errorf("unknown scope %q", conf.Scope)
+	}
+	a := &Announcer{store: store, scope: conf.Scope, ranks: conf.Ranks, servers: make(map[string]templates, len(conf.Servers))}
+	var err error
+	if a.def, err = parse(conf.Messages); err != nil {
+		return nil, err
+	}
+	for name, m := range conf.Servers {
+		t, err := parse(m)
+		if err != nil {
+			return nil, fmt.Errorf("messages of server %s: %w", name, err)
+		}
+		a.servers[strings.ToLower(name)] = t
+	}
+	return a, nil
+}
+
+// parse parses the templates of the messages passed.
+func parse(m Messages) (templates, error) {
+	t := templates{suppress: m.Suppress}
+	var err error
+	if m.Join != "" {
+		if t.join, err = template.New("join").Parse(m.Join); err != nil {
+			return t, fmt.Errorf("parse join message: %w", err)
+		}
+	}
+	if m.Quit != "" {
+		if t.quit, err = template.New("quit").Parse(m.Quit); err != nil {
+			return t, fmt.Errorf("parse quit message: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// templates returns the templates of the server with the name passed.
+func (a *Announcer) templates(srv string) templates {
+	if t, ok := a.servers[strings.ToLower(srv)]; ok {
+		return t
+	}
+	return a.def
+}
+
+// announce shows the message of the template passed about the session s to every player matched by the
+// function passed, other than s itself. Nothing is shown if the template is nil.
+func (a *Announcer) announce(t *template.Template, s *session.Session, srv string, match func(other *session.Session) bool) {
+	if t == nil {
+		return
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, Data{Name: s.DisplayName(), Prefix: permission.Prefix(a.ranks, s), Server: srv}); err != nil {
+		return
+	}
+	msg := b.String()
+	for _, other := range a.store.All() {
+		if other != s && match(other) {
+			other.SendMessage(msg)
+		}
+	}
+}
+
+// everyone matches every session.
+func everyone(*session.Session) bool {
+	return true
+}
+
+// on returns a function that matches the sessions on the server with the name passed.
+func on(srv string) func(s *session.Session) bool {
+	return func(s *session.Session) bool {
+		current, ok := s.TryServer()
+		return ok && current != nil && current.Name() == srv
+	}
+}
@@ -0,0 +1,58 @@
+package punishment
+
+import (
+	"strings"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// NewMuteHandler returns a function that creates a session.Handler which enforces the mutes in the store passed,
+// so that mutes apply on every server of the network as soon as they are created. Chat messages of muted players
+// are blocked, as are the commands with the names passed, such as "tell" or "me". The function returned may be
+// passed to portal.Handle directly.
+func NewMuteHandler(mutes Store, commands ...string) func(s *session.Session) session.Handler {
+	blocked := make(map[string]struct{}, len(commands))
+	for _, c := range commands {
+		blocked[strings.ToLower(strings.TrimPrefix(c, "/"))] = struct{}{}
+	}
+	return func(s *session.Session) session.Handler {
+		return &muteHandler{mutes: mutes, commands: blocked, s: s}
+	}
+}
+
+// muteHandler is a session.Handler that enforces the mutes of a single session.
+type muteHandler struct {
+	session.NopHandler
+	mutes    Store
+	commands map[string]struct{}
+	s        *session.Session
+}
+
+// HandleServerBoundPacket ...
+func (h *muteHandler) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	if ctx.Cancelled() {
+		return
+	}
+	switch pk := pk.(type) {
+	case *packet.Text:
+		if pk.TextType != packet.TextTypeChat {
+			return
+		}
+	case *packet.CommandRequest:
+		fields := strings.Fields(strings.TrimPrefix(pk.CommandLine, "/"))
+		if len(fields) == 0 {
+			return
+		}
+		if _, ok := h.commands[strings.ToLower(fields[0])]; !ok {
+			return
+		}
+	default:
+		return
+	}
+	if mute, ok := h.mutes.Muted(h.s.UUID()); ok {
+		ctx.Cancel()
+		h.s.SendMessage(mute.Message())
+	}
+}
@@ -0,0 +1,24 @@
+package punishment
+
+import (
+	"time"
+)
+
+// IPBan is a ban of an IP address, which prevents any connection from it from reaching the proxy.
+type IPBan struct {
+	// IP is the banned IP address.
+	IP string `json:"ip"`
+	// Reason is the reason the IP address was banned for.
+	Reason string `json:"reason"`
+	// Source is the name of whoever or whatever banned the IP address, such as "throttle".
+	Source string `json:"source"`
+	// Created is the time at which the ban was created.
+	Created time.Time `json:"created"`
+	// Expiry is the time at which the ban expires. If zero, the ban never expires.
+	Expiry time.Time `json:"expiry"`
+}
+
+// Expired returns if the ban has expired.
+func (b IPBan) Expired() bool {
+	return !b.Expiry.IsZero() && time.Now().After(b.Expiry)
+}
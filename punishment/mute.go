@@ -0,0 +1,35 @@
+package punishment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// Mute is a mute of a player, which prevents them from chatting on any server of the network.
+type Mute struct {
+	// UUID is the UUID of the muted player.
+	UUID uuid.UUID `json:"uuid"`
+	// Reason is the reason the player was muted for.
+	Reason string `json:"reason"`
+	// Source is the name of whoever or whatever muted the player, such as the name of a staff member or "anti-spam".
+	Source string `json:"source"`
+	// Created is the time at which the mute was created.
+	Created time.Time `json:"created"`
+	// Expiry is the time at which the mute expires. If zero, the mute never expires.
+	Expiry time.Time `json:"expiry"`
+}
+
+// Expired returns if the mute has expired.
+func (m Mute) Expired() bool {
+	return !m.Expiry.IsZero() && time.Now().After(m.Expiry)
+}
+
+// Message returns the message shown to the muted player when they try to chat.
+func (m Mute) Message() string {
+	if m.Expiry.IsZero() {
+		return text.Colourf("<red>You are muted: %s</red>", m.Reason)
+	}
+	return text.Colourf("<red>You are muted for %s: %s</red>", time.Until(m.Expiry).Round(time.Second), m.Reason)
+}
@@ -0,0 +1,225 @@
+// Package punishment implements the storage of punishments of players, such as mutes and IP bans, so that they apply
+// across every server of the network and persist across restarts of the proxy.
+package punishment
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/storage"
+)
+
+// Store persists the punishments of players.
+type Store interface {
+	// Mute stores the mute passed, replacing any mute of the same player stored before.
+	Mute(m Mute) error
+	// Unmute removes the mute of the player with the UUID passed, if any.
+	Unmute(id uuid.UUID) error
+	// Muted returns the mute of the player with the UUID passed. If the player is not muted, or their mute has
+	// expired, false is returned.
+	Muted(id uuid.UUID) (Mute, bool)
+	// BanIP stores the IP ban passed, replacing any ban of the same IP address stored before.
+	BanIP(b IPBan) error
+	// UnbanIP removes the ban of the IP address passed, if any.
+	UnbanIP(ip string) error
+	// IPBanned returns the ban of the IP address passed. If the IP address is not banned, or its ban has
+	// expired, false is returned.
+	IPBanned(ip string) (IPBan, bool)
+}
+
+// FileStore is a Store that keeps punishments in memory and persists them to a JSON file on every change.
+type FileStore struct {
+	path string
+
+	mu     sync.Mutex
+	mutes  map[uuid.UUID]Mute
+	ipBans map[string]IPBan
+}
+
+// fileData is the data stored in the file of a FileStore.
+type fileData struct {
+	Mutes  []Mute  `json:"mutes"`
+	IPBans []IPBan `json:"ip_bans"`
+}
+
+// NewFileStore creates a FileStore that persists punishments to the file at the path passed. Punishments that
+// are already stored in the file are loaded.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, mutes: make(map[uuid.UUID]Mute), ipBans: make(map[string]IPBan)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var d fileData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	for _, m := range d.Mutes {
+		if !m.Expired() {
+			s.mutes[m.UUID] = m
+		}
+	}
+	for _, b := range d.IPBans {
+		if !b.Expired() {
+			s.ipBans[b.IP] = b
+		}
+	}
+	return s, nil
+}
+
+// Mute ...
+func (s *FileStore) Mute(m Mute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mutes[m.UUID] = m
+	return s.save()
+}
+
+// Unmute ...
+func (s *FileStore) Unmute(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mutes[id]; !ok {
+		return nil
+	}
+	delete(s.mutes, id)
+	return s.save()
+}
+
+// Muted ...
+func (s *FileStore) Muted(id uuid.UUID) (Mute, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.mutes[id]
+	if !ok || m.Expired() {
+		return Mute{}, false
+	}
+	return m, true
+}
+
+// BanIP ...
+func (s *FileStore) BanIP(b IPBan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ipBans[b.IP] = b
+	return s.save()
+}
+
+// UnbanIP ...
+func (s *FileStore) UnbanIP(ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ipBans[ip]; !ok {
+		return nil
+	}
+	delete(s.ipBans, ip)
+	return s.save()
+}
+
+// IPBanned ...
+func (s *FileStore) IPBanned(ip string) (IPBan, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.ipBans[ip]
+	if !ok || b.Expired() {
+		return IPBan{}, false
+	}
+	return b, true
+}
+
+// save writes all punishments that have not expired to the file of the store. It must be called while holding
+// the mutex of the store.
+func (s *FileStore) save() error {
+	var d fileData
+	for id, m := range s.mutes {
+		if m.Expired() {
+			delete(s.mutes, id)
+			continue
+		}
+		d.Mutes = append(d.Mutes, m)
+	}
+	for ip, b := range s.ipBans {
+		if b.Expired() {
+			delete(s.ipBans, ip)
+			continue
+		}
+		d.IPBans = append(d.IPBans, b)
+	}
+	data, err := json.MarshalIndent(d, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// ProviderStore is a Store that stores punishments using a storage.Provider, so that they may be shared by
+// multiple proxies using the same database.
+type ProviderStore struct {
+	p storage.Provider
+}
+
+const (
+	// muteBucket is the bucket of the storage.Provider in which mutes are stored.
+	muteBucket = "mutes"
+	// ipBanBucket is the bucket of the storage.Provider in which IP bans are stored.
+	ipBanBucket = "ip_bans"
+)
+
+// NewProviderStore creates a ProviderStore that stores punishments using the provider passed.
+func NewProviderStore(p storage.Provider) *ProviderStore {
+	return &ProviderStore{p: p}
+}
+
+// Mute ...
+func (s *ProviderStore) Mute(m Mute) error {
+	return storage.Save(s.p, muteBucket, m.UUID.String(), m)
+}
+
+// Unmute ...
+func (s *ProviderStore) Unmute(id uuid.UUID) error {
+	return s.p.Delete(muteBucket, id.String())
+}
+
+// Muted ...
+func (s *ProviderStore) Muted(id uuid.UUID) (Mute, bool) {
+	var m Mute
+	if ok, err := storage.Load(s.p, muteBucket, id.String(), &m); err != nil || !ok {
+		// Mutes that cannot be loaded are treated as absent, so that an unavailable database does not prevent
+		// every player from chatting.
+		return Mute{}, false
+	}
+	if m.Expired() {
+		_ = s.p.Delete(muteBucket, id.String())
+		return Mute{}, false
+	}
+	return m, true
+}
+
+// BanIP ...
+func (s *ProviderStore) BanIP(b IPBan) error {
+	return storage.Save(s.p, ipBanBucket, b.IP, b)
+}
+
+// UnbanIP ...
+func (s *ProviderStore) UnbanIP(ip string) error {
+	return s.p.Delete(ipBanBucket, ip)
+}
+
+// IPBanned ...
+func (s *ProviderStore) IPBanned(ip string) (IPBan, bool) {
+	var b IPBan
+	if ok, err := storage.Load(s.p, ipBanBucket, ip, &b); err != nil || !ok {
+		return IPBan{}, false
+	}
+	if b.Expired() {
+		_ = s.p.Delete(ipBanBucket, ip)
+		return IPBan{}, false
+	}
+	return b, true
+}
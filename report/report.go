@@ -0,0 +1,49 @@
+// Package report implements reporting of errors that occur in the proxy, such as panics and failed dials, to
+// external error tracking services.
+package report
+
+import "time"
+
+const (
+	// KindPanic is the kind of events of panics that were recovered from.
+	KindPanic = "panic"
+	// KindDial is the kind of events of servers that could not be dialed.
+	KindDial = "dial"
+	// KindLogin is the kind of events of players that could not log in to a server.
+	KindLogin = "login"
+	// KindTransfer is the kind of events of transfers that failed.
+	KindTransfer = "transfer"
+	// KindDecode is the kind of events of packets that could not be decoded.
+	KindDecode = "decode"
+)
+
+// Event is an error that occurred in the proxy.
+type Event struct {
+	// Time is the time at which the error occurred.
+	Time time.Time
+	// Kind is the kind of error, such as KindPanic.
+	Kind string
+	// Message is the message of the error.
+	Message string
+	// Stack is the stack trace of the goroutine in which the error occurred, as returned by debug.Stack. It is
+	// only set for panics.
+	Stack []byte
+	// Tags holds the context of the error, such as the name of the player and server it concerns.
+	Tags map[string]string
+}
+
+// Reporter reports errors to an external error tracking service. Report may be called concurrently, and should
+// not block for long.
+type Reporter interface {
+	// Report reports the event passed.
+	Report(e Event)
+}
+
+// NopReporter is a Reporter that discards all events reported to it.
+type NopReporter struct{}
+
+// Compile time check to make sure NopReporter implements Reporter.
+var _ Reporter = NopReporter{}
+
+// Report ...
+func (NopReporter) Report(Event) {}
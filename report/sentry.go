@@ -0,0 +1,175 @@
+package report
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// sentryQueueSize is the number of events that may be waiting to be sent before events are dropped.
+const sentryQueueSize = 256
+
+// Sentry is a Reporter that sends events to Sentry, or any service compatible with its store API. Events are
+// sent from a goroutine of their own, and are dropped if too many are waiting to be sent.
+type Sentry struct {
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+
+	events chan Event
+	closed chan struct{}
+	errf   func(err error)
+}
+
+// NewSentry creates a Sentry reporter that sends events to the project of the DSN passed, such as
+// "https://<key>@o0.ingest.sentry.io/<project>". The environment and release passed are sent with every event
+// and may be empty. The function passed is called with any error that occurs while sending an event, and may
+// be nil.
+func NewSentry(dsn, environment, release string, errf func(err error)) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse sentry dsn: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("sentry dsn %q has no public key", dsn)
+	}
+	i := strings.LastIndex(u.Path, "/")
+	project := u.Path[i+1:]
+	if project == "" {
+		return nil, fmt.Errorf("sentry dsn %q has no project", dsn)
+	}
+	auth := "Sentry sentry_version=7, sentry_client=portal, sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	if errf == nil {
+		errf = func(error) {}
+	}
+	host, _ := os.Hostname()
+	s := &Sentry{
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, u.Path[:i], project),
+		auth:        auth,
+		environment: environment,
+		release:     release,
+		serverName:  host,
+		client:      &http.Client{Timeout: time.Second * 10},
+		events:      make(chan Event, sentryQueueSize),
+		closed:      make(chan struct{}),
+		errf:        errf,
+	}
+	go s.run()
+	return s, nil
+}
+
+// Report ...
+func (s *Sentry) Report(e Event) {
+	select {
+	case s.events <- e:
+	default:
+	}
+}
+
+// Close sends the events that are waiting to be sent and stops the reporter. Report must not be called after
+// Close.
+func (s *Sentry) Close() error {
+	close(s.events)
+	<-s.closed
+	return nil
+}
+
+// run sends the events reported until the reporter is closed.
+func (s *Sentry) run() {
+	defer close(s.closed)
+	for e := range s.events {
+		if err := s.send(e); err != nil {
+			s.errf(err)
+		}
+	}
+}
+
+// sentryEvent is an event in the format of the store API of Sentry.
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+}
+
+// sentryExceptions holds the exceptions of a sentryEvent.
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+// sentryException is a single exception of a sentryEvent.
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// send sends the event passed to Sentry.
+func (s *Sentry) send(e Event) error {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	level := "error"
+	if e.Kind == KindPanic {
+		level = "fatal"
+	}
+	tags := map[string]string{"kind": e.Kind}
+	for k, v := range e.Tags {
+		tags[k] = v
+	}
+	event := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   e.Time.UTC().Format(time.RFC3339),
+		Level:       level,
+		Platform:    "go",
+		Logger:      "portal",
+		ServerName:  s.serverName,
+		Environment: s.environment,
+		Release:     s.release,
+		Exception:   sentryExceptions{Values: []sentryException{{Type: e.Kind, Value: e.Message}}},
+		Tags:        tags,
+	}
+	if len(e.Stack) > 0 {
+		event.Extra = map[string]string{"stack": string(e.Stack)}
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("sentry responded with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package restart implements the orchestration of scheduled restarts of servers. Players are warned of the
+// restart, moved to a fallback server before it happens and the server is kept out of rotation until it is
+// healthy again.
+package restart
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+)
+
+// announcements holds the remaining durations at which players are reminded of an upcoming restart.
+var announcements = []time.Duration{
+	time.Minute * 10, time.Minute * 5, time.Minute * 2, time.Minute,
+	time.Second * 30, time.Second * 10, time.Second * 5, time.Second * 4, time.Second * 3, time.Second * 2, time.Second,
+}
+
+// DefaultGracePeriod is the default time after which a restarted server that was never seen going down is put
+// back in rotation once it is healthy.
+const DefaultGracePeriod = time.Minute * 2
+
+// Restart is a restart of a server that has been scheduled.
+type Restart struct {
+	// Server is the server that is restarted.
+	Server *server.Server
+	// Time is the time at which the players are moved off the server.
+	Time time.Time
+	// Fallback is the name of the server or group that players are moved to.
+	Fallback string
+
+	cancel chan struct{}
+	// down is true once the server has been seen unhealthy after its players were moved off it.
+	down bool
+	// evacuated is true once the players have been moved off the server.
+	evacuated bool
+	// graceElapsed is true once the grace period passed after the players were moved off the server.
+	graceElapsed bool
+}
+
+// Scheduler schedules restarts of servers. When a restart is due, the server is taken out of rotation and its
+// players are transferred to a fallback server. The server is put back in rotation once the health checker
+// passed has seen it go down and come back up. Servers that restart faster than the health checker notices are
+// put back in rotation once they are healthy after the grace period.
+type Scheduler struct {
+	sessions *session.Store
+	registry *server.Registry
+	log      internal.Logger
+
+	mu       sync.Mutex
+	restarts map[*server.Server]*Restart
+	grace    time.Duration
+}
+
+// NewScheduler creates a new Scheduler that moves the sessions in the store passed to servers in the registry
+// passed. The health checker is used to find out when a restarted server is back up.
+func NewScheduler(sessions *session.Store, registry *server.Registry, health *server.HealthChecker, log internal.Logger) *Scheduler {
+	s := &Scheduler{sessions: sessions, registry: registry, log: log, restarts: make(map[*server.Server]*Restart), grace: DefaultGracePeriod}
+	health.OnChange(s.healthChanged)
+	return s
+}
+
+// SetGracePeriod sets the time after the players were moved off a server after which the server is put back in
+// rotation once it is healthy, even if it was never seen going down. It defaults to DefaultGracePeriod.
+func (s *Scheduler) SetGracePeriod(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grace = d
+}
+
+// Schedule schedules a restart of the server passed after the countdown passed. The players on the server are
+// reminded of the restart regularly, and moved to the server or group with the name passed as fallback once the
+// countdown has passed. An error is returned if a restart is already scheduled for the server.
+func (s *Scheduler) Schedule(srv *server.Server, countdown time.Duration, fallback string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.restarts[srv]; ok {
+		return fmt.Errorf("a restart of %s is already scheduled", srv.Name())
+	}
+	r := &Restart{Server: srv, Time: time.Now().Add(countdown), Fallback: fallback, cancel: make(chan struct{})}
+	s.restarts[srv] = r
+	go s.run(r)
+
+	s.log.Infof("scheduled a restart of %s in %s", srv.Name(), countdown)
+	return nil
+}
+
+// Cancel cancels the scheduled restart of the server passed and puts it back in rotation. An error is returned
+// if no restart is scheduled for the server.
+func (s *Scheduler) Cancel(srv *server.Server) error {
+	s.mu.Lock()
+	r, ok := s.restarts[srv]
+	delete(s.restarts, srv)
+	s.mu.Unlock()
+	if !ok {
+		return errors.New("no restart is scheduled for the server")
+	}
+	close(r.cancel)
+	srv.SetInRotation(true)
+
+	s.log.Infof("cancelled the restart of %s", srv.Name())
+	return nil
+}
+
+// Restarts returns all restarts that are scheduled or in progress.
+func (s *Scheduler) Restarts() []Restart {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	restarts := make([]Restart, 0, len(s.restarts))
+	for _, r := range s.restarts {
+		restarts = append(restarts, *r)
+	}
+	return restarts
+}
+
+// run announces the restart passed to the players of its server until it is due, and then moves the players off
+// the server.
+func (s *Scheduler) run(r *Restart) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	remaining := time.Until(r.Time)
+	next := 0
+	for next < len(announcements) && remaining <= announcements[next] {
+		next++
+	}
+	s.announce(r, remaining.Round(time.Second))
+	for {
+		select {
+		case <-t.C:
+		case <-r.cancel:
+			return
+		}
+		remaining = time.Until(r.Time)
+		if remaining <= 0 {
+			break
+		}
+		crossed := false
+		for next < len(announcements) && remaining <= announcements[next] {
+			next++
+			crossed = true
+		}
+		if crossed {
+			s.announce(r, remaining.Round(time.Second))
+		}
+	}
+	s.evacuate(r)
+}
+
+// announce reminds the players on the server of the restart passed of the time remaining.
+func (s *Scheduler) announce(r *Restart, remaining time.Duration) {
+	for _, ses := range s.sessions.All() {
+		if srv, ok := ses.TryServer(); ok && srv == r.Server {
+			ses.SendMessage(text.Colourf("<yellow>This server restarts in %s.</yellow>", remaining))
+			if remaining <= time.Second*10 {
+				ses.SendTitle(text.Colourf("<red>Restarting</red>"), text.Colourf("<yellow>in %s</yellow>", remaining))
+			}
+		}
+	}
+}
+
+// evacuate takes the server of the restart passed out of rotation and moves all players on it to the fallback of
+// the restart.
+func (s *Scheduler) evacuate(r *Restart) {
+	r.Server.SetInRotation(false)
+	for _, ses := range s.sessions.All() {
+		if srv, ok := ses.TryServer(); !ok || srv != r.Server {
+			continue
+		}
+		fallback, ok := s.registry.Find(r.Fallback)
+		if !ok || fallback == r.Server {
+			s.log.Errorf("no fallback server found in %s for %s", r.Fallback, ses.IdentityData().DisplayName)
+			continue
+		}
+		if err := ses.Transfer(fallback); err != nil {
+			s.log.Errorf("failed to move %s off %s for its restart: %v", ses.IdentityData().DisplayName, r.Server.Name(), err)
+		}
+	}
+
+	s.mu.Lock()
+	r.evacuated = true
+	grace := s.grace
+	s.mu.Unlock()
+	s.log.Infof("moved all players off %s, waiting for it to restart", r.Server.Name())
+
+	// The server may restart between two health checks, in which case it is never seen going down.
+	time.AfterFunc(grace, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.restarts[r.Server] != r {
+			return
+		}
+		r.graceElapsed = true
+		if r.Server.Healthy() {
+			s.finish(r, fmt.Sprintf("was not seen going down within %s, but is healthy", grace))
+		}
+	})
+}
+
+// healthChanged puts servers that were restarted back in rotation once they are healthy again.
+func (s *Scheduler) healthChanged(srv *server.Server, healthy bool, _ error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.restarts[srv]
+	if !ok || !r.evacuated {
+		return
+	}
+	if !healthy {
+		r.down = true
+		return
+	}
+	if r.down {
+		s.finish(r, "is healthy again after restarting")
+	} else if r.graceElapsed {
+		s.finish(r, "is healthy after the grace period")
+	}
+}
+
+// finish removes the restart passed and puts its server back in rotation, logging the reason passed. The mutex
+// of the Scheduler must be held.
+func (s *Scheduler) finish(r *Restart, reason string) {
+	delete(s.restarts, r.Server)
+	r.Server.SetInRotation(true)
+	s.log.Infof("%s %s and was put back in rotation", r.Server.Name(), reason)
+}
@@ -0,0 +1,42 @@
+package server
+
+// Policy decides which packets are allowed through in a single direction, based on their IDs. The zero value
+// of Policy allows all packets.
+type Policy struct {
+	allow bool
+	ids   map[uint32]struct{}
+}
+
+// BlockList returns a Policy that blocks all packets with the IDs passed and allows all other packets.
+func BlockList(ids ...uint32) Policy {
+	return Policy{ids: idSet(ids)}
+}
+
+// AllowList returns a Policy that allows only the packets with the IDs passed and blocks all other packets.
+func AllowList(ids ...uint32) Policy {
+	return Policy{allow: true, ids: idSet(ids)}
+}
+
+// Allowed returns if a packet with the ID passed is allowed through by the policy.
+func (p Policy) Allowed(id uint32) bool {
+	_, ok := p.ids[id]
+	return ok == p.allow
+}
+
+// idSet returns a set of the IDs passed.
+func idSet(ids []uint32) map[uint32]struct{} {
+	m := make(map[uint32]struct{}, len(ids))
+	for _, id := range ids {
+		m[id] = struct{}{}
+	}
+	return m
+}
+
+// PacketFilter holds the policies for the packets sent between a server and the players connected to it through
+// the proxy. Packets that are not allowed by a policy are dropped by the proxy.
+type PacketFilter struct {
+	// ClientBound is the policy for packets sent by the server to players.
+	ClientBound Policy
+	// ServerBound is the policy for packets sent by players to the server.
+	ServerBound Policy
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sandertv/go-raknet"
+)
+
+// Ping attempts to reach the server passed over its network within the timeout passed, and returns an error if
+// the server could not be reached. Servers on networks other than RakNet and TCP are assumed to be reachable.
+func Ping(srv *Server, timeout time.Duration) error {
+	_, err := PingRTT(srv, timeout)
+	return err
+}
+
+// PingRTT pings the server passed like Ping, and returns the round trip time of the ping. For servers on
+// networks other than RakNet and TCP, the round trip time returned is zero.
+func PingRTT(srv *Server, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
+	switch srv.Network() {
+	case "raknet", "raknet_pooled":
+		if _, err := raknet.PingTimeout(srv.Address(), timeout); err != nil {
+			return 0, err
+		}
+		return time.Since(start), nil
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", srv.Address(), timeout)
+		if err != nil {
+			return 0, err
+		}
+		rtt := time.Since(start)
+		return rtt, conn.Close()
+	}
+	return 0, nil
+}
+
+// HealthChecker periodically pings all the servers in a registry to check if they are healthy, recording the
+// round trip time of the pings as the latency of the servers. Functions may be registered to be notified when
+// the health or the packs of a server change.
+type HealthChecker struct {
+	registry *Registry
+	timeout  time.Duration
+
+	mu            sync.Mutex
+	onChange      []func(srv *Server, healthy bool, err error)
+	onPacksChange []func(srv *Server, previous, current []Pack)
+}
+
+// NewHealthChecker creates a new HealthChecker for the servers in the registry passed. Servers that do not
+// respond within the timeout passed are marked as unhealthy.
+func NewHealthChecker(registry *Registry, timeout time.Duration) *HealthChecker {
+	return &HealthChecker{registry: registry, timeout: timeout}
+}
+
+// OnChange registers a function that is called every time the health of a server changes. The error passed is
+// the error that caused the server to be marked as unhealthy, or nil if it became healthy.
+func (h *HealthChecker) OnChange(f func(srv *Server, healthy bool, err error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onChange = append(h.onChange, f)
+}
+
+// OnPacksChange registers a function that is called when the packs a server sends to players changed since the
+// last check, so that servers of a network drifting apart in the packs they require are noticed.
+func (h *HealthChecker) OnPacksChange(f func(srv *Server, previous, current []Pack)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onPacksChange = append(h.onPacksChange, f)
+}
+
+// Run checks the health of all servers every interval. It blocks forever, so it should generally be called in
+// a separate goroutine.
+func (h *HealthChecker) Run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		h.Check()
+	}
+}
+
+// Check checks the health of all servers in the registry once, and blocks until all servers have been checked.
+func (h *HealthChecker) Check() {
+	var wg sync.WaitGroup
+	for _, srv := range h.registry.Servers() {
+		wg.Add(1)
+		go func(srv *Server) {
+			defer wg.Done()
+			h.checkPacks(srv)
+			rtt, err := PingRTT(srv, h.timeout)
+			if err == nil && rtt > 0 {
+				srv.recordLatency(rtt)
+			}
+			if srv.healthy.Swap(err == nil) == (err == nil) {
+				return
+			}
+			h.mu.Lock()
+			onChange := make([]func(*Server, bool, error), len(h.onChange))
+			copy(onChange, h.onChange)
+			h.mu.Unlock()
+			for _, f := range onChange {
+				f(srv, err == nil, err)
+			}
+		}(srv)
+	}
+	wg.Wait()
+}
+
+// checkPacks notifies the functions registered with OnPacksChange if the packs of the server passed changed.
+func (h *HealthChecker) checkPacks(srv *Server) {
+	previous, current, ok := srv.takePacksChange()
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	onPacksChange := make([]func(*Server, []Pack, []Pack), len(h.onPacksChange))
+	copy(onPacksChange, h.onPacksChange)
+	h.mu.Unlock()
+	for _, f := range onPacksChange {
+		f(srv, previous, current)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+)
+
+// Pack is a resource or behaviour pack that a server sends to players when they join it.
+type Pack struct {
+	// UUID and Version identify the pack.
+	UUID    string
+	Version string
+	// Name is the name of the pack from its manifest.
+	Name string
+	// Size is the size of the pack in bytes.
+	Size int
+	// Behaviours is true if the pack is a behaviour pack rather than a texture pack.
+	Behaviours bool
+}
+
+// String returns the name, UUID and version of the pack.
+func (p Pack) String() string {
+	return fmt.Sprintf("%s (%s v%s)", p.Name, p.UUID, p.Version)
+}
+
+// PacksOf returns the Packs of the resource packs passed, such as those returned by minecraft.Conn.ResourcePacks
+// after connecting to a server.
+func PacksOf(packs []*resource.Pack) []Pack {
+	p := make([]Pack, 0, len(packs))
+	for _, pack := range packs {
+		p = append(p, Pack{
+			UUID:       pack.UUID(),
+			Version:    pack.Version(),
+			Name:       pack.Name(),
+			Size:       pack.Len(),
+			Behaviours: pack.HasBehaviours(),
+		})
+	}
+	return p
+}
+
+// Packs returns the packs the server sent the last time a player connected to it, and if a player has connected
+// to it at all.
+func (s *Server) Packs() ([]Pack, bool) {
+	s.packsMu.RLock()
+	defer s.packsMu.RUnlock()
+	return append([]Pack(nil), s.packs...), s.packsKnown
+}
+
+// SetPacks sets the packs the server sent to a player that connected to it. If the server sent different packs
+// to a player before, the change is reported to the functions registered with HealthChecker.OnPacksChange the
+// next time the health of the server is checked.
+func (s *Server) SetPacks(packs []Pack) {
+	s.packsMu.Lock()
+	defer s.packsMu.Unlock()
+	if s.packsKnown && !samePacks(s.packs, packs) && !s.packsChanged {
+		s.packsChanged, s.previousPacks = true, s.packs
+	}
+	s.packs, s.packsKnown = append([]Pack(nil), packs...), true
+	if s.packsChanged && samePacks(s.previousPacks, s.packs) {
+		// The packs were changed back before the change was reported, so there is nothing to report.
+		s.packsChanged, s.previousPacks = false, nil
+	}
+}
+
+// takePacksChange returns the packs the server sent before and after its packs changed, if they changed since
+// the last call.
+func (s *Server) takePacksChange() (previous, current []Pack, changed bool) {
+	s.packsMu.Lock()
+	defer s.packsMu.Unlock()
+	if !s.packsChanged {
+		return nil, nil, false
+	}
+	previous, current = s.previousPacks, append([]Pack(nil), s.packs...)
+	s.packsChanged, s.previousPacks = false, nil
+	return previous, current, true
+}
+
+// DiffPacks returns the packs that are in current but not in previous, and the packs that are in previous but not
+// in current. A pack of which the version changed is both added and removed.
+func DiffPacks(previous, current []Pack) (added, removed []Pack) {
+	prev := make(map[Pack]struct{}, len(previous))
+	for _, p := range previous {
+		prev[p] = struct{}{}
+	}
+	cur := make(map[Pack]struct{}, len(current))
+	for _, p := range current {
+		cur[p] = struct{}{}
+		if _, ok := prev[p]; !ok {
+			added = append(added, p)
+		}
+	}
+	for _, p := range previous {
+		if _, ok := cur[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	return added, removed
+}
+
+// samePacks checks if the packs passed are the same, ignoring their order.
+func samePacks(a, b []Pack) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := make(map[Pack]int, len(a))
+	for _, p := range a {
+		m[p]++
+	}
+	for _, p := range b {
+		if m[p] == 0 {
+			return false
+		}
+		m[p]--
+	}
+	return true
+}
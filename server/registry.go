@@ -1,6 +1,7 @@
 package server
 
 import (
+	"sort"
 	"strings"
 	"sync"
 )
@@ -25,6 +26,76 @@ func (r *Registry) Server(name string) (*Server, bool) {
 	return srv, ok
 }
 
+// Group returns all the servers that are part of the group with the name passed. The name is matched without
+// regard to case.
+func (r *Registry) Group(name string) (all []*Server) {
+	for _, srv := range r.Servers() {
+		if strings.EqualFold(srv.Group(), name) {
+			all = append(all, srv)
+		}
+	}
+	return
+}
+
+// Find attempts to find a server from its name. If no server has the name passed, it is treated as the name of
+// a group, and the healthy server with the fewest players in that group is returned instead, respecting the
+// capacity of the servers. Names are matched without regard to case.
+func (r *Registry) Find(name string) (*Server, bool) {
+	if srv, ok := r.Server(name); ok {
+		return srv, true
+	}
+	found := Least(r.Group(name), func(srv *Server) bool {
+		return srv.Healthy()
+	})
+	return found, found != nil
+}
+
+// Least returns the server with the fewest players out of the servers passed for which the filter returns true,
+// respecting the capacity of the servers. Servers that are full or out of rotation are never returned, and servers
+// below their soft cap are preferred over servers that have reached it. If no server is found, nil is returned.
+func Least(servers []*Server, filter func(srv *Server) bool) *Server {
+	var found *Server
+	for _, srv := range servers {
+		if srv.Full() || !srv.InRotation() || !filter(srv) {
+			continue
+		}
+		if found == nil {
+			found = srv
+			continue
+		}
+		if above, foundAbove := srv.AboveSoftCap(), found.AboveSoftCap(); above != foundAbove {
+			if !above {
+				found = srv
+			}
+			continue
+		}
+		if srv.PlayerCount() < found.PlayerCount() {
+			found = srv
+		}
+	}
+	return found
+}
+
+// Rank returns the servers passed for which the filter returns true, ordered in the same way as Least prefers
+// them: servers below their soft cap first, and servers with fewer players before servers with more. Servers
+// that are full or out of rotation are left out.
+func Rank(servers []*Server, filter func(srv *Server) bool) []*Server {
+	ranked := make([]*Server, 0, len(servers))
+	for _, srv := range servers {
+		if srv.Full() || !srv.InRotation() || !filter(srv) {
+			continue
+		}
+		ranked = append(ranked, srv)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if above, otherAbove := ranked[i].AboveSoftCap(), ranked[j].AboveSoftCap(); above != otherAbove {
+			return !above
+		}
+		return ranked[i].PlayerCount() < ranked[j].PlayerCount()
+	})
+	return ranked
+}
+
 // Servers returns a slice of all the available servers on the proxy.
 func (r *Registry) Servers() (all []*Server) {
 	r.mu.Lock()
@@ -1,23 +1,61 @@
 package server
 
 import (
+	"sync"
+	"time"
+
 	"go.uber.org/atomic"
 )
 
 // Server represents a server connected to the proxy which players can join and play on.
 type Server struct {
 	name    string
+	network string
 	address string
 
+	group atomic.String
+
 	playerCount atomic.Int64
+	softCap     atomic.Int64
+	maxPlayers  atomic.Int64
+	healthy     atomic.Bool
+	// latency is the smoothed round trip time of the health checks of the server in nanoseconds.
+	latency atomic.Int64
+	// outOfRotation is true if load balancers should not send new players to the server.
+	outOfRotation atomic.Bool
+
+	filterMu sync.RWMutex
+	filter   PacketFilter
+
+	metadataMu sync.RWMutex
+	metadata   map[string]string
+
+	packsMu    sync.RWMutex
+	packs      []Pack
+	packsKnown bool
+	// packsChanged is true if the packs of the server changed from previousPacks since the last health check.
+	packsChanged  bool
+	previousPacks []Pack
 }
 
-// New creates a new Server with the provided name, group and address.
+// New creates a new Server with the provided name and address. The server is connected to over RakNet.
 func New(name, address string) *Server {
+	return NewWithNetwork(name, "raknet", address)
+}
+
+// NewWithNetwork creates a new Server with the provided name and address, which is connected to using the
+// network passed, such as "raknet" or "tcp". The network must be registered with gophertunnel using
+// minecraft.RegisterNetwork.
+func NewWithNetwork(name, network, address string) *Server {
+	if network == "" {
+		network = "raknet"
+	}
 	s := &Server{
 		name:    name,
+		network: network,
 		address: address,
 	}
+	s.healthy.Store(true)
 
 	return s
 }
@@ -27,12 +65,29 @@ func (s *Server) Name() string {
 	return s.name
 }
 
+// Network returns the name of the network used to connect to the server, such as "raknet".
+func (s *Server) Network() string {
+	return s.network
+}
+
 // Address returns the IP address the server was registered with. This should also contain the port separated
 // by a colon. E.g. "127.0.0.1:19132".
 func (s *Server) Address() string {
 	return s.address
 }
 
+// Group returns the name of the group the server is part of, such as "lobby". If the server is not part of a
+// group, an empty string is returned.
+func (s *Server) Group() string {
+	return s.group.Load()
+}
+
+// SetGroup sets the name of the group the server is part of. Servers in the same group are interchangeable, so
+// players may be sent to any of them by the name of the group.
+func (s *Server) SetGroup(group string) {
+	s.group.Store(group)
+}
+
 // IncrementPlayerCount increments the player count of the server.
 func (s *Server) IncrementPlayerCount() {
 	s.playerCount.Add(1)
@@ -48,3 +103,139 @@ func (s *Server) DecrementPlayerCount() {
 func (s *Server) PlayerCount() int {
 	return int(s.playerCount.Load())
 }
+
+// InRotation returns if load balancers may send players to the server. Servers are in rotation unless taken out
+// of it using SetInRotation, for example while they are restarting.
+func (s *Server) InRotation() bool {
+	return !s.outOfRotation.Load()
+}
+
+// SetInRotation sets if load balancers may send players to the server. Players that are already on the server
+// are not affected.
+func (s *Server) SetInRotation(v bool) {
+	s.outOfRotation.Store(!v)
+}
+
+// Capacity returns the soft cap and maximum player count of the server. Zero means that there is no limit.
+func (s *Server) Capacity() (softCap, maxPlayers int) {
+	return int(s.softCap.Load()), int(s.maxPlayers.Load())
+}
+
+// SetCapacity sets the soft cap and maximum player count of the server. Load balancers prefer servers below
+// their soft cap and never send players to servers that have reached their maximum player count. Zero means
+// that there is no limit.
+func (s *Server) SetCapacity(softCap, maxPlayers int) {
+	s.softCap.Store(int64(softCap))
+	s.maxPlayers.Store(int64(maxPlayers))
+}
+
+// Full returns if the server has reached its maximum player count.
+func (s *Server) Full() bool {
+	_, maxPlayers := s.Capacity()
+	return maxPlayers > 0 && s.PlayerCount() >= maxPlayers
+}
+
+// AboveSoftCap returns if the server has reached its soft cap.
+func (s *Server) AboveSoftCap() bool {
+	softCap, _ := s.Capacity()
+	return softCap > 0 && s.PlayerCount() >= softCap
+}
+
+// Healthy returns if the server responded to the last health check performed by a HealthChecker. Servers are
+// considered healthy until a health check fails.
+func (s *Server) Healthy() bool {
+	return s.healthy.Load()
+}
+
+// Latency returns the round trip time of the pings of health checks to the server, smoothed over the last
+// health checks. Zero is returned if the latency of the server has not been measured.
+func (s *Server) Latency() time.Duration {
+	return time.Duration(s.latency.Load())
+}
+
+// recordLatency records the round trip time of a health check to the server. The latency is smoothed using an
+// exponentially weighted moving average, so that a single slow ping does not move players away.
+func (s *Server) recordLatency(rtt time.Duration) {
+	for {
+		old := s.latency.Load()
+		updated := int64(rtt)
+		if old != 0 {
+			updated = old + (int64(rtt)-old)/4
+		}
+		if s.latency.CAS(old, updated) {
+			return
+		}
+	}
+}
+
+// Filter returns the packet filter of the server, which decides which packets sent between the server and its
+// players are dropped by the proxy.
+func (s *Server) Filter() PacketFilter {
+	s.filterMu.RLock()
+	defer s.filterMu.RUnlock()
+	return s.filter
+}
+
+// SetFilter sets the packet filter of the server. The filter applies to all players connected to the server,
+// including those already connected.
+func (s *Server) SetFilter(f PacketFilter) {
+	s.filterMu.Lock()
+	defer s.filterMu.Unlock()
+	s.filter = f
+}
+
+// Metadata returns the value of the metadata of the server with the key passed, such as "region", and if the
+// server has metadata with that key.
+func (s *Server) Metadata(key string) (string, bool) {
+	s.metadataMu.RLock()
+	defer s.metadataMu.RUnlock()
+	v, ok := s.metadata[key]
+	return v, ok
+}
+
+// AllMetadata returns a copy of all metadata of the server.
+func (s *Server) AllMetadata() map[string]string {
+	s.metadataMu.RLock()
+	defer s.metadataMu.RUnlock()
+	m := make(map[string]string, len(s.metadata))
+	for k, v := range s.metadata {
+		m[k] = v
+	}
+	return m
+}
+
+// SetMetadata sets the metadata of the server with the key passed to the value passed. Metadata holds arbitrary
+// attributes of the server, such as "region=eu" or "tier=premium", which load balancers and handlers may use
+// to decide where to send players.
+func (s *Server) SetMetadata(key, value string) {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	if s.metadata == nil {
+		s.metadata = make(map[string]string)
+	}
+	s.metadata[key] = value
+}
+
+// ReplaceMetadata replaces all metadata of the server with the metadata passed.
+func (s *Server) ReplaceMetadata(m map[string]string) {
+	metadata := make(map[string]string, len(m))
+	for k, v := range m {
+		metadata[k] = v
+	}
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	s.metadata = metadata
+}
+
+// MatchMetadata returns if the server has all the metadata passed with the same values. It always returns true if
+// no metadata is passed.
+func (s *Server) MatchMetadata(match map[string]string) bool {
+	s.metadataMu.RLock()
+	defer s.metadataMu.RUnlock()
+	for k, v := range match {
+		if actual, ok := s.metadata[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package session
+
+import (
+	"sync"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// asyncQueueSize is the number of events an asynchronous handler may lag behind before events are dropped.
+const asyncQueueSize = 256
+
+// asyncHandler is a Handler that calls the methods of the handler it wraps on a goroutine of its own.
+type asyncHandler struct {
+	s      *Session
+	h      Handler
+	events chan func()
+
+	mu     sync.Mutex
+	closed bool
+	// quit is the call to HandleQuit of the handler, which is made once all events queued before it were
+	// handled. It is kept apart from the events, so that it is never dropped and queueing it never blocks.
+	quit func()
+}
+
+// Async returns a Handler that calls the methods of the handler passed on a goroutine of its own, in the order
+// the events happen, so that a slow handler, such as one writing to a database, does not stall the packets of
+// the session. Because the events have already happened by the time the handler is called, cancelling them or
+// storing values in their context has no effect, and packets passed must not be modified. If the handler lags
+// behind too far, events are dropped, except for HandleQuit, after which the goroutine stops.
+func Async(s *Session, h Handler) Handler {
+	a := &asyncHandler{s: s, h: h, events: make(chan func(), asyncQueueSize)}
+	go a.run()
+	return a
+}
+
+// run calls the events queued until HandleQuit is queued, after which it calls HandleQuit.
+func (a *asyncHandler) run() {
+	for f := range a.events {
+		a.call(f)
+	}
+	a.mu.Lock()
+	quit := a.quit
+	a.mu.Unlock()
+	a.call(quit)
+}
+
+// call calls the function passed, recovering from any panic in it.
+func (a *asyncHandler) call(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			recovered(a.s, "asynchronous handler", r)
+		}
+	}()
+	f()
+}
+
+// queue queues the function passed, dropping it if the queue is full or if HandleQuit was already queued.
+func (a *asyncHandler) queue(f func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.events <- f:
+	default:
+		a.s.log.Debugf("dropped event of asynchronous handler of %s, as it is lagging behind", a.s.identity.DisplayName)
+	}
+}
+
+// HandleClientBoundPacket ...
+func (a *asyncHandler) HandleClientBoundPacket(_ *event.Context, pk packet.Packet) {
+	a.queue(func() { a.h.HandleClientBoundPacket(event.C(), pk) })
+}
+
+// HandleServerBoundPacket ...
+func (a *asyncHandler) HandleServerBoundPacket(_ *event.Context, pk packet.Packet) {
+	a.queue(func() { a.h.HandleServerBoundPacket(event.C(), pk) })
+}
+
+// HandleServerConnect ...
+func (a *asyncHandler) HandleServerConnect(srv *server.Server, conn *minecraft.Conn) {
+	a.queue(func() { a.h.HandleServerConnect(srv, conn) })
+}
+
+// HandleServerDisconnect ...
+func (a *asyncHandler) HandleServerDisconnect(_ *event.Context, err error) {
+	a.queue(func() { a.h.HandleServerDisconnect(event.C(), err) })
+}
+
+// HandleServerKick ...
+func (a *asyncHandler) HandleServerKick(_ *event.Context, srv *server.Server, message string) {
+	a.queue(func() { a.h.HandleServerKick(event.C(), srv, message) })
+}
+
+// HandleClientDisconnect ...
+func (a *asyncHandler) HandleClientDisconnect(_ *event.Context, err error) {
+	a.queue(func() { a.h.HandleClientDisconnect(event.C(), err) })
+}
+
+// HandleTransfer ...
+func (a *asyncHandler) HandleTransfer(_ *event.Context, srv *server.Server) {
+	a.queue(func() { a.h.HandleTransfer(event.C(), srv) })
+}
+
+// HandleServerSelect ...
+func (a *asyncHandler) HandleServerSelect(_ *event.Context, candidates []Candidate) {
+	a.queue(func() { a.h.HandleServerSelect(event.C(), candidates) })
+}
+
+// HandleRoute ...
+func (a *asyncHandler) HandleRoute(_ *event.Context, group string) {
+	a.queue(func() { a.h.HandleRoute(event.C(), group) })
+}
+
+// HandleTransferFailure ...
+func (a *asyncHandler) HandleTransferFailure(srv *server.Server, err error) {
+	a.queue(func() { a.h.HandleTransferFailure(srv, err) })
+}
+
+// HandleChangeConn ...
+func (a *asyncHandler) HandleChangeConn(conn *minecraft.Conn) {
+	a.queue(func() { a.h.HandleChangeConn(conn) })
+}
+
+// HandleQuit ...
+func (a *asyncHandler) HandleQuit(reason CloseReason) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	a.closed = true
+	a.quit = func() { a.h.HandleQuit(reason) }
+	close(a.events)
+}
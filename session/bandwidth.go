@@ -0,0 +1,21 @@
+package session
+
+import (
+	"github.com/paroxity/portal/transport"
+)
+
+// SetBandwidthCounter sets the counter holding the number of bytes sent over the connection of the session. It
+// is set by the proxy if the network the session connected over is wrapped by a transport.Meter.
+func (s *Session) SetBandwidthCounter(c *transport.Counter) {
+	s.bandwidth.Store(c)
+}
+
+// Bandwidth returns the number of bytes received from and sent to the client of the session, after compression
+// and encryption. If the bandwidth of the session is not metered, zero is returned for both.
+func (s *Session) Bandwidth() (in, out uint64) {
+	c, ok := s.bandwidth.Load().(*transport.Counter)
+	if !ok {
+		return 0, 0
+	}
+	return c.In(), c.Out()
+}
@@ -0,0 +1,53 @@
+package session
+
+// maxBlobHashes is the maximum number of cache blob hashes remembered for a session. Clients report the status
+// of blobs right after receiving them, so only the most recent hashes need to be remembered.
+const maxBlobHashes = 1 << 16
+
+// blobHashes holds the hashes of the most recent cache blobs sent by the server of a session. Once it holds
+// maxBlobHashes hashes, the oldest hash is forgotten for every new hash added. It is not safe for concurrent use.
+type blobHashes struct {
+	hashes map[uint64]struct{}
+	// order holds the hashes in the order they were added, and next is the index in order of the oldest hash
+	// once order is full.
+	order []uint64
+	next  int
+}
+
+// newBlobHashes returns a new, empty blobHashes.
+func newBlobHashes() *blobHashes {
+	return &blobHashes{hashes: make(map[uint64]struct{})}
+}
+
+// add adds the hashes passed, forgetting the oldest hashes if needed.
+func (b *blobHashes) add(hashes ...uint64) {
+	for _, hash := range hashes {
+		if _, ok := b.hashes[hash]; ok {
+			continue
+		}
+		b.hashes[hash] = struct{}{}
+		if len(b.order) < maxBlobHashes {
+			b.order = append(b.order, hash)
+			continue
+		}
+		delete(b.hashes, b.order[b.next])
+		b.order[b.next] = hash
+		b.next = (b.next + 1) % maxBlobHashes
+	}
+}
+
+// has returns if the hash passed was added and has not been forgotten yet.
+func (b *blobHashes) has(hash uint64) bool {
+	_, ok := b.hashes[hash]
+	return ok
+}
+
+// clear forgets all hashes.
+func (b *blobHashes) clear() {
+	b.hashes, b.order, b.next = make(map[uint64]struct{}), nil, 0
+}
+
+// size returns the number of hashes remembered.
+func (b *blobHashes) size() int {
+	return len(b.hashes)
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"github.com/paroxity/portal/event"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// DefaultBufferedPackets holds the IDs of the packets buffered during a transfer if no IDs are passed to
+// SetTransferBuffer. Packets that refer to the state of the server the player is leaving, such as inventory
+// transactions and form responses, are not buffered by default, as they would be misinterpreted by the new
+// server.
+var DefaultBufferedPackets = []uint32{packet.IDText, packet.IDCommandRequest}
+
+// SetTransferBuffer sets the maximum number of packets sent by the client during a transfer that are buffered
+// and sent to the server once the transfer is done, instead of being dropped. If the transfer fails, the
+// packets are sent to the server the session is still on. Only packets with the IDs passed are buffered, or
+// those in DefaultBufferedPackets if none are passed. Entity runtime IDs in buffered packets are translated for
+// the server the session was on when they were sent. If size is zero, which is the default, no packets are
+// buffered.
+func (s *Session) SetTransferBuffer(size int, ids ...uint32) {
+	if len(ids) == 0 {
+		ids = DefaultBufferedPackets
+	}
+	set := make(map[uint32]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	s.bufferMu.Lock()
+	defer s.bufferMu.Unlock()
+	s.bufferSize, s.bufferIDs = size, set
+}
+
+// bufferPacket buffers a packet sent by the client during a transfer. It returns false if the session is not
+// transferring, in which case the packet should be handled as usual. Packets that are not buffered are dropped.
+func (s *Session) bufferPacket(pk packet.Packet) bool {
+	s.bufferMu.Lock()
+	defer s.bufferMu.Unlock()
+	if !s.Transferring() {
+		return false
+	}
+	if _, ok := s.bufferIDs[pk.ID()]; ok && len(s.buffered) < s.bufferSize {
+		s.buffered = append(s.buffered, pk)
+	}
+	return true
+}
+
+// flushBuffer sends the packets buffered during the transfer that just ended to the current server of the
+// session, passing them through the handlers of the session as if they were just sent.
+func (s *Session) flushBuffer() {
+	s.bufferMu.Lock()
+	buffered := s.buffered
+	s.buffered = nil
+	s.bufferMu.Unlock()
+	if len(buffered) == 0 {
+		return
+	}
+
+	filter := s.Server().Filter()
+	for _, pk := range buffered {
+		if !filter.ServerBound.Allowed(pk.ID()) {
+			continue
+		}
+		ctx := event.C()
+		s.handler().HandleServerBoundPacket(ctx, pk)
+		ctx.Continue(func() {
+			s.writeServerPacket(pk)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/paroxity/portal/server"
+)
+
+// Canary is a server in a group that runs a new build of the group, which only a part of the players sent to
+// the group are sent to, so that the build can be tested safely before it is rolled out to every server.
+type Canary struct {
+	// Server is the name of the canary server. It must be part of the group of the canary.
+	Server string
+	// Percentage is the percentage of players, between 0 and 100, sent to the group that are sent to the canary
+	// server. Players are assigned to the canary deterministically, so that a player keeps being sent to the
+	// same build when they rejoin.
+	Percentage float64
+	// XUIDs holds the XUIDs of players that are always sent to the canary server, such as those of testers.
+	XUIDs []string
+}
+
+// includes returns if the session passed should be sent to the canary server.
+func (c Canary) includes(s *Session) bool {
+	xuid := s.IdentityData().XUID
+	for _, x := range c.XUIDs {
+		if xuid != "" && x == xuid {
+			return true
+		}
+	}
+	id := s.UUID()
+	h := fnv.New32a()
+	_, _ = h.Write(id[:])
+	return float64(h.Sum32()%10000) < c.Percentage*100
+}
+
+// CanaryLoadBalancer is a load balancer that sends a part of the players sent to a group to the canary server of
+// that group, and keeps all other players off the canary server. Groups without a canary are balanced by the
+// wrapped load balancer as usual. The canaries of groups may be changed while the proxy is running.
+type CanaryLoadBalancer struct {
+	lb       LoadBalancer
+	registry *server.Registry
+
+	mu       sync.RWMutex
+	canaries map[string]Canary
+}
+
+// NewCanaryLoadBalancer creates a CanaryLoadBalancer wrapping around the load balancer passed, which finds the
+// canary servers in the registry passed.
+func NewCanaryLoadBalancer(lb LoadBalancer, registry *server.Registry) *CanaryLoadBalancer {
+	return &CanaryLoadBalancer{lb: lb, registry: registry, canaries: make(map[string]Canary)}
+}
+
+// SetCanary sets the canary of the group passed, replacing the previous canary of the group if it had one. The
+// group is matched without case sensitivity.
+func (b *CanaryLoadBalancer) SetCanary(group string, c Canary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.canaries[strings.ToLower(group)] = c
+}
+
+// RemoveCanary removes the canary of the group passed, after which players are sent to every server of the
+// group again.
+func (b *CanaryLoadBalancer) RemoveCanary(group string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.canaries, strings.ToLower(group))
+}
+
+// Canary returns the canary of the group passed, if it has one.
+func (b *CanaryLoadBalancer) Canary(group string) (Canary, bool) {
+	if group == "" {
+		return Canary{}, false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	c, ok := b.canaries[strings.ToLower(group)]
+	return c, ok
+}
+
+// Canaries returns the canaries of all groups that have one, by the lower case name of their group.
+func (b *CanaryLoadBalancer) Canaries() map[string]Canary {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	canaries := make(map[string]Canary, len(b.canaries))
+	for group, c := range b.canaries {
+		canaries[group] = c
+	}
+	return canaries
+}
+
+// FindServer ...
+func (b *CanaryLoadBalancer) FindServer(s *Session) *server.Server {
+	return b.FindServerExcluding(s)
+}
+
+// FindServerExcluding ...
+func (b *CanaryLoadBalancer) FindServerExcluding(s *Session, exclude ...*server.Server) *server.Server {
+	if candidates := b.Rank(s, exclude...); len(candidates) > 0 {
+		return candidates[0].Server
+	}
+	return nil
+}
+
+// Rank ...
+func (b *CanaryLoadBalancer) Rank(s *Session, exclude ...*server.Server) []Candidate {
+	var candidates []Candidate
+	for _, c := range rank(b.lb, s, exclude...) {
+		if canary, ok := b.Canary(c.Server.Group()); ok && strings.EqualFold(canary.Server, c.Server.Name()) && !canary.includes(s) {
+			// Players not assigned to the canary are never sent to it, even if it is the only server left.
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	canary, ok := b.Canary(candidates[0].Server.Group())
+	if !ok || !canary.includes(s) {
+		return candidates
+	}
+	srv, ok := b.registry.Server(canary.Server)
+	if !ok || srv == candidates[0].Server || !strings.EqualFold(srv.Group(), candidates[0].Server.Group()) {
+		return candidates
+	}
+	if !srv.Healthy() || !srv.InRotation() || srv.Full() || excluded(srv, exclude) {
+		return candidates
+	}
+	ranked := []Candidate{{Server: srv, Score: float64(srv.PlayerCount())}}
+	for _, c := range candidates {
+		if c.Server != srv {
+			ranked = append(ranked, c)
+		}
+	}
+	return ranked
+}
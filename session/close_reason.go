@@ -0,0 +1,43 @@
+package session
+
+// CloseReason is the reason a session was closed. The values of the reasons are stable, as they are sent to
+// servers in the PlayerQuit packet.
+type CloseReason int
+
+const (
+	// CloseKicked is the reason of sessions disconnected by their server or by the proxy, for example because
+	// the player logged in from another location. Sessions closed without a reason are closed with this reason.
+	CloseKicked CloseReason = iota
+	// CloseClientQuit is the reason of sessions whose client closed the connection, either by leaving or by
+	// losing their connection.
+	CloseClientQuit
+	// CloseServerLost is the reason of sessions whose server closed the connection unexpectedly, for example
+	// because it crashed, without the player being sent to a fallback server.
+	CloseServerLost
+	// CloseTransferFailed is the reason of sessions that could not be connected to any server.
+	CloseTransferFailed
+	// CloseProxyShutdown is the reason of sessions closed because the proxy shut down.
+	CloseProxyShutdown
+	// CloseReconnected is the reason of sessions of which the client was sent back to the proxy to join another
+	// server from scratch, using Session.TransferReconnect.
+	CloseReconnected
+)
+
+// String returns the name of the reason, such as "client_quit".
+func (r CloseReason) String() string {
+	switch r {
+	case CloseKicked:
+		return "kicked"
+	case CloseClientQuit:
+		return "client_quit"
+	case CloseServerLost:
+		return "server_lost"
+	case CloseTransferFailed:
+		return "transfer_failed"
+	case CloseProxyShutdown:
+		return "proxy_shutdown"
+	case CloseReconnected:
+		return "reconnected"
+	}
+	return "unknown"
+}
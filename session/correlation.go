@@ -0,0 +1,48 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/paroxity/portal/internal"
+)
+
+// CorrelationID returns the ID of the session that is included in every message the proxy logs about it and may
+// be sent to its servers, so that operators are able to find the logs of the same session across the proxy and
+// its servers. It is unique to the session, so a player joining again has a different ID.
+func (s *Session) CorrelationID() string {
+	return s.correlationID
+}
+
+// newCorrelationID returns a new random correlation ID of twelve hexadecimal characters.
+func newCorrelationID() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// correlatedLogger is an internal.Logger that prefixes every message with the correlation ID of a session.
+type correlatedLogger struct {
+	internal.Logger
+	id string
+}
+
+// Debugf ...
+func (l correlatedLogger) Debugf(format string, v ...interface{}) {
+	l.Logger.Debugf("["+l.id+"] "+format, v...)
+}
+
+// Infof ...
+func (l correlatedLogger) Infof(format string, v ...interface{}) {
+	l.Logger.Infof("["+l.id+"] "+format, v...)
+}
+
+// Errorf ...
+func (l correlatedLogger) Errorf(format string, v ...interface{}) {
+	l.Logger.Errorf("["+l.id+"] "+format, v...)
+}
+
+// Fatalf ...
+func (l correlatedLogger) Fatalf(format string, v ...interface{}) {
+	l.Logger.Fatalf("["+l.id+"] "+format, v...)
+}
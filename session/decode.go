@@ -0,0 +1,203 @@
+package session
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/paroxity/portal/report"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"go.uber.org/atomic"
+)
+
+// hexdumpBufferSize is the size of the buffers packets are read into in hexdump mode. It must be able to hold
+// the largest packet sent, as gophertunnel drops packets that do not fit in the buffer they are read into.
+const hexdumpBufferSize = 1024 * 1024 * 16
+
+// hexdumpBuffers holds the buffers packets are read into in hexdump mode. A reader takes a buffer when it starts
+// reading in hexdump mode and puts it back once it stops, so that buffers are reused by the readers of other
+// sessions instead of every reader allocating its own.
+var hexdumpBuffers = sync.Pool{New: func() any {
+	buf := make([]byte, hexdumpBufferSize)
+	return &buf
+}}
+
+var (
+	// decodeFailures is the number of packets that failed to decode in hexdump mode.
+	decodeFailures atomic.Uint64
+	// unknownPackets is the number of packets with an unknown ID that were read.
+	unknownPackets atomic.Uint64
+)
+
+// DecodeMetrics holds the number of packets read by sessions that could not be decoded, since the proxy started.
+type DecodeMetrics struct {
+	// Failed is the number of packets that failed to decode. Only sessions in hexdump mode count these, as
+	// packets that fail to decode are otherwise dropped before the session sees them.
+	Failed uint64
+	// Unknown is the number of packets with an ID unknown to the proxy.
+	Unknown uint64
+}
+
+// DecodeFailures returns the number of packets read by all sessions that could not be decoded.
+func DecodeFailures() DecodeMetrics {
+	return DecodeMetrics{Failed: decodeFailures.Load(), Unknown: unknownPackets.Load()}
+}
+
+// SetHexdump sets if the session is in hexdump mode. In hexdump mode, the session decodes packets itself, and
+// logs packets that fail to decode or have an unknown ID as a hexdump together with the player and server they
+// were sent between. Packets that fail to decode are forwarded as they are instead of being dropped, unless
+// the player is on a server that gave them different entity IDs than their client knows: the IDs in packets
+// forwarded as they are can not be translated, so these packets are dropped instead.
+// Every session in hexdump mode holds two buffers of 16 MiB while reading packets, so hexdump mode should only
+// be enabled while investigating packets that fail to decode.
+func (s *Session) SetHexdump(v bool) {
+	s.hexdump.Store(v)
+}
+
+// packetReader reads the packets of one direction of a session. It decodes the packets itself if the session
+// is in hexdump mode, and lets gophertunnel decode them otherwise.
+type packetReader struct {
+	s           *Session
+	clientBound bool
+
+	// buf is the buffer taken from hexdumpBuffers that packets are read into in hexdump mode.
+	buf *[]byte
+	// conn is the connection the shield ID was last found for.
+	conn     *minecraft.Conn
+	shieldID int32
+}
+
+// read reads the next packet from the connection passed. If the packet failed to decode in hexdump mode, it is
+// forwarded as it is and a nil packet is returned without an error.
+func (r *packetReader) read(conn *minecraft.Conn) (packet.Packet, error) {
+	if !r.s.hexdump.Load() {
+		r.release()
+		pk, err := conn.ReadPacket()
+		if _, ok := pk.(*packet.Unknown); ok {
+			unknownPackets.Inc()
+		}
+		return pk, err
+	}
+	if r.buf == nil {
+		r.buf = hexdumpBuffers.Get().(*[]byte)
+	}
+	n, err := conn.Read(*r.buf)
+	if err != nil {
+		if r.s.closed.Load() {
+			r.release()
+		}
+		return nil, err
+	}
+	// The data is copied out of the buffer, as packets may be forwarded or handled after the next packet is
+	// read into it.
+	data := append([]byte(nil), (*r.buf)[:n]...)
+
+	buf := bytes.NewBuffer(data)
+	header := &packet.Header{}
+	if err := header.Read(buf); err != nil {
+		decodeFailures.Inc()
+		r.dump(0, data, fmt.Errorf("read header: %w", err))
+		return nil, nil
+	}
+	pk, err := r.decode(conn, header.PacketID, buf)
+	if err != nil {
+		decodeFailures.Inc()
+		r.dump(header.PacketID, data, err)
+		r.s.report(report.KindDecode, nil, fmt.Errorf("decode packet %d: %w", header.PacketID, err), nil)
+		r.forward(header.PacketID, data)
+		return nil, nil
+	}
+	if _, ok := pk.(*packet.Unknown); ok {
+		unknownPackets.Inc()
+		r.dump(header.PacketID, data, fmt.Errorf("unknown packet id"))
+	}
+	return pk, nil
+}
+
+// release puts the buffer of the reader back in hexdumpBuffers, if it holds one.
+func (r *packetReader) release() {
+	if r.buf != nil {
+		hexdumpBuffers.Put(r.buf)
+		r.buf = nil
+	}
+}
+
+// record records a packet with the ID passed as read in the direction of the reader.
+func (r *packetReader) record(id uint32) {
+	if r.clientBound {
+		r.s.serverPackets.add(id, r.s.env.Clock.Now())
+		return
+	}
+	r.s.clientPackets.add(id, r.s.env.Clock.Now())
+}
+
+// decode decodes the payload of a packet with the ID passed read from the connection passed, in the same way
+// gophertunnel does.
+func (r *packetReader) decode(conn *minecraft.Conn, id uint32, payload *bytes.Buffer) (pk packet.Packet, err error) {
+	if r.conn != conn {
+		r.conn, r.shieldID = conn, shieldID(conn)
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%T: %v", pk, recovered)
+		}
+	}()
+	f, ok := minecraft.DefaultProtocol.Packets(!r.clientBound)[id]
+	if !ok {
+		pk = &packet.Unknown{PacketID: id}
+	} else {
+		pk = f()
+	}
+	pk.Marshal(minecraft.DefaultProtocol.NewReader(payload, r.shieldID, !r.clientBound))
+	if payload.Len() != 0 {
+		return nil, fmt.Errorf("%T: %v unread bytes left", pk, payload.Len())
+	}
+	return pk, nil
+}
+
+// dump logs the packet data passed as a hexdump, together with the error it failed to decode with.
+func (r *packetReader) dump(id uint32, data []byte, err error) {
+	direction, srv := "server bound", "none"
+	if r.clientBound {
+		direction = "client bound"
+	}
+	if s, ok := r.s.TryServer(); ok && s != nil {
+		srv = s.Name()
+	}
+	r.s.log.Errorf("failed to decode %s packet %d of %s on server %s: %v\n%s", direction, id, r.s.identity.DisplayName, srv, err, hex.Dump(data))
+}
+
+// forward writes the packet data passed to the other side of the session as it is. The packet is dropped if the
+// session translates entity IDs, as the IDs in the packet can not be translated without decoding it.
+func (r *packetReader) forward(id uint32, data []byte) {
+	if r.s.translates() {
+		r.s.log.Debugf("dropped packet %d of %s that failed to decode, as its entity IDs can not be translated", id, r.s.identity.DisplayName)
+		return
+	}
+	if r.clientBound {
+		if srv, ok := r.s.TryServer(); ok && srv != nil && !srv.Filter().ClientBound.Allowed(id) {
+			return
+		}
+		_, _ = r.s.Conn().Write(data)
+		return
+	}
+	if srv, ok := r.s.TryServer(); ok && srv != nil && !srv.Filter().ServerBound.Allowed(id) {
+		return
+	}
+	if conn, ok := r.s.TryServerConn(); ok && conn != nil {
+		_, _ = conn.Write(data)
+	}
+}
+
+// shieldID returns the runtime ID of the shield item of the connection passed, which is needed to decode
+// packets holding items.
+func shieldID(conn *minecraft.Conn) int32 {
+	for _, item := range conn.GameData().Items {
+		if item.Name == "minecraft:shield" {
+			return int32(item.RuntimeID)
+		}
+	}
+	return 0
+}
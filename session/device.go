@@ -0,0 +1,129 @@
+package session
+
+import (
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// deviceNames maps the names of devices used in rules to their operating system.
+var deviceNames = map[string]protocol.DeviceOS{
+	"android":   protocol.DeviceAndroid,
+	"ios":       protocol.DeviceIOS,
+	"osx":       protocol.DeviceOSX,
+	"fireos":    protocol.DeviceFireOS,
+	"gearvr":    protocol.DeviceGearVR,
+	"hololens":  protocol.DeviceHololens,
+	"win10":     protocol.DeviceWin10,
+	"win32":     protocol.DeviceWin32,
+	"dedicated": protocol.DeviceDedicated,
+	"tvos":      protocol.DeviceTVOS,
+	"ps4":       protocol.DeviceOrbis,
+	"switch":    protocol.DeviceNX,
+	"xbox":      protocol.DeviceXBOX,
+	"wp":        protocol.DeviceWP,
+	"linux":     protocol.DeviceLinux,
+}
+
+// inputModeNames maps the names of input modes used in rules to their value in the client data.
+var inputModeNames = map[string]int{
+	"mouse":             packet.InputModeMouse,
+	"touch":             packet.InputModeTouch,
+	"gamepad":           packet.InputModeGamePad,
+	"motion_controller": packet.InputModeMotionController,
+}
+
+// ParseDeviceOS parses the name of a device, such as "android", "ios", "win10", "xbox", "ps4" or "switch", and
+// returns the operating system of the device. False is returned if the name is unknown.
+func ParseDeviceOS(name string) (protocol.DeviceOS, bool) {
+	os, ok := deviceNames[strings.ToLower(name)]
+	return os, ok
+}
+
+// DeviceName returns the name of the operating system passed, as accepted by ParseDeviceOS, such as "android" or
+// "win10". "unknown" is returned if the operating system has no name.
+func DeviceName(os protocol.DeviceOS) string {
+	for name, d := range deviceNames {
+		if d == os {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// ParseInputMode parses the name of an input mode, which is either "mouse", "touch", "gamepad" or
+// "motion_controller". False is returned if the name is unknown.
+func ParseInputMode(name string) (int, bool) {
+	mode, ok := inputModeNames[strings.ToLower(name)]
+	return mode, ok
+}
+
+// ImpossibleDevice returns if the client data passed reports a device that no official client reports, such
+// as an unknown operating system or input mode, a dedicated server or a console with a touch screen. These
+// are generally reported by custom clients.
+func ImpossibleDevice(d login.ClientData) bool {
+	if d.DeviceOS < protocol.DeviceAndroid || d.DeviceOS > protocol.DeviceLinux || d.DeviceOS == protocol.DeviceDedicated {
+		return true
+	}
+	for _, mode := range []int{d.CurrentInputMode, d.DefaultInputMode} {
+		if mode < packet.InputModeMouse || mode > packet.InputModeMotionController {
+			return true
+		}
+		if mode == packet.InputModeTouch && (d.DeviceOS == protocol.DeviceXBOX || d.DeviceOS == protocol.DeviceOrbis) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceRestriction is a restriction that prevents players on specific devices from joining the proxy. A
+// player matches the restriction if they match every condition of it that is set, and a condition matches if
+// any of its values match.
+type DeviceRestriction struct {
+	// Devices holds the operating systems of the devices the restriction matches.
+	Devices []protocol.DeviceOS
+	// InputModes holds the current input modes the restriction matches, such as packet.InputModeTouch.
+	InputModes []int
+	// Message is the message shown to players matching the restriction on their disconnection screen.
+	Message string
+}
+
+// Matches returns if the client data passed matches the restriction.
+func (r DeviceRestriction) Matches(d login.ClientData) bool {
+	if len(r.Devices) == 0 && len(r.InputModes) == 0 {
+		return false
+	}
+	return matchDevice(r.Devices, r.InputModes, d)
+}
+
+// matchDevice returns if the client data passed reports one of the devices and one of the input modes passed.
+// Empty lists match any device or input mode.
+func matchDevice(devices []protocol.DeviceOS, inputModes []int, d login.ClientData) bool {
+	if len(devices) > 0 {
+		found := false
+		for _, os := range devices {
+			if os == d.DeviceOS {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(inputModes) > 0 {
+		found := false
+		for _, mode := range inputModes {
+			if mode == d.CurrentInputMode {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// recentPacketsSize is the number of most recent packets kept by a session for each direction.
+const recentPacketsSize = 8
+
+// PacketRecord is a packet read by a session.
+type PacketRecord struct {
+	// ID is the ID of the packet.
+	ID uint32
+	// Time is the time at which the packet was read.
+	Time time.Time
+}
+
+// recentPackets holds the most recent packets read in one direction of a session.
+type recentPackets struct {
+	mu      sync.Mutex
+	packets [recentPacketsSize]PacketRecord
+	next    int
+	count   int
+}
+
+// add records a packet with the ID passed read at the time passed.
+func (r *recentPackets) add(id uint32, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packets[r.next] = PacketRecord{ID: id, Time: t}
+	r.next = (r.next + 1) % recentPacketsSize
+	if r.count < recentPacketsSize {
+		r.count++
+	}
+}
+
+// list returns the packets recorded, from newest to oldest.
+func (r *recentPackets) list() []PacketRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	packets := make([]PacketRecord, r.count)
+	for i := range packets {
+		packets[i] = r.packets[(r.next-1-i+recentPacketsSize)%recentPacketsSize]
+	}
+	return packets
+}
+
+// Diagnostics holds the internal state of a session, which may be used to find out why a player is stuck, such as
+// on a loading screen after a transfer, without profiling the proxy.
+type Diagnostics struct {
+	// LoggedIn is true if the session finished logging in to its first server.
+	LoggedIn bool
+	// Detached is true if the client lost its connection while the session was kept open.
+	Detached bool
+	// Closed is true if the session was closed.
+	Closed bool
+	// Server is the name of the server the session is on, or empty if it is not on a server yet.
+	Server string
+	// TransferState is the state of the transfer of the session.
+	TransferState TransferState
+	// Reconnecting is true if the session is reconnecting to its server.
+	Reconnecting bool
+	// Held is true if the session is waiting to be released by the server it is being transferred to.
+	Held bool
+	// Buffered is the number of packets sent by the client during the current transfer that are buffered, out of
+	// BufferSize.
+	Buffered, BufferSize int
+	// Handlers is the number of handlers of the session.
+	Handlers int
+	// Footprint holds the number of entries the session tracks.
+	Footprint Footprint
+	// ClientPackets and ServerPackets hold the most recent packets read from the client and the server, from
+	// newest to oldest.
+	ClientPackets, ServerPackets []PacketRecord
+}
+
+// Diagnostics returns the internal state of the session.
+func (s *Session) Diagnostics() Diagnostics {
+	d := Diagnostics{
+		LoggedIn:      s.LoggedIn(),
+		Detached:      s.Detached(),
+		Closed:        s.Closed(),
+		TransferState: s.TransferState(),
+		Reconnecting:  s.reconnecting.Load(),
+		Footprint:     s.Footprint(),
+		ClientPackets: s.clientPackets.list(),
+		ServerPackets: s.serverPackets.list(),
+	}
+	// The server is read directly rather than using TryServer, so that it is also known while logging in.
+	s.serverMu.RLock()
+	if s.server != nil {
+		d.Server = s.server.Name()
+	}
+	s.serverMu.RUnlock()
+	s.releaseMu.Lock()
+	d.Held = s.release != nil && s.Transferring()
+	s.releaseMu.Unlock()
+	s.bufferMu.Lock()
+	d.Buffered, d.BufferSize = len(s.buffered), s.bufferSize
+	s.bufferMu.Unlock()
+	s.hMutex.RLock()
+	d.Handlers = len(s.handlers)
+	s.hMutex.RUnlock()
+	return d
+}
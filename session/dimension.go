@@ -0,0 +1,54 @@
+package session
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// dimensionNames holds the names of the vanilla dimensions by their IDs, as used in dimension definitions.
+var dimensionNames = map[int32]string{
+	packet.DimensionOverworld: "minecraft:overworld",
+	packet.DimensionNether:    "minecraft:nether",
+	packet.DimensionEnd:       "minecraft:the_end",
+}
+
+// DimensionDefinitions returns the data-driven dimension definitions sent to the client by servers through the
+// DimensionData packet, by the names of the dimensions. The client keeps definitions for the rest of its
+// connection, so definitions sent by previous servers are included.
+func (s *Session) DimensionDefinitions() map[string]protocol.DimensionDefinition {
+	s.dimensionsMu.RLock()
+	defer s.dimensionsMu.RUnlock()
+	m := make(map[string]protocol.DimensionDefinition, len(s.dimensionDefinitions))
+	for name, def := range s.dimensionDefinitions {
+		m[name] = def
+	}
+	return m
+}
+
+// addDimensionDefinitions adds the dimension definitions passed to the definitions known by the client.
+func (s *Session) addDimensionDefinitions(defs []protocol.DimensionDefinition) {
+	s.dimensionsMu.Lock()
+	defer s.dimensionsMu.Unlock()
+	for _, def := range defs {
+		s.dimensionDefinitions[def.Name] = def
+	}
+}
+
+// intermediateDimension returns a dimension that is different from both the current dimension of the client and
+// the dimension of the server it is transferred to. Either of those may be a custom dimension. Vanilla
+// dimensions that were redefined through dimension definitions are never returned, as the empty chunks sent
+// during a transfer only fit the vanilla height of a dimension. False is returned if no dimension is suitable.
+func (s *Session) intermediateDimension(current, destination int32) (int32, bool) {
+	s.dimensionsMu.RLock()
+	defer s.dimensionsMu.RUnlock()
+	for _, dimension := range []int32{packet.DimensionOverworld, packet.DimensionNether, packet.DimensionEnd} {
+		if dimension == current || dimension == destination {
+			continue
+		}
+		if _, ok := s.dimensionDefinitions[dimensionNames[dimension]]; ok {
+			continue
+		}
+		return dimension, true
+	}
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// DisplayName returns the name presented for the player of the session to other players, which is the name set
+// using SetDisplayName, or the name of the player if none is set.
+func (s *Session) DisplayName() string {
+	if name := s.displayName.Load(); name != "" {
+		return name
+	}
+	return s.identity.DisplayName
+}
+
+// SetDisplayName sets the name presented for the player of the session, such as a nickname. The name of the
+// player is replaced with it in the player list, the name tags and the chat messages sent to every player on the
+// proxy, including the player itself. If the name is empty, the name of the player is presented again.
+func (s *Session) SetDisplayName(name string) {
+	if name == s.identity.DisplayName {
+		name = ""
+	}
+	s.displayName.Store(name)
+	s.store.setDisplayName(s, name)
+}
+
+// SetDisplayNameDownstream sets if the name set using SetDisplayName is also presented to the servers the
+// session connects to, so that plugins on those servers see it as the name of the player. Servers only see the
+// name from the next time the session connects to them, such as after a transfer or Resync.
+func (s *Session) SetDisplayNameDownstream(v bool) {
+	s.displayNameDownstream.Store(v)
+}
+
+// rewriteDisplayNames replaces the names of the players with a display name set in the packet passed, which is
+// sent to the client of the session, with their display names. Names in the player list and of the senders of
+// chat messages are also decorated by the Decorator of the store of the session.
+func (s *Session) rewriteDisplayNames(pk packet.Packet) {
+	switch pk := pk.(type) {
+	case *packet.PlayerList:
+		if pk.ActionType != packet.PlayerListActionAdd {
+			return
+		}
+		for i, e := range pk.Entries {
+			if other, ok := s.store.Load(e.UUID); ok {
+				if name, ok := s.store.presentedName(other); ok {
+					pk.Entries[i].Username = name
+				}
+			}
+		}
+	case *packet.AddPlayer:
+		if name, ok := s.store.displayName(pk.UUID); ok {
+			pk.Username = name
+		}
+	case *packet.Text:
+		if other, ok := s.store.LoadFromName(pk.SourceName); ok && pk.TextType == packet.TextTypeChat {
+			if name, ok := s.store.presentedName(other); ok {
+				pk.SourceName = name
+			}
+		} else {
+			pk.SourceName = s.store.replaceNames(pk.SourceName)
+		}
+		pk.Message = s.store.replaceNames(pk.Message)
+		for i, param := range pk.Parameters {
+			pk.Parameters[i] = s.store.replaceNames(param)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// entityPruneInterval is the maximum interval at which stale entities are pruned.
+const entityPruneInterval = time.Minute
+
+// entityAges holds the time at which each entity tracked by a session last appeared in a packet, so that entities
+// that the server never removes can be forgotten once they have not appeared for a while.
+type entityAges struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entities  map[int64]entityAge
+	unique    map[uint64]int64
+	lastPrune time.Time
+}
+
+// entityAge is the runtime ID of an entity and the time at which it last appeared in a packet.
+type entityAge struct {
+	runtimeID uint64
+	seen      time.Time
+}
+
+// newEntityAges returns entityAges that forget entities which did not appear in a packet for the duration passed.
+func newEntityAges(ttl time.Duration, now time.Time) *entityAges {
+	return &entityAges{ttl: ttl, entities: make(map[int64]entityAge), unique: make(map[uint64]int64), lastPrune: now}
+}
+
+// add starts tracking the entity with the unique and runtime ID passed, which was spawned at the time passed.
+func (a *entityAges) add(uniqueID int64, runtimeID uint64, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entities[uniqueID] = entityAge{runtimeID: runtimeID, seen: now}
+	a.unique[runtimeID] = uniqueID
+}
+
+// touch marks the entities with the runtime IDs passed as seen at the time passed.
+func (a *entityAges) touch(now time.Time, runtimeIDs ...uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, id := range runtimeIDs {
+		if uniqueID, ok := a.unique[id]; ok {
+			a.touchUniqueID(uniqueID, now)
+		}
+	}
+}
+
+// touchUnique marks the entities with the unique IDs passed as seen at the time passed.
+func (a *entityAges) touchUnique(now time.Time, uniqueIDs ...int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, id := range uniqueIDs {
+		a.touchUniqueID(id, now)
+	}
+}
+
+// touchUniqueID marks the entity with the unique ID passed as seen at the time passed, if it is tracked. It must
+// be called while holding the lock.
+func (a *entityAges) touchUniqueID(uniqueID int64, now time.Time) {
+	if e, ok := a.entities[uniqueID]; ok {
+		e.seen = now
+		a.entities[uniqueID] = e
+	}
+}
+
+// remove stops tracking the entity with the unique ID passed.
+func (a *entityAges) remove(uniqueID int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.forget(uniqueID)
+}
+
+// clear stops tracking all entities.
+func (a *entityAges) clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entities, a.unique = make(map[int64]entityAge), make(map[uint64]int64)
+}
+
+// prune stops tracking the entities that did not appear in a packet for longer than the TTL at the time passed,
+// and returns their unique IDs. Entities are only pruned once per entityPruneInterval, or per TTL if shorter.
+func (a *entityAges) prune(now time.Time) []int64 {
+	interval := entityPruneInterval
+	if a.ttl < interval {
+		interval = a.ttl
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if now.Sub(a.lastPrune) < interval {
+		return nil
+	}
+	a.lastPrune = now
+
+	var pruned []int64
+	for id, e := range a.entities {
+		if now.Sub(e.seen) > a.ttl {
+			pruned = append(pruned, id)
+		}
+	}
+	for _, id := range pruned {
+		a.forget(id)
+	}
+	return pruned
+}
+
+// forget removes the entity with the unique ID passed. It must be called while holding the lock.
+func (a *entityAges) forget(uniqueID int64) {
+	if e, ok := a.entities[uniqueID]; ok {
+		delete(a.entities, uniqueID)
+		delete(a.unique, e.runtimeID)
+	}
+}
+
+// trackEntityAge updates the time at which the entities referenced in the packet passed, sent by the server,
+// last appeared, and prunes the entities tracked by the session that did not appear for longer than the
+// EntityTTL of its Env. Pruned entities are no longer removed from the client when the session is transferred.
+func (s *Session) trackEntityAge(pk packet.Packet) {
+	a := s.entityAges
+	if a == nil {
+		return
+	}
+	now := s.env.Clock.Now()
+	switch pk := pk.(type) {
+	case *packet.AddActor:
+		a.add(pk.EntityUniqueID, pk.EntityRuntimeID, now)
+	case *packet.AddItemActor:
+		a.add(pk.EntityUniqueID, pk.EntityRuntimeID, now)
+	case *packet.AddPainting:
+		a.add(pk.EntityUniqueID, pk.EntityRuntimeID, now)
+	case *packet.AddPlayer:
+		a.add(pk.AbilityData.EntityUniqueID, pk.EntityRuntimeID, now)
+	case *packet.RemoveActor:
+		a.remove(pk.EntityUniqueID)
+	case *packet.MoveActorAbsolute:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.MoveActorDelta:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.SetActorData:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.SetActorMotion:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.MotionPredictionHints:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.ActorEvent:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.Animate:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.AnimateEntity:
+		a.touch(now, pk.EntityRuntimeIDs...)
+	case *packet.MobEquipment:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.MobArmourEquipment:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.UpdateAttributes:
+		a.touch(now, pk.EntityRuntimeID)
+	case *packet.TakeItemActor:
+		a.touch(now, pk.ItemEntityRuntimeID, pk.TakerEntityRuntimeID)
+	case *packet.SetActorLink:
+		a.touchUnique(now, pk.EntityLink.RiddenEntityUniqueID, pk.EntityLink.RiderEntityUniqueID)
+	case *packet.BossEvent:
+		a.touchUnique(now, pk.BossEntityUniqueID)
+	}
+	if pruned := a.prune(now); len(pruned) > 0 {
+		s.entities.Remove(pruned...)
+		s.log.Debugf("pruned %d entities of %s that did not appear for %s", len(pruned), s.identity.DisplayName, a.ttl)
+	}
+}
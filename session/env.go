@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/paroxity/portal/report"
+	"github.com/paroxity/portal/server"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// Clock provides the current time and timers to sessions. It may be replaced in tests, so that timeouts and
+// cooldowns can be triggered deterministically instead of waiting for them.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc calls f once the duration passed has elapsed, without blocking the caller. The function
+	// returned stops the timer, and returns false if f was already called.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// SystemClock is the Clock used by sessions by default. It uses the time of the system.
+var SystemClock Clock = systemClock{}
+
+// systemClock implements Clock using the time package.
+type systemClock struct{}
+
+// Now ...
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc ...
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// DialFunc dials a connection to the server passed for the session passed. The context passed is cancelled
+// once the session is closed.
+type DialFunc func(ctx context.Context, s *Session, srv *server.Server) (*minecraft.Conn, error)
+
+// DefaultDial is the DialFunc used by sessions by default. It dials the server over the network the server was
+// registered with, which must be registered with gophertunnel, for example by importing the transport package,
+// logging in with the client and identity data of the player. The display name of the player is
+// used as their name if it is presented to servers.
+func DefaultDial(ctx context.Context, s *Session, srv *server.Server) (*minecraft.Conn, error) {
+	i := s.identity
+	i.XUID = ""
+	if s.displayNameDownstream.Load() {
+		i.DisplayName = s.DisplayName()
+	}
+	return minecraft.Dialer{
+		ClientData:   s.clientConn().ClientData(),
+		IdentityData: i,
+
+		EnableClientCache: s.clientConn().ClientCacheEnabled(),
+		FlushRate:         -1,
+	}.DialContext(ctx, srv.Network(), srv.Address())
+}
+
+// Env holds the dependencies of a session on its environment, which may be replaced to test sessions without
+// real servers or waiting for timeouts.
+type Env struct {
+	// Clock is the clock used for the timeouts and times of the session. If nil, SystemClock is used.
+	Clock Clock
+	// Dial is the function used to dial the servers the session connects to. If nil, DefaultDial is used.
+	Dial DialFunc
+	// Experiments holds the experiments the session is assigned to buckets of. If nil, no experiments are
+	// running and the session is in bucket zero of every experiment.
+	Experiments *Experiments
+	// Transfers limits the number of transfers of all sessions dialing their destination at the same time. If
+	// nil, transfers are not limited.
+	Transfers *TransferLimiter
+	// Reporter is the reporter that errors of the session, such as panics in its handlers and failed dials, are
+	// reported to. If nil, errors are not reported.
+	Reporter report.Reporter
+	// EntityTTL is the time after which entities tracked for the session that did not appear in any packet are
+	// forgotten, so that servers that never remove their entities do not grow the memory used by the session. If
+	// zero, entities are tracked until the server removes them.
+	EntityTTL time.Duration
+	// GameOverrides holds the experiments and features forced in the game data sent to the player when they join.
+	// If nil, the game data of the first server of the player is sent as it is.
+	GameOverrides *GameOverrides
+	// Rejoins holds the servers players transferred with a full reconnect are sent to when they join again. If
+	// nil, sessions can not be transferred with a full reconnect.
+	Rejoins *Rejoins
+
+	// The fields below set the initial settings of the session, which are applied before it connects to its
+	// first server. They may be changed later using the setters of the session of the same name.
+
+	// SpawnHold is the maximum time the session is held on a loading screen after being transferred. See
+	// Session.SetSpawnHold.
+	SpawnHold time.Duration
+	// KickPolicy is the policy applied when the server of the session kicks the player, and KickMessage the
+	// message shown if the policy is KickMessage. See Session.SetKickPolicy.
+	KickPolicy  KickPolicy
+	KickMessage string
+	// KickRewriter rewrites the messages of the server when it kicks the player. See Session.SetKickRewriter.
+	KickRewriter *KickRewriter
+	// PalettePolicy is the policy applied when the session is transferred to a server with different custom
+	// blocks or items. See Session.SetPalettePolicy.
+	PalettePolicy PalettePolicy
+	// Hexdump specifies if the session is in hexdump mode. See Session.SetHexdump.
+	Hexdump bool
+	// TransferBuffer is the number of packets of the client buffered during a transfer. See
+	// Session.SetTransferBuffer.
+	TransferBuffer int
+}
+
+// withDefaults returns the Env with any unset fields set to their default values.
+func (e Env) withDefaults() Env {
+	if e.Clock == nil {
+		e.Clock = SystemClock
+	}
+	if e.Dial == nil {
+		e.Dial = DefaultDial
+	}
+	return e
+}
+
+// Clock returns the clock used by the session.
+func (s *Session) Clock() Clock {
+	return s.env.Clock
+}
+
+// withTimeout returns a context derived from the context of the session, which is cancelled once the duration
+// passed has elapsed on the clock of the session.
+func (s *Session) withTimeout(d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := s.env.Clock.(systemClock); ok {
+		return context.WithTimeout(s.ctx, d)
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	stop := s.env.Clock.AfterFunc(d, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// report reports the error of the kind passed, such as report.KindDial, to the reporter of the session. The
+// error is tagged with the player of the session and the server passed, or the current server of the session if
+// nil. The stack passed is only set for panics. It must not be called while the server lock of the session is
+// held.
+func (s *Session) report(kind string, srv *server.Server, err error, stack []byte) {
+	if s.env.Reporter == nil {
+		return
+	}
+	tags := map[string]string{"player": s.identity.DisplayName, "uuid": s.uuid.String(), "correlation_id": s.correlationID}
+	if s.identity.XUID != "" {
+		tags["xuid"] = s.identity.XUID
+	}
+	if srv == nil {
+		srv, _ = s.TryServer()
+	}
+	if srv != nil {
+		tags["server"] = srv.Name()
+	}
+	s.env.Reporter.Report(report.Event{Time: s.env.Clock.Now(), Kind: kind, Message: err.Error(), Stack: stack, Tags: tags})
+}
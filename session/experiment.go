@@ -0,0 +1,100 @@
+package session
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// Experiments holds the gameplay experiments running on the proxy, together with the number of buckets players
+// are split into for each of them. Sessions are assigned to a bucket of every experiment deterministically, so
+// that a player stays in the same bucket when they rejoin or move to another proxy.
+type Experiments struct {
+	mu      sync.RWMutex
+	buckets map[string]int
+}
+
+// NewExperiments creates Experiments holding the experiments passed, which map the names of the experiments to
+// their number of buckets.
+func NewExperiments(experiments map[string]int) *Experiments {
+	e := &Experiments{buckets: make(map[string]int, len(experiments))}
+	for name, buckets := range experiments {
+		e.Set(name, buckets)
+	}
+	return e
+}
+
+// Set starts the experiment with the name passed, or changes its number of buckets if it is already running.
+// Changing the number of buckets of an experiment reassigns players to its buckets. Experiments with fewer
+// than one bucket are not started.
+func (e *Experiments) Set(name string, buckets int) {
+	if buckets < 1 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.buckets[name] = buckets
+}
+
+// Remove stops the experiment with the name passed.
+func (e *Experiments) Remove(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.buckets, name)
+}
+
+// Buckets returns the number of buckets of the experiment with the name passed, and if the experiment exists.
+func (e *Experiments) Buckets(name string) (int, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	buckets, ok := e.buckets[name]
+	return buckets, ok
+}
+
+// All returns the names of all experiments mapped to their number of buckets.
+func (e *Experiments) All() map[string]int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	all := make(map[string]int, len(e.buckets))
+	for name, buckets := range e.buckets {
+		all[name] = buckets
+	}
+	return all
+}
+
+// bucket returns the bucket out of the number of buckets passed that the session passed is assigned to for the
+// experiment with the name passed. The name is part of the hash, so that the buckets of a player in different
+// experiments are independent of each other.
+func bucket(s *Session, name string, buckets int) int {
+	id := s.UUID()
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write(id[:])
+	return int(h.Sum64() % uint64(buckets))
+}
+
+// Bucket returns the bucket the session is assigned to for the experiment with the name passed, ranging from
+// zero to the number of buckets of the experiment. Sessions are always assigned to bucket zero of experiments
+// that are not running.
+func (s *Session) Bucket(experiment string) int {
+	if s.env.Experiments == nil {
+		return 0
+	}
+	buckets, ok := s.env.Experiments.Buckets(experiment)
+	if !ok {
+		return 0
+	}
+	return bucket(s, experiment, buckets)
+}
+
+// Buckets returns the buckets the session is assigned to for all running experiments, mapped by the names of
+// the experiments.
+func (s *Session) Buckets() map[string]int {
+	if s.env.Experiments == nil {
+		return nil
+	}
+	all := s.env.Experiments.All()
+	for name, buckets := range all {
+		all[name] = bucket(s, name, buckets)
+	}
+	return all
+}
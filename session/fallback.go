@@ -0,0 +1,80 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+)
+
+// FallbackHandler is a Handler that transfers a session to another server when the server it is connected to
+// closes the connection unexpectedly, instead of disconnecting the player from the proxy. The server that closed
+// the connection and servers that recently failed to accept the player are never chosen as fallback.
+type FallbackHandler struct {
+	NopHandler
+	s      *Session
+	lb     LoadBalancer
+	expiry time.Duration
+
+	mu     sync.Mutex
+	failed map[*server.Server]time.Time
+}
+
+// NewFallbackHandler returns a function that creates a FallbackHandler for a session, which finds fallback
+// servers using the load balancer passed. Servers that failed to accept the player are excluded for the expiry
+// passed. The function returned may be passed to portal.Handle directly.
+func NewFallbackHandler(loadBalancer LoadBalancer, expiry time.Duration) func(s *Session) Handler {
+	return func(s *Session) Handler {
+		return &FallbackHandler{s: s, lb: loadBalancer, expiry: expiry, failed: make(map[*server.Server]time.Time)}
+	}
+}
+
+// HandleServerDisconnect ...
+func (h *FallbackHandler) HandleServerDisconnect(ctx *event.Context, _ error) {
+	if ctx.Cancelled() {
+		return
+	}
+	from := h.s.Server()
+	h.fail(from)
+
+	srv := SelectServer(h.lb, h.s, h.excluded()...)
+	if srv == nil {
+		return
+	}
+	h.s.log.Infof("sending %s to fallback server %s after losing connection to %s", h.s.IdentityData().DisplayName, srv.Name(), from.Name())
+	// Transfer also returns nil if a handler cancelled the transfer, so the transfer only succeeded if the
+	// session left the server that closed the connection.
+	if err := h.s.Transfer(srv); err == nil && h.s.Server() != from {
+		ctx.Cancel()
+	}
+}
+
+// HandleTransferFailure ...
+func (h *FallbackHandler) HandleTransferFailure(srv *server.Server, _ error) {
+	h.fail(srv)
+}
+
+// fail marks the server passed as failed for the session.
+func (h *FallbackHandler) fail(srv *server.Server) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failed[srv] = h.s.Clock().Now()
+}
+
+// excluded returns all servers that failed for the session within the expiry of the handler.
+func (h *FallbackHandler) excluded() []*server.Server {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.s.Clock().Now()
+	var servers []*server.Server
+	for srv, t := range h.failed {
+		if now.Sub(t) > h.expiry {
+			delete(h.failed, srv)
+			continue
+		}
+		servers = append(servers, srv)
+	}
+	return servers
+}
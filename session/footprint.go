@@ -0,0 +1,71 @@
+package session
+
+// Footprint holds the number of entries the proxy tracks for a session to clean up after transfers, such as the
+// entities spawned for the player by their server. As entries are only removed when the server removes them, a
+// footprint that keeps growing usually means a server never despawns its entities or never hides its boss bars.
+type Footprint struct {
+	// Entities is the number of entities spawned for the player.
+	Entities int
+	// PlayerList is the number of entries in the player list of the player.
+	PlayerList int
+	// Effects is the number of effects the player has.
+	Effects int
+	// BossBars is the number of boss bars shown to the player.
+	BossBars int
+	// Scoreboards is the number of scoreboards shown to the player.
+	Scoreboards int
+	// Sounds is the number of looping sounds and music playing for the player.
+	Sounds int
+	// BlobHashes is the number of recent cache blobs sent by the server of the player.
+	BlobHashes int
+	// Dimensions is the number of dimension definitions sent to the player.
+	Dimensions int
+}
+
+// Total returns the total number of entries in the footprint.
+func (f Footprint) Total() int {
+	return f.Entities + f.PlayerList + f.Effects + f.BossBars + f.Scoreboards + f.Sounds + f.BlobHashes + f.Dimensions
+}
+
+// Add returns the sum of the footprint and the footprint passed.
+func (f Footprint) Add(o Footprint) Footprint {
+	return Footprint{
+		Entities:    f.Entities + o.Entities,
+		PlayerList:  f.PlayerList + o.PlayerList,
+		Effects:     f.Effects + o.Effects,
+		BossBars:    f.BossBars + o.BossBars,
+		Scoreboards: f.Scoreboards + o.Scoreboards,
+		Sounds:      f.Sounds + o.Sounds,
+		BlobHashes:  f.BlobHashes + o.BlobHashes,
+		Dimensions:  f.Dimensions + o.Dimensions,
+	}
+}
+
+// Footprint returns the number of entries the proxy currently tracks for the session. The counts are read while
+// packets are still being handled, so they are a snapshot that may be slightly out of date.
+func (s *Session) Footprint() Footprint {
+	s.dimensionsMu.RLock()
+	dimensions := len(s.dimensionDefinitions)
+	s.dimensionsMu.RUnlock()
+	s.blobMu.Lock()
+	blobHashes := s.blobHashes.size()
+	s.blobMu.Unlock()
+	return Footprint{
+		Entities:    s.entities.Size(),
+		PlayerList:  s.playerList.Size(),
+		Effects:     s.effects.Size(),
+		BossBars:    s.bossBars.Size(),
+		Scoreboards: s.scoreboards.Size(),
+		Sounds:      s.sounds.count(),
+		BlobHashes:  blobHashes,
+		Dimensions:  dimensions,
+	}
+}
+
+// Footprint returns the sum of the footprints of all sessions in the store.
+func (s *Store) Footprint() (total Footprint) {
+	for _, session := range s.All() {
+		total = total.Add(session.Footprint())
+	}
+	return total
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"sort"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// GameOverrides holds experiments and features that are forced in the game data sent to players when they join,
+// regardless of the settings of the server they join. The client only receives the game data once, so servers
+// with different experiments than the first server of a player may crash the client after a transfer unless the
+// same set is forced for every player.
+type GameOverrides struct {
+	// Experiments maps the names of experiments, such as "gametest" or "upcoming_creator_features", to whether
+	// they are forced to be enabled or disabled. Experiments not in the map are sent as the server sent them.
+	Experiments map[string]bool
+	// ClientSideGeneration, PersonaDisabled, CustomSkinsDisabled and DisablePlayerInteractions, if not nil,
+	// force the feature of the game data with the same name to be enabled or disabled.
+	ClientSideGeneration      *bool
+	PersonaDisabled           *bool
+	CustomSkinsDisabled       *bool
+	DisablePlayerInteractions *bool
+}
+
+// Apply applies the overrides to the game data passed.
+func (o *GameOverrides) Apply(data *minecraft.GameData) {
+	if len(o.Experiments) > 0 {
+		experiments := make([]protocol.ExperimentData, 0, len(data.Experiments)+len(o.Experiments))
+		seen := make(map[string]struct{}, len(data.Experiments))
+		for _, e := range data.Experiments {
+			if enabled, ok := o.Experiments[e.Name]; ok {
+				e.Enabled = enabled
+			}
+			seen[e.Name] = struct{}{}
+			experiments = append(experiments, e)
+		}
+		names := make([]string, 0, len(o.Experiments))
+		for name := range o.Experiments {
+			if _, ok := seen[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			experiments = append(experiments, protocol.ExperimentData{Name: name, Enabled: o.Experiments[name]})
+		}
+		data.Experiments = experiments
+	}
+	override(&data.ClientSideGeneration, o.ClientSideGeneration)
+	override(&data.PersonaDisabled, o.PersonaDisabled)
+	override(&data.CustomSkinsDisabled, o.CustomSkinsDisabled)
+	override(&data.DisablePlayerInteractions, o.DisablePlayerInteractions)
+}
+
+// override sets the value pointed to by v to the value pointed to by o, if o is not nil.
+func override(v *bool, o *bool) {
+	if o != nil {
+		*v = *o
+	}
+}
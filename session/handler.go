@@ -1,13 +1,19 @@
 package session
 
 import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+
 	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/report"
 	"github.com/paroxity/portal/server"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 )
 
-// Handler handles events that are called by a player's session.
+// Handler handles events that are called by a player's session. A panic in any of the methods is recovered and
+// logged, after which the session is disconnected. Handlers doing slow work may be wrapped using Async.
 type Handler interface {
 	// HandleClientBoundPacket handles a packet that's sent by the session's connected server. ctx.Cancel()
 	// may be called to cancel the packet.
@@ -15,22 +21,58 @@ type Handler interface {
 	// HandleServerBoundPacket handles a packet that's sent by the session. ctx.Cancel() may be called to
 	// cancel the packet.
 	HandleServerBoundPacket(ctx *event.Context, pk packet.Packet)
-	// HandleServerDisconnect handles the server connection getting closed. ctx.Cancel() may be called after
-	// transferring the player to cancel disconnecting them.
+	// HandleServerConnect handles the proxy establishing a connection to a server for the session, either when
+	// the session first joins or when it is being transferred. It is called once the player has spawned in on
+	// the server.
+	HandleServerConnect(srv *server.Server, conn *minecraft.Conn)
+	// HandleServerDisconnect handles the server connection getting closed unexpectedly, for example when the
+	// server crashes or kicks the player. It is not called when the session itself is closed. The error passed
+	// is the error that caused the connection to be closed. ctx.Cancel() may be called after transferring the
+	// player to cancel disconnecting them.
 	HandleServerDisconnect(ctx *event.Context, err error)
+	// HandleServerKick handles the server of the session disconnecting the player with the message passed. It
+	// is called before HandleServerDisconnect. The policy applied may be changed by storing a different policy
+	// under the KickAction key using event.Store, and the message shown to the player by storing it under the
+	// KickReason key. ctx.Cancel() may be called after transferring the player to another server to keep them
+	// connected.
+	HandleServerKick(ctx *event.Context, srv *server.Server, message string)
+	// HandleClientDisconnect handles the connection of the player getting closed unexpectedly, for example when
+	// their internet connection drops. ctx.Cancel() may be called to keep the session and its server connection
+	// open, so that a new connection of the player may be attached to it using Reattach. The handler is then
+	// responsible for closing the session if no connection is attached.
+	HandleClientDisconnect(ctx *event.Context, err error)
 	// HandleTransfer handles a session being transferred to another server. ctx.Cancel() may be called to
-	// cancel the transfer.
+	// cancel the transfer. The destination may be changed by storing a different server under the
+	// TransferServer key using event.Store.
 	HandleTransfer(ctx *event.Context, svr *server.Server)
+	// HandleServerSelect handles a load balancer selecting a server for the session, either when it first joins
+	// or when a fallback server is found for it. The candidates considered by the load balancer are passed,
+	// ordered from most to least preferred. ctx.Cancel() may be called to veto the selection, in which case no
+	// server is selected, and the candidates may be re-ordered or filtered by storing a different list under the
+	// ServerCandidates key using event.Store. The first candidate left is selected.
+	HandleServerSelect(ctx *event.Context, candidates []Candidate)
+	// HandleRoute handles a RouteLoadBalancer deciding on the group of servers preferred for the session, such as
+	// the group for the locale of the player. The group is empty if no rule matched the session. ctx.Cancel()
+	// may be called to ignore the rules for the session, and a different group may be preferred by storing it
+	// under the RouteGroup key using event.Store.
+	HandleRoute(ctx *event.Context, group string)
+	// HandleTransferFailure handles a transfer of the session to the server passed failing, either because the
+	// server could not be reached or because the player could not spawn in on it. The session stays on the
+	// server it was on before the transfer.
+	HandleTransferFailure(srv *server.Server, err error)
 	// HandleChangeConn handles a session's connection being changed. This is called when Portal sets the
 	// temporary server conn to the main server conn.
 	HandleChangeConn(conn *minecraft.Conn)
 	// HandleQuit handles the closing of a session. It is always called when the session is disconnected,
-	// regardless of the reason.
-	HandleQuit()
+	// regardless of the reason, which is passed.
+	HandleQuit(reason CloseReason)
 }
 
-// NopHandler implements the Handler interface but does not execute any code when an event is called. The
-// default handler of sessions is set to NopHandler.
+// TransferServer is the key of the server a session is being transferred to in the context passed to
+// HandleTransfer. Handlers may store a different server under this key to change the destination.
+var TransferServer = event.NewKey[*server.Server]("transfer server")
+
+// NopHandler implements the Handler interface but does not execute any code when an event is called.
 // Users may embed NopHandler to avoid having to implement each method.
 type NopHandler struct{}
 
@@ -43,14 +85,179 @@ func (NopHandler) HandleClientBoundPacket(*event.Context, packet.Packet) {}
 // HandleServerBoundPacket ...
 func (NopHandler) HandleServerBoundPacket(*event.Context, packet.Packet) {}
 
+// HandleServerConnect ...
+func (NopHandler) HandleServerConnect(*server.Server, *minecraft.Conn) {}
+
 // HandleServerDisconnect ...
 func (NopHandler) HandleServerDisconnect(*event.Context, error) {}
 
+// HandleServerKick ...
+func (NopHandler) HandleServerKick(*event.Context, *server.Server, string) {}
+
+// HandleClientDisconnect ...
+func (NopHandler) HandleClientDisconnect(*event.Context, error) {}
+
 // HandleTransfer ...
 func (NopHandler) HandleTransfer(*event.Context, *server.Server) {}
 
+// HandleServerSelect ...
+func (NopHandler) HandleServerSelect(*event.Context, []Candidate) {}
+
+// HandleRoute ...
+func (NopHandler) HandleRoute(*event.Context, string) {}
+
+// HandleTransferFailure ...
+func (NopHandler) HandleTransferFailure(*server.Server, error) {}
+
 // HandleChangeConn ...
 func (NopHandler) HandleChangeConn(*minecraft.Conn) {}
 
 // HandleQuit ...
-func (NopHandler) HandleQuit() {}
+func (NopHandler) HandleQuit(CloseReason) {}
+
+// handlerEntry is a handler added to a session together with its priority.
+type handlerEntry struct {
+	h        Handler
+	priority int
+}
+
+// handlerChain is a Handler that calls every handler in the chain in order. The same event context is passed
+// to all the handlers, so a handler further down the chain is able to see if the event was cancelled using
+// ctx.Cancelled(). A panic in any of the handlers is recovered, after which the session is disconnected.
+type handlerChain struct {
+	s        *Session
+	handlers []Handler
+}
+
+// newHandlerChain returns a handlerChain of the session and entries passed, sorted by their priority from highest
+// to lowest. Entries with the same priority keep the order in which they were added.
+func newHandlerChain(s *Session, entries []handlerEntry) handlerChain {
+	sorted := make([]handlerEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].priority > sorted[j].priority
+	})
+	chain := handlerChain{s: s, handlers: make([]Handler, 0, len(sorted))}
+	for _, e := range sorted {
+		chain.handlers = append(chain.handlers, e.h)
+	}
+	return chain
+}
+
+// HandleClientBoundPacket ...
+func (c handlerChain) HandleClientBoundPacket(ctx *event.Context, pk packet.Packet) {
+	defer c.recover("HandleClientBoundPacket")
+	for _, h := range c.handlers {
+		h.HandleClientBoundPacket(ctx, pk)
+	}
+}
+
+// HandleServerBoundPacket ...
+func (c handlerChain) HandleServerBoundPacket(ctx *event.Context, pk packet.Packet) {
+	defer c.recover("HandleServerBoundPacket")
+	for _, h := range c.handlers {
+		h.HandleServerBoundPacket(ctx, pk)
+	}
+}
+
+// HandleServerConnect ...
+func (c handlerChain) HandleServerConnect(srv *server.Server, conn *minecraft.Conn) {
+	defer c.recover("HandleServerConnect")
+	for _, h := range c.handlers {
+		h.HandleServerConnect(srv, conn)
+	}
+}
+
+// HandleServerDisconnect ...
+func (c handlerChain) HandleServerDisconnect(ctx *event.Context, err error) {
+	defer c.recover("HandleServerDisconnect")
+	for _, h := range c.handlers {
+		h.HandleServerDisconnect(ctx, err)
+	}
+}
+
+// HandleServerKick ...
+func (c handlerChain) HandleServerKick(ctx *event.Context, srv *server.Server, message string) {
+	defer c.recover("HandleServerKick")
+	for _, h := range c.handlers {
+		h.HandleServerKick(ctx, srv, message)
+	}
+}
+
+// HandleClientDisconnect ...
+func (c handlerChain) HandleClientDisconnect(ctx *event.Context, err error) {
+	defer c.recover("HandleClientDisconnect")
+	for _, h := range c.handlers {
+		h.HandleClientDisconnect(ctx, err)
+	}
+}
+
+// HandleTransfer ...
+func (c handlerChain) HandleTransfer(ctx *event.Context, svr *server.Server) {
+	defer c.recover("HandleTransfer")
+	for _, h := range c.handlers {
+		h.HandleTransfer(ctx, svr)
+	}
+}
+
+// HandleServerSelect ...
+func (c handlerChain) HandleServerSelect(ctx *event.Context, candidates []Candidate) {
+	defer c.recover("HandleServerSelect")
+	for _, h := range c.handlers {
+		h.HandleServerSelect(ctx, candidates)
+	}
+}
+
+// HandleRoute ...
+func (c handlerChain) HandleRoute(ctx *event.Context, group string) {
+	defer c.recover("HandleRoute")
+	for _, h := range c.handlers {
+		h.HandleRoute(ctx, group)
+	}
+}
+
+// HandleTransferFailure ...
+func (c handlerChain) HandleTransferFailure(srv *server.Server, err error) {
+	defer c.recover("HandleTransferFailure")
+	for _, h := range c.handlers {
+		h.HandleTransferFailure(srv, err)
+	}
+}
+
+// HandleChangeConn ...
+func (c handlerChain) HandleChangeConn(conn *minecraft.Conn) {
+	defer c.recover("HandleChangeConn")
+	for _, h := range c.handlers {
+		h.HandleChangeConn(conn)
+	}
+}
+
+// HandleQuit ...
+func (c handlerChain) HandleQuit(reason CloseReason) {
+	defer c.recover("HandleQuit")
+	for _, h := range c.handlers {
+		h.HandleQuit(reason)
+	}
+}
+
+// recover recovers from a panic in a handler of the chain while handling the event with the name passed. The
+// panic is logged with the stack trace and the session is disconnected, so that a faulty handler does not crash
+// the entire proxy. It must be deferred directly.
+func (c handlerChain) recover(name string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	recovered(c.s, name, r)
+}
+
+// recovered logs the value passed, recovered from a panic in a handler of the session passed while handling the
+// event with the name passed, and disconnects the session.
+func recovered(s *Session, name string, r any) {
+	stack := debug.Stack()
+	s.log.Errorf("panic in %s of %s: %v\n%s", name, s.identity.DisplayName, r, stack)
+	// The panic is reported and the session is disconnected in a separate goroutine, as the handler may have
+	// panicked while the session held one of its locks.
+	go s.report(report.KindPanic, nil, fmt.Errorf("panic in %s: %v", name, r), stack)
+	go s.Disconnect("An internal error occurred.")
+}
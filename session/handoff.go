@@ -0,0 +1,195 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/storage"
+)
+
+// HandoffState holds the minimal state of a session that is stored when the proxy shuts down, so that the
+// session may be claimed by a proxy started afterwards.
+type HandoffState struct {
+	// UUID is the UUID of the player of the session.
+	UUID uuid.UUID `json:"uuid"`
+	// Server is the name of the server the session was connected to.
+	Server string `json:"server"`
+	// Time is the time at which the state was stored.
+	Time time.Time `json:"time"`
+	// RuntimeID and UniqueID are the entity runtime and unique IDs of the player as known by the client, which
+	// were given by the first server the session joined. A resumed session gives the client the same IDs and
+	// translates them to the IDs given by its new server, so that IDs are translated consistently across the
+	// handoff. They are zero if the session had not logged in.
+	RuntimeID uint64 `json:"runtime_id,omitempty"`
+	UniqueID  int64  `json:"unique_id,omitempty"`
+}
+
+// HandoffStore stores the states of sessions when the proxy shuts down. Experimental: a proxy that is
+// restarted quickly, or a sibling instance of it, may claim the states of returning players to route them
+// straight back to their previous server. Implementations may store the states on disk or in a shared store
+// such as Redis.
+type HandoffStore interface {
+	// Save stores the states passed, replacing any states stored before.
+	Save(states []HandoffState) error
+	// Claim returns the state stored for the UUID passed and removes it from the store, so that it can only be
+	// claimed once. If no state was stored, false is returned.
+	Claim(id uuid.UUID) (HandoffState, bool)
+}
+
+// FileHandoffStore is a HandoffStore that stores the states of sessions in a JSON file.
+type FileHandoffStore struct {
+	path string
+
+	mu     sync.Mutex
+	states map[uuid.UUID]HandoffState
+}
+
+// NewFileHandoffStore creates a FileHandoffStore that stores states in the file at the path passed. Any states
+// already stored in the file are loaded and the file is removed, so that states are not claimed by more than
+// one run of the proxy.
+func NewFileHandoffStore(path string) (*FileHandoffStore, error) {
+	s := &FileHandoffStore{path: path, states: make(map[uuid.UUID]HandoffState)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var states []HandoffState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+	for _, state := range states {
+		s.states[state.UUID] = state
+	}
+	return s, os.Remove(path)
+}
+
+// Save ...
+func (s *FileHandoffStore) Save(states []HandoffState) error {
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Claim ...
+func (s *FileHandoffStore) Claim(id uuid.UUID) (HandoffState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[id]
+	delete(s.states, id)
+	return state, ok
+}
+
+// ProviderHandoffStore is a HandoffStore that stores the states of sessions using a storage.Provider. Proxies
+// sharing the same database may claim the states stored by each other.
+type ProviderHandoffStore struct {
+	p storage.Provider
+}
+
+// handoffBucket is the bucket of the storage.Provider in which states are stored.
+const handoffBucket = "handoff"
+
+// NewProviderHandoffStore creates a ProviderHandoffStore that stores states using the provider passed.
+func NewProviderHandoffStore(p storage.Provider) *ProviderHandoffStore {
+	return &ProviderHandoffStore{p: p}
+}
+
+// Save ...
+func (s *ProviderHandoffStore) Save(states []HandoffState) error {
+	if err := s.p.Clear(handoffBucket); err != nil {
+		return err
+	}
+	for _, state := range states {
+		if err := storage.Save(s.p, handoffBucket, state.UUID.String(), state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Claim ...
+func (s *ProviderHandoffStore) Claim(id uuid.UUID) (HandoffState, bool) {
+	var state HandoffState
+	if ok, err := storage.Load(s.p, handoffBucket, id.String(), &state); err != nil || !ok {
+		return HandoffState{}, false
+	}
+	if err := s.p.Delete(handoffBucket, id.String()); err != nil {
+		// The state could not be removed, so it is not claimed to prevent it from being claimed twice.
+		return HandoffState{}, false
+	}
+	return state, true
+}
+
+// HandoffLoadBalancer is a load balancer that routes players with a state in a HandoffStore back to the server
+// they were connected to before the proxy was restarted. Players without a state, with a state that is older
+// than the expiry, or whose previous server is unhealthy, out of rotation or full, are passed on to the fallback
+// load balancer.
+type HandoffLoadBalancer struct {
+	store    HandoffStore
+	registry *server.Registry
+	fallback LoadBalancer
+	expiry   time.Duration
+}
+
+// NewHandoffLoadBalancer creates a HandoffLoadBalancer that claims states from the store passed. States older
+// than the expiry passed are ignored.
+func NewHandoffLoadBalancer(store HandoffStore, registry *server.Registry, fallback LoadBalancer, expiry time.Duration) *HandoffLoadBalancer {
+	return &HandoffLoadBalancer{store: store, registry: registry, fallback: fallback, expiry: expiry}
+}
+
+// FindServer ...
+func (b *HandoffLoadBalancer) FindServer(session *Session) *server.Server {
+	if state, ok := b.store.Claim(session.UUID()); ok && time.Since(state.Time) < b.expiry {
+		session.handoff.Store(state)
+		// The server is only returned if it is still able to accept players, like the StickyLoadBalancer does.
+		if srv, ok := b.registry.Server(state.Server); ok && srv.Healthy() && srv.InRotation() && !srv.Full() {
+			return srv
+		}
+	}
+	return b.fallback.FindServer(session)
+}
+
+// FindServerExcluding ...
+func (b *HandoffLoadBalancer) FindServerExcluding(session *Session, exclude ...*server.Server) *server.Server {
+	return FindServerExcluding(b.fallback, session, exclude...)
+}
+
+// SaveHandoff stores the state of every session in the store passed to the HandoffStore passed.
+func SaveHandoff(sessions *Store, handoff HandoffStore) error {
+	var states []HandoffState
+	for _, s := range sessions.All() {
+		s.serverMu.RLock()
+		srv := s.server
+		s.serverMu.RUnlock()
+		if srv == nil {
+			continue
+		}
+		state := HandoffState{UUID: s.UUID(), Server: srv.Name(), Time: time.Now()}
+		if s.LoggedIn() && s.translator != nil {
+			state.RuntimeID, state.UniqueID = s.originalRuntimeID, s.originalUniqueID
+		}
+		states = append(states, state)
+	}
+	return handoff.Save(states)
+}
+
+// resumedIDs returns the entity runtime and unique IDs the client knew the player by before the handoff the
+// session was resumed from. If the session was not resumed from a handoff, or the IDs were not stored, false is
+// returned.
+func (s *Session) resumedIDs() (runtimeID uint64, uniqueID int64, ok bool) {
+	state, ok := s.handoff.Load().(HandoffState)
+	if !ok || state.RuntimeID == 0 {
+		return 0, 0, false
+	}
+	return state.RuntimeID, state.UniqueID, true
+}
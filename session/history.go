@@ -0,0 +1,40 @@
+package session
+
+import (
+	"time"
+
+	"github.com/paroxity/portal/server"
+)
+
+// transferHistorySize is the number of most recent transfers kept by a session.
+const transferHistorySize = 5
+
+// TransferRecord is a transfer of a session from one server to another.
+type TransferRecord struct {
+	// Time is the time at which the session spawned on the server it was transferred to.
+	Time time.Time
+	// From and To are the names of the servers the session was transferred from and to.
+	From, To string
+}
+
+// TransferHistory returns the most recent transfers of the session, from newest to oldest. Transfers that failed
+// are not included.
+func (s *Session) TransferHistory() []TransferRecord {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	history := make([]TransferRecord, len(s.history))
+	for i, r := range s.history {
+		history[len(history)-1-i] = r
+	}
+	return history
+}
+
+// recordTransfer records a transfer of the session from the server from to the server to in its history.
+func (s *Session) recordTransfer(from, to *server.Server) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if len(s.history) == transferHistorySize {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+	s.history = append(s.history, TransferRecord{Time: s.env.Clock.Now(), From: from.Name(), To: to.Name()})
+}
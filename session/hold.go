@@ -0,0 +1,63 @@
+package session
+
+import (
+	"time"
+
+	"github.com/paroxity/portal/server"
+)
+
+// SetSpawnHold sets the maximum time the session is held on a loading screen after being transferred, until the
+// destination server releases it using Release. This allows servers to finish loading the data of a player
+// before it is able to play. If the duration is zero, which is the default, sessions are not held.
+func (s *Session) SetSpawnHold(d time.Duration) {
+	s.spawnHold.Store(d)
+}
+
+// Release releases the session from the loading screen it is held on during a transfer. If it is called during
+// a transfer before the session is held, the session is not held at all. Release has no effect if the session
+// is not being transferred or if spawn holding is disabled.
+func (s *Session) Release() {
+	s.releaseMu.Lock()
+	defer s.releaseMu.Unlock()
+	if s.release != nil {
+		close(s.release)
+		s.release = nil
+	}
+}
+
+// prepareHold prepares the session to be held during a transfer. It is called before dialing the destination
+// server, so that a release sent by the server before the session is held is not missed.
+func (s *Session) prepareHold() {
+	s.releaseMu.Lock()
+	defer s.releaseMu.Unlock()
+	if s.spawnHold.Load() > 0 {
+		s.release = make(chan struct{})
+	}
+}
+
+// hold holds the session until it is released by the server passed, the hold duration passes or the session is
+// closed.
+func (s *Session) hold(srv *server.Server) {
+	d := s.spawnHold.Load()
+	if d <= 0 {
+		return
+	}
+	s.releaseMu.Lock()
+	release := s.release
+	s.releaseMu.Unlock()
+	if release == nil {
+		// The server released the session before it was held.
+		return
+	}
+
+	expired := make(chan struct{})
+	stop := s.env.Clock.AfterFunc(d, func() { close(expired) })
+	defer stop()
+	select {
+	case <-release:
+	case <-expired:
+		s.log.Debugf("%s was not released by %s within %v, releasing them", s.identity.DisplayName, srv.Name(), d)
+		s.Release()
+	case <-s.ctx.Done():
+	}
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/paroxity/portal/event"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// KickPolicy is the policy applied when the server of a session disconnects the player with a message.
+type KickPolicy string
+
+const (
+	// KickForward shows the message of the server to the player and disconnects them from the proxy.
+	KickForward KickPolicy = "forward"
+	// KickFallback handles the kick like the server closing the connection unexpectedly, so that handlers such
+	// as the FallbackHandler may send the player to another server. If the player is not sent to another
+	// server, the message of the server is shown to them.
+	KickFallback KickPolicy = "fallback"
+	// KickMessage shows the kick message set using SetKickPolicy to the player instead of the message of the
+	// server, and disconnects them from the proxy.
+	KickMessage KickPolicy = "message"
+)
+
+var (
+	// KickAction is the key of the policy applied to a kick in the context passed to HandleServerKick. Handlers
+	// may store a different policy under this key to decide how the kick is handled.
+	KickAction = event.NewKey[KickPolicy]("kick action")
+	// KickReason is the key of the message shown to the player in the context passed to HandleServerKick.
+	// Handlers may store a different message under this key to change it.
+	KickReason = event.NewKey[string]("kick reason")
+)
+
+// SetKickPolicy sets the policy applied when the server of the session disconnects the player with a message.
+// The message passed is shown if the policy is KickMessage. Any "{server}" in it is replaced with the name of
+// the server and any "{reason}" with the message of the server. The default policy is KickFallback.
+func (s *Session) SetKickPolicy(policy KickPolicy, message string) {
+	s.kickPolicy.Store(string(policy))
+	s.kickMessage.Store(message)
+}
+
+// handleServerError handles the error returned when reading from the connection of the server of the session.
+// It returns true if the session was closed as a result.
+func (s *Session) handleServerError(err error) bool {
+	disconnect, kicked := errors.Unwrap(err).(minecraft.DisconnectError)
+	message := ""
+	if kicked {
+		srv := s.Server()
+		message = disconnect.Error()
+		reason := message
+		if r, ok := s.kickRewriter.Load().(*KickRewriter); ok && r != nil {
+			reason = r.Rewrite(srv.Name(), reason)
+		}
+
+		policy := KickPolicy(s.kickPolicy.Load())
+		if policy == "" {
+			policy = KickFallback
+		}
+		ctx := event.C()
+		event.Store(ctx, KickAction, policy)
+		if policy == KickMessage {
+			event.Store(ctx, KickReason, strings.NewReplacer("{server}", srv.Name(), "{reason}", reason).Replace(s.kickMessage.Load()))
+		} else {
+			event.Store(ctx, KickReason, reason)
+		}
+		s.handler().HandleServerKick(ctx, srv, message)
+		if ctx.Cancelled() {
+			return false
+		}
+		policy, _ = event.Load(ctx, KickAction)
+		message, _ = event.Load(ctx, KickReason)
+		s.log.Debugf("%s was kicked from %s: %s", s.identity.DisplayName, srv.Name(), disconnect.Error())
+		if policy != KickFallback {
+			s.Disconnect(message)
+			return true
+		}
+	}
+
+	ctx := event.C()
+	s.handler().HandleServerDisconnect(ctx, err)
+
+	closed := false
+	ctx.Continue(func() {
+		closed = true
+		if kicked {
+			s.DisconnectWithReason(message, CloseKicked)
+			return
+		}
+		s.CloseWithReason(CloseServerLost)
+	})
+	return closed
+}
+
+// KickRewrite is a rule of a KickRewriter, which replaces the kick messages matching its pattern.
+type KickRewrite struct {
+	// Pattern is the pattern the message of the server must match.
+	Pattern *regexp.Regexp
+	// Template is the message shown instead. It may refer to submatches of the pattern as in
+	// regexp.Regexp.Expand, such as $1 or ${name}.
+	Template string
+}
+
+// KickRewriter rewrites the messages of players kicked by their server, so that for example internal errors
+// of servers are not shown to players verbatim and every kick screen carries the same branding.
+type KickRewriter struct {
+	rewrites []KickRewrite
+	brand    string
+}
+
+// NewKickRewriter returns a KickRewriter applying the first of the rewrites passed that matches a message. The
+// brand passed is applied to every message after it is rewritten. Any "{message}" in it is replaced with the
+// message and any "{server}" with the name of the server. If the brand is empty, messages are not branded.
+func NewKickRewriter(rewrites []KickRewrite, brand string) *KickRewriter {
+	return &KickRewriter{rewrites: rewrites, brand: brand}
+}
+
+// Rewrite returns the message passed, sent by the server with the name passed, after rewriting and branding it.
+func (r *KickRewriter) Rewrite(server, message string) string {
+	for _, rw := range r.rewrites {
+		if m := rw.Pattern.FindStringSubmatchIndex(message); m != nil {
+			message = string(rw.Pattern.ExpandString(nil, rw.Template, message, m))
+			break
+		}
+	}
+	if r.brand == "" {
+		return message
+	}
+	return strings.NewReplacer("{server}", server, "{message}", message).Replace(r.brand)
+}
+
+// SetKickRewriter sets the KickRewriter used to rewrite the messages of the server when it kicks the player. If
+// nil, messages are shown as sent by the server.
+func (s *Session) SetKickRewriter(r *KickRewriter) {
+	s.kickRewriter.Store(r)
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"math"
+	"sort"
+
+	"github.com/paroxity/portal/server"
+)
+
+// LatencyLoadBalancer is a load balancer that prefers the servers with the lowest latency to the proxy, as
+// measured by the health checker of the servers. The latency of a server is weighed against its player count,
+// so that players are still spread over servers that are roughly as close as each other. Servers of which the
+// latency has not been measured yet are only considered after all other servers.
+type LatencyLoadBalancer struct {
+	registry     *server.Registry
+	playerWeight float64
+}
+
+// NewLatencyLoadBalancer creates a LatencyLoadBalancer which finds servers in the registry passed. The player
+// weight is the number of milliseconds of latency each player on a server is worth, so that for a weight of 2,
+// a server with 10 players and a latency of 5ms is preferred over an empty server with a latency of 30ms.
+func NewLatencyLoadBalancer(registry *server.Registry, playerWeight float64) *LatencyLoadBalancer {
+	return &LatencyLoadBalancer{registry: registry, playerWeight: playerWeight}
+}
+
+// FindServer ...
+func (b *LatencyLoadBalancer) FindServer(s *Session) *server.Server {
+	return b.FindServerExcluding(s)
+}
+
+// FindServerExcluding ...
+func (b *LatencyLoadBalancer) FindServerExcluding(s *Session, exclude ...*server.Server) *server.Server {
+	if candidates := b.Rank(s, exclude...); len(candidates) > 0 {
+		return candidates[0].Server
+	}
+	return nil
+}
+
+// Rank ...
+func (b *LatencyLoadBalancer) Rank(_ *Session, exclude ...*server.Server) []Candidate {
+	servers := server.Rank(b.registry.Servers(), func(srv *server.Server) bool {
+		return srv.Healthy() && !excluded(srv, exclude)
+	})
+	candidates := make([]Candidate, 0, len(servers))
+	for _, srv := range servers {
+		candidates = append(candidates, Candidate{Server: srv, Score: b.score(srv)})
+	}
+	// The servers are already ordered by their capacity, so a stable sort keeps servers below their soft cap
+	// ahead of servers with the same score that have reached it.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score < candidates[j].Score
+	})
+	return candidates
+}
+
+// score returns the score of the server passed, which is its latency in milliseconds plus the weight of its
+// players. Servers of which the latency is unknown score infinitely high.
+func (b *LatencyLoadBalancer) score(srv *server.Server) float64 {
+	latency := srv.Latency()
+	if latency == 0 {
+		return math.Inf(1)
+	}
+	return float64(latency.Microseconds())/1000 + b.playerWeight*float64(srv.PlayerCount())
+}
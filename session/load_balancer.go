@@ -11,7 +11,42 @@ type LoadBalancer interface {
 	FindServer(session *Session) *server.Server
 }
 
-// SplitLoadBalancer attempts to split players evenly across all the servers.
+// ExcludingLoadBalancer is a LoadBalancer that is also able to find a server while excluding specific servers,
+// such as the server a player is leaving or a server that just failed for them.
+type ExcludingLoadBalancer interface {
+	LoadBalancer
+	// FindServerExcluding finds a server for the session to connect to that is not one of the servers passed.
+	// If no such server is found, nil is returned.
+	FindServerExcluding(session *Session, exclude ...*server.Server) *server.Server
+}
+
+// FindServerExcluding finds a server for the session passed using the load balancer passed, which is not one of
+// the excluded servers. If the load balancer does not implement ExcludingLoadBalancer, the server it finds is
+// only returned if it is not excluded.
+func FindServerExcluding(loadBalancer LoadBalancer, session *Session, exclude ...*server.Server) *server.Server {
+	if b, ok := loadBalancer.(ExcludingLoadBalancer); ok {
+		return b.FindServerExcluding(session, exclude...)
+	}
+	srv := loadBalancer.FindServer(session)
+	if excluded(srv, exclude) {
+		return nil
+	}
+	return srv
+}
+
+// excluded returns if the server passed is one of the excluded servers.
+func excluded(srv *server.Server, exclude []*server.Server) bool {
+	for _, e := range exclude {
+		if srv == e {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitLoadBalancer attempts to split players evenly across all the servers. Servers that are full are skipped,
+// and servers below their soft cap are preferred, so that players overflow to the next server instead of
+// overloading a single one.
 type SplitLoadBalancer struct {
 	registry *server.Registry
 }
@@ -22,11 +57,20 @@ func NewSplitLoadBalancer(registry *server.Registry) *SplitLoadBalancer {
 }
 
 // FindServer ...
-func (b *SplitLoadBalancer) FindServer(*Session) (srv *server.Server) {
-	for _, s := range b.registry.Servers() {
-		if srv == nil || srv.PlayerCount() > s.PlayerCount() {
-			srv = s
-		}
-	}
-	return srv
+func (b *SplitLoadBalancer) FindServer(session *Session) *server.Server {
+	return b.FindServerExcluding(session)
+}
+
+// FindServerExcluding ...
+func (b *SplitLoadBalancer) FindServerExcluding(_ *Session, exclude ...*server.Server) *server.Server {
+	return server.Least(b.registry.Servers(), func(srv *server.Server) bool {
+		return !excluded(srv, exclude)
+	})
+}
+
+// Rank ...
+func (b *SplitLoadBalancer) Rank(_ *Session, exclude ...*server.Server) []Candidate {
+	return rankServers(server.Rank(b.registry.Servers(), func(srv *server.Server) bool {
+		return !excluded(srv, exclude)
+	}))
 }
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"github.com/paroxity/portal/server"
+)
+
+// MetadataProvider provides the server metadata that the servers sessions are sent to by a MetadataLoadBalancer
+// must have, such as the region of a player.
+type MetadataProvider interface {
+	// Metadata returns the metadata the server of the session passed must have. If nil is returned, the
+	// session is balanced as usual.
+	Metadata(s *Session) map[string]string
+}
+
+// MetadataFunc is a function that implements MetadataProvider.
+type MetadataFunc func(s *Session) map[string]string
+
+// Metadata ...
+func (f MetadataFunc) Metadata(s *Session) map[string]string {
+	return f(s)
+}
+
+// MetadataLoadBalancer is a load balancer that sends sessions to the server with the fewest players out of the
+// servers of which the metadata matches the metadata provided for the session, so that for example players are
+// sent to a server in their region. Sessions without metadata, or for which no matching server is available,
+// are passed on to the wrapped load balancer.
+type MetadataLoadBalancer struct {
+	lb       LoadBalancer
+	registry *server.Registry
+	metadata MetadataProvider
+}
+
+// NewMetadataLoadBalancer creates a MetadataLoadBalancer wrapping around the load balancer passed, which finds
+// servers in the registry passed using the metadata provided by the provider passed.
+func NewMetadataLoadBalancer(lb LoadBalancer, registry *server.Registry, metadata MetadataProvider) *MetadataLoadBalancer {
+	return &MetadataLoadBalancer{lb: lb, registry: registry, metadata: metadata}
+}
+
+// FindServer ...
+func (b *MetadataLoadBalancer) FindServer(s *Session) *server.Server {
+	return b.FindServerExcluding(s)
+}
+
+// FindServerExcluding ...
+func (b *MetadataLoadBalancer) FindServerExcluding(s *Session, exclude ...*server.Server) *server.Server {
+	if match := b.metadata.Metadata(s); len(match) > 0 {
+		srv := server.Least(b.registry.Servers(), func(srv *server.Server) bool {
+			return srv.Healthy() && srv.MatchMetadata(match) && !excluded(srv, exclude)
+		})
+		if srv != nil {
+			return srv
+		}
+	}
+	return FindServerExcluding(b.lb, s, exclude...)
+}
+
+// Rank ...
+func (b *MetadataLoadBalancer) Rank(s *Session, exclude ...*server.Server) []Candidate {
+	var candidates []Candidate
+	if match := b.metadata.Metadata(s); len(match) > 0 {
+		candidates = rankServers(server.Rank(b.registry.Servers(), func(srv *server.Server) bool {
+			return srv.Healthy() && srv.MatchMetadata(match) && !excluded(srv, exclude)
+		}))
+	}
+	// The servers of the wrapped load balancer are considered after the servers with matching metadata.
+	for _, c := range rank(b.lb, s, exclude...) {
+		if !containsCandidate(candidates, c.Server) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
+
+// containsCandidate returns if the candidates passed contain the server passed.
+func containsCandidate(candidates []Candidate, srv *server.Server) bool {
+	for _, c := range candidates {
+		if c.Server == srv {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,10 @@
 package session
 
 import (
-	"errors"
 	"sync"
+	"time"
 
 	"github.com/paroxity/portal/event"
-	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 )
@@ -13,115 +12,43 @@ import (
 // handlePackets handles the packets sent between the client and the server. Processes such as runtime
 // translations are also handled here.
 func handlePackets(s *Session) {
-	go func() {
-		defer s.Close()
-		for {
-			pk, err := s.Conn().ReadPacket()
-			if err != nil {
-				s.log.Errorf("failed to read packet from connection: %v", err)
-				return
-			}
-			s.translatePacket(pk)
-
-			switch pk := pk.(type) {
-			case *packet.BookEdit:
-				pk.XUID = ""
-			case *packet.PlayerAction:
-				if pk.ActionType == protocol.PlayerActionDimensionChangeDone {
-					if s.transferring.Load() {
-						s.serverMu.Lock()
-						gameData := s.tempServerConn.GameData()
-						s.changeDimension(packet.DimensionOverworld, gameData.PlayerPosition)
-
-						var w sync.WaitGroup
-						w.Add(2)
-						go func() {
-							s.clearEntities()
-							s.clearEffects()
-							w.Done()
-						}()
-						go func() {
-							s.clearPlayerList()
-							s.clearBossBars()
-							s.clearScoreboard()
-							w.Done()
-						}()
-
-						_ = s.conn.WritePacket(&packet.MovePlayer{
-							EntityRuntimeID: s.originalRuntimeID,
-							Position:        gameData.PlayerPosition,
-							Pitch:           gameData.Pitch,
-							Yaw:             gameData.Yaw,
-							Mode:            packet.MoveModeReset,
-						})
-
-						_ = s.conn.WritePacket(&packet.LevelEvent{EventType: packet.LevelEventStopRaining, EventData: 10000})
-						_ = s.conn.WritePacket(&packet.LevelEvent{EventType: packet.LevelEventStopThunderstorm})
-						_ = s.conn.WritePacket(&packet.SetDifficulty{Difficulty: uint32(gameData.Difficulty)})
-						_ = s.conn.WritePacket(&packet.GameRulesChanged{GameRules: gameData.GameRules})
-						_ = s.conn.WritePacket(&packet.SetPlayerGameType{GameType: gameData.PlayerGameMode})
-
-						w.Wait()
-
-						_ = s.serverConn.Close()
-
-						s.serverConn = s.tempServerConn
-						s.tempServerConn = nil
-
-						s.handler().HandleChangeConn(s.serverConn)
-						s.serverMu.Unlock()
-
-						s.updateTranslatorData(gameData)
-
-						s.transferring.Store(false)
-						s.postTransfer.Store(true)
-
-						s.log.Infof("%s finished transferring to %s", s.Conn().IdentityData().DisplayName, s.Server().Name())
-						continue
-					} else if s.postTransfer.CAS(true, false) {
-						continue
-					}
-				}
-			case *packet.Text:
-				pk.XUID = ""
-			}
-
-			if s.Transferring() {
-				continue
-			}
-
-			ctx := event.C()
-			s.handler().HandleServerBoundPacket(ctx, pk)
-
-			ctx.Continue(func() {
-				_ = s.ServerConn().WritePacket(pk)
-			})
-		}
-	}()
+	go s.handleClientPackets()
 
 	go func() {
+		r := &packetReader{s: s, clientBound: true}
 		for {
 			conn := s.ServerConn()
-			pk, err := conn.ReadPacket()
+			pk, err := r.read(conn)
 			if err != nil {
+				if s.closed.Load() {
+					return
+				}
 				if conn != s.ServerConn() {
 					continue
 				}
-				ctx := event.C()
-				s.handler().HandleServerDisconnect(ctx, err)
-
-				c := false
-				ctx.Continue(func() {
-					c = true
-					if disconnect, ok := errors.Unwrap(err).(minecraft.DisconnectError); ok {
-						s.log.Debugf(disconnect.Error())
-						_ = s.conn.WritePacket(&packet.Disconnect{Message: disconnect.Error()})
+				if s.reconnecting.Load() {
+					// The server may close the old connection of a session reconnecting to it, so we wait for
+					// the new connection to replace it. If reconnecting fails, the error is handled as usual.
+					for conn == s.ServerConn() && s.reconnecting.Load() && !s.closed.Load() {
+						time.Sleep(time.Millisecond * 50)
 					}
-					s.Close()
-				})
-				if c {
+					continue
+				}
+				if s.handleServerError(err) {
 					return
 				}
+				// A handler transferred the session to another server, so we wait for the connection to be
+				// replaced before reading again, rather than reading from the closed connection.
+				for conn == s.ServerConn() && s.Transferring() && !s.closed.Load() {
+					time.Sleep(time.Millisecond * 50)
+				}
+				continue
+			}
+			if pk == nil {
+				continue
+			}
+			r.record(pk.ID())
+			if !s.Server().Filter().ClientBound.Allowed(pk.ID()) {
 				continue
 			}
 			s.translatePacket(pk)
@@ -135,12 +62,37 @@ func handlePackets(s *Session) {
 				s.entities.Add(pk.EntityUniqueID)
 			case *packet.AddPlayer:
 				s.entities.Add(pk.AbilityData.EntityUniqueID)
+			case *packet.LevelChunk:
+				if pk.CacheEnabled {
+					s.addBlobHashes(pk.BlobHashes...)
+				}
+			case *packet.ChangeDimension:
+				s.dimension.Store(pk.Dimension)
+				// The client never reports blobs of the previous dimension again, so they are forgotten.
+				s.blobMu.Lock()
+				s.blobHashes.clear()
+				s.blobMu.Unlock()
+			case *packet.DimensionData:
+				s.addDimensionDefinitions(pk.Definitions)
+			case *packet.SubChunk:
+				if !s.Transferring() {
+					s.dimension.Store(pk.Dimension)
+				}
+				if pk.CacheEnabled {
+					hashes := make([]uint64, 0, len(pk.SubChunkEntries))
+					for _, entry := range pk.SubChunkEntries {
+						hashes = append(hashes, entry.BlobHash)
+					}
+					s.addBlobHashes(hashes...)
+				}
 			case *packet.BossEvent:
 				if pk.EventType == packet.BossEventShow {
 					s.bossBars.Add(pk.BossEntityUniqueID)
 				} else if pk.EventType == packet.BossEventHide {
 					s.bossBars.Remove(pk.BossEntityUniqueID)
 				}
+			case *packet.PlaySound, *packet.StopSound, *packet.LevelSoundEvent:
+				s.sounds.handle(pk)
 			case *packet.MobEffect:
 				if pk.Operation == packet.MobEffectAdd {
 					s.effects.Add(pk.EffectType)
@@ -164,6 +116,8 @@ func handlePackets(s *Session) {
 			case *packet.SetDisplayObjective:
 				s.scoreboards.Add(pk.ObjectiveName)
 			}
+			s.trackEntityAge(pk)
+			s.rewriteDisplayNames(pk)
 
 			ctx := event.C()
 			s.handler().HandleClientBoundPacket(ctx, pk)
@@ -174,3 +128,160 @@ func handlePackets(s *Session) {
 		}
 	}()
 }
+
+// handleClientPackets handles the packets sent by the client of the session until its connection is closed.
+func (s *Session) handleClientPackets() {
+	conn := s.Conn()
+	r := &packetReader{s: s}
+	for {
+		pk, err := r.read(conn)
+		if err != nil {
+			if s.closed.Load() {
+				return
+			}
+			s.log.Errorf("failed to read packet from connection: %v", err)
+			ctx := event.C()
+			s.handler().HandleClientDisconnect(ctx, err)
+			if ctx.Cancelled() {
+				s.detached.Store(true)
+				s.log.Infof("%s lost their connection, keeping their session open", s.identity.DisplayName)
+				return
+			}
+			s.CloseWithReason(CloseClientQuit)
+			return
+		}
+		if pk == nil {
+			continue
+		}
+		r.record(pk.ID())
+		s.translatePacket(pk)
+
+		switch pk := pk.(type) {
+		case *packet.PlayerAuthInput:
+			s.position.Store(pk.Position)
+		case *packet.MovePlayer:
+			s.position.Store(pk.Position)
+		case *packet.BookEdit:
+			pk.XUID = ""
+		case *packet.EmoteList:
+			s.emoteList.Store(pk)
+		case *packet.RequestChunkRadius:
+			s.chunkRadius.Store(pk)
+		case *packet.ClientCacheBlobStatus:
+			if !s.filterBlobStatus(pk) {
+				continue
+			}
+		case *packet.PlayerAction:
+			if pk.ActionType == protocol.PlayerActionDimensionChangeDone {
+				if s.advanceTransfer(TransferChangingDimension, TransferSwapping) {
+					s.serverMu.RLock()
+					conn := s.tempServerConn
+					s.serverMu.RUnlock()
+					gameData := conn.GameData()
+					s.changeDimension(gameData.Dimension, gameData.PlayerPosition)
+
+					var w sync.WaitGroup
+					w.Add(2)
+					go func() {
+						s.clearEntities()
+						s.clearEffects()
+						w.Done()
+					}()
+					go func() {
+						s.clearPlayerList()
+						s.clearBossBars()
+						s.clearScoreboard()
+						s.clearSounds()
+						w.Done()
+					}()
+
+					_ = s.clientConn().WritePacket(&packet.MovePlayer{
+						EntityRuntimeID: s.originalRuntimeID,
+						Position:        gameData.PlayerPosition,
+						Pitch:           gameData.Pitch,
+						Yaw:             gameData.Yaw,
+						Mode:            packet.MoveModeReset,
+					})
+
+					_ = s.clientConn().WritePacket(&packet.LevelEvent{EventType: packet.LevelEventStopRaining, EventData: 10000})
+					_ = s.clientConn().WritePacket(&packet.LevelEvent{EventType: packet.LevelEventStopThunderstorm})
+					_ = s.clientConn().WritePacket(&packet.SetDifficulty{Difficulty: uint32(gameData.Difficulty)})
+					_ = s.clientConn().WritePacket(&packet.GameRulesChanged{GameRules: gameData.GameRules})
+					_ = s.clientConn().WritePacket(&packet.SetPlayerGameType{GameType: gameData.PlayerGameMode})
+
+					w.Wait()
+
+					// The connections are swapped before the previous connection is closed, so that ServerConn
+					// never returns a closed connection.
+					s.serverMu.Lock()
+					previous := s.serverConn
+					s.serverConn = conn
+					s.tempServerConn = nil
+					s.blobMu.Lock()
+					s.blobHashes.clear()
+					s.blobMu.Unlock()
+					s.handler().HandleChangeConn(conn)
+					s.serverMu.Unlock()
+					_ = previous.Close()
+
+					s.updateTranslatorData(gameData)
+					s.sendEmoteList()
+					s.sendChunkRadius()
+
+					s.reconnecting.Store(false)
+					s.postTransfer.Store(true)
+					s.endTransfer()
+					s.flushBuffer()
+
+					s.log.Infof("%s finished transferring to %s", s.Conn().IdentityData().DisplayName, s.Server().Name())
+					continue
+				} else if s.postTransfer.CAS(true, false) {
+					continue
+				}
+			}
+		case *packet.Text:
+			pk.XUID = ""
+		}
+
+		if s.bufferPacket(pk) {
+			continue
+		}
+		if !s.Server().Filter().ServerBound.Allowed(pk.ID()) {
+			continue
+		}
+
+		ctx := event.C()
+		s.handler().HandleServerBoundPacket(ctx, pk)
+
+		ctx.Continue(func() {
+			s.writeServerPacket(pk)
+		})
+	}
+}
+
+// addBlobHashes records the hashes passed as hashes of cache blobs sent by the current server.
+func (s *Session) addBlobHashes(hashes ...uint64) {
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+	s.blobHashes.add(hashes...)
+}
+
+// filterBlobStatus removes all hashes from a ClientCacheBlobStatus packet that were not sent by the server the
+// session is currently connected to. This prevents the client from reporting blobs sent by a previous server
+// after being transferred. If no hashes remain, false is returned and the packet should not be forwarded.
+func (s *Session) filterBlobStatus(pk *packet.ClientCacheBlobStatus) bool {
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+	filter := func(hashes []uint64) []uint64 {
+		n := 0
+		for _, hash := range hashes {
+			if s.blobHashes.has(hash) {
+				hashes[n] = hash
+				n++
+			}
+		}
+		return hashes[:n]
+	}
+	pk.MissHashes, pk.HitHashes = filter(pk.MissHashes), filter(pk.HitHashes)
+	return len(pk.MissHashes) != 0 || len(pk.HitHashes) != 0
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/paroxity/portal/server"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// PalettePolicy is the policy applied when the server a session is transferred to has different custom blocks or
+// items than the client received when it joined. The client only receives its block and item palettes once, so
+// after such a transfer it renders the wrong blocks and items.
+type PalettePolicy string
+
+const (
+	// PaletteIgnore transfers the session anyway, logging the mismatch as a warning.
+	PaletteIgnore PalettePolicy = "ignore"
+	// PaletteReject fails the transfer with an error wrapping ErrPaletteMismatch, so that the session stays on its
+	// current server.
+	PaletteReject PalettePolicy = "reject"
+	// PaletteReconnect transfers the session with a full reconnect instead, so that the client receives the
+	// palettes of the server. The Env of the session must have Rejoins set, or the transfer is rejected.
+	PaletteReconnect PalettePolicy = "reconnect"
+)
+
+var (
+	// ErrPaletteMismatch is wrapped by the error of a transfer rejected because the palettes of the destination
+	// server differ from those of the client.
+	ErrPaletteMismatch = errors.New("palette mismatch")
+	// errPaletteReconnect is returned by checkPalette if the session should be transferred with a full reconnect.
+	errPaletteReconnect = errors.New("palette mismatch, reconnecting")
+)
+
+// SetPalettePolicy sets the policy applied when the session is transferred to a server of which the custom blocks
+// or items differ from those the client received when it joined. The default policy is PaletteIgnore.
+func (s *Session) SetPalettePolicy(policy PalettePolicy) {
+	s.palettePolicy.Store(string(policy))
+}
+
+// PaletteMismatch compares the custom blocks and items of the two game data passed, and returns a description of
+// the first difference found, or an empty string if the palettes match. Biome definitions are not part of the
+// game data, as servers send them once the player spawned, so they are not compared.
+func PaletteMismatch(client, server minecraft.GameData) string {
+	if client.UseBlockNetworkIDHashes != server.UseBlockNetworkIDHashes {
+		return fmt.Sprintf("block network ID hashes are %v on the server, but %v on the client", server.UseBlockNetworkIDHashes, client.UseBlockNetworkIDHashes)
+	}
+	if client.ServerBlockStateChecksum != 0 && server.ServerBlockStateChecksum != 0 && client.ServerBlockStateChecksum != server.ServerBlockStateChecksum {
+		return fmt.Sprintf("block state checksum is %d on the server, but %d on the client", server.ServerBlockStateChecksum, client.ServerBlockStateChecksum)
+	}
+	if len(client.CustomBlocks) != len(server.CustomBlocks) {
+		return fmt.Sprintf("server has %d custom blocks, but the client has %d", len(server.CustomBlocks), len(client.CustomBlocks))
+	}
+	for i, b := range server.CustomBlocks {
+		if c := client.CustomBlocks[i]; c.Name != b.Name || !reflect.DeepEqual(c.Properties, b.Properties) {
+			return fmt.Sprintf("custom block %d is %s on the server, but %s on the client", i, b.Name, c.Name)
+		}
+	}
+	items := make(map[string]int16, len(client.Items))
+	for _, item := range client.Items {
+		items[item.Name] = item.RuntimeID
+	}
+	for _, item := range server.Items {
+		id, ok := items[item.Name]
+		if !ok {
+			return fmt.Sprintf("item %s of the server is unknown to the client", item.Name)
+		}
+		if id != item.RuntimeID {
+			return fmt.Sprintf("item %s has runtime ID %d on the server, but %d on the client", item.Name, item.RuntimeID, id)
+		}
+	}
+	if len(server.Items) != len(client.Items) {
+		return fmt.Sprintf("server has %d items, but the client has %d", len(server.Items), len(client.Items))
+	}
+	return ""
+}
+
+// checkPalette checks if the palettes of the connection to the server passed match those the client received,
+// and returns an error wrapping ErrPaletteMismatch if they do not and the palette policy of the session is
+// PaletteReject, or errPaletteReconnect if the session should be transferred with a full reconnect.
+func (s *Session) checkPalette(srv *server.Server, conn *minecraft.Conn) error {
+	mismatch := PaletteMismatch(s.clientConn().GameData(), conn.GameData())
+	if mismatch == "" {
+		return nil
+	}
+	switch PalettePolicy(s.palettePolicy.Load()) {
+	case PaletteReject:
+		return fmt.Errorf("%w: %s", ErrPaletteMismatch, mismatch)
+	case PaletteReconnect:
+		if s.env.Rejoins == nil {
+			return fmt.Errorf("%w: %s, and %v", ErrPaletteMismatch, mismatch, errRejoinsDisabled)
+		}
+		s.log.Infof("palettes of %s do not match those of the client of %s: %s", srv.Name(), s.identity.DisplayName, mismatch)
+		return errPaletteReconnect
+	}
+	s.log.Errorf("palettes of %s do not match those of the client of %s, transferring anyway: %s", srv.Name(), s.identity.DisplayName, mismatch)
+	return nil
+}
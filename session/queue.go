@@ -0,0 +1,262 @@
+package session
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/server"
+)
+
+// PriorityProvider provides the priority tier of sessions in a Queue. Sessions with a higher priority are let
+// through the queue before sessions with a lower priority.
+type PriorityProvider interface {
+	// Priority returns the priority tier of the session passed.
+	Priority(s *Session) int
+}
+
+// NopPriorityProvider is a PriorityProvider that gives every session the same priority, so that sessions are
+// let through the queue in the order they joined.
+type NopPriorityProvider struct{}
+
+// Priority ...
+func (NopPriorityProvider) Priority(*Session) int {
+	return 0
+}
+
+// QueueEntry is an entry of a session waiting in a Queue.
+type QueueEntry struct {
+	// Session is the session waiting in the queue.
+	Session *Session
+	// Priority is the priority tier of the session.
+	Priority int
+	// Joined is the time at which the session joined the queue.
+	Joined time.Time
+	// Position is the position of the session in the queue, starting at 1.
+	Position int
+
+	srv chan *server.Server
+}
+
+// Queue is a network-wide login queue. It wraps around a LoadBalancer and makes sessions wait in the queue
+// when the load balancer does not return a server for them, for example because all servers are full or
+// because no servers have registered yet after a restart. Sessions are let through ordered by their priority
+// tier and, within the same tier, the time they joined the queue.
+// Queue implements the LoadBalancer interface itself, so it can be used as the load balancer of the proxy.
+type Queue struct {
+	lb       LoadBalancer
+	priority PriorityProvider
+
+	mu      sync.Mutex
+	entries []*QueueEntry
+	update  func(e QueueEntry, total int)
+}
+
+// NewQueue creates a new Queue wrapping around the load balancer passed. The priority provider is used to find
+// the priority tier of sessions joining the queue. Every interval, the queue attempts to find a server for the
+// sessions at the front of the queue.
+func NewQueue(lb LoadBalancer, priority PriorityProvider, interval time.Duration) *Queue {
+	if priority == nil {
+		priority = NopPriorityProvider{}
+	}
+	q := &Queue{lb: lb, priority: priority}
+	go q.run(interval)
+	return q
+}
+
+// OnUpdate sets a function that is called for every session in the queue with its current position every time
+// the queue is processed. It may be used to show players their position in the queue.
+func (q *Queue) OnUpdate(f func(e QueueEntry, total int)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.update = f
+}
+
+// FindServer returns a server for the session passed. If the queue is empty and the wrapped load balancer
+// returns a server, it is returned immediately. Otherwise, the session joins the queue and FindServer blocks
+// until it reaches the front of the queue and a server is found, or until the session is closed, in which case
+// nil is returned.
+func (q *Queue) FindServer(s *Session) *server.Server {
+	// The wrapped load balancer is called without holding the mutex, so that a slow load balancer does not
+	// block other sessions joining or leaving the queue.
+	if q.Len() == 0 {
+		if srv := q.lb.FindServer(s); srv != nil {
+			return srv
+		}
+	}
+	e := &QueueEntry{Session: s, Priority: q.priority.Priority(s), Joined: time.Now(), srv: make(chan *server.Server, 1)}
+	q.mu.Lock()
+	q.entries = append(q.entries, e)
+	q.sort()
+	position := e.Position
+	q.mu.Unlock()
+
+	s.log.Infof("%s joined the login queue at position %d", s.IdentityData().DisplayName, position)
+	select {
+	case srv := <-e.srv:
+		if srv != nil {
+			// The slot reserved for the session when it was let through is only released once it finished
+			// connecting, as it counts towards the player count of the server itself from then on.
+			go func() {
+				<-s.loginDone
+				srv.DecrementPlayerCount()
+			}()
+		}
+		return srv
+	case <-s.ctx.Done():
+		q.leave(e)
+		return nil
+	}
+}
+
+// leave removes the entry passed from the queue. If the entry was let through the queue at the same time, the
+// slot reserved for it is released.
+func (q *Queue) leave(e *QueueEntry) {
+	q.mu.Lock()
+	for i, other := range q.entries {
+		if other == e {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			q.sort()
+			break
+		}
+	}
+	q.mu.Unlock()
+
+	select {
+	case srv := <-e.srv:
+		if srv != nil {
+			srv.DecrementPlayerCount()
+		}
+	default:
+	}
+}
+
+// FindServerExcluding finds a server for the session passed that is not one of the excluded servers. Sessions
+// that are joining the proxy with no excluded servers are queued like in FindServer. Sessions that already
+// logged in to a server, or that exclude servers, for example because they failed to dial them, find a server
+// using the wrapped load balancer without being put back in the queue.
+func (q *Queue) FindServerExcluding(s *Session, exclude ...*server.Server) *server.Server {
+	if len(exclude) == 0 && !s.LoggedIn() {
+		return q.FindServer(s)
+	}
+	return FindServerExcluding(q.lb, s, exclude...)
+}
+
+// Entries returns all the entries currently in the queue, ordered by their position.
+func (q *Queue) Entries() []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]QueueEntry, 0, len(q.entries))
+	for _, e := range q.entries {
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+// Position returns the position of the session with the UUID passed in the queue, starting at 1. If the session
+// is not in the queue, false is returned.
+func (q *Queue) Position(id uuid.UUID) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range q.entries {
+		if e.Session.UUID() == id {
+			return e.Position, true
+		}
+	}
+	return 0, false
+}
+
+// Len returns the amount of sessions waiting in the queue.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// run processes the queue every interval.
+func (q *Queue) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		q.process()
+	}
+}
+
+// process lets through as many sessions from the front of the queue as the wrapped load balancer finds servers
+// for, removes sessions that were closed and sends position updates for the remaining sessions. A slot is
+// reserved on the server of every session let through, so that the load balancer does not hand out the same
+// slot to several sessions in one pass. The wrapped load balancer and the update function are called without
+// holding the mutex, so that they do not block sessions joining the queue while the queue is processed.
+func (q *Queue) process() {
+	q.mu.Lock()
+	entries := make([]*QueueEntry, len(q.entries))
+	copy(entries, q.entries)
+	q.mu.Unlock()
+
+	servers := make(map[*QueueEntry]*server.Server)
+	blocked := false
+	for _, e := range entries {
+		if e.Session.closed.Load() {
+			servers[e] = nil
+			continue
+		}
+		if !blocked {
+			if srv := q.lb.FindServer(e.Session); srv != nil && !srv.Full() {
+				srv.IncrementPlayerCount()
+				servers[e] = srv
+				continue
+			}
+			// Sessions further back in the queue may not skip the session at the front, so we stop trying
+			// to find servers as soon as no server with a free slot is found for a session.
+			blocked = true
+		}
+	}
+
+	q.mu.Lock()
+	remaining := q.entries[:0]
+	for _, e := range q.entries {
+		if srv, ok := servers[e]; ok {
+			e.srv <- srv
+			delete(servers, e)
+			continue
+		}
+		remaining = append(remaining, e)
+	}
+	q.entries = remaining
+	// Sessions that left the queue while the load balancer was called no longer need the slots reserved for them.
+	for _, srv := range servers {
+		if srv != nil {
+			srv.DecrementPlayerCount()
+		}
+	}
+	q.sort()
+	update := q.update
+	updates := make([]QueueEntry, 0, len(q.entries))
+	for _, e := range q.entries {
+		updates = append(updates, *e)
+	}
+	q.mu.Unlock()
+
+	if update != nil {
+		for _, e := range updates {
+			update(e, len(updates))
+		}
+	}
+}
+
+// sort sorts the entries of the queue by their priority and join time and updates their positions. The queue
+// mutex must be held when calling sort.
+func (q *Queue) sort() {
+	sort.SliceStable(q.entries, func(i, j int) bool {
+		if q.entries[i].Priority != q.entries[j].Priority {
+			return q.entries[i].Priority > q.entries[j].Priority
+		}
+		return q.entries[i].Joined.Before(q.entries[j].Joined)
+	})
+	for i, e := range q.entries {
+		e.Position = i + 1
+	}
+}
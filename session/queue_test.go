@@ -0,0 +1,105 @@
+package session_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paroxity/portal"
+	"github.com/paroxity/portal/portaltest"
+	"github.com/paroxity/portal/session"
+)
+
+// TestQueueJoin tests that a player joining while every server is full waits in the login queue, and joins once
+// a slot frees up.
+func TestQueueJoin(t *testing.T) {
+	lobby, p, q := newQueueEnv(t, 1)
+	clients := dialQueued(t, p, 1)
+	waitFor(t, func() bool { return q.Len() == 1 })
+	if _, err := lobby.Accept(time.Millisecond * 100); err == nil {
+		t.Fatalf("expected player not to join a full server")
+	}
+
+	lobby.Server().DecrementPlayerCount()
+	conn, err := lobby.Accept(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("accept lobby: %v", err)
+	}
+	defer conn.Close()
+	if err := <-clients; err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("expected queue to be empty, got %d sessions", q.Len())
+	}
+}
+
+// TestQueueCapacity tests that the queue lets through only as many players as there are free slots, even if
+// several players are waiting when a slot frees up.
+func TestQueueCapacity(t *testing.T) {
+	lobby, p, q := newQueueEnv(t, 2)
+	clients := dialQueued(t, p, 3)
+	waitFor(t, func() bool { return q.Len() == 3 })
+
+	lobby.Server().DecrementPlayerCount()
+	conn, err := lobby.Accept(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("accept lobby: %v", err)
+	}
+	defer conn.Close()
+	if err := <-clients; err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if _, err := lobby.Accept(time.Millisecond * 200); err == nil {
+		t.Fatalf("expected only one player to be let through the queue")
+	}
+	if q.Len() != 2 {
+		t.Fatalf("expected 2 sessions left in the queue, got %d", q.Len())
+	}
+	if count := lobby.Server().PlayerCount(); count != 2 {
+		t.Fatalf("expected a player count of 2, got %d", count)
+	}
+}
+
+// newQueueEnv starts a proxy with a lobby server that is full with the maximum player count passed, using a
+// Queue as its load balancer. Everything is closed when the test ends.
+func newQueueEnv(t *testing.T, maxPlayers int) (*portaltest.Server, *portaltest.Proxy, *session.Queue) {
+	t.Helper()
+	lobby, err := portaltest.NewServer("lobby")
+	if err != nil {
+		t.Fatalf("start lobby: %v", err)
+	}
+	t.Cleanup(func() { _ = lobby.Close() })
+	lobby.Server().SetCapacity(0, maxPlayers)
+	for i := 0; i < maxPlayers; i++ {
+		lobby.Server().IncrementPlayerCount()
+	}
+
+	p, err := portaltest.NewProxy(portal.Options{}, lobby)
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	q := session.NewQueue(session.NewSplitLoadBalancer(p.ServerRegistry()), nil, time.Millisecond*10)
+	p.SetLoadBalancer(q)
+	return lobby, p, q
+}
+
+// dialQueued connects the amount of clients passed to the proxy in the background. The error of every dial is
+// sent to the channel returned once the client spawned or failed to. The clients are closed when the test ends.
+func dialQueued(t *testing.T, p *portaltest.Proxy, n int) <-chan error {
+	t.Helper()
+	errs, done := make(chan error, n), make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	for i := 0; i < n; i++ {
+		name := "Steve" + string(rune('A'+i))
+		go func() {
+			conn, err := portaltest.Dial(p.Addr(), name)
+			errs <- err
+			if err == nil {
+				<-done
+				_ = conn.Close()
+			}
+		}()
+	}
+	return errs
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Rejoins issues route tokens for players transferred with a full reconnect, so that they are sent to the server
+// they were transferred to when they join again. A full reconnect sends the client to a proxy with a Transfer
+// packet, so that it joins the destination server from scratch and receives its game data, such as its palettes
+// and experiments, which is impossible with a regular transfer.
+type Rejoins struct {
+	host  string
+	port  uint16
+	ttl   time.Duration
+	store RouteTokenStore
+}
+
+// NewRejoins creates Rejoins that send clients to the address passed, which must be the address at which players
+// are able to reach the proxy, in the format of "host:port". Players that do not join again within the duration
+// passed are sent to a server by the load balancer as usual. Route tokens are stored in the store passed, or in
+// memory if nil. A store shared by multiple proxies allows players to be handed off between them.
+func NewRejoins(address string, ttl time.Duration, store RouteTokenStore) (*Rejoins, error) {
+	host, port, err := splitAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		store = NewMemoryRouteTokenStore()
+	}
+	return &Rejoins{host: host, port: port, ttl: ttl, store: store}, nil
+}
+
+// Issue issues a route token that sends the player with the key passed, as returned by RouteKey, to the server
+// with the name passed when they join within the TTL of the Rejoins.
+func (r *Rejoins) Issue(key, srv string) error {
+	return r.store.Issue(RouteToken{Key: key, Server: srv, Expires: time.Now().Add(r.ttl)})
+}
+
+// Redeem returns the name of the server the player with the key passed should be sent to, if a route token was
+// issued for them that did not yet expire. The token is removed, so that it only routes the player once.
+func (r *Rejoins) Redeem(key string) (string, bool) {
+	t, ok := r.store.Redeem(key)
+	return t.Server, ok
+}
+
+// splitAddress splits the address passed in the format of "host:port" into its host and port.
+func splitAddress(address string) (string, uint16, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q: %w", portStr, err)
+	}
+	return host, uint16(port), nil
+}
+
+// errRejoinsDisabled is returned when a session is transferred with a full reconnect while the Env of the session
+// has no Rejoins.
+var errRejoinsDisabled = errors.New("full reconnect transfers are not enabled")
+
+// TransferReconnect transfers the session to the server passed with a full reconnect: the client is sent back to
+// the proxy using a Transfer packet and the session is closed, after which the client joins the server passed
+// from scratch when it connects again. Unlike Transfer, the client receives the game data of the server, so it
+// may be used to move players to servers with different palettes or experiments. The Env of the session must
+// have Rejoins set.
+func (s *Session) TransferReconnect(srv *server.Server) (err error) {
+	if s.env.Rejoins == nil {
+		return errRejoinsDisabled
+	}
+	s.waitForLogin()
+
+	ctx := event.C()
+	event.Store(ctx, TransferServer, srv)
+	s.handler().HandleTransfer(ctx, srv)
+	if dst, ok := event.Load(ctx, TransferServer); ok && dst != nil {
+		srv = dst
+	}
+	ctx.Continue(func() {
+		err = s.rejoin(srv)
+	})
+	return err
+}
+
+// TransferProxy hands the session off to the proxy at the address passed, in the format of "host:port", which
+// sends the player to the server with the name passed when they join it. The route token of the player is issued
+// in the store of the Rejoins of the Env of the session, which must be shared with the other proxy, such as a
+// ProviderRouteTokenStore using the same database. The session is closed once the client is sent off.
+func (s *Session) TransferProxy(address, srv string) error {
+	if s.env.Rejoins == nil {
+		return errRejoinsDisabled
+	}
+	host, port, err := splitAddress(address)
+	if err != nil {
+		return err
+	}
+	s.waitForLogin()
+	if err := s.env.Rejoins.Issue(s.RouteKey(), srv); err != nil {
+		return fmt.Errorf("issue route token: %w", err)
+	}
+	s.log.Infof("%s is being handed off to %s to join %s", s.identity.DisplayName, address, srv)
+	_ = s.clientConn().WritePacket(&packet.Transfer{Address: host, Port: port})
+	s.CloseWithReason(CloseReconnected)
+	return nil
+}
+
+// RouteKey returns the key route tokens of the session are issued under.
+func (s *Session) RouteKey() string {
+	return RouteKey(s.identity.XUID, s.uuid)
+}
+
+// rejoin sends the client back to the proxy to join the server passed from scratch, and closes the session.
+func (s *Session) rejoin(srv *server.Server) error {
+	r := s.env.Rejoins
+	if err := r.Issue(s.RouteKey(), srv.Name()); err != nil {
+		return fmt.Errorf("issue route token: %w", err)
+	}
+	s.log.Infof("%s is reconnecting to the proxy to join %s", s.identity.DisplayName, srv.Name())
+	_ = s.clientConn().WritePacket(&packet.Transfer{Address: r.host, Port: r.port})
+	s.CloseWithReason(CloseReconnected)
+	return nil
+}
@@ -0,0 +1,202 @@
+package session
+
+import (
+	"net"
+	"strings"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// RouteRule is a rule that sends the sessions matching it to a preferred group of servers, such as sending
+// players with a Portuguese client to the Brazilian lobby, or players on consoles to a console-only lobby. A
+// session matches a rule if it matches every condition of the rule that is set, and a condition matches if any
+// of its values match.
+type RouteRule struct {
+	// Group is the group of servers the sessions matching the rule are sent to.
+	Group string
+	// Locales holds the language codes of the clients the rule matches, such as "pt_BR". A language without a
+	// region, such as "pt", matches every region of the language.
+	Locales []string
+	// Regions holds the regions of the IP addresses the rule matches, as resolved by the RegionResolver of the
+	// load balancer.
+	Regions []string
+	// Devices holds the operating systems of the devices the rule matches.
+	Devices []protocol.DeviceOS
+	// InputModes holds the current input modes the rule matches, such as packet.InputModeGamePad.
+	InputModes []int
+}
+
+// matches returns if the session passed, of which the IP address is in the region passed, matches the rule.
+func (r RouteRule) matches(s *Session, region string) bool {
+	if len(r.Locales) > 0 && !matchLocale(r.Locales, s.Locale()) {
+		return false
+	}
+	if len(r.Regions) > 0 && !containsFold(r.Regions, region) {
+		return false
+	}
+	return matchDevice(r.Devices, r.InputModes, s.ClientData())
+}
+
+// matchLocale returns if the locale passed matches any of the locales passed. Locales without a region match
+// every region of their language.
+func matchLocale(locales []string, locale string) bool {
+	for _, l := range locales {
+		if strings.EqualFold(l, locale) {
+			return true
+		}
+		if len(locale) > len(l) && strings.EqualFold(locale[:len(l)], l) && (locale[len(l)] == '_' || locale[len(l)] == '-') {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold returns if the values passed contain the value passed, without regard to case.
+func containsFold(values []string, v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegionResolver resolves the region of IP addresses, such as the country found in a GeoIP database.
+type RegionResolver interface {
+	// Region returns the region of the address passed, or an empty string if it is unknown.
+	Region(addr net.Addr) string
+}
+
+// RegionFunc is a function that implements RegionResolver.
+type RegionFunc func(addr net.Addr) string
+
+// Region ...
+func (f RegionFunc) Region(addr net.Addr) string {
+	return f(addr)
+}
+
+// CIDRRegions is a RegionResolver which resolves the region of addresses using a list of IP ranges per region.
+type CIDRRegions struct {
+	ranges []cidrRegion
+}
+
+// cidrRegion is an IP range of a region.
+type cidrRegion struct {
+	network *net.IPNet
+	region  string
+}
+
+// NewCIDRRegions creates CIDRRegions from the regions passed, which map the names of regions to IP ranges in the
+// CIDR notation, such as "177.0.0.0/8". An error is returned if any of the ranges is invalid.
+func NewCIDRRegions(regions map[string][]string) (*CIDRRegions, error) {
+	r := &CIDRRegions{}
+	for region, ranges := range regions {
+		for _, cidr := range ranges {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, err
+			}
+			r.ranges = append(r.ranges, cidrRegion{network: network, region: region})
+		}
+	}
+	return r, nil
+}
+
+// Region ...
+func (r *CIDRRegions) Region(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return ""
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	for _, c := range r.ranges {
+		if c.network.Contains(ip) {
+			return c.region
+		}
+	}
+	return ""
+}
+
+// RouteGroup is the key of the group preferred for a session in the context passed to HandleRoute. Handlers may
+// store a different group under this key to change the group the session is sent to.
+var RouteGroup = event.NewKey[string]("route group")
+
+// RouteLoadBalancer is a load balancer that sends sessions to the group of servers preferred for them by the
+// first rule they match. Sessions that match no rule, or for which no server in their preferred group is
+// available, are passed on to the wrapped load balancer.
+type RouteLoadBalancer struct {
+	lb       LoadBalancer
+	registry *server.Registry
+	rules    []RouteRule
+	regions  RegionResolver
+}
+
+// NewRouteLoadBalancer creates a RouteLoadBalancer wrapping around the load balancer passed, which finds the
+// groups of the rules passed in the registry passed. The region resolver passed is used to resolve the regions
+// of players. If it is nil, rules with regions never match.
+func NewRouteLoadBalancer(lb LoadBalancer, registry *server.Registry, rules []RouteRule, regions RegionResolver) *RouteLoadBalancer {
+	return &RouteLoadBalancer{lb: lb, registry: registry, rules: rules, regions: regions}
+}
+
+// PreferredGroup returns the group preferred for the session passed by the rules of the load balancer and the
+// handlers of the session, or an empty string if no group is preferred.
+func (b *RouteLoadBalancer) PreferredGroup(s *Session) string {
+	var region string
+	if b.regions != nil {
+		region = b.regions.Region(s.RemoteAddr())
+	}
+	var group string
+	for _, r := range b.rules {
+		if r.matches(s, region) {
+			group = r.Group
+			break
+		}
+	}
+
+	ctx := event.C()
+	event.Store(ctx, RouteGroup, group)
+	s.handler().HandleRoute(ctx, group)
+	if ctx.Cancelled() {
+		return ""
+	}
+	group, _ = event.Load(ctx, RouteGroup)
+	return group
+}
+
+// FindServer ...
+func (b *RouteLoadBalancer) FindServer(s *Session) *server.Server {
+	return b.FindServerExcluding(s)
+}
+
+// FindServerExcluding ...
+func (b *RouteLoadBalancer) FindServerExcluding(s *Session, exclude ...*server.Server) *server.Server {
+	if candidates := b.Rank(s, exclude...); len(candidates) > 0 {
+		return candidates[0].Server
+	}
+	return nil
+}
+
+// Rank ...
+func (b *RouteLoadBalancer) Rank(s *Session, exclude ...*server.Server) []Candidate {
+	var candidates []Candidate
+	if group := b.PreferredGroup(s); group != "" {
+		candidates = rankServers(server.Rank(b.registry.Group(group), func(srv *server.Server) bool {
+			return srv.Healthy() && !excluded(srv, exclude)
+		}))
+	}
+	// The servers of the wrapped load balancer are considered after the servers of the preferred group.
+	for _, c := range rank(b.lb, s, exclude...) {
+		if !containsCandidate(candidates, c.Server) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
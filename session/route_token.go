@@ -0,0 +1,106 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/storage"
+)
+
+// RouteToken is a short-lived token that routes a player to a predetermined server when they join a proxy, such
+// as after being transferred with a full reconnect or handed off from another proxy.
+type RouteToken struct {
+	// Key is the key of the player the token belongs to, as returned by RouteKey.
+	Key string `json:"key"`
+	// Server is the name of the server the player is routed to.
+	Server string `json:"server"`
+	// Expires is the time after which the token may no longer be redeemed.
+	Expires time.Time `json:"expires"`
+}
+
+// RouteKey returns the key route tokens of a player with the XUID and UUID passed are stored under. The XUID is
+// used if the player has one, so that a token cannot be redeemed by a player spoofing the UUID of another.
+func RouteKey(xuid string, id uuid.UUID) string {
+	if xuid != "" {
+		return "xuid:" + xuid
+	}
+	return "uuid:" + id.String()
+}
+
+// RouteTokenStore stores route tokens until they are redeemed. Implementations may store the tokens in memory or
+// in a store shared by multiple proxies, so that players can be handed off between them.
+type RouteTokenStore interface {
+	// Issue stores the token passed, replacing any token stored for the same key.
+	Issue(t RouteToken) error
+	// Redeem returns the token stored under the key passed and removes it, so that it can only be redeemed once.
+	// If no token is stored, or if it expired, false is returned.
+	Redeem(key string) (RouteToken, bool)
+}
+
+// MemoryRouteTokenStore is a RouteTokenStore that keeps tokens in memory. Tokens are only redeemed by the proxy
+// that issued them.
+type MemoryRouteTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]RouteToken
+}
+
+// NewMemoryRouteTokenStore creates an empty MemoryRouteTokenStore.
+func NewMemoryRouteTokenStore() *MemoryRouteTokenStore {
+	return &MemoryRouteTokenStore{tokens: make(map[string]RouteToken)}
+}
+
+// Issue ...
+func (s *MemoryRouteTokenStore) Issue(t RouteToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, token := range s.tokens {
+		if now.After(token.Expires) {
+			delete(s.tokens, key)
+		}
+	}
+	s.tokens[t.Key] = t
+	return nil
+}
+
+// Redeem ...
+func (s *MemoryRouteTokenStore) Redeem(key string) (RouteToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[key]
+	delete(s.tokens, key)
+	return t, ok && time.Now().Before(t.Expires)
+}
+
+// ProviderRouteTokenStore is a RouteTokenStore that stores tokens using a storage.Provider. Proxies sharing the
+// same database redeem the tokens issued by each other, so players may be handed off between them.
+type ProviderRouteTokenStore struct {
+	p storage.Provider
+}
+
+// routeTokenBucket is the bucket of the storage.Provider in which route tokens are stored.
+const routeTokenBucket = "route_tokens"
+
+// NewProviderRouteTokenStore creates a ProviderRouteTokenStore that stores tokens using the provider passed.
+func NewProviderRouteTokenStore(p storage.Provider) *ProviderRouteTokenStore {
+	return &ProviderRouteTokenStore{p: p}
+}
+
+// Issue ...
+func (s *ProviderRouteTokenStore) Issue(t RouteToken) error {
+	return storage.Save(s.p, routeTokenBucket, t.Key, t)
+}
+
+// Redeem ...
+func (s *ProviderRouteTokenStore) Redeem(key string) (RouteToken, bool) {
+	var t RouteToken
+	if ok, err := storage.Load(s.p, routeTokenBucket, key, &t); err != nil || !ok {
+		return RouteToken{}, false
+	}
+	if err := s.p.Delete(routeTokenBucket, key); err != nil {
+		// The token could not be removed, so it is not redeemed to prevent it from being redeemed twice.
+		return RouteToken{}, false
+	}
+	return t, time.Now().Before(t.Expires)
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+)
+
+// Candidate is a server considered by a load balancer for a session, together with the score the load balancer
+// gave it. What the score means depends on the load balancer, but lower scores are preferred.
+type Candidate struct {
+	// Server is the server considered.
+	Server *server.Server
+	// Score is the score given to the server by the load balancer.
+	Score float64
+}
+
+// RankingLoadBalancer is a LoadBalancer that is able to return every server it considers for a session, instead
+// of only the server it prefers. The first candidate returned must be the server FindServerExcluding returns.
+type RankingLoadBalancer interface {
+	LoadBalancer
+	// Rank returns the servers considered for the session passed that are not one of the servers passed,
+	// ordered from most to least preferred.
+	Rank(session *Session, exclude ...*server.Server) []Candidate
+}
+
+// ServerCandidates is the key of the candidates in the context passed to HandleServerSelect. Handlers may store
+// a re-ordered or filtered list of candidates under this key to change the server selected for the session.
+var ServerCandidates = event.NewKey[[]Candidate]("server candidates")
+
+// SelectServer selects a server for the session passed using the load balancer passed, which is not one of the
+// excluded servers. The handlers of the session are able to veto or re-order the candidates considered by the
+// load balancer in HandleServerSelect, after which the first candidate left is returned. If the selection was
+// cancelled or no candidate is left, nil is returned. Every decision is logged, together with the candidates
+// considered and their scores.
+func SelectServer(loadBalancer LoadBalancer, s *Session, exclude ...*server.Server) *server.Server {
+	candidates := rank(loadBalancer, s, exclude...)
+	ctx := event.C()
+	event.Store(ctx, ServerCandidates, candidates)
+	s.handler().HandleServerSelect(ctx, candidates)
+	if ctx.Cancelled() {
+		s.log.Debugf("server selection for %s: vetoed by a handler, candidates=%s", s.identity.DisplayName, formatCandidates(candidates))
+		return nil
+	}
+	final, _ := event.Load(ctx, ServerCandidates)
+	var selected *server.Server
+	for _, c := range final {
+		if c.Server != nil && !excluded(c.Server, exclude) {
+			selected = c.Server
+			break
+		}
+	}
+	if selected == nil {
+		s.log.Debugf("server selection for %s: no server selected, candidates=%s", s.identity.DisplayName, formatCandidates(candidates))
+		return nil
+	}
+	s.log.Debugf("server selection for %s: selected=%s candidates=%s", s.identity.DisplayName, selected.Name(), formatCandidates(candidates))
+	return selected
+}
+
+// rank returns the candidates the load balancer passed considers for the session passed. If the load balancer
+// does not implement RankingLoadBalancer, only the server it finds is returned.
+func rank(loadBalancer LoadBalancer, s *Session, exclude ...*server.Server) []Candidate {
+	if b, ok := loadBalancer.(RankingLoadBalancer); ok {
+		return b.Rank(s, exclude...)
+	}
+	if srv := FindServerExcluding(loadBalancer, s, exclude...); srv != nil {
+		return []Candidate{{Server: srv}}
+	}
+	return nil
+}
+
+// formatCandidates formats the candidates passed for logging, such as "[lobby-1(3) lobby-2(5)]".
+func formatCandidates(candidates []Candidate) string {
+	parts := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		parts = append(parts, fmt.Sprintf("%s(%g)", c.Server.Name(), c.Score))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// rankServers returns the servers passed as candidates, scored by their player count.
+func rankServers(servers []*server.Server) []Candidate {
+	candidates := make([]Candidate, 0, len(servers))
+	for _, srv := range servers {
+		candidates = append(candidates, Candidate{Server: srv, Score: float64(srv.PlayerCount())})
+	}
+	return candidates
+}
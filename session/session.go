@@ -1,7 +1,11 @@
 package session
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -9,10 +13,13 @@ import (
 	"github.com/google/uuid"
 	"github.com/paroxity/portal/event"
 	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/report"
 	"github.com/paroxity/portal/server"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/text"
 	"github.com/scylladb/go-set/b16set"
 	"github.com/scylladb/go-set/i32set"
 	"github.com/scylladb/go-set/i64set"
@@ -24,119 +31,292 @@ import (
 type Session struct {
 	*translator
 
-	log   internal.Logger
-	conn  *minecraft.Conn
-	store *Store
+	env      Env
+	log      internal.Logger
+	connMu   sync.RWMutex
+	conn     *minecraft.Conn
+	store    *Store
+	registry *server.Registry
+
+	// ctx is the context of the session, which is cancelled once the session is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	hMutex sync.RWMutex
-	// h holds the current handler of the session.
-	h Handler
+	// handlers holds all the handlers added to the session, in the order they were added.
+	handlers []handlerEntry
+	// h holds the chain of handlers of the session, sorted by their priority.
+	h handlerChain
+
+	loginMu sync.RWMutex
+	// loginDone is closed once the session has finished logging in, regardless of whether it succeeded.
+	loginDone chan struct{}
+	loggedIn  atomic.Bool
+	// joined is the time at which the session finished logging in.
+	joined time.Time
 
-	loginMu        sync.RWMutex
 	serverMu       sync.RWMutex
 	server         *server.Server
 	serverConn     *minecraft.Conn
 	tempServerConn *minecraft.Conn
 
-	entities    *i64set.Set
+	entities *i64set.Set
+	// entityAges holds the time at which the entities in entities last appeared in a packet. It is nil if the
+	// EntityTTL of the Env is zero.
+	entityAges  *entityAges
 	playerList  *b16set.Set
 	effects     *i32set.Set
 	bossBars    *i64set.Set
 	scoreboards *strset.Set
+	sounds      *sounds
+	// blobHashes holds the hashes of the most recent cache blobs sent by the current server in the current
+	// dimension. It is used to make sure the client only reports the status of blobs the current server knows
+	// about. It is written while reading packets from the server and read while reading packets from the
+	// client, so it is guarded by blobMu.
+	blobMu     sync.Mutex
+	blobHashes *blobHashes
+
+	dimensionsMu sync.RWMutex
+	// dimensionDefinitions holds the dimension definitions sent to the client, by the names of the dimensions.
+	dimensionDefinitions map[string]protocol.DimensionDefinition
 
 	uuid uuid.UUID
+	// identity holds the identity data of the session. It is equal to the identity data of the connection,
+	// except for the UUID of players that are not authenticated with Xbox Live.
+	identity login.IdentityData
+	// correlationID is the ID included in the logs about the session.
+	correlationID string
+
+	// emoteList holds the last EmoteList packet sent by the client. It is sent to every new server the
+	// session is transferred to, as the client only sends it once after spawning.
+	emoteList atomic.Value
+	// chunkRadius holds the last *packet.RequestChunkRadius sent by the client.
+	chunkRadius atomic.Value
+
+	// position holds the last position of the player sent by the client, as a mgl32.Vec3.
+	position atomic.Value
+
+	// handoff holds the HandoffState the session was resumed from, if it was routed by a HandoffLoadBalancer.
+	handoff atomic.Value
+
+	// spawnHold is the maximum time the session is held on a loading screen after a transfer, until the
+	// destination server releases it.
+	spawnHold atomic.Duration
+	releaseMu sync.Mutex
+	release   chan struct{}
+
+	// kickPolicy and kickMessage determine how the session is handled when its server kicks the player.
+	kickPolicy atomic.String
+	// palettePolicy is the PalettePolicy applied when the session is transferred to a server with different
+	// palettes than the client.
+	palettePolicy atomic.String
+	kickMessage   atomic.String
+	kickRewriter  atomic.Value
+
+	// bufferSize and bufferIDs determine which packets sent by the client during a transfer are buffered in
+	// buffered, to be sent to the server once the transfer is done.
+	bufferMu   sync.Mutex
+	bufferSize int
+	bufferIDs  map[uint32]struct{}
+	buffered   []packet.Packet
+
+	// bandwidth holds the *transport.Counter of the connection of the session, if it is metered.
+	bandwidth atomic.Value
+
+	// hexdump is true if packets that fail to decode are logged as a hexdump and forwarded as they are.
+	hexdump atomic.Bool
+
+	// displayName is the name presented for the player instead of their own name, or empty if none is set. If
+	// displayNameDownstream is true, it is also presented to the servers of the session.
+	displayName           atomic.String
+	displayNameDownstream atomic.Bool
+
+	// onClose holds the functions registered using OnClose, in the order they were registered.
+	onCloseMu sync.Mutex
+	onClose   []func()
+	// closeReason is the reason the session was closed with.
+	closeReason atomic.Int32
+
+	// transferState holds the TransferState of the session.
+	transferState atomic.Int32
+
+	// history holds the most recent transfers of the session, from oldest to newest.
+	historyMu sync.Mutex
+	history   []TransferRecord
 
-	transferring atomic.Bool
+	// clientPackets and serverPackets hold the most recent packets read from the client and the server.
+	clientPackets, serverPackets recentPackets
+
+	reconnecting atomic.Bool
 	postTransfer atomic.Bool
+	detached     atomic.Bool
+	closed       atomic.Bool
 	once         sync.Once
 }
 
-// New creates a new Session with the provided connection.
-func New(conn *minecraft.Conn, store *Store, loadBalancer LoadBalancer, log internal.Logger) (s *Session, err error) {
+// New creates a new Session with the provided connection. The handler factories passed are called with the new
+// session to create handlers which are added to it before it connects to a server.
+func New(conn *minecraft.Conn, store *Store, registry *server.Registry, loadBalancer LoadBalancer, log internal.Logger, factories ...func(s *Session) Handler) (s *Session, err error) {
+	return NewWithEnv(Env{}, conn, store, registry, loadBalancer, log, factories...)
+}
+
+// NewWithEnv creates a new Session like New, which uses the clock and dial function of the Env passed. The
+// initial settings of the Env are applied before the session starts connecting to its first server.
+func NewWithEnv(env Env, conn *minecraft.Conn, store *Store, registry *server.Registry, loadBalancer LoadBalancer, log internal.Logger, factories ...func(s *Session) Handler) (s *Session, err error) {
+	id := newCorrelationID()
 	s = &Session{
-		log:   log,
-		conn:  conn,
-		store: store,
+		env:      env.withDefaults(),
+		log:      correlatedLogger{Logger: log, id: id},
+		conn:     conn,
+		store:    store,
+		registry: registry,
 
 		entities:    i64set.New(),
 		playerList:  b16set.New(),
 		effects:     i32set.New(),
 		bossBars:    i64set.New(),
 		scoreboards: strset.New(),
+		sounds:      newSounds(),
+		blobHashes:  newBlobHashes(),
 
-		h:    NopHandler{},
-		uuid: uuid.MustParse(conn.IdentityData().Identity),
-	}
+		dimensionDefinitions: make(map[string]protocol.DimensionDefinition),
 
-	store.Store(s)
-	defer func() {
-		if err != nil {
-			store.Delete(s.UUID())
-		}
-	}()
+		identity:      conn.IdentityData(),
+		correlationID: id,
 
-	srv := loadBalancer.FindServer(s)
-	if srv == nil {
-		return s, errors.New("load balancer did not return a server for the player to join")
+		loginDone: make(chan struct{}),
+	}
+	if s.env.EntityTTL > 0 {
+		s.entityAges = newEntityAges(s.env.EntityTTL, s.env.Clock.Now())
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.uuid = ConnUUID(conn)
+	s.identity.Identity = s.uuid.String()
+	for _, f := range factories {
+		s.AddHandler(f(s), 0)
 	}
-	srv.IncrementPlayerCount()
-	s.server = srv
+	s.SetSpawnHold(s.env.SpawnHold)
+	s.SetKickPolicy(s.env.KickPolicy, s.env.KickMessage)
+	s.SetKickRewriter(s.env.KickRewriter)
+	s.SetPalettePolicy(s.env.PalettePolicy)
+	s.SetHexdump(s.env.Hexdump)
+	s.SetTransferBuffer(s.env.TransferBuffer)
+
+	store.Store(s)
 
 	s.loginMu.Lock()
 	go func() {
-		defer s.loginMu.Unlock()
-		srvConn, err := s.dial(srv)
-		if err != nil {
-			log.Errorf("failed to dial server %s: %w", srv.Address(), err)
+		if err := s.connect(loadBalancer); err != nil {
+			log.Errorf("failed to connect %s to a server: %v", conn.IdentityData().DisplayName, err)
+			s.DisconnectWithReason(text.Colourf("<red>%v</red>", err), CloseTransferFailed)
 			return
 		}
-
-		s.serverConn = srvConn
-		if err = s.login(); err != nil {
-			_ = srvConn.Close()
-			log.Errorf("failed to login to server %s: %w", srv.Address(), err)
-			return
-		}
-		log.Infof("%s has been connected to server %s", conn.IdentityData().DisplayName, srv.Name())
-
-		s.translator = newTranslator(srvConn.GameData())
 		handlePackets(s)
 	}()
 	return s, nil
 }
 
-// dial dials a new connection to the provided server. It then returns the connection between the proxy and
-// that server, along with any error that may have occurred.
-func (s *Session) dial(srv *server.Server) (*minecraft.Conn, error) {
-	i := s.conn.IdentityData()
-	i.XUID = ""
-	return minecraft.Dialer{
-		ClientData:   s.conn.ClientData(),
-		IdentityData: i,
+// connect finds a server for the session to join using the load balancer passed, and connects the session to
+// it. The login mutex must be locked before calling connect, and is unlocked once connect returns.
+func (s *Session) connect(loadBalancer LoadBalancer) (err error) {
+	defer func() {
+		if err == nil {
+			s.joined = s.env.Clock.Now()
+		}
+		s.loggedIn.Store(err == nil)
+		s.loginMu.Unlock()
+		close(s.loginDone)
+	}()
+
+	// Servers that could not be dialed are excluded and the load balancer is asked for another server, until
+	// one can be dialed or the load balancer has no servers left.
+	var (
+		failed  []*server.Server
+		srv     *server.Server
+		srvConn *minecraft.Conn
+	)
+	for {
+		srv = SelectServer(loadBalancer, s, failed...)
+		if srv == nil {
+			if err != nil {
+				return err
+			}
+			return errors.New("load balancer did not return a server for the player to join")
+		}
+		srv.IncrementPlayerCount()
+		s.serverMu.Lock()
+		s.server = srv
+		s.serverMu.Unlock()
+
+		if srvConn, err = s.env.Dial(s.ctx, s, srv); err == nil {
+			break
+		}
+		err = fmt.Errorf("failed to dial server %s: %w", srv.Address(), err)
 
-		FlushRate: -1,
-	}.Dial("raknet", srv.Address())
+		s.serverMu.Lock()
+		s.server = nil
+		s.serverMu.Unlock()
+		srv.DecrementPlayerCount()
+		if s.ctx.Err() != nil {
+			return err
+		}
+		s.log.Errorf("%s: %v", s.clientConn().IdentityData().DisplayName, err)
+		s.report(report.KindDial, srv, err, nil)
+		failed = append(failed, srv)
+	}
+
+	s.serverConn = srvConn
+	srv.SetPacks(server.PacksOf(srvConn.ResourcePacks()))
+	if err = s.login(); err != nil {
+		_ = srvConn.Close()
+		err = fmt.Errorf("failed to login to server %s: %w", srv.Address(), err)
+		s.report(report.KindLogin, srv, err, nil)
+		return err
+	}
+	s.log.Infof("%s has been connected to server %s", s.clientConn().IdentityData().DisplayName, srv.Name())
+	s.handler().HandleServerConnect(srv, srvConn)
+
+	s.translator = newTranslator(srvConn.GameData())
+	if runtimeID, uniqueID, ok := s.resumedIDs(); ok {
+		s.translator.restore(runtimeID, uniqueID)
+	}
+	return nil
 }
 
 // login performs the initial login sequence for the session.
-func (s *Session) login() (err error) {
+func (s *Session) login() error {
 	var g sync.WaitGroup
 	g.Add(2)
 
 	data := s.serverConn.GameData()
 	data.PlayerMovementSettings.MovementType = protocol.PlayerMovementModeServerWithRewind
 	data.PlayerMovementSettings.RewindHistorySize = 100
+	if s.env.GameOverrides != nil {
+		s.env.GameOverrides.Apply(&data)
+	}
+	if runtimeID, uniqueID, ok := s.resumedIDs(); ok {
+		// The client is given the IDs it knew the player by before the handoff, which are translated to the
+		// IDs given by the server like after a transfer.
+		data.EntityRuntimeID, data.EntityUniqueID = runtimeID, uniqueID
+	}
+
+	ctx, cancel := s.withTimeout(time.Minute)
+	defer cancel()
 
+	var clientErr, serverErr error
 	go func() {
-		err = s.conn.StartGameTimeout(data, time.Minute)
+		clientErr = s.clientConn().StartGameContext(ctx, data)
 		g.Done()
 	}()
 	go func() {
-		err = s.serverConn.DoSpawnTimeout(time.Minute)
+		serverErr = s.serverConn.DoSpawnContext(ctx)
 		g.Done()
 	}()
 	g.Wait()
-	return
+	if clientErr != nil {
+		return clientErr
+	}
+	return serverErr
 }
 
 // waitForLogin uses the login mutex to wait for the login to complete. If the player is still logging in, loginMu will
@@ -146,12 +326,119 @@ func (s *Session) waitForLogin() {
 	s.loginMu.RUnlock()
 }
 
+// Playtime returns the time the session has been playing on the proxy since it finished logging in. Zero is
+// returned if the session has not logged in.
+func (s *Session) Playtime() time.Duration {
+	if !s.LoggedIn() {
+		return 0
+	}
+	return s.env.Clock.Now().Sub(s.joined)
+}
+
+// JoinTime returns the time at which the session finished logging in. The zero time is returned if the session
+// has not logged in.
+func (s *Session) JoinTime() time.Time {
+	if !s.LoggedIn() {
+		return time.Time{}
+	}
+	return s.joined
+}
+
+// LoggedIn returns if the session has finished logging in to its first server. Unlike Server and ServerConn, it
+// never blocks.
+func (s *Session) LoggedIn() bool {
+	return s.loggedIn.Load()
+}
+
+// WaitLogin blocks until the session has finished logging in to its first server, or until the context passed
+// is done. An error is returned if the context is done first, or if the session failed to log in.
+func (s *Session) WaitLogin(ctx context.Context) error {
+	select {
+	case <-s.loginDone:
+		if !s.loggedIn.Load() {
+			return errors.New("session failed to log in")
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Conn returns the active connection for the session.
 func (s *Session) Conn() *minecraft.Conn {
 	s.waitForLogin()
+	return s.clientConn()
+}
+
+// clientConn returns the connection of the player without waiting for the session to finish logging in. The
+// connection is replaced when the session is reattached, so it must always be read using clientConn rather than
+// directly.
+func (s *Session) clientConn() *minecraft.Conn {
+	s.connMu.RLock()
+	defer s.connMu.RUnlock()
 	return s.conn
 }
 
+// Detached returns if the connection of the player was lost while the session was kept open, waiting for a new
+// connection to be attached using Reattach.
+func (s *Session) Detached() bool {
+	return s.detached.Load()
+}
+
+// Reattach attaches a new connection of the player to a detached session, so that the player continues on the
+// server connection of the session without joining the server again. The connection passed must not have been
+// spawned yet. Experimental: the server is not aware that the client was replaced, so the world is only shown
+// to the player as far as the server sends it again.
+func (s *Session) Reattach(conn *minecraft.Conn) error {
+	if !s.detached.Load() {
+		return errors.New("session is not detached")
+	}
+	data := s.ServerConn().GameData()
+	data.EntityRuntimeID, data.EntityUniqueID = s.originalRuntimeID, s.originalUniqueID
+	if pos, ok := s.position.Load().(mgl32.Vec3); ok {
+		data.PlayerPosition = pos
+	}
+	data.Dimension = s.dimension.Load()
+	data.PlayerMovementSettings.MovementType = protocol.PlayerMovementModeServerWithRewind
+	data.PlayerMovementSettings.RewindHistorySize = 100
+	if s.env.GameOverrides != nil {
+		s.env.GameOverrides.Apply(&data)
+	}
+	if runtimeID, uniqueID, ok := s.resumedIDs(); ok {
+		// The client is given the IDs it knew the player by before the handoff, which are translated to the
+		// IDs given by the server like after a transfer.
+		data.EntityRuntimeID, data.EntityUniqueID = runtimeID, uniqueID
+	}
+
+	ctx, cancel := s.withTimeout(time.Minute)
+	defer cancel()
+	if err := conn.StartGameContext(ctx, data); err != nil {
+		return err
+	}
+
+	s.connMu.Lock()
+	_ = s.conn.Close()
+	s.conn = conn
+	s.connMu.Unlock()
+
+	// The new client has none of the state sent to the old client, so we forget about it without sending any
+	// packets to remove it.
+	s.entities.Clear()
+	if s.entityAges != nil {
+		s.entityAges.clear()
+	}
+	s.playerList.Clear()
+	s.effects.Clear()
+	s.bossBars.Clear()
+	s.scoreboards.Clear()
+	s.sounds.clear()
+
+	s.detached.Store(false)
+	s.log.Infof("%s reattached to their session on %s", s.identity.DisplayName, s.Server().Name())
+	go s.handleClientPackets()
+	return nil
+}
+
 // Server returns the server the session is currently connected to.
 func (s *Session) Server() *server.Server {
 	s.waitForLogin()
@@ -160,6 +447,28 @@ func (s *Session) Server() *server.Server {
 	return s.server
 }
 
+// TryServer returns the server the session is currently connected to. Unlike Server, it does not wait for the
+// session to finish logging in, but returns false if it has not yet.
+func (s *Session) TryServer() (*server.Server, bool) {
+	if !s.LoggedIn() {
+		return nil, false
+	}
+	s.serverMu.RLock()
+	defer s.serverMu.RUnlock()
+	return s.server, true
+}
+
+// TryServerConn returns the connection for the session's current server. Unlike ServerConn, it does not wait
+// for the session to finish logging in, but returns false if it has not yet.
+func (s *Session) TryServerConn() (*minecraft.Conn, bool) {
+	if !s.LoggedIn() {
+		return nil, false
+	}
+	s.serverMu.RLock()
+	defer s.serverMu.RUnlock()
+	return s.serverConn, true
+}
+
 // ServerConn returns the connection for the session's current server.
 func (s *Session) ServerConn() *minecraft.Conn {
 	s.waitForLogin()
@@ -168,143 +477,476 @@ func (s *Session) ServerConn() *minecraft.Conn {
 	return s.serverConn
 }
 
+// writeServerPacket writes the packet passed to the connection of the current server and flushes it right away.
+// Connections to servers are dialed without a flush rate, so that packets of the client are not delayed by the
+// proxy, which means packets written to them are only sent once flushed.
+func (s *Session) writeServerPacket(pk packet.Packet) {
+	conn := s.ServerConn()
+	_ = conn.WritePacket(pk)
+	_ = conn.Flush()
+}
+
+// Context returns the context of the session. It is cancelled once the session is closed, so it may be used to
+// stop goroutines tied to the session when the player leaves the proxy. Any dial, login or transfer of the
+// session in progress is aborted when the context is cancelled.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// IdentityData returns the identity data of the session's connection. Unlike Conn, it does not wait for the
+// session to finish logging in. If the player is not authenticated, the identity holds the UUID returned by
+// OfflineUUID.
+func (s *Session) IdentityData() login.IdentityData {
+	return s.identity
+}
+
+// ConnUUID returns the UUID that a session created for the connection passed would have. This is the UUID of
+// the identity of the connection, or the UUID returned by OfflineUUID if the player is not authenticated.
+func ConnUUID(conn *minecraft.Conn) uuid.UUID {
+	identity := conn.IdentityData()
+	if identity.XUID == "" {
+		// The player is not authenticated, so the UUID sent by the client can't be trusted and may change
+		// between joins. We derive a UUID from the name of the player instead.
+		return OfflineUUID(identity.DisplayName)
+	}
+	return uuid.MustParse(identity.Identity)
+}
+
+// OfflineUUID returns the UUID given to a player with the name passed that is not authenticated with Xbox Live.
+// The UUID is derived from the name, so a player keeps the same UUID across joins.
+func OfflineUUID(name string) uuid.UUID {
+	return uuid.NewMD5(uuid.NameSpaceOID, []byte("OfflinePlayer:"+name))
+}
+
+// ClientData returns the client data of the session's connection, such as the skin and device of the player. Unlike
+// Conn, it does not wait for the session to finish logging in.
+func (s *Session) ClientData() login.ClientData {
+	return s.clientConn().ClientData()
+}
+
+// RemoteAddr returns the address of the player connected to the session.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.clientConn().RemoteAddr()
+}
+
+// Latency returns the latency of the connection of the player to the proxy. Unlike Conn, it does not wait for the
+// session to finish logging in.
+func (s *Session) Latency() time.Duration {
+	return s.clientConn().Latency()
+}
+
+// LocalAddr returns the address of the proxy listener that the player of the session connected to.
+func (s *Session) LocalAddr() net.Addr {
+	return s.clientConn().LocalAddr()
+}
+
+// Locale returns the language code of the player's client, such as "en_US".
+func (s *Session) Locale() string {
+	return s.clientConn().ClientData().LanguageCode
+}
+
+// DeviceOS returns the operating system of the device the player joined with.
+func (s *Session) DeviceOS() protocol.DeviceOS {
+	return s.clientConn().ClientData().DeviceOS
+}
+
 // UUID returns the UUID from the session's connection.
 func (s *Session) UUID() uuid.UUID {
 	return s.uuid
 }
 
+// OriginalRuntimeID returns the entity runtime ID of the player as known by the client, which is the runtime ID
+// given by the first server the session joined. Runtime IDs in packets sent by the client are translated from
+// this ID to the current runtime ID before being sent to the server.
+func (s *Session) OriginalRuntimeID() uint64 {
+	s.waitForLogin()
+	if s.translator == nil {
+		return 0
+	}
+	return s.originalRuntimeID
+}
+
+// OriginalUniqueID returns the entity unique ID of the player as known by the client, which is the unique ID
+// given by the first server the session joined.
+func (s *Session) OriginalUniqueID() int64 {
+	s.waitForLogin()
+	if s.translator == nil {
+		return 0
+	}
+	return s.originalUniqueID
+}
+
+// CurrentRuntimeID returns the entity runtime ID of the player as known by the server the session is currently
+// connected to.
+func (s *Session) CurrentRuntimeID() uint64 {
+	s.waitForLogin()
+	if s.translator == nil {
+		return 0
+	}
+	return s.currentRuntimeID.Load()
+}
+
+// CurrentUniqueID returns the entity unique ID of the player as known by the server the session is currently
+// connected to.
+func (s *Session) CurrentUniqueID() int64 {
+	s.waitForLogin()
+	if s.translator == nil {
+		return 0
+	}
+	return s.currentUniqueID.Load()
+}
+
+// Dimension returns the dimension the player is currently in, such as packet.DimensionOverworld.
+func (s *Session) Dimension() int32 {
+	s.waitForLogin()
+	if s.translator == nil {
+		return 0
+	}
+	return s.dimension.Load()
+}
+
 // Handle sets the handler for the current session which can be used to handle different events from the
-// session. If the handler is nil, a NopHandler is used instead.
+// session. Any handlers previously added using Handle or AddHandler are removed. If the handler is nil, the
+// session is left without handlers.
 func (s *Session) Handle(h Handler) {
 	s.hMutex.Lock()
 	defer s.hMutex.Unlock()
 
+	s.handlers = nil
+	if h != nil {
+		s.handlers = append(s.handlers, handlerEntry{h: h})
+	}
+	s.h = newHandlerChain(s, s.handlers)
+}
+
+// AddHandler adds a handler to the session, next to any handlers already added. Handlers with a higher priority
+// are called before handlers with a lower priority, and handlers with the same priority are called in the order
+// they were added. Every handler receives the same event context, so a handler may check if the event was
+// cancelled by a handler before it.
+func (s *Session) AddHandler(h Handler, priority int) {
 	if h == nil {
-		h = NopHandler{}
+		return
+	}
+	s.hMutex.Lock()
+	defer s.hMutex.Unlock()
+
+	s.handlers = append(s.handlers, handlerEntry{h: h, priority: priority})
+	s.h = newHandlerChain(s, s.handlers)
+}
+
+// RemoveHandler removes a handler previously added to the session using Handle or AddHandler.
+func (s *Session) RemoveHandler(h Handler) {
+	s.hMutex.Lock()
+	defer s.hMutex.Unlock()
+
+	for i, e := range s.handlers {
+		if e.h == h {
+			s.handlers = append(s.handlers[:i:i], s.handlers[i+1:]...)
+			break
+		}
 	}
-	s.h = h
+	s.h = newHandlerChain(s, s.handlers)
 }
 
 // Transfer transfers the session to the provided server, returning any error that may have occurred during
 // the initial transfer.
 func (s *Session) Transfer(srv *server.Server) (err error) {
 	s.waitForLogin()
-	if !s.transferring.CAS(false, true) {
+	if !s.advanceTransfer(TransferIdle, TransferDialing) {
 		return errors.New("already being transferred")
 	}
 
-	s.log.Infof("%s is being transferred from %s to %s", s.conn.IdentityData().DisplayName, s.Server().Name(), srv.Name())
-
 	ctx := event.C()
+	event.Store(ctx, TransferServer, srv)
 	s.handler().HandleTransfer(ctx, srv)
+	if dst, ok := event.Load(ctx, TransferServer); ok && dst != nil {
+		srv = dst
+	}
+	// The server may close the current connection of the session once the new connection logs in, which must
+	// not be handled as a disconnection.
+	s.reconnecting.Store(srv == s.Server())
+
+	s.log.Infof("%s is being transferred from %s to %s", s.clientConn().IdentityData().DisplayName, s.Server().Name(), srv.Name())
 
 	ctx.Continue(func() {
-		conn, err := s.dial(srv)
+		s.prepareHold()
+
+		var release func()
+		if release, err = s.env.Transfers.acquire(s.ctx, s.env.Clock); err != nil {
+			s.transferFailed(srv, err)
+			return
+		}
+		var conn *minecraft.Conn
+		conn, err = s.env.Dial(s.ctx, s, srv)
+		if err != nil {
+			release()
+			s.transferFailed(srv, err)
+			return
+		}
+		ctx, cancel := s.withTimeout(time.Minute)
+		err = conn.DoSpawnContext(ctx)
+		cancel()
+		release()
+		if err == nil {
+			err = s.checkPalette(srv, conn)
+		}
+		if errors.Is(err, errPaletteReconnect) {
+			_ = conn.Close()
+			s.reconnecting.Store(false)
+			s.endTransfer()
+			if err = s.rejoin(srv); err != nil {
+				s.transferFailed(srv, err)
+			}
+			return
+		}
 		if err != nil {
+			_ = conn.Close()
+			s.transferFailed(srv, err)
 			return
 		}
-		if err = conn.DoSpawnTimeout(time.Minute); err != nil {
+		srv.SetPacks(server.PacksOf(conn.ResourcePacks()))
+		s.handler().HandleServerConnect(srv, conn)
+
+		// The client is first moved to a dimension that is neither the dimension it is currently in, nor the
+		// dimension of the new server, so that both dimension changes show a loading screen. The current
+		// dimension is tracked rather than taken from the game data of the old server, as the player may
+		// have changed dimension since joining it.
+		proxyDimension, ok := s.intermediateDimension(s.dimension.Load(), conn.GameData().Dimension)
+		if !ok {
+			_ = conn.Close()
+			err = errors.New("no vanilla dimension left to transfer through, as the others are in use or redefined")
+			s.transferFailed(srv, err)
 			return
 		}
 
+		// The session is moved to the new server before it is held, so that Server and the player counts of the
+		// servers already report the new server while the destination prepares the player. The new connection
+		// only replaces the current connection once the client finished the dimension change, which it can only
+		// do after receiving the chunks below.
 		s.serverMu.Lock()
+		previous := s.server
 		s.tempServerConn = conn
+		s.server.DecrementPlayerCount()
+		s.server = srv
+		s.server.IncrementPlayerCount()
 		s.serverMu.Unlock()
+		s.recordTransfer(previous, srv)
 
-		var proxyDimension int32
-		for _, dimension := range []int32{packet.DimensionOverworld, packet.DimensionNether, packet.DimensionEnd} {
-			if dimension != s.serverConn.GameData().Dimension && dimension != conn.GameData().Dimension {
-				proxyDimension = dimension
-				break
-			}
-		}
-
-		pos := s.conn.GameData().PlayerPosition
+		pos := s.clientConn().GameData().PlayerPosition
 		s.changeDimension(proxyDimension, pos)
+		// The client stays on the loading screen of the dimension change until it receives chunks, so the
+		// session is held before they are sent.
+		s.hold(srv)
+		s.advanceTransfer(TransferDialing, TransferChangingDimension)
 
 		chunkX := int32(pos.X()) >> 4
 		chunkZ := int32(pos.Z()) >> 4
 		for x := int32(-1); x <= 1; x++ {
 			for z := int32(-1); z <= 1; z++ {
-				_ = s.conn.WritePacket(&packet.LevelChunk{
+				_ = s.clientConn().WritePacket(&packet.LevelChunk{
 					Position:      protocol.ChunkPos{chunkX + x, chunkZ + z},
 					SubChunkCount: 1,
 					RawPayload:    emptyChunk(proxyDimension),
 				})
 			}
 		}
-
-		s.serverMu.Lock()
-		s.server.DecrementPlayerCount()
-		s.server = srv
-		s.server.IncrementPlayerCount()
-		s.serverMu.Unlock()
 	})
 
 	ctx.Stop(func() {
-		s.setTransferring(false)
+		s.reconnecting.Store(false)
+		s.endTransfer()
+		s.flushBuffer()
 	})
 
 	return
 }
 
-// Transferring returns if the session is currently transferring to a different server or not.
-func (s *Session) Transferring() bool {
-	return s.transferring.Load()
+// transferFailed resets the transferring state of the session after a failed transfer to the server passed and
+// notifies the handler of the failure.
+func (s *Session) transferFailed(srv *server.Server, err error) {
+	s.log.Errorf("failed to transfer %s to %s: %v", s.clientConn().IdentityData().DisplayName, srv.Name(), err)
+	s.report(report.KindTransfer, srv, err, nil)
+	s.reconnecting.Store(false)
+	s.endTransfer()
+	s.flushBuffer()
+	s.handler().HandleTransferFailure(srv, err)
+}
+
+// TransferTo transfers the session to the server with the name passed. If no server has that name, the name is
+// treated as the name of a group and the session is transferred to the healthy server with the fewest players
+// in it. Names are matched without regard to case.
+func (s *Session) TransferTo(name string) error {
+	srv, ok := s.registry.Find(name)
+	if !ok {
+		return fmt.Errorf("no server or group found with the name %s", name)
+	}
+	return s.Transfer(srv)
 }
 
-// setTransferring sets if the session is transferring to a different server.
-func (s *Session) setTransferring(v bool) {
-	s.transferring.Store(v)
+// Resync rejoins the server the session is currently connected to, so that the server sends all of its state,
+// such as chunks, entities and the player list, again. It may be used to recover the client from visual
+// desyncs without the player having to reconnect to the proxy. The chunk radius last requested by the client is
+// sent to the server once the session rejoined it.
+func (s *Session) Resync() error {
+	return s.Reconnect()
 }
 
-// handler() returns the handler connected to the session.
+// Reconnect dials a fresh connection to the server the session is currently connected to and swaps it with the
+// current connection once the player spawned, in the same way as a transfer to another server. It may be used to
+// move players onto a server again after it restarted without closing its connections. Transferring a session
+// to the server it is on using Transfer has the same effect.
+func (s *Session) Reconnect() error {
+	return s.Transfer(s.Server())
+}
+
+// handler() returns the chain of handlers connected to the session.
 func (s *Session) handler() Handler {
 	s.hMutex.RLock()
 	defer s.hMutex.RUnlock()
 	return s.h
 }
 
-// Close closes the session and any linked connections/counters.
+// Close closes the session and any linked connections/counters with CloseKicked as reason. See CloseWithReason
+// for the order in which the session is closed.
 func (s *Session) Close() {
+	s.CloseWithReason(CloseKicked)
+}
+
+// CloseWithReason closes the session and any linked connections/counters, recording the reason passed. Closing
+// the session happens in the following order, after which CloseWithReason returns:
+//
+//  1. The session is marked as closed and its context is cancelled, so Closed returns true from here on.
+//  2. HandleQuit is called on the handlers of the session with the reason, after which they are removed.
+//  3. The functions registered using OnClose are called, in the reverse order of their registration.
+//  4. The session is removed from the session store.
+//  5. The connections of the client and the server of the session are closed.
+//  6. The player count of the server of the session is decremented.
+//
+// Connections are still open during the first three steps, so handlers and functions registered using OnClose
+// may still send packets. The session may be closed multiple times, but it is only closed once, with the reason
+// passed the first time.
+func (s *Session) CloseWithReason(reason CloseReason) {
 	s.once.Do(func() {
-		s.handler().HandleQuit()
-		s.Handle(NopHandler{})
+		s.closeReason.Store(int32(reason))
+		s.closed.Store(true)
+		s.cancel()
+		s.handler().HandleQuit(reason)
+		s.Handle(nil)
 
-		s.store.Delete(s.UUID())
+		s.onCloseMu.Lock()
+		hooks := s.onClose
+		s.onClose = nil
+		s.onCloseMu.Unlock()
+		for i := len(hooks) - 1; i >= 0; i-- {
+			s.callOnClose(hooks[i])
+		}
+
+		s.store.remove(s)
 
-		_ = s.conn.Close()
+		_ = s.clientConn().Close()
+		s.serverMu.Lock()
+		defer s.serverMu.Unlock()
 		if s.serverConn != nil {
 			_ = s.serverConn.Close()
 		}
 		if s.tempServerConn != nil {
 			_ = s.tempServerConn.Close()
 		}
-
 		if s.server != nil {
 			s.server.DecrementPlayerCount()
 		}
 	})
 }
 
+// OnClose registers a function that is called when the session is closed, after HandleQuit has been called on
+// its handlers. This may be used to stop goroutines started for the session. Functions are called in the reverse
+// order of their registration, like deferred functions. If the session is already closed, the function is
+// called immediately.
+func (s *Session) OnClose(f func()) {
+	s.onCloseMu.Lock()
+	if !s.closed.Load() {
+		s.onClose = append(s.onClose, f)
+		s.onCloseMu.Unlock()
+		return
+	}
+	s.onCloseMu.Unlock()
+	s.callOnClose(f)
+}
+
+// Closed returns if the session has been closed.
+func (s *Session) Closed() bool {
+	return s.closed.Load()
+}
+
+// CloseReason returns the reason the session was closed with. It is only meaningful once Closed returns true.
+func (s *Session) CloseReason() CloseReason {
+	return CloseReason(s.closeReason.Load())
+}
+
+// callOnClose calls a function registered using OnClose, recovering from any panic in it.
+func (s *Session) callOnClose(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			s.log.Errorf("panic in close function of %s: %v\n%s", s.identity.DisplayName, r, stack)
+			s.report(report.KindPanic, nil, fmt.Errorf("panic in close function: %v", r), stack)
+		}
+	}()
+	f()
+}
+
 // Disconnect disconnects the session from the proxy and shows them the provided message. If the message is empty, the
-// player will be immediately sent to the server list instead of seeing the disconnect screen.
+// player will be immediately sent to the server list instead of seeing the disconnect screen. The session is
+// closed with CloseKicked as reason.
 func (s *Session) Disconnect(message string) {
-	_ = s.conn.WritePacket(&packet.Disconnect{
+	s.DisconnectWithReason(message, CloseKicked)
+}
+
+// DisconnectWithReason disconnects the session like Disconnect, but closes it with the reason passed.
+func (s *Session) DisconnectWithReason(message string, reason CloseReason) {
+	_ = s.clientConn().WritePacket(&packet.Disconnect{
 		HideDisconnectionScreen: message == "",
 		Message:                 message,
 	})
-	s.Close()
+	s.CloseWithReason(reason)
+}
+
+// SendMessage sends a chat message to the session that appears to come from the proxy itself.
+func (s *Session) SendMessage(message string) {
+	_ = s.clientConn().WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: message})
+}
+
+// SendTitle shows a title with the subtitle passed on the screen of the session. The subtitle may be empty.
+func (s *Session) SendTitle(title, subtitle string) {
+	if subtitle != "" {
+		_ = s.clientConn().WritePacket(&packet.SetTitle{ActionType: packet.TitleActionSetSubtitle, Text: subtitle})
+	}
+	_ = s.clientConn().WritePacket(&packet.SetTitle{ActionType: packet.TitleActionSetTitle, Text: title})
+}
+
+// SendActionBar shows the message passed above the hotbar of the session.
+func (s *Session) SendActionBar(message string) {
+	_ = s.clientConn().WritePacket(&packet.SetTitle{ActionType: packet.TitleActionSetActionBar, Text: message})
+}
+
+// SendToast shows a toast notification with the title and message passed to the session. Unlike the other
+// methods sending messages, it may be used before the session finished logging in, such as to show players their
+// position in a Queue.
+func (s *Session) SendToast(title, message string) {
+	_ = s.clientConn().WritePacket(&packet.ToastRequest{Title: title, Message: message})
 }
 
 // clearEntities flushes the entities map and despawns the entities for the client.
 func (s *Session) clearEntities() {
 	s.entities.Each(func(id int64) bool {
-		_ = s.conn.WritePacket(&packet.RemoveActor{EntityUniqueID: id})
+		_ = s.clientConn().WritePacket(&packet.RemoveActor{EntityUniqueID: id})
 		return true
 	})
 
 	s.entities.Clear()
+	if s.entityAges != nil {
+		s.entityAges.clear()
+	}
 }
 
 // clearPlayerList flushes the playerList map and removes all the entries for the client.
@@ -315,7 +957,7 @@ func (s *Session) clearPlayerList() {
 		return true
 	})
 
-	_ = s.conn.WritePacket(&packet.PlayerList{ActionType: packet.PlayerListActionRemove, Entries: entries})
+	_ = s.clientConn().WritePacket(&packet.PlayerList{ActionType: packet.PlayerListActionRemove, Entries: entries})
 
 	s.playerList.Clear()
 }
@@ -323,7 +965,7 @@ func (s *Session) clearPlayerList() {
 // clearEffects flushes the effects map and removes all the effects for the client.
 func (s *Session) clearEffects() {
 	s.effects.Each(func(i int32) bool {
-		_ = s.conn.WritePacket(&packet.MobEffect{
+		_ = s.clientConn().WritePacket(&packet.MobEffect{
 			EntityRuntimeID: s.originalRuntimeID,
 			Operation:       packet.MobEffectRemove,
 			EffectType:      i,
@@ -337,7 +979,7 @@ func (s *Session) clearEffects() {
 // clearBossBars clears all the boss bars currently visible the client.
 func (s *Session) clearBossBars() {
 	s.bossBars.Each(func(b int64) bool {
-		_ = s.conn.WritePacket(&packet.BossEvent{
+		_ = s.clientConn().WritePacket(&packet.BossEvent{
 			BossEntityUniqueID: b,
 			EventType:          packet.BossEventHide,
 		})
@@ -350,18 +992,50 @@ func (s *Session) clearBossBars() {
 // clearScoreboard clears the current scoreboard visible by the client.
 func (s *Session) clearScoreboard() {
 	s.scoreboards.Each(func(sb string) bool {
-		_ = s.conn.WritePacket(&packet.RemoveObjective{ObjectiveName: sb})
+		_ = s.clientConn().WritePacket(&packet.RemoveObjective{ObjectiveName: sb})
 		return true
 	})
 
 	s.scoreboards.Clear()
 }
 
+// clearSounds stops all the music that was started by the previous server. Sounds started using PlaySound are
+// already stopped by the StopSound packet sent when changing dimension, so only the music discs are stopped.
+func (s *Session) clearSounds() {
+	for _, pos := range s.sounds.clear() {
+		_ = s.clientConn().WritePacket(&packet.LevelSoundEvent{SoundType: packet.SoundEventRecordNull, Position: pos})
+	}
+}
+
+// sendEmoteList sends the last emote list of the client to the current server, so that the player is able to
+// use their emotes on it.
+func (s *Session) sendEmoteList() {
+	pk, ok := s.emoteList.Load().(*packet.EmoteList)
+	if !ok {
+		return
+	}
+	s.writeServerPacket(&packet.EmoteList{
+		PlayerRuntimeID: s.currentRuntimeID.Load(),
+		EmotePieces:     pk.EmotePieces,
+	})
+}
+
+// sendChunkRadius sends the chunk radius last requested by the client to the server, as servers otherwise use
+// the chunk radius requested when the proxy spawned on them.
+func (s *Session) sendChunkRadius() {
+	pk, ok := s.chunkRadius.Load().(*packet.RequestChunkRadius)
+	if !ok {
+		return
+	}
+	s.writeServerPacket(&packet.RequestChunkRadius{ChunkRadius: pk.ChunkRadius, MaxChunkRadius: pk.MaxChunkRadius})
+}
+
 func (s *Session) changeDimension(dimension int32, pos mgl32.Vec3) {
-	_ = s.conn.WritePacket(&packet.ChangeDimension{
+	s.dimension.Store(dimension)
+	_ = s.clientConn().WritePacket(&packet.ChangeDimension{
 		Dimension: dimension,
 		Position:  pos,
 	})
-	_ = s.conn.WritePacket(&packet.StopSound{StopAll: true})
-	_ = s.conn.WritePacket(&packet.PlayerAction{ActionType: protocol.PlayerActionDimensionChangeDone})
+	_ = s.clientConn().WritePacket(&packet.StopSound{StopAll: true})
+	_ = s.clientConn().WritePacket(&packet.PlayerAction{ActionType: protocol.PlayerActionDimensionChangeDone})
 }
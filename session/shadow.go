@@ -0,0 +1,52 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/paroxity/portal/server"
+)
+
+// ShadowResult is the result of a successful ShadowTransfer.
+type ShadowResult struct {
+	// Dial is the time it took to dial the server and log in to it.
+	Dial time.Duration
+	// Spawn is the time it took to spawn on the server once logged in.
+	Spawn time.Duration
+}
+
+// ShadowTransfer dials the server passed and spawns on it as the player of the session, without moving the
+// player, to test if the server accepts the player before transferring players to it, such as before a
+// migration. The connection is closed once the player spawned, so the server sees the player join and leave
+// again. The player is not allowed to shadow transfer to the server they are on, as the server may disconnect
+// the player if they join it twice.
+func (s *Session) ShadowTransfer(srv *server.Server) (ShadowResult, error) {
+	s.waitForLogin()
+	if current, ok := s.TryServer(); ok && current != nil && current.Address() == srv.Address() {
+		return ShadowResult{}, errors.New("player is already on the server")
+	}
+
+	release, err := s.env.Transfers.acquire(s.ctx, s.env.Clock)
+	if err != nil {
+		return ShadowResult{}, err
+	}
+	defer release()
+
+	start := s.env.Clock.Now()
+	conn, err := s.env.Dial(s.ctx, s, srv)
+	if err != nil {
+		return ShadowResult{}, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+	dialed := s.env.Clock.Now()
+
+	ctx, cancel := s.withTimeout(time.Minute)
+	defer cancel()
+	if err := conn.DoSpawnContext(ctx); err != nil {
+		return ShadowResult{}, fmt.Errorf("spawn: %w", err)
+	}
+	res := ShadowResult{Dial: dialed.Sub(start), Spawn: s.env.Clock.Now().Sub(dialed)}
+	s.log.Infof("%s shadow transferred to %s (dial %v, spawn %v)", s.identity.DisplayName, srv.Name(), res.Dial, res.Spawn)
+	return res, nil
+}
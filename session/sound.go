@@ -0,0 +1,94 @@
+package session
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// maxSounds is the maximum number of sounds and music discs tracked for a session. Servers that start more than
+// this are not tracked any further, so that a server playing many sounds cannot grow the state of a session
+// without limit.
+const maxSounds = 32
+
+// sounds holds the looping sounds and music playing for a session, so that they can be stopped when the session
+// is transferred to another server. One-shot sounds end by themselves and are not tracked.
+type sounds struct {
+	mu sync.Mutex
+	// names holds the names of the music started using PlaySound.
+	names map[string]struct{}
+	// records holds the positions of the music discs started using LevelSoundEvent.
+	records map[mgl32.Vec3]struct{}
+}
+
+// newSounds returns a new, empty sounds.
+func newSounds() *sounds {
+	return &sounds{names: make(map[string]struct{}), records: make(map[mgl32.Vec3]struct{})}
+}
+
+// handle updates the sounds tracked using the sound packet passed.
+func (s *sounds) handle(pk packet.Packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch pk := pk.(type) {
+	case *packet.PlaySound:
+		if music(pk.SoundName) && s.size() < maxSounds {
+			s.names[pk.SoundName] = struct{}{}
+		}
+	case *packet.StopSound:
+		if pk.StopAll {
+			s.names = make(map[string]struct{})
+			return
+		}
+		delete(s.names, pk.SoundName)
+	case *packet.LevelSoundEvent:
+		if pk.SoundType == packet.SoundEventRecordNull {
+			delete(s.records, pk.Position)
+		} else if record(pk.SoundType) && s.size() < maxSounds {
+			s.records[pk.Position] = struct{}{}
+		}
+	}
+}
+
+// clear stops tracking all sounds and returns the positions of the music discs that were playing.
+func (s *sounds) clear() []mgl32.Vec3 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	positions := make([]mgl32.Vec3, 0, len(s.records))
+	for pos := range s.records {
+		positions = append(positions, pos)
+	}
+	s.names, s.records = make(map[string]struct{}), make(map[mgl32.Vec3]struct{})
+	return positions
+}
+
+// count returns the number of sounds tracked.
+func (s *sounds) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size()
+}
+
+// size returns the number of sounds tracked. The mutex must be held when calling size.
+func (s *sounds) size() int {
+	return len(s.names) + len(s.records)
+}
+
+// music returns if the sound with the name passed is music, which keeps playing until it is stopped or the
+// track ends, as opposed to a one-shot sound.
+func music(name string) bool {
+	return strings.HasPrefix(name, "music.") || strings.HasPrefix(name, "record.")
+}
+
+// record returns if the sound event type passed starts playing a music disc.
+func record(soundType uint32) bool {
+	switch soundType {
+	case packet.SoundEventRecordPigstep, packet.SoundEventRecordOtherside, packet.SoundEventRecord5, packet.SoundEventRecordRelic:
+		return true
+	}
+	return soundType >= packet.SoundEventRecord13 && soundType <= packet.SoundEventRecordWait
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/server"
+)
+
+// AffinityProvider provides the affinity key of sessions for a StickyLoadBalancer, such as the ID of the party
+// or team a player is part of. Sessions with the same affinity key are kept on the same server.
+type AffinityProvider interface {
+	// Affinity returns the affinity key of the session passed. If an empty string is returned, the session has
+	// no affinity and is balanced as usual.
+	Affinity(s *Session) string
+}
+
+// AffinityFunc is a function that implements AffinityProvider.
+type AffinityFunc func(s *Session) string
+
+// Affinity ...
+func (f AffinityFunc) Affinity(s *Session) string {
+	return f(s)
+}
+
+// stickyEntry is the server that sessions with an affinity key were last sent to.
+type stickyEntry struct {
+	srv  *server.Server
+	time time.Time
+}
+
+// StickyLoadBalancer is a load balancer that keeps sessions with the same affinity key on the same server when
+// they join within a time window of each other, so that for example members of the same party end up on the
+// same server. Sessions without an affinity key, or with a key that was not seen within the window, are passed
+// on to the wrapped load balancer.
+type StickyLoadBalancer struct {
+	lb       LoadBalancer
+	affinity AffinityProvider
+	window   time.Duration
+
+	mu      sync.Mutex
+	entries map[string]stickyEntry
+}
+
+// NewStickyLoadBalancer creates a StickyLoadBalancer wrapping around the load balancer passed. The affinity
+// provider is used to find the affinity key of sessions, and sessions joining within the window passed of the
+// last session with the same key are sent to the same server.
+func NewStickyLoadBalancer(lb LoadBalancer, affinity AffinityProvider, window time.Duration) *StickyLoadBalancer {
+	return &StickyLoadBalancer{lb: lb, affinity: affinity, window: window, entries: make(map[string]stickyEntry)}
+}
+
+// FindServer ...
+func (b *StickyLoadBalancer) FindServer(s *Session) *server.Server {
+	return b.FindServerExcluding(s)
+}
+
+// FindServerExcluding ...
+func (b *StickyLoadBalancer) FindServerExcluding(s *Session, exclude ...*server.Server) *server.Server {
+	key := b.affinity.Affinity(s)
+	if key == "" {
+		return FindServerExcluding(b.lb, s, exclude...)
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	for k, e := range b.entries {
+		if now.Sub(e.time) > b.window {
+			delete(b.entries, k)
+		}
+	}
+	e, ok := b.entries[key]
+	b.mu.Unlock()
+	if ok && e.srv.Healthy() && e.srv.InRotation() && !e.srv.Full() && !excluded(e.srv, exclude) {
+		b.remember(key, e.srv)
+		return e.srv
+	}
+
+	srv := FindServerExcluding(b.lb, s, exclude...)
+	if srv != nil {
+		b.remember(key, srv)
+	}
+	return srv
+}
+
+// remember stores the server passed as the server that sessions with the affinity key passed are sent to.
+func (b *StickyLoadBalancer) remember(key string, srv *server.Server) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[key] = stickyEntry{srv: srv, time: time.Now()}
+}
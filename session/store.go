@@ -2,6 +2,7 @@ package session
 
 import (
 	"github.com/google/uuid"
+	"strings"
 	"sync"
 )
 
@@ -10,6 +11,22 @@ type Store struct {
 	mu           sync.Mutex
 	sessions     map[uuid.UUID]*Session
 	sessionNames map[string]*Session
+
+	// displayNames holds the display names of the sessions that have one set, by their UUIDs, and names replaces
+	// the names of those sessions with their display names.
+	namesMu      sync.RWMutex
+	displayNames map[uuid.UUID]string
+	names        map[string]string
+	replacer     *strings.Replacer
+	decorator    Decorator
+}
+
+// Decorator decorates the names of players presented to other players in the player list and chat, such as by
+// adding the prefix of their rank.
+type Decorator interface {
+	// DecorateName returns the name passed, which is the display name of the player of the session passed,
+	// decorated to be shown to other players.
+	DecorateName(s *Session, name string) string
 }
 
 // NewDefaultStore creates a new Store and returns it.
@@ -17,6 +34,9 @@ func NewDefaultStore() *Store {
 	return &Store{
 		sessions:     make(map[uuid.UUID]*Session),
 		sessionNames: make(map[string]*Session),
+
+		displayNames: make(map[uuid.UUID]string),
+		names:        make(map[string]string),
 	}
 }
 
@@ -55,7 +75,7 @@ func (s *Store) Store(x *Session) {
 	defer s.mu.Unlock()
 
 	s.sessions[x.UUID()] = x
-	s.sessionNames[x.Conn().IdentityData().DisplayName] = x
+	s.sessionNames[x.identity.DisplayName] = x
 }
 
 // Delete deletes a session from the store.
@@ -66,6 +86,91 @@ func (s *Store) Delete(x uuid.UUID) {
 	v, ok := s.sessions[x]
 	if ok {
 		delete(s.sessions, x)
-		delete(s.sessionNames, v.Conn().IdentityData().DisplayName)
+		delete(s.sessionNames, v.identity.DisplayName)
+		if v.displayName.Load() != "" {
+			s.setDisplayName(v, "")
+		}
+	}
+}
+
+// remove deletes the session passed from the store, but only if it is still the session stored for its UUID. This
+// prevents a session that is closed after a newer session of the same player was stored from removing it.
+func (s *Store) remove(x *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions[x.UUID()] == x {
+		delete(s.sessions, x.UUID())
+	}
+	if s.sessionNames[x.identity.DisplayName] == x {
+		delete(s.sessionNames, x.identity.DisplayName)
+	}
+	if x.displayName.Load() != "" {
+		s.setDisplayName(x, "")
+	}
+}
+
+// setDisplayName sets the name presented for the session passed to the players of the store. If the name is
+// empty, the name of the session is presented again.
+func (s *Store) setDisplayName(x *Session, name string) {
+	s.namesMu.Lock()
+	defer s.namesMu.Unlock()
+
+	if name == "" {
+		delete(s.displayNames, x.UUID())
+		delete(s.names, x.identity.DisplayName)
+	} else {
+		s.displayNames[x.UUID()] = name
+		s.names[x.identity.DisplayName] = name
+	}
+	s.replacer = nil
+	if len(s.names) > 0 {
+		pairs := make([]string, 0, len(s.names)*2)
+		for old, n := range s.names {
+			pairs = append(pairs, old, n)
+		}
+		s.replacer = strings.NewReplacer(pairs...)
+	}
+}
+
+// SetDecorator sets the decorator of the names of the sessions in the store presented to other players. If nil,
+// names are presented as they are.
+func (s *Store) SetDecorator(d Decorator) {
+	s.namesMu.Lock()
+	defer s.namesMu.Unlock()
+	s.decorator = d
+}
+
+// presentedName returns the name presented for the session passed in the player list and chat, which is its
+// display name decorated by the decorator of the store. False is returned if the name is not different from the
+// name of the session.
+func (s *Store) presentedName(x *Session) (string, bool) {
+	s.namesMu.RLock()
+	d := s.decorator
+	s.namesMu.RUnlock()
+
+	name := x.DisplayName()
+	if d != nil {
+		return d.DecorateName(x, name), true
+	}
+	return name, name != x.identity.DisplayName
+}
+
+// displayName returns the display name of the session with the UUID passed, if it has one set.
+func (s *Store) displayName(id uuid.UUID) (string, bool) {
+	s.namesMu.RLock()
+	defer s.namesMu.RUnlock()
+	name, ok := s.displayNames[id]
+	return name, ok
+}
+
+// replaceNames replaces the names of all sessions with a display name set in the string passed with their
+// display names.
+func (s *Store) replaceNames(str string) string {
+	s.namesMu.RLock()
+	defer s.namesMu.RUnlock()
+	if s.replacer == nil {
+		return str
 	}
+	return s.replacer.Replace(str)
 }
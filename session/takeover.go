@@ -0,0 +1,84 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/event"
+)
+
+// Takeover keeps the sessions of players that lost their connection open for a grace period, so that a player
+// reconnecting within that period is attached to their session again instead of joining the server from
+// scratch. This is mostly useful for players on flaky mobile connections. Sessions are matched by the XUID of
+// the player, so players that are not authenticated are never kept open.
+type Takeover struct {
+	grace time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewTakeover creates a new Takeover that keeps sessions open for the grace period passed.
+func NewTakeover(grace time.Duration) *Takeover {
+	return &Takeover{grace: grace, sessions: make(map[string]*Session)}
+}
+
+// Handler returns a function that creates a Handler which keeps the session open when the connection of the
+// player is lost. The function returned may be passed to portal.Handle directly.
+func (t *Takeover) Handler() func(s *Session) Handler {
+	return func(s *Session) Handler {
+		return &takeoverHandler{t: t, s: s}
+	}
+}
+
+// Claim returns the detached session of the player with the XUID passed and removes it from the Takeover. If the
+// player has no detached session, false is returned.
+func (t *Takeover) Claim(xuid string) (*Session, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[xuid]
+	if !ok || !s.Detached() || s.closed.Load() {
+		return nil, false
+	}
+	delete(t.sessions, xuid)
+	return s, true
+}
+
+// detach keeps the session passed open until it is claimed or the grace period has passed, after which it is
+// closed.
+func (t *Takeover) detach(s *Session) {
+	xuid := s.IdentityData().XUID
+
+	t.mu.Lock()
+	t.sessions[xuid] = s
+	t.mu.Unlock()
+
+	time.AfterFunc(t.grace, func() {
+		t.mu.Lock()
+		expired := t.sessions[xuid] == s
+		if expired {
+			delete(t.sessions, xuid)
+		}
+		t.mu.Unlock()
+		if expired {
+			s.CloseWithReason(CloseClientQuit)
+		}
+	})
+}
+
+// takeoverHandler is the Handler of a Takeover for a single session.
+type takeoverHandler struct {
+	NopHandler
+	t *Takeover
+	s *Session
+}
+
+// HandleClientDisconnect ...
+func (h *takeoverHandler) HandleClientDisconnect(ctx *event.Context, _ error) {
+	if h.s.IdentityData().XUID == "" || h.s.Transferring() {
+		return
+	}
+	ctx.Cancel()
+	h.t.detach(h.s)
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// TransferLimitMetrics holds the state of a TransferLimiter and the time transfers waited on it since it was
+// created.
+type TransferLimitMetrics struct {
+	// InFlight is the number of transfers currently dialing or spawning on their destination server.
+	InFlight int
+	// Queued is the number of transfers currently waiting for another transfer to finish.
+	Queued int
+	// Waits is the number of transfers that had to wait for another transfer to finish.
+	Waits uint64
+	// TotalWait is the total time transfers waited for other transfers to finish.
+	TotalWait time.Duration
+	// MaxWait is the longest time a single transfer waited for other transfers to finish.
+	MaxWait time.Duration
+}
+
+// TransferLimiter limits the number of transfers that dial and spawn on their destination server at the same
+// time across all sessions, so that moving many players at once, such as sending everyone back to the lobby,
+// does not dial thousands of connections at the same time. Transfers over the limit are queued until another
+// transfer finishes spawning. The rest of a transfer, such as the dimension change of the client, is not
+// limited.
+type TransferLimiter struct {
+	slots chan struct{}
+
+	queued             atomic.Int64
+	waits              atomic.Uint64
+	totalWait, maxWait atomic.Int64
+}
+
+// NewTransferLimiter returns a TransferLimiter which allows at most n transfers at the same time.
+func NewTransferLimiter(n int) *TransferLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return &TransferLimiter{slots: make(chan struct{}, n)}
+}
+
+// Metrics returns the state of the limiter and the time transfers waited on it since it was created.
+func (l *TransferLimiter) Metrics() TransferLimitMetrics {
+	return TransferLimitMetrics{
+		InFlight:  len(l.slots),
+		Queued:    int(l.queued.Load()),
+		Waits:     l.waits.Load(),
+		TotalWait: time.Duration(l.totalWait.Load()),
+		MaxWait:   time.Duration(l.maxWait.Load()),
+	}
+}
+
+// acquire waits until a transfer may dial its destination server, or until the context passed is done. The time
+// waited is measured using the clock passed. The function returned must be called once the transfer spawned or
+// failed. If the limiter is nil, transfers are never limited.
+func (l *TransferLimiter) acquire(ctx context.Context, clock Clock) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	release = func() { <-l.slots }
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	l.queued.Inc()
+	defer l.queued.Dec()
+	start := clock.Now()
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	wait := int64(clock.Now().Sub(start))
+	l.waits.Inc()
+	l.totalWait.Add(wait)
+	for {
+		max := l.maxWait.Load()
+		if wait <= max || l.maxWait.CAS(max, wait) {
+			break
+		}
+	}
+	return release, nil
+}
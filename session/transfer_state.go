@@ -0,0 +1,58 @@
+package session
+
+// TransferState is the state of a session in a transfer to another server. A transfer moves through the states
+// in the order they are declared in, after which the session is TransferIdle again. A transfer that fails or
+// is cancelled moves back to TransferIdle from the state it was in.
+type TransferState int32
+
+const (
+	// TransferIdle is the state of a session that is not being transferred.
+	TransferIdle TransferState = iota
+	// TransferDialing is the state of a session of which a connection to the destination server is being dialed
+	// and spawned. The current connection of the session is still used.
+	TransferDialing
+	// TransferChangingDimension is the state of a session that is spawned on the destination server, while the
+	// client is moved through a dimension change. The current connection of the session is still used until the
+	// client finishes the dimension change.
+	TransferChangingDimension
+	// TransferSwapping is the state of a session of which the client finished the dimension change, while the
+	// state of the previous server is cleared from the client and the connections are swapped.
+	TransferSwapping
+)
+
+// String returns the name of the state, such as "changing_dimension".
+func (t TransferState) String() string {
+	switch t {
+	case TransferIdle:
+		return "idle"
+	case TransferDialing:
+		return "dialing"
+	case TransferChangingDimension:
+		return "changing_dimension"
+	case TransferSwapping:
+		return "swapping"
+	}
+	return "unknown"
+}
+
+// TransferState returns the state of the session in the transfer it is in, or TransferIdle if it is not being
+// transferred.
+func (s *Session) TransferState() TransferState {
+	return TransferState(s.transferState.Load())
+}
+
+// Transferring returns if the session is currently transferring to a different server or not.
+func (s *Session) Transferring() bool {
+	return s.TransferState() != TransferIdle
+}
+
+// advanceTransfer moves the transfer of the session from the state from to the state to. False is returned if
+// the session was not in the state from, in which case its state is left unchanged.
+func (s *Session) advanceTransfer(from, to TransferState) bool {
+	return s.transferState.CAS(int32(from), int32(to))
+}
+
+// endTransfer moves the session back to TransferIdle, ending the transfer it was in.
+func (s *Session) endTransfer() {
+	s.transferState.Store(int32(TransferIdle))
+}
@@ -0,0 +1,276 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paroxity/portal"
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/portaltest"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// TestTransferStates tests that a transfer moves through every state in order and ends up idle.
+func TestTransferStates(t *testing.T) {
+	var dialStates []session.TransferState
+	e := newEnv(t, func(lobby, game *server.Server) portal.Options {
+		return portal.Options{Dial: func(ctx context.Context, s *session.Session, srv *server.Server) (*minecraft.Conn, error) {
+			dialStates = append(dialStates, s.TransferState())
+			return session.DefaultDial(ctx, s, srv)
+		}}
+	})
+	h := &stateHandler{s: e.s}
+	e.s.Handle(h)
+
+	if err := e.s.Transfer(e.game.Server()); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if state := e.s.TransferState(); state != session.TransferChangingDimension {
+		t.Fatalf("expected state %v after spawning on the destination, got %v", session.TransferChangingDimension, state)
+	}
+	e.finishDimensionChange(t)
+	waitFor(t, func() bool { return !e.s.Transferring() })
+
+	if len(dialStates) != 2 || dialStates[1] != session.TransferDialing {
+		t.Fatalf("expected state %v while dialing the destination, got %v", session.TransferDialing, dialStates)
+	}
+	if h.swapState != session.TransferSwapping {
+		t.Fatalf("expected state %v while swapping connections, got %v", session.TransferSwapping, h.swapState)
+	}
+	if srv := e.s.Server(); srv != e.game.Server() {
+		t.Fatalf("expected session to be on game, got %s", srv.Name())
+	}
+}
+
+// TestTransferFailure tests that a transfer that fails to dial the destination moves back to idle and leaves
+// the session on its server.
+func TestTransferFailure(t *testing.T) {
+	dialErr := errors.New("server unreachable")
+	e := newEnv(t, func(lobby, game *server.Server) portal.Options {
+		return portal.Options{Dial: portaltest.FailDial(dialErr, game)}
+	})
+	h := &stateHandler{s: e.s}
+	e.s.Handle(h)
+
+	if err := e.s.Transfer(e.game.Server()); !errors.Is(err, dialErr) {
+		t.Fatalf("expected error %v, got %v", dialErr, err)
+	}
+	if e.s.Transferring() {
+		t.Fatalf("expected state %v after a failed transfer, got %v", session.TransferIdle, e.s.TransferState())
+	}
+	if srv := e.s.Server(); srv != e.lobby.Server() {
+		t.Fatalf("expected session to stay on lobby, got %s", srv.Name())
+	}
+	if !errors.Is(h.failure, dialErr) {
+		t.Fatalf("expected transfer failure %v, got %v", dialErr, h.failure)
+	}
+}
+
+// TestTransferCancel tests that a transfer cancelled by a handler moves back to idle without dialing.
+func TestTransferCancel(t *testing.T) {
+	dialed := false
+	e := newEnv(t, func(lobby, game *server.Server) portal.Options {
+		return portal.Options{Dial: func(ctx context.Context, s *session.Session, srv *server.Server) (*minecraft.Conn, error) {
+			dialed = dialed || srv == game
+			return session.DefaultDial(ctx, s, srv)
+		}}
+	})
+	e.s.Handle(cancelHandler{})
+
+	if err := e.s.Transfer(e.game.Server()); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if e.s.Transferring() {
+		t.Fatalf("expected state %v after a cancelled transfer, got %v", session.TransferIdle, e.s.TransferState())
+	}
+	if dialed {
+		t.Fatalf("expected cancelled transfer not to dial the destination")
+	}
+	if srv := e.s.Server(); srv != e.lobby.Server() {
+		t.Fatalf("expected session to stay on lobby, got %s", srv.Name())
+	}
+}
+
+// TestTransferAlreadyTransferring tests that a session cannot be transferred while it is being transferred.
+func TestTransferAlreadyTransferring(t *testing.T) {
+	e := newEnv(t, nil)
+	if err := e.s.Transfer(e.game.Server()); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if err := e.s.Transfer(e.lobby.Server()); err == nil {
+		t.Fatalf("expected transfer during a transfer to fail")
+	}
+}
+
+// TestTransferSwap tests that the connection to the previous server is only closed once the connection to the
+// new server replaced it, so that ServerConn never returns a closed connection.
+func TestTransferSwap(t *testing.T) {
+	e := newEnv(t, nil)
+	previous := e.s.ServerConn()
+	if err := e.s.Transfer(e.game.Server()); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	gameConn, err := e.game.Accept(portaltest.Timeout)
+	if err != nil {
+		t.Fatalf("accept game: %v", err)
+	}
+	defer gameConn.Close()
+	if conn := e.s.ServerConn(); conn != previous {
+		t.Fatalf("expected connection to be swapped only after the dimension change")
+	}
+	e.finishDimensionChange(t)
+
+	select {
+	case <-e.lobbyConn.Closed():
+	case <-time.After(portaltest.Timeout):
+		t.Fatalf("expected connection to lobby to be closed")
+	}
+	if conn := e.s.ServerConn(); conn == previous {
+		t.Fatalf("expected connection to be swapped before the previous connection was closed")
+	}
+}
+
+// TestTransferHold tests that a session held by its destination already reports the destination as its server.
+func TestTransferHold(t *testing.T) {
+	e := newEnv(t, nil)
+	e.s.SetSpawnHold(time.Minute)
+
+	transferred := make(chan error, 1)
+	go func() {
+		transferred <- e.s.Transfer(e.game.Server())
+	}()
+	waitFor(t, func() bool { return e.s.Server() == e.game.Server() })
+	if state := e.s.TransferState(); state != session.TransferDialing {
+		t.Fatalf("expected state %v while held, got %v", session.TransferDialing, state)
+	}
+	if lobby, game := e.lobby.Server().PlayerCount(), e.game.Server().PlayerCount(); lobby != 0 || game != 1 {
+		t.Fatalf("expected player counts of 0 on lobby and 1 on game while held, got %d and %d", lobby, game)
+	}
+
+	e.s.Release()
+	if err := <-transferred; err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if state := e.s.TransferState(); state != session.TransferChangingDimension {
+		t.Fatalf("expected state %v once released, got %v", session.TransferChangingDimension, state)
+	}
+}
+
+// env is a proxy with a lobby and game server, and a client that joined the lobby.
+type env struct {
+	lobby, game *portaltest.Server
+	client      *portaltest.Conn
+	lobbyConn   *portaltest.Conn
+	s           *session.Session
+}
+
+// newEnv starts a proxy with a lobby and game server, and joins the lobby with a client. The options of the proxy
+// are returned by the function passed, which may be nil. Everything is closed when the test ends.
+func newEnv(t *testing.T, options func(lobby, game *server.Server) portal.Options) *env {
+	t.Helper()
+	e := &env{}
+	var err error
+	if e.lobby, err = portaltest.NewServer("lobby"); err != nil {
+		t.Fatalf("start lobby: %v", err)
+	}
+	t.Cleanup(func() { _ = e.lobby.Close() })
+	if e.game, err = portaltest.NewServer("game"); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	t.Cleanup(func() { _ = e.game.Close() })
+
+	var opts portal.Options
+	if options != nil {
+		opts = options(e.lobby.Server(), e.game.Server())
+	}
+	p, err := portaltest.NewProxy(opts, e.lobby)
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+
+	if e.client, err = portaltest.Dial(p.Addr(), "Steve"); err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = e.client.Close() })
+	if e.lobbyConn, err = e.lobby.Accept(portaltest.Timeout); err != nil {
+		t.Fatalf("accept lobby: %v", err)
+	}
+	t.Cleanup(func() { _ = e.lobbyConn.Close() })
+	// The game server is only added once the client joined, so that the client always joins the lobby.
+	p.ServerRegistry().AddServer(e.game.Server())
+	if e.s, err = p.Session(portaltest.Timeout); err != nil {
+		t.Fatalf("session: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), portaltest.Timeout)
+	defer cancel()
+	if err := e.s.WaitLogin(ctx); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	return e
+}
+
+// finishDimensionChange waits for the client to be moved to another dimension and finishes the dimension change.
+func (e *env) finishDimensionChange(t *testing.T) {
+	t.Helper()
+	if _, err := portaltest.ExpectPacket[*packet.ChangeDimension](e.client, portaltest.Timeout); err != nil {
+		t.Fatalf("expect dimension change: %v", err)
+	}
+	if err := e.client.WritePacket(&packet.PlayerAction{ActionType: protocol.PlayerActionDimensionChangeDone}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// waitFor waits until the function passed returns true, failing the test if it does not within the timeout.
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(portaltest.Timeout)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %v", portaltest.Timeout)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// stateHandler records the transfer state of a session while its connections are swapped, and the error of the
+// last failed transfer.
+type stateHandler struct {
+	session.NopHandler
+	s *session.Session
+
+	mu        sync.Mutex
+	swapState session.TransferState
+	failure   error
+}
+
+// HandleChangeConn ...
+func (h *stateHandler) HandleChangeConn(*minecraft.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.swapState = h.s.TransferState()
+}
+
+// HandleTransferFailure ...
+func (h *stateHandler) HandleTransferFailure(_ *server.Server, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failure = err
+}
+
+// cancelHandler cancels every transfer.
+type cancelHandler struct {
+	session.NopHandler
+}
+
+// HandleTransfer ...
+func (cancelHandler) HandleTransfer(ctx *event.Context, _ *server.Server) {
+	ctx.Cancel()
+}
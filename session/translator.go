@@ -14,6 +14,9 @@ type translator struct {
 
 	currentRuntimeID atomic.Uint64
 	currentUniqueID  atomic.Int64
+
+	// dimension is the dimension the player is currently in.
+	dimension atomic.Int32
 }
 
 // newTranslator creates a new translator based off of the provided GameData from the initial server.
@@ -24,13 +27,29 @@ func newTranslator(data minecraft.GameData) *translator {
 
 		currentRuntimeID: *atomic.NewUint64(data.EntityRuntimeID),
 		currentUniqueID:  *atomic.NewInt64(data.EntityUniqueID),
+
+		dimension: *atomic.NewInt32(data.Dimension),
 	}
 }
 
-// updateTranslatorData updates the translator with the runtime IDs from a new server.
+// updateTranslatorData updates the translator with the runtime IDs and dimension from a new server.
 func (t *translator) updateTranslatorData(data minecraft.GameData) {
 	t.currentRuntimeID.Store(data.EntityRuntimeID)
 	t.currentUniqueID.Store(data.EntityUniqueID)
+	t.dimension.Store(data.Dimension)
+}
+
+// restore sets the runtime IDs of the player as known by the client to those passed, such as the IDs the client
+// was given before a handoff, so that they are translated to the runtime IDs from the current server.
+func (t *translator) restore(runtimeID uint64, uniqueID int64) {
+	t.originalRuntimeID = runtimeID
+	t.originalUniqueID = uniqueID
+}
+
+// translates returns if the translator changes any IDs, which is the case once the player is on a server that
+// gave them different IDs than the IDs known by their client.
+func (t *translator) translates() bool {
+	return t.originalRuntimeID != t.currentRuntimeID.Load() || t.originalUniqueID != t.currentUniqueID.Load()
 }
 
 // translatePacket translates the runtime IDs in packets sent by the client and the connected server. If this
@@ -1,8 +1,12 @@
 package session
 
 import (
+	"sync"
+
+	"github.com/paroxity/portal/storage"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/text"
+	"go.uber.org/atomic"
 )
 
 // Whitelist handles the players joining the proxy to decide which are allowed join.
@@ -12,19 +16,71 @@ type Whitelist interface {
 	Authorize(conn *minecraft.Conn) (bool, string)
 }
 
+// EditableWhitelist is a Whitelist of which the players may be listed and replaced while the proxy is running,
+// such as when importing the state of the proxy.
+type EditableWhitelist interface {
+	Whitelist
+	// Enabled returns if the whitelist is enabled.
+	Enabled() bool
+	// SetEnabled enables or disables the whitelist.
+	SetEnabled(enabled bool)
+	// Players returns the names of the players on the whitelist.
+	Players() ([]string, error)
+	// SetPlayers replaces the players on the whitelist with the players with the names passed.
+	SetPlayers(players []string) error
+}
+
+// Compile time checks to make sure SimpleWhitelist and ProviderWhitelist implement EditableWhitelist.
+var (
+	_ EditableWhitelist = (*SimpleWhitelist)(nil)
+	_ EditableWhitelist = (*ProviderWhitelist)(nil)
+)
+
 // SimpleWhitelist is a whitelist that, if enabled, only allows a set list of players to join.
 type SimpleWhitelist struct {
+	mu      sync.RWMutex
 	enabled bool
 	players []string
 }
 
 // NewSimpleWhitelist returns a simple whitelist from the enabled status and a player list passed.
 func NewSimpleWhitelist(enabled bool, players []string) *SimpleWhitelist {
-	return &SimpleWhitelist{enabled, players}
+	return &SimpleWhitelist{enabled: enabled, players: players}
+}
+
+// Enabled ...
+func (s *SimpleWhitelist) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled
+}
+
+// SetEnabled ...
+func (s *SimpleWhitelist) SetEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = enabled
+}
+
+// Players ...
+func (s *SimpleWhitelist) Players() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append(make([]string, 0, len(s.players)), s.players...), nil
+}
+
+// SetPlayers ...
+func (s *SimpleWhitelist) SetPlayers(players []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.players = append([]string(nil), players...)
+	return nil
 }
 
 // Authorize ...
 func (s *SimpleWhitelist) Authorize(conn *minecraft.Conn) (bool, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if !s.enabled {
 		return true, ""
 	}
@@ -36,3 +92,74 @@ func (s *SimpleWhitelist) Authorize(conn *minecraft.Conn) (bool, string) {
 	}
 	return false, text.Colourf("<red>Server is whitelisted</red>")
 }
+
+// ProviderWhitelist is a whitelist that, if enabled, only allows players stored using a storage.Provider to
+// join. Players may be added and removed while the proxy is running.
+type ProviderWhitelist struct {
+	enabled atomic.Bool
+	p       storage.Provider
+}
+
+// whitelistBucket is the bucket of the storage.Provider in which whitelisted players are stored.
+const whitelistBucket = "whitelist"
+
+// NewProviderWhitelist returns a whitelist from the enabled status passed, which stores whitelisted players
+// using the provider passed.
+func NewProviderWhitelist(enabled bool, p storage.Provider) *ProviderWhitelist {
+	w := &ProviderWhitelist{p: p}
+	w.enabled.Store(enabled)
+	return w
+}
+
+// Enabled ...
+func (s *ProviderWhitelist) Enabled() bool {
+	return s.enabled.Load()
+}
+
+// SetEnabled ...
+func (s *ProviderWhitelist) SetEnabled(enabled bool) {
+	s.enabled.Store(enabled)
+}
+
+// Players ...
+func (s *ProviderWhitelist) Players() ([]string, error) {
+	return s.p.Keys(whitelistBucket)
+}
+
+// SetPlayers ...
+func (s *ProviderWhitelist) SetPlayers(players []string) error {
+	if err := s.p.Clear(whitelistBucket); err != nil {
+		return err
+	}
+	for _, name := range players {
+		if err := s.Add(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add adds the player with the username passed to the whitelist.
+func (s *ProviderWhitelist) Add(name string) error {
+	return s.p.Put(whitelistBucket, name, []byte(name))
+}
+
+// Remove removes the player with the username passed from the whitelist.
+func (s *ProviderWhitelist) Remove(name string) error {
+	return s.p.Delete(whitelistBucket, name)
+}
+
+// Authorize ...
+func (s *ProviderWhitelist) Authorize(conn *minecraft.Conn) (bool, string) {
+	if !s.enabled.Load() {
+		return true, ""
+	}
+	_, ok, err := s.p.Get(whitelistBucket, conn.IdentityData().DisplayName)
+	if err != nil {
+		return false, text.Colourf("<red>Unable to check the whitelist, please try again later</red>")
+	}
+	if !ok {
+		return false, text.Colourf("<red>Server is whitelisted</red>")
+	}
+	return true, ""
+}
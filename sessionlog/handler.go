@@ -0,0 +1,85 @@
+package sessionlog
+
+import (
+	"time"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"go.uber.org/atomic"
+)
+
+// NewHandler returns a function that creates a session.Handler which records the lifecycle of the session in
+// the log passed. Errors that occur while recording are logged to the logger passed. The function returned may
+// be passed to portal.Handle directly.
+func NewHandler(l *Log, log internal.Logger) func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{l: l, log: log, s: s}
+	}
+}
+
+// handler is a session.Handler that records the lifecycle of a single session.
+type handler struct {
+	session.NopHandler
+	l   *Log
+	log internal.Logger
+	s   *session.Session
+
+	joined atomic.Bool
+	// server is the name of the server the session last connected to. It is tracked by the handler, as the
+	// server of the session may not be looked up while its connection is being changed.
+	server atomic.String
+}
+
+// HandleServerConnect ...
+func (h *handler) HandleServerConnect(srv *server.Server, _ *minecraft.Conn) {
+	h.server.Store(srv.Name())
+	if h.joined.CAS(false, true) {
+		h.record(EventJoin, srv.Name(), "")
+	}
+}
+
+// HandleTransfer ...
+func (h *handler) HandleTransfer(_ *event.Context, srv *server.Server) {
+	h.record(EventTransfer, srv.Name(), "")
+}
+
+// HandleChangeConn ...
+func (h *handler) HandleChangeConn(*minecraft.Conn) {
+	h.record(EventTransferComplete, h.server.Load(), "")
+}
+
+// HandleTransferFailure ...
+func (h *handler) HandleTransferFailure(srv *server.Server, err error) {
+	h.record(EventTransferFailure, srv.Name(), err.Error())
+}
+
+// HandleServerKick ...
+func (h *handler) HandleServerKick(_ *event.Context, srv *server.Server, message string) {
+	h.record(EventServerKick, srv.Name(), message)
+}
+
+// HandleServerDisconnect ...
+func (h *handler) HandleServerDisconnect(_ *event.Context, err error) {
+	h.record(EventServerDisconnect, h.server.Load(), err.Error())
+}
+
+// HandleClientDisconnect ...
+func (h *handler) HandleClientDisconnect(_ *event.Context, err error) {
+	h.record(EventClientDisconnect, h.server.Load(), err.Error())
+}
+
+// HandleQuit ...
+func (h *handler) HandleQuit(reason session.CloseReason) {
+	h.record(EventQuit, h.server.Load(), reason.String())
+}
+
+// record records an event of the session in the log.
+func (h *handler) record(e Event, srv, message string) {
+	r := Record{Time: time.Now(), Event: e, PlayerName: h.s.IdentityData().DisplayName, CorrelationID: h.s.CorrelationID(), Server: srv, Message: message}
+	if err := h.l.Record(h.s.UUID(), r); err != nil {
+		h.log.Errorf("failed to record %s of %s in session log: %v", e, r.PlayerName, err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package sessionlog implements logging of the lifecycle of every session to a file of its own, such as when
+// the player joined, which servers they were transferred to and why they left, for support investigations.
+package sessionlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/internal"
+)
+
+// dateLayout is the layout of the names of the directories that hold the files of a single day.
+const dateLayout = "2006-01-02"
+
+// Log writes records of sessions to files in a directory. The records of a session are written to a file named
+// after the UUID of the player, in a directory named after the date of the record, such as
+// "sessions/2024-01-31/<uuid>.jsonl", in the JSON lines format.
+type Log struct {
+	dir       string
+	retention time.Duration
+	log       internal.Logger
+
+	mu sync.Mutex
+}
+
+// NewLog creates a Log that writes records to the directory passed. Days of records older than the retention
+// passed are removed by Prune. If the retention is zero, records are never removed. Errors that occur while
+// writing or removing records are logged to the logger passed.
+func NewLog(dir string, retention time.Duration, log internal.Logger) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Log{dir: dir, retention: retention, log: log}, nil
+}
+
+// Record writes the record passed to the file of the session with the UUID passed.
+func (l *Log) Record(id uuid.UUID, r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(l.dir, r.Time.Format(dateLayout))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, id.String()+".jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Prune removes the records of the days that are older than the retention of the log, relative to the time
+// passed.
+func (l *Log) Prune(now time.Time) {
+	if l.retention <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		l.log.Errorf("unable to read session log directory: %v", err)
+		return
+	}
+	oldest := now.Add(-l.retention).Format(dateLayout)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range entries {
+		if _, err := time.Parse(dateLayout, e.Name()); err != nil || !e.IsDir() {
+			// Files not written by the log are left alone.
+			continue
+		}
+		// Dates in this layout sort in the same order as the days they represent.
+		if e.Name() < oldest {
+			if err := os.RemoveAll(filepath.Join(l.dir, e.Name())); err != nil {
+				l.log.Errorf("unable to remove session logs of %s: %v", e.Name(), err)
+			}
+		}
+	}
+}
+
+// Run prunes the records of the log every interval until the channel passed is closed.
+func (l *Log) Run(interval time.Duration, stop <-chan struct{}) {
+	l.Prune(time.Now())
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			l.Prune(time.Now())
+		case <-stop:
+			return
+		}
+	}
+}
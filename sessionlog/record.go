@@ -0,0 +1,43 @@
+package sessionlog
+
+import "time"
+
+// Event is the type of event in the lifecycle of a session that a Record describes.
+type Event string
+
+const (
+	// EventJoin is recorded when the session joined its first server.
+	EventJoin Event = "join"
+	// EventTransfer is recorded when the session started being transferred to another server.
+	EventTransfer Event = "transfer"
+	// EventTransferComplete is recorded when the session finished being transferred to another server.
+	EventTransferComplete Event = "transfer_complete"
+	// EventTransferFailure is recorded when a transfer of the session failed.
+	EventTransferFailure Event = "transfer_failure"
+	// EventServerKick is recorded when the server of the session kicked the player.
+	EventServerKick Event = "server_kick"
+	// EventServerDisconnect is recorded when the connection to the server of the session was closed
+	// unexpectedly.
+	EventServerDisconnect Event = "server_disconnect"
+	// EventClientDisconnect is recorded when the connection of the player was closed unexpectedly.
+	EventClientDisconnect Event = "client_disconnect"
+	// EventQuit is recorded when the session was closed.
+	EventQuit Event = "quit"
+)
+
+// Record is a single event in the lifecycle of a session.
+type Record struct {
+	// Time is the time at which the event occurred.
+	Time time.Time `json:"time"`
+	// Event is the type of the event.
+	Event Event `json:"event"`
+	// PlayerName is the name of the player of the session.
+	PlayerName string `json:"player_name"`
+	// CorrelationID is the correlation ID of the session, which is also included in the logs of the proxy.
+	CorrelationID string `json:"correlation_id"`
+	// Server is the name of the server the event concerns, such as the destination of a transfer.
+	Server string `json:"server,omitempty"`
+	// Message holds details of the event, such as the error that caused a disconnection or the reason the
+	// session was closed with.
+	Message string `json:"message,omitempty"`
+}
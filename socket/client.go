@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/paroxity/portal/internal"
 	"github.com/paroxity/portal/socket/packet"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
@@ -135,3 +136,13 @@ func (c *Client) WritePacket(pk packet.Packet) error {
 
 	return nil
 }
+
+// SendMessage sends a plugin message on the channel passed to the client. The player passed may be uuid.Nil if
+// the message does not relate to a specific player.
+func (c *Client) SendMessage(channel string, player uuid.UUID, payload []byte) error {
+	return c.WritePacket(&packet.PluginMessage{
+		Channel:    channel,
+		PlayerUUID: player,
+		Payload:    payload,
+	})
+}
@@ -0,0 +1,39 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// CorrelationChannel is the channel of the plugin messages sent to servers by the handler returned by
+// CorrelationIDs. The payload of the messages is the correlation ID of the player the message is sent for.
+const CorrelationChannel = "portal:correlation"
+
+// CorrelationIDs returns a function that creates a session.Handler which sends the correlation ID of the session
+// to every server it connects to on the CorrelationChannel, once it spawned on the server. Servers may include
+// the ID in their own logs about the player, so that operators are able to find the logs of the same session
+// across the proxy and its servers. The function returned may be passed to portal.Handle directly.
+func (s *DefaultServer) CorrelationIDs() func(*session.Session) session.Handler {
+	return func(sess *session.Session) session.Handler {
+		return &correlationHandler{srv: s, s: sess}
+	}
+}
+
+// correlationHandler is the session.Handler returned by CorrelationIDs.
+type correlationHandler struct {
+	session.NopHandler
+
+	srv *DefaultServer
+	s   *session.Session
+}
+
+// HandleServerConnect ...
+func (h *correlationHandler) HandleServerConnect(srv *server.Server, _ *minecraft.Conn) {
+	if _, ok := h.srv.Client(srv.Name()); !ok {
+		return
+	}
+	if err := h.srv.messenger.Send(srv.Name(), CorrelationChannel, h.s.UUID(), []byte(h.s.CorrelationID())); err != nil {
+		h.srv.log.Errorf("failed to send correlation ID of %s to %s: %v", h.s.IdentityData().DisplayName, srv.Name(), err)
+	}
+}
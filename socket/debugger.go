@@ -0,0 +1,188 @@
+package socket
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/socket/packet"
+	mcpacket "github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// debugQueueSize is the number of packets that may be waiting to be sent to a connection attached to a session
+// before packets are dropped.
+const debugQueueSize = 1024
+
+// DebugFilter decides which packets of a session are sent to a connection attached to it.
+type DebugFilter struct {
+	// Direction is the direction of the packets sent, such as packet.DebugDirectionClientBound.
+	Direction byte
+	// PacketIDs holds the IDs of the packets sent. If empty, packets of any ID are sent.
+	PacketIDs []uint32
+}
+
+// matches returns if a packet with the ID passed sent in the direction passed matches the filter.
+func (f DebugFilter) matches(clientBound bool, id uint32) bool {
+	switch f.Direction {
+	case packet.DebugDirectionClientBound:
+		if !clientBound {
+			return false
+		}
+	case packet.DebugDirectionServerBound:
+		if clientBound {
+			return false
+		}
+	}
+	if len(f.PacketIDs) == 0 {
+		return true
+	}
+	for _, i := range f.PacketIDs {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Debugger keeps track of the sessions that socket connections are attached to in order to inspect their
+// packets. The packets of an attached session are encoded as JSON and sent to the connection as they pass
+// through the proxy, turning the proxy into a live protocol inspector.
+type Debugger struct {
+	srv Server
+
+	mu       sync.Mutex
+	attached map[*Client]map[uuid.UUID]*debugHandler
+}
+
+// NewDebugger creates a Debugger which logs using the logger of the server passed.
+func NewDebugger(srv Server) *Debugger {
+	return &Debugger{srv: srv, attached: make(map[*Client]map[uuid.UUID]*debugHandler)}
+}
+
+// Attach attaches the client passed to the session passed, so that the packets of the session matching the
+// filter passed are sent to the client. If the client was already attached to the session, its filter is
+// replaced.
+func (d *Debugger) Attach(c *Client, s *session.Session, filter DebugFilter) {
+	d.Detach(c, s.UUID())
+
+	h := &debugHandler{d: d, c: c, s: s, filter: filter, packets: make(chan *packet.DebugPacket, debugQueueSize), done: make(chan struct{})}
+	d.mu.Lock()
+	if d.attached[c] == nil {
+		d.attached[c] = make(map[uuid.UUID]*debugHandler)
+	}
+	d.attached[c][s.UUID()] = h
+	d.mu.Unlock()
+
+	go h.run()
+	s.AddHandler(h, 0)
+	d.srv.Logger().Infof("socket connection \"%s\" attached to %s", c.Name(), s.IdentityData().DisplayName)
+}
+
+// Detach detaches the client passed from the session with the UUID passed. It returns false if the client was
+// not attached to the session.
+func (d *Debugger) Detach(c *Client, id uuid.UUID) bool {
+	d.mu.Lock()
+	h, ok := d.attached[c][id]
+	if ok {
+		delete(d.attached[c], id)
+		if len(d.attached[c]) == 0 {
+			delete(d.attached, c)
+		}
+	}
+	d.mu.Unlock()
+	if ok {
+		h.s.RemoveHandler(h)
+		h.stop()
+	}
+	return ok
+}
+
+// DetachAll detaches the client passed from all sessions it is attached to.
+func (d *Debugger) DetachAll(c *Client) {
+	d.mu.Lock()
+	ids := make([]uuid.UUID, 0, len(d.attached[c]))
+	for id := range d.attached[c] {
+		ids = append(ids, id)
+	}
+	d.mu.Unlock()
+	for _, id := range ids {
+		d.Detach(c, id)
+	}
+}
+
+// debugHandler is the session.Handler added to sessions that a client is attached to. Packets are encoded on
+// the goroutine of the session, so that they are sent as they were when they passed through the proxy, but
+// written to the client on a goroutine of their own, so that a slow client does not stall the session.
+type debugHandler struct {
+	session.NopHandler
+
+	d      *Debugger
+	c      *Client
+	s      *session.Session
+	filter DebugFilter
+
+	packets  chan *packet.DebugPacket
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// HandleClientBoundPacket ...
+func (h *debugHandler) HandleClientBoundPacket(_ *event.Context, pk mcpacket.Packet) {
+	h.queue(true, pk)
+}
+
+// HandleServerBoundPacket ...
+func (h *debugHandler) HandleServerBoundPacket(_ *event.Context, pk mcpacket.Packet) {
+	h.queue(false, pk)
+}
+
+// HandleQuit ...
+func (h *debugHandler) HandleQuit(session.CloseReason) {
+	if h.d.Detach(h.c, h.s.UUID()) {
+		_ = h.c.WritePacket(&packet.DebugResponse{PlayerUUID: h.s.UUID(), Status: packet.DebugResponseSessionClosed})
+	}
+}
+
+// queue encodes the packet passed and queues it to be sent to the client if it matches the filter of the
+// handler. If the client lags behind too far, the packet is dropped.
+func (h *debugHandler) queue(clientBound bool, pk mcpacket.Packet) {
+	if !h.filter.matches(clientBound, pk.ID()) {
+		return
+	}
+	payload, err := json.Marshal(pk)
+	if err != nil {
+		payload, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	t := reflect.TypeOf(pk)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	select {
+	case h.packets <- &packet.DebugPacket{PlayerUUID: h.s.UUID(), ClientBound: clientBound, PacketID: pk.ID(), Name: t.Name(), Payload: payload}:
+	default:
+	}
+}
+
+// run writes the queued packets to the client until the handler is stopped.
+func (h *debugHandler) run() {
+	for {
+		select {
+		case pk := <-h.packets:
+			if err := h.c.WritePacket(pk); err != nil {
+				h.d.srv.Logger().Debugf("unable to send debug packet to socket connection \"%s\": %v", h.c.Name(), err)
+				go h.d.Detach(h.c, h.s.UUID())
+				return
+			}
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// stop stops writing packets to the client.
+func (h *debugHandler) stop() {
+	h.stopOnce.Do(func() { close(h.done) })
+}
@@ -0,0 +1,40 @@
+package socket
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// Dial connects to the socket server of a proxy at the address passed and authenticates with the secret and
+// name passed. The Client returned may be used by tools to send requests to the proxy and read the responses.
+func Dial(address, secret, name string, log internal.Logger) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, time.Second*10)
+	if err != nil {
+		return nil, err
+	}
+	c := NewClient(conn, log, true)
+	if err := c.WritePacket(&packet.AuthRequest{Protocol: packet.ProtocolVersion, Secret: secret, Name: name}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	pk, err := c.ReadPacket()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	resp, ok := pk.(*packet.AuthResponse)
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("expected AuthResponse, got %T", pk)
+	}
+	if resp.Status != packet.AuthResponseSuccess {
+		_ = conn.Close()
+		return nil, fmt.Errorf("authentication failed with status %d", resp.Status)
+	}
+	c.Authenticate(name)
+	return c, nil
+}
@@ -27,6 +27,27 @@ func init() {
 	RegisterHandler(packet.IDPlayerInfoRequest, &PlayerInfoRequestHandler{})
 	RegisterHandler(packet.IDServerListRequest, &ServerListRequestHandler{})
 	RegisterHandler(packet.IDFindPlayerRequest, &FindPlayerRequestHandler{})
+	RegisterHandler(packet.IDQueueInfoRequest, &QueueInfoRequestHandler{})
+	RegisterHandler(packet.IDAuditRequest, &AuditRequestHandler{})
+	RegisterHandler(packet.IDUpdateServerCapacity, &UpdateServerCapacityHandler{})
+	RegisterHandler(packet.IDRestartRequest, &RestartRequestHandler{})
+	RegisterHandler(packet.IDPluginMessage, &PluginMessageHandler{})
+	RegisterHandler(packet.IDReleasePlayer, &ReleasePlayerHandler{})
+	RegisterHandler(packet.IDBroadcastRequest, &BroadcastRequestHandler{})
+	RegisterHandler(packet.IDStatsRequest, &StatsRequestHandler{})
+	RegisterHandler(packet.IDPlayerListRequest, &PlayerListRequestHandler{})
+	RegisterHandler(packet.IDKickRequest, &KickRequestHandler{})
+	RegisterHandler(packet.IDMaintenanceRequest, &MaintenanceRequestHandler{})
+	RegisterHandler(packet.IDUpdateServerMetadata, &UpdateServerMetadataHandler{})
+	RegisterHandler(packet.IDCanaryRequest, &CanaryRequestHandler{})
+	RegisterHandler(packet.IDDebugRequest, &DebugRequestHandler{})
+	RegisterHandler(packet.IDReconnectRequest, &ReconnectRequestHandler{})
+	RegisterHandler(packet.IDProxyInfoRequest, &ProxyInfoRequestHandler{})
+	RegisterHandler(packet.IDSlowModeRequest, &SlowModeRequestHandler{})
+	RegisterHandler(packet.IDShadowTransferRequest, &ShadowTransferRequestHandler{})
+	RegisterHandler(packet.IDStateRequest, &StateRequestHandler{})
+	RegisterHandler(packet.IDFootprintRequest, &FootprintRequestHandler{})
+	RegisterHandler(packet.IDServerPacksRequest, &ServerPacksRequestHandler{})
 }
 
 // requireAuth implements the RequiresAuth() method and always returns true.
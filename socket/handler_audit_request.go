@@ -0,0 +1,33 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// AuditRequestHandler is responsible for handling the AuditRequest packet sent by servers.
+type AuditRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*AuditRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.AuditRequest)
+	l := srv.Audit()
+	if l == nil {
+		return c.WritePacket(&packet.AuditResponse{})
+	}
+
+	var entries []packet.AuditEntry
+	for _, e := range l.Query(pk.PlayerName, int(pk.Limit)) {
+		entries = append(entries, packet.AuditEntry{
+			Time:       e.Time.UnixMilli(),
+			PlayerUUID: e.PlayerUUID,
+			PlayerName: e.PlayerName,
+			Server:     e.Server,
+			Command:    e.Command,
+			Proxy:      e.Proxy,
+		})
+	}
+	return c.WritePacket(&packet.AuditResponse{
+		Enabled: true,
+		Entries: entries,
+	})
+}
@@ -0,0 +1,37 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/broadcast"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// BroadcastRequestHandler is responsible for handling the BroadcastRequest packet sent by servers.
+type BroadcastRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*BroadcastRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.BroadcastRequest)
+	response := func(status byte) error {
+		return c.WritePacket(&packet.BroadcastResponse{Status: status})
+	}
+
+	b := srv.Broadcaster()
+	if b == nil {
+		return response(packet.BroadcastResponseUnavailable)
+	}
+	t := broadcast.Target{Group: pk.Group, Server: pk.Server}
+
+	var err error
+	switch pk.Type {
+	case packet.BroadcastTypeMessage:
+		err = b.Message(t, pk.Message)
+	case packet.BroadcastTypeTitle:
+		err = b.Title(t, pk.Message, pk.Subtitle)
+	default:
+		return response(packet.BroadcastResponseInvalidType)
+	}
+	if err != nil {
+		return response(packet.BroadcastResponseRateLimited)
+	}
+	return response(packet.BroadcastResponseSuccess)
+}
@@ -0,0 +1,39 @@
+package socket
+
+import (
+	"strings"
+
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// CanaryRequestHandler is responsible for handling the CanaryRequest packet sent by connections.
+type CanaryRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*CanaryRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.CanaryRequest)
+	response := func(status byte) error {
+		return c.WritePacket(&packet.CanaryResponse{Group: pk.Group, Status: status})
+	}
+
+	balancer := srv.Canary()
+	if balancer == nil {
+		return response(packet.CanaryResponseUnavailable)
+	}
+	if pk.Remove {
+		balancer.RemoveCanary(pk.Group)
+		srv.Logger().Infof("socket connection \"%s\" removed the canary of group %s", c.Name(), pk.Group)
+		return response(packet.CanaryResponseSuccess)
+	}
+	if pk.Percentage < 0 || pk.Percentage > 100 {
+		return response(packet.CanaryResponseInvalidPercentage)
+	}
+	target, ok := srv.ServerRegistry().Server(pk.Server)
+	if !ok || pk.Group == "" || !strings.EqualFold(target.Group(), pk.Group) {
+		return response(packet.CanaryResponseServerNotFound)
+	}
+	balancer.SetCanary(pk.Group, session.Canary{Server: target.Name(), Percentage: float64(pk.Percentage), XUIDs: pk.XUIDs})
+	srv.Logger().Infof("socket connection \"%s\" set the canary of group %s to %s at %g%%", c.Name(), pk.Group, target.Name(), pk.Percentage)
+	return response(packet.CanaryResponseSuccess)
+}
@@ -0,0 +1,26 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/control"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// DebugRequestHandler is responsible for handling the DebugRequest packet sent by connections.
+type DebugRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*DebugRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.DebugRequest)
+	s, err := control.Find(srv.SessionStore(), pk.PlayerUUID, pk.PlayerName)
+	if err != nil {
+		return c.WritePacket(&packet.DebugResponse{PlayerUUID: pk.PlayerUUID, Status: packet.DebugResponsePlayerNotFound})
+	}
+
+	if pk.Detach {
+		srv.Debugger().Detach(c, s.UUID())
+		srv.Logger().Infof("socket connection \"%s\" detached from %s", c.Name(), s.IdentityData().DisplayName)
+	} else {
+		srv.Debugger().Attach(c, s, DebugFilter{Direction: pk.Direction, PacketIDs: pk.PacketIDs})
+	}
+	return c.WritePacket(&packet.DebugResponse{PlayerUUID: s.UUID(), Status: packet.DebugResponseSuccess})
+}
@@ -24,7 +24,7 @@ func (*FindPlayerRequestHandler) Handle(p packet.Packet, srv Server, c *Client)
 
 	return c.WritePacket(&packet.FindPlayerResponse{
 		PlayerUUID: s.UUID(),
-		PlayerName: s.Conn().IdentityData().DisplayName,
+		PlayerName: s.IdentityData().DisplayName,
 		Online:     true,
 		Server:     s.Server().Name(),
 	})
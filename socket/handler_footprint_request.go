@@ -0,0 +1,64 @@
+package socket
+
+import (
+	"sort"
+
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// FootprintRequestHandler is responsible for handling the FootprintRequest packet sent by connections.
+type FootprintRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*FootprintRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.FootprintRequest)
+	all := srv.SessionStore().All()
+	footprints := make([]session.Footprint, len(all))
+	for i, s := range all {
+		footprints[i] = s.Footprint()
+	}
+	order := make([]int, len(all))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return footprints[order[i]].Total() > footprints[order[j]].Total()
+	})
+	if pk.Limit > 0 && int(pk.Limit) < len(order) {
+		order = order[:pk.Limit]
+	}
+
+	var total session.Footprint
+	for _, f := range footprints {
+		total = total.Add(f)
+	}
+	resp := &packet.FootprintResponse{Total: footprint(total), Sessions: make([]packet.SessionFootprint, 0, len(order))}
+	for _, i := range order {
+		var name string
+		if sessionServer, ok := all[i].TryServer(); ok && sessionServer != nil {
+			name = sessionServer.Name()
+		}
+		resp.Sessions = append(resp.Sessions, packet.SessionFootprint{
+			PlayerUUID: all[i].UUID(),
+			PlayerName: all[i].IdentityData().DisplayName,
+			Server:     name,
+			Footprint:  footprint(footprints[i]),
+		})
+	}
+	return c.WritePacket(resp)
+}
+
+// footprint converts the footprint passed to the footprint sent over the socket.
+func footprint(f session.Footprint) packet.Footprint {
+	return packet.Footprint{
+		Entities:    int32(f.Entities),
+		PlayerList:  int32(f.PlayerList),
+		Effects:     int32(f.Effects),
+		BossBars:    int32(f.BossBars),
+		Scoreboards: int32(f.Scoreboards),
+		Sounds:      int32(f.Sounds),
+		BlobHashes:  int32(f.BlobHashes),
+		Dimensions:  int32(f.Dimensions),
+	}
+}
@@ -0,0 +1,21 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/control"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// KickRequestHandler is responsible for handling the KickRequest packet sent by connections.
+type KickRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*KickRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.KickRequest)
+	s, err := control.Kick(srv.SessionStore(), pk.PlayerUUID, pk.PlayerName, pk.Message)
+	if err != nil {
+		return c.WritePacket(&packet.KickResponse{PlayerUUID: pk.PlayerUUID, Status: packet.KickResponsePlayerNotFound})
+	}
+
+	srv.Logger().Infof("socket connection \"%s\" kicked %s: %s", c.Name(), s.IdentityData().DisplayName, pk.Message)
+	return c.WritePacket(&packet.KickResponse{PlayerUUID: s.UUID(), Status: packet.KickResponseSuccess})
+}
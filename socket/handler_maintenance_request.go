@@ -0,0 +1,21 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/control"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// MaintenanceRequestHandler is responsible for handling the MaintenanceRequest packet sent by connections.
+type MaintenanceRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*MaintenanceRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.MaintenanceRequest)
+	target, err := control.SetMaintenance(srv.ServerRegistry(), pk.Server, pk.Enabled)
+	if err != nil {
+		return c.WritePacket(&packet.MaintenanceResponse{Server: pk.Server, Status: packet.MaintenanceResponseServerNotFound})
+	}
+
+	srv.Logger().Infof("socket connection \"%s\" set maintenance of server %s to %v", c.Name(), target.Name(), pk.Enabled)
+	return c.WritePacket(&packet.MaintenanceResponse{Server: target.Name(), Status: packet.MaintenanceResponseSuccess})
+}
@@ -24,5 +24,5 @@ func (*PlayerInfoRequestHandler) Handle(p packet.Packet, srv Server, c *Client)
 		return response(packet.PlayerInfoResponsePlayerNotFound, "", "")
 	}
 
-	return response(packet.PlayerInfoResponseSuccess, s.Conn().IdentityData().XUID, s.Conn().RemoteAddr().String())
+	return response(packet.PlayerInfoResponseSuccess, s.IdentityData().XUID, s.RemoteAddr().String())
 }
@@ -0,0 +1,21 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/control"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// PlayerListRequestHandler is responsible for handling the PlayerListRequest packet sent by connections.
+type PlayerListRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*PlayerListRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.PlayerListRequest)
+
+	players := control.Players(srv.SessionStore(), pk.Server)
+	entries := make([]packet.PlayerEntry, 0, len(players))
+	for _, player := range players {
+		entries = append(entries, packet.PlayerEntry{UUID: player.UUID, Name: player.Name, Server: player.Server})
+	}
+	return c.WritePacket(&packet.PlayerListResponse{Players: entries})
+}
@@ -0,0 +1,13 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// PluginMessageHandler is responsible for handling the PluginMessage packet sent by servers.
+type PluginMessageHandler struct{ requireAuth }
+
+// Handle ...
+func (*PluginMessageHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	return srv.Messenger().dispatch(c, p.(*packet.PluginMessage))
+}
@@ -0,0 +1,13 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// ProxyInfoRequestHandler is responsible for handling the ProxyInfoRequest packet sent by connections.
+type ProxyInfoRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*ProxyInfoRequestHandler) Handle(_ packet.Packet, srv Server, c *Client) error {
+	return c.WritePacket(srv.ProxyInfo())
+}
@@ -0,0 +1,30 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// QueueInfoRequestHandler is responsible for handling the QueueInfoRequest packet sent by servers.
+type QueueInfoRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*QueueInfoRequestHandler) Handle(_ packet.Packet, srv Server, c *Client) error {
+	q := srv.Queue()
+	if q == nil {
+		return c.WritePacket(&packet.QueueInfoResponse{})
+	}
+
+	var entries []packet.QueueEntry
+	for _, e := range q.Entries() {
+		entries = append(entries, packet.QueueEntry{
+			PlayerUUID: e.Session.UUID(),
+			PlayerName: e.Session.IdentityData().DisplayName,
+			Priority:   int32(e.Priority),
+			Position:   int32(e.Position),
+		})
+	}
+	return c.WritePacket(&packet.QueueInfoResponse{
+		Enabled: true,
+		Entries: entries,
+	})
+}
@@ -0,0 +1,26 @@
+package socket
+
+import (
+	"errors"
+
+	"github.com/paroxity/portal/control"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// ReconnectRequestHandler is responsible for handling the ReconnectRequest packet sent by connections.
+type ReconnectRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*ReconnectRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.ReconnectRequest)
+	s, err := control.Reconnect(srv.SessionStore(), pk.PlayerUUID, pk.PlayerName)
+	switch {
+	case errors.Is(err, control.ErrPlayerNotFound):
+		return c.WritePacket(&packet.ReconnectResponse{PlayerUUID: pk.PlayerUUID, Status: packet.ReconnectResponsePlayerNotFound})
+	case err != nil:
+		return c.WritePacket(&packet.ReconnectResponse{PlayerUUID: s.UUID(), Status: packet.ReconnectResponseError, Error: err.Error()})
+	}
+
+	srv.Logger().Infof("socket connection \"%s\" reconnected %s", c.Name(), s.IdentityData().DisplayName)
+	return c.WritePacket(&packet.ReconnectResponse{PlayerUUID: s.UUID(), Status: packet.ReconnectResponseSuccess})
+}
@@ -11,7 +11,8 @@ type RegisterServerHandler struct{ requireAuth }
 // Handle ...
 func (*RegisterServerHandler) Handle(p packet.Packet, srv Server, c *Client) error {
 	pk := p.(*packet.RegisterServer)
-	srv.ServerRegistry().AddServer(server.New(c.Name(), pk.Address))
-	srv.Logger().Debugf("socket connection \"%s\" has registered itself as a server with the address \"%s\"", c.Name(), pk.Address)
+	s := server.NewWithNetwork(c.Name(), pk.Network, pk.Address)
+	srv.ServerRegistry().AddServer(s)
+	srv.Logger().Debugf("socket connection \"%s\" has registered itself as a server with the address \"%s\" over %s", c.Name(), pk.Address, s.Network())
 	return nil
 }
@@ -0,0 +1,26 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// ReleasePlayerHandler is responsible for handling the ReleasePlayer packet sent by servers.
+type ReleasePlayerHandler struct{ requireAuth }
+
+// Handle ...
+func (*ReleasePlayerHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.ReleasePlayer)
+	s, ok := srv.SessionStore().Load(pk.PlayerUUID)
+	if !ok {
+		srv.Logger().Debugf("socket connection \"%s\" tried to release player %s who is not online", c.Name(), pk.PlayerUUID)
+		return nil
+	}
+	// The server of a session is switched to the destination before the session is held, so only releases sent
+	// by the server the session is on are accepted.
+	if dst, ok := s.TryServer(); !ok || dst.Name() != c.Name() {
+		srv.Logger().Debugf("socket connection \"%s\" tried to release player %s who is not being transferred to it", c.Name(), pk.PlayerUUID)
+		return nil
+	}
+	s.Release()
+	return nil
+}
@@ -0,0 +1,42 @@
+package socket
+
+import (
+	"time"
+
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// RestartRequestHandler is responsible for handling the RestartRequest packet sent by servers.
+type RestartRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*RestartRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.RestartRequest)
+	response := func(status byte, error string) error {
+		return c.WritePacket(&packet.RestartResponse{
+			Server: pk.Server,
+			Status: status,
+			Error:  error,
+		})
+	}
+
+	scheduler := srv.Restarts()
+	if scheduler == nil {
+		return response(packet.RestartResponseUnavailable, "")
+	}
+	target, ok := srv.ServerRegistry().Server(pk.Server)
+	if !ok {
+		return response(packet.RestartResponseServerNotFound, "")
+	}
+
+	var err error
+	if pk.Cancel {
+		err = scheduler.Cancel(target)
+	} else {
+		err = scheduler.Schedule(target, time.Duration(pk.Countdown)*time.Second, pk.Fallback)
+	}
+	if err != nil {
+		return response(packet.RestartResponseError, err.Error())
+	}
+	return response(packet.RestartResponseSuccess, "")
+}
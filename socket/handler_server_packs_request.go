@@ -0,0 +1,45 @@
+package socket
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// ServerPacksRequestHandler is responsible for handling the ServerPacksRequest packet sent by connections.
+type ServerPacksRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*ServerPacksRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.ServerPacksRequest)
+	servers := srv.ServerRegistry().Servers()
+	if pk.Server != "" {
+		s, ok := srv.ServerRegistry().Server(pk.Server)
+		if !ok {
+			return c.WritePacket(&packet.ServerPacksResponse{Status: packet.ServerPacksResponseServerNotFound})
+		}
+		servers = []*server.Server{s}
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return strings.ToLower(servers[i].Name()) < strings.ToLower(servers[j].Name())
+	})
+
+	resp := &packet.ServerPacksResponse{Status: packet.ServerPacksResponseSuccess}
+	for _, s := range servers {
+		packs, known := s.Packs()
+		entry := packet.ServerPacks{Server: s.Name(), Known: known}
+		for _, pack := range packs {
+			entry.Packs = append(entry.Packs, packet.PackEntry{
+				UUID:       pack.UUID,
+				Version:    pack.Version,
+				Name:       pack.Name,
+				Size:       int64(pack.Size),
+				Behaviours: pack.Behaviours,
+			})
+		}
+		resp.Servers = append(resp.Servers, entry)
+	}
+	return c.WritePacket(resp)
+}
@@ -0,0 +1,43 @@
+package socket
+
+import (
+	"errors"
+
+	"github.com/paroxity/portal/control"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// ShadowTransferRequestHandler is responsible for handling the ShadowTransferRequest packet sent by connections.
+type ShadowTransferRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*ShadowTransferRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.ShadowTransferRequest)
+	// Spawning on the server may take a while, so the test is run in the background to keep handling other
+	// packets of the connection.
+	go func() {
+		s, target, res, err := control.ShadowTransfer(srv.SessionStore(), srv.ServerRegistry(), pk.PlayerUUID, pk.PlayerName, pk.Server)
+		resp := &packet.ShadowTransferResponse{PlayerUUID: pk.PlayerUUID, Server: pk.Server}
+		if s != nil {
+			resp.PlayerUUID = s.UUID()
+		}
+		if target != nil {
+			resp.Server = target.Name()
+		}
+		switch {
+		case errors.Is(err, control.ErrServerNotFound):
+			resp.Status = packet.ShadowTransferResponseServerNotFound
+		case errors.Is(err, control.ErrPlayerNotFound):
+			resp.Status = packet.ShadowTransferResponsePlayerNotFound
+		case err != nil:
+			resp.Status, resp.Error = packet.ShadowTransferResponseError, err.Error()
+		default:
+			srv.Logger().Infof("socket connection \"%s\" shadow transferred %s to %s", c.Name(), s.IdentityData().DisplayName, target.Name())
+			resp.Status, resp.DialTime, resp.SpawnTime = packet.ShadowTransferResponseSuccess, res.Dial.Milliseconds(), res.Spawn.Milliseconds()
+		}
+		if err := c.WritePacket(resp); err != nil {
+			srv.Logger().Errorf("socket server unable to write shadow transfer response: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,29 @@
+package socket
+
+import (
+	"time"
+
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// SlowModeRequestHandler is responsible for handling the SlowModeRequest packet sent by connections.
+type SlowModeRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*SlowModeRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.SlowModeRequest)
+	response := func(status byte) error {
+		return c.WritePacket(&packet.SlowModeResponse{Group: pk.Group, Status: status})
+	}
+
+	m := srv.SlowMode()
+	if m == nil {
+		return response(packet.SlowModeResponseUnavailable)
+	}
+	if pk.Interval < 0 {
+		return response(packet.SlowModeResponseInvalidInterval)
+	}
+	m.Set(pk.Group, time.Second*time.Duration(pk.Interval))
+	srv.Logger().Infof("socket connection \"%s\" set the slow mode of group %s to %d seconds", c.Name(), pk.Group, pk.Interval)
+	return response(packet.SlowModeResponseSuccess)
+}
@@ -0,0 +1,28 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// StateRequestHandler is responsible for handling the StateRequest packet sent by connections.
+type StateRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*StateRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.StateRequest)
+	st := srv.State()
+	if st == nil {
+		return c.WritePacket(&packet.StateResponse{Status: packet.StateResponseUnavailable})
+	}
+	if pk.Import {
+		if err := st.ImportState(pk.State); err != nil {
+			return c.WritePacket(&packet.StateResponse{Status: packet.StateResponseError, Error: err.Error()})
+		}
+		srv.Logger().Infof("socket connection \"%s\" imported the state of the proxy", c.Name())
+	}
+	data, err := st.ExportState()
+	if err != nil {
+		return c.WritePacket(&packet.StateResponse{Status: packet.StateResponseError, Error: err.Error()})
+	}
+	return c.WritePacket(&packet.StateResponse{Status: packet.StateResponseSuccess, State: data})
+}
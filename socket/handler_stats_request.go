@@ -0,0 +1,36 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// StatsRequestHandler is responsible for handling the StatsRequest packet sent by servers.
+type StatsRequestHandler struct{ requireAuth }
+
+// Handle ...
+func (*StatsRequestHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.StatsRequest)
+	t := srv.Stats()
+	if t == nil {
+		return c.WritePacket(&packet.StatsResponse{PlayerUUID: pk.PlayerUUID})
+	}
+	r, ok, err := t.Record(pk.PlayerUUID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.WritePacket(&packet.StatsResponse{PlayerUUID: pk.PlayerUUID})
+	}
+
+	servers := make([]packet.ServerPlaytime, 0, len(r.Servers))
+	for name, d := range r.Servers {
+		servers = append(servers, packet.ServerPlaytime{Server: name, Playtime: int64(d.Seconds())})
+	}
+	return c.WritePacket(&packet.StatsResponse{
+		PlayerUUID: pk.PlayerUUID,
+		Found:      true,
+		Joins:      int32(r.Joins),
+		Playtime:   int64(r.Playtime.Seconds()),
+		Servers:    servers,
+	})
+}
@@ -1,6 +1,9 @@
 package socket
 
 import (
+	"errors"
+
+	"github.com/paroxity/portal/control"
 	"github.com/paroxity/portal/socket/packet"
 )
 
@@ -18,23 +21,16 @@ func (*TransferRequestHandler) Handle(p packet.Packet, srv Server, c *Client) er
 		})
 	}
 
-	targetSrv, ok := srv.ServerRegistry().Server(pk.Server)
-	if !ok {
+	_, err := control.Transfer(srv.SessionStore(), srv.ServerRegistry(), pk.PlayerUUID, "", pk.Server)
+	switch {
+	case err == nil:
+		return response(packet.TransferResponseSuccess, "")
+	case errors.Is(err, control.ErrServerNotFound):
 		return response(packet.TransferResponseServerNotFound, "")
-	}
-
-	s, ok := srv.SessionStore().Load(pk.PlayerUUID)
-	if !ok {
+	case errors.Is(err, control.ErrPlayerNotFound):
 		return response(packet.TransferResponsePlayerNotFound, "")
-	}
-
-	if s.Server().Address() == targetSrv.Address() {
+	case errors.Is(err, control.ErrAlreadyOnServer):
 		return response(packet.TransferResponseAlreadyOnServer, "")
 	}
-
-	if err := s.Transfer(targetSrv); err != nil {
-		return response(packet.TransferResponseError, err.Error())
-	}
-
-	return response(packet.TransferResponseSuccess, "")
+	return response(packet.TransferResponseError, err.Error())
 }
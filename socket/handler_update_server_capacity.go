@@ -0,0 +1,21 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// UpdateServerCapacityHandler is responsible for handling the UpdateServerCapacity packet sent by servers.
+type UpdateServerCapacityHandler struct{ requireAuth }
+
+// Handle ...
+func (*UpdateServerCapacityHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.UpdateServerCapacity)
+	s, ok := srv.ServerRegistry().Server(c.Name())
+	if !ok {
+		srv.Logger().Debugf("socket connection \"%s\" tried to update its capacity without registering as a server", c.Name())
+		return nil
+	}
+	s.SetCapacity(int(pk.SoftCap), int(pk.MaxPlayers))
+	srv.Logger().Debugf("server \"%s\" updated its capacity to a soft cap of %d and a maximum of %d players", c.Name(), pk.SoftCap, pk.MaxPlayers)
+	return nil
+}
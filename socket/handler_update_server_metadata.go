@@ -0,0 +1,21 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// UpdateServerMetadataHandler is responsible for handling the UpdateServerMetadata packet sent by servers.
+type UpdateServerMetadataHandler struct{ requireAuth }
+
+// Handle ...
+func (*UpdateServerMetadataHandler) Handle(p packet.Packet, srv Server, c *Client) error {
+	pk := p.(*packet.UpdateServerMetadata)
+	s, ok := srv.ServerRegistry().Server(c.Name())
+	if !ok {
+		srv.Logger().Debugf("socket connection \"%s\" tried to update its metadata without registering as a server", c.Name())
+		return nil
+	}
+	s.ReplaceMetadata(pk.Metadata)
+	srv.Logger().Debugf("server \"%s\" updated its metadata to %v", c.Name(), pk.Metadata)
+	return nil
+}
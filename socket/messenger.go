@@ -0,0 +1,75 @@
+package socket
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// MessageHandler handles a plugin message received by the proxy on a channel. The client passed is the socket
+// connection of the server that sent the message, and player is uuid.Nil if the message does not relate to a
+// specific player.
+type MessageHandler func(c *Client, player uuid.UUID, payload []byte)
+
+// Messenger allows the proxy and servers to exchange custom data on named channels through the PluginMessage
+// packet. Handlers may be registered for a channel to handle the messages sent to the proxy on it.
+type Messenger struct {
+	srv Server
+
+	mu       sync.RWMutex
+	handlers map[string][]MessageHandler
+}
+
+// NewMessenger returns a new Messenger which sends messages to the clients of the socket server passed.
+func NewMessenger(srv Server) *Messenger {
+	return &Messenger{srv: srv, handlers: make(map[string][]MessageHandler)}
+}
+
+// Handle registers a handler for messages sent to the proxy on the channel passed. Multiple handlers may be
+// registered for the same channel, in which case they are called in the order they were registered.
+func (m *Messenger) Handle(channel string, h MessageHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[channel] = append(m.handlers[channel], h)
+}
+
+// Send sends a message on the channel passed to the server with the name passed. An error is returned if the
+// server does not have a socket connection to the proxy.
+func (m *Messenger) Send(server, channel string, player uuid.UUID, payload []byte) error {
+	c, ok := m.srv.Client(server)
+	if !ok {
+		return fmt.Errorf("server %q is not connected", server)
+	}
+	return c.SendMessage(channel, player, payload)
+}
+
+// Broadcast sends a message on the channel passed to every server connected to the proxy.
+func (m *Messenger) Broadcast(channel string, player uuid.UUID, payload []byte) {
+	for _, c := range m.srv.Clients() {
+		if err := c.SendMessage(channel, player, payload); err != nil {
+			m.srv.Logger().Errorf("failed to send plugin message to %s: %v", c.Name(), err)
+		}
+	}
+}
+
+// dispatch handles a PluginMessage packet received from the client passed, either by forwarding it to its
+// target or by passing it to the handlers of its channel.
+func (m *Messenger) dispatch(c *Client, pk *packet.PluginMessage) error {
+	if pk.Target != "" {
+		return m.Send(pk.Target, pk.Channel, pk.PlayerUUID, pk.Payload)
+	}
+
+	m.mu.RLock()
+	handlers := m.handlers[pk.Channel]
+	m.mu.RUnlock()
+	if len(handlers) == 0 {
+		m.srv.Logger().Debugf("unhandled plugin message on channel \"%s\" from %s", pk.Channel, c.Name())
+		return nil
+	}
+	for _, h := range handlers {
+		h(c, pk.PlayerUUID, pk.Payload)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// AuditRequest is sent by a connection to request the most recent commands recorded in the audit log of the
+// proxy.
+type AuditRequest struct {
+	// PlayerName is the name of the player to return the commands of. If empty, the commands of all players
+	// are returned.
+	PlayerName string
+	// Limit is the maximum number of entries to return. If zero, all entries kept by the proxy are returned.
+	Limit int32
+}
+
+// ID ...
+func (*AuditRequest) ID() uint16 {
+	return IDAuditRequest
+}
+
+// Marshal ...
+func (pk *AuditRequest) Marshal(w *protocol.Writer) {
+	w.String(&pk.PlayerName)
+	w.Int32(&pk.Limit)
+}
+
+// Unmarshal ...
+func (pk *AuditRequest) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.PlayerName)
+	r.Int32(&pk.Limit)
+}
@@ -0,0 +1,69 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// AuditResponse is sent by the proxy in response to AuditRequest. It holds the matching entries of the audit
+// log, from newest to oldest.
+type AuditResponse struct {
+	// Enabled is if the proxy has an audit log. If false, Entries is always empty.
+	Enabled bool
+	// Entries holds the entries of the audit log that matched the request.
+	Entries []AuditEntry
+}
+
+// AuditEntry represents a single command recorded in the audit log.
+type AuditEntry struct {
+	// Time is the time at which the command was run as a Unix timestamp in milliseconds.
+	Time int64
+	// PlayerUUID is the UUID of the player that ran the command.
+	PlayerUUID uuid.UUID
+	// PlayerName is the name of the player that ran the command.
+	PlayerName string
+	// Server is the name of the server the player was connected to.
+	Server string
+	// Command is the command line that was run.
+	Command string
+	// Proxy is true if the command was handled by the proxy itself.
+	Proxy bool
+}
+
+// ID ...
+func (*AuditResponse) ID() uint16 {
+	return IDAuditResponse
+}
+
+// Marshal ...
+func (pk *AuditResponse) Marshal(w *protocol.Writer) {
+	w.Bool(&pk.Enabled)
+	l := uint32(len(pk.Entries))
+	w.Uint32(&l)
+
+	for _, e := range pk.Entries {
+		w.Int64(&e.Time)
+		w.UUID(&e.PlayerUUID)
+		w.String(&e.PlayerName)
+		w.String(&e.Server)
+		w.String(&e.Command)
+		w.Bool(&e.Proxy)
+	}
+}
+
+// Unmarshal ...
+func (pk *AuditResponse) Unmarshal(r *protocol.Reader) {
+	r.Bool(&pk.Enabled)
+	var l uint32
+	r.Uint32(&l)
+
+	pk.Entries = make([]AuditEntry, l)
+	for i := uint32(0); i < l; i++ {
+		r.Int64(&pk.Entries[i].Time)
+		r.UUID(&pk.Entries[i].PlayerUUID)
+		r.String(&pk.Entries[i].PlayerName)
+		r.String(&pk.Entries[i].Server)
+		r.String(&pk.Entries[i].Command)
+		r.Bool(&pk.Entries[i].Proxy)
+	}
+}
@@ -0,0 +1,48 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	BroadcastTypeMessage byte = iota
+	BroadcastTypeTitle
+)
+
+// BroadcastRequest is sent by a connection to send an announcement to the players on the proxy, or to the players
+// in a group or on a server.
+type BroadcastRequest struct {
+	// Type is the type of the announcement. The possible values for this can be found above.
+	Type byte
+	// Group is the name of the group whose players receive the announcement. It may be empty.
+	Group string
+	// Server is the name of the server whose players receive the announcement. It may be empty.
+	Server string
+	// Message is the chat message or title of the announcement.
+	Message string
+	// Subtitle is the subtitle shown below the title if the Type is BroadcastTypeTitle. It may be empty.
+	Subtitle string
+}
+
+// ID ...
+func (*BroadcastRequest) ID() uint16 {
+	return IDBroadcastRequest
+}
+
+// Marshal ...
+func (pk *BroadcastRequest) Marshal(w *protocol.Writer) {
+	w.Uint8(&pk.Type)
+	w.String(&pk.Group)
+	w.String(&pk.Server)
+	w.String(&pk.Message)
+	w.String(&pk.Subtitle)
+}
+
+// Unmarshal ...
+func (pk *BroadcastRequest) Unmarshal(r *protocol.Reader) {
+	r.Uint8(&pk.Type)
+	r.String(&pk.Group)
+	r.String(&pk.Server)
+	r.String(&pk.Message)
+	r.String(&pk.Subtitle)
+}
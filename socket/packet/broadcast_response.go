@@ -0,0 +1,33 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	BroadcastResponseSuccess byte = iota
+	BroadcastResponseRateLimited
+	BroadcastResponseUnavailable
+	BroadcastResponseInvalidType
+)
+
+// BroadcastResponse is sent by the proxy in response to a broadcast request.
+type BroadcastResponse struct {
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+}
+
+// ID ...
+func (*BroadcastResponse) ID() uint16 {
+	return IDBroadcastResponse
+}
+
+// Marshal ...
+func (pk *BroadcastResponse) Marshal(w *protocol.Writer) {
+	w.Uint8(&pk.Status)
+}
+
+// Unmarshal ...
+func (pk *BroadcastResponse) Unmarshal(r *protocol.Reader) {
+	r.Uint8(&pk.Status)
+}
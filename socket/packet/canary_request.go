@@ -0,0 +1,54 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// CanaryRequest is sent by a connection to set or remove the canary server of a group. Only a percentage of the
+// players sent to the group, and the players with one of the XUIDs, are sent to the canary server.
+type CanaryRequest struct {
+	// Group is the name of the group to set or remove the canary of.
+	Group string
+	// Remove is true if the canary of the group should be removed instead of set.
+	Remove bool
+	// Server is the name of the canary server, which must be part of the group.
+	Server string
+	// Percentage is the percentage of players, between 0 and 100, that are sent to the canary server.
+	Percentage float32
+	// XUIDs holds the XUIDs of players that are always sent to the canary server.
+	XUIDs []string
+}
+
+// ID ...
+func (*CanaryRequest) ID() uint16 {
+	return IDCanaryRequest
+}
+
+// Marshal ...
+func (pk *CanaryRequest) Marshal(w *protocol.Writer) {
+	w.String(&pk.Group)
+	w.Bool(&pk.Remove)
+	if !pk.Remove {
+		w.String(&pk.Server)
+		w.Float32(&pk.Percentage)
+		l := uint32(len(pk.XUIDs))
+		w.Uint32(&l)
+		for _, xuid := range pk.XUIDs {
+			w.String(&xuid)
+		}
+	}
+}
+
+// Unmarshal ...
+func (pk *CanaryRequest) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Group)
+	r.Bool(&pk.Remove)
+	if !pk.Remove {
+		r.String(&pk.Server)
+		r.Float32(&pk.Percentage)
+		var l uint32
+		r.Uint32(&l)
+		pk.XUIDs = make([]string, l)
+		for i := uint32(0); i < l; i++ {
+			r.String(&pk.XUIDs[i])
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+const (
+	CanaryResponseSuccess byte = iota
+	CanaryResponseUnavailable
+	CanaryResponseServerNotFound
+	CanaryResponseInvalidPercentage
+)
+
+// CanaryResponse is sent by the proxy in response to CanaryRequest.
+type CanaryResponse struct {
+	// Group is the name of the group from the request.
+	Group string
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+}
+
+// ID ...
+func (*CanaryResponse) ID() uint16 {
+	return IDCanaryResponse
+}
+
+// Marshal ...
+func (pk *CanaryResponse) Marshal(w *protocol.Writer) {
+	w.String(&pk.Group)
+	w.Uint8(&pk.Status)
+}
+
+// Unmarshal ...
+func (pk *CanaryResponse) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Group)
+	r.Uint8(&pk.Status)
+}
@@ -0,0 +1,45 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// DebugPacket is sent by the proxy to a connection attached to a session using DebugRequest, for every packet
+// of the session that matches the filter of the request.
+type DebugPacket struct {
+	// PlayerUUID is the UUID of the player the packet was sent by or to.
+	PlayerUUID uuid.UUID
+	// ClientBound is true if the packet was sent by the server to the player, and false if it was sent by the
+	// player to the server.
+	ClientBound bool
+	// PacketID is the ID of the Minecraft packet.
+	PacketID uint32
+	// Name is the name of the type of the packet, such as "Text".
+	Name string
+	// Payload holds the packet encoded as JSON.
+	Payload []byte
+}
+
+// ID ...
+func (*DebugPacket) ID() uint16 {
+	return IDDebugPacket
+}
+
+// Marshal ...
+func (pk *DebugPacket) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.Bool(&pk.ClientBound)
+	w.Varuint32(&pk.PacketID)
+	w.String(&pk.Name)
+	w.ByteSlice(&pk.Payload)
+}
+
+// Unmarshal ...
+func (pk *DebugPacket) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.Bool(&pk.ClientBound)
+	r.Varuint32(&pk.PacketID)
+	r.String(&pk.Name)
+	r.ByteSlice(&pk.Payload)
+}
@@ -0,0 +1,60 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	DebugDirectionBoth byte = iota
+	DebugDirectionClientBound
+	DebugDirectionServerBound
+)
+
+// DebugRequest is sent by a connection to attach to or detach from a session, in order to inspect the packets
+// sent between the player and their server. While attached, the proxy sends a DebugPacket for every packet of
+// the session that matches the filter of the request.
+type DebugRequest struct {
+	// PlayerUUID is the UUID of the player to attach to.
+	PlayerUUID uuid.UUID
+	// PlayerName is the name of the player to attach to. It is used if no player with the UUID is found.
+	PlayerName string
+	// Detach is true if the connection should detach from the session instead of attaching to it.
+	Detach bool
+	// Direction is the direction of the packets to send. The possible values for this can be found above.
+	Direction byte
+	// PacketIDs holds the IDs of the packets to send. If empty, packets of any ID are sent.
+	PacketIDs []uint32
+}
+
+// ID ...
+func (*DebugRequest) ID() uint16 {
+	return IDDebugRequest
+}
+
+// Marshal ...
+func (pk *DebugRequest) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.PlayerName)
+	w.Bool(&pk.Detach)
+	w.Uint8(&pk.Direction)
+	l := uint32(len(pk.PacketIDs))
+	w.Uint32(&l)
+	for _, id := range pk.PacketIDs {
+		w.Varuint32(&id)
+	}
+}
+
+// Unmarshal ...
+func (pk *DebugRequest) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.PlayerName)
+	r.Bool(&pk.Detach)
+	r.Uint8(&pk.Direction)
+	var l uint32
+	r.Uint32(&l)
+	pk.PacketIDs = make([]uint32, l)
+	for i := uint32(0); i < l; i++ {
+		r.Varuint32(&pk.PacketIDs[i])
+	}
+}
@@ -0,0 +1,38 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	DebugResponseSuccess byte = iota
+	DebugResponsePlayerNotFound
+	DebugResponseSessionClosed
+)
+
+// DebugResponse is sent by the proxy in response to DebugRequest. It is also sent with the status
+// DebugResponseSessionClosed when a session the connection is attached to is closed.
+type DebugResponse struct {
+	// PlayerUUID is the UUID of the player from the request.
+	PlayerUUID uuid.UUID
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+}
+
+// ID ...
+func (*DebugResponse) ID() uint16 {
+	return IDDebugResponse
+}
+
+// Marshal ...
+func (pk *DebugResponse) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.Uint8(&pk.Status)
+}
+
+// Unmarshal ...
+func (pk *DebugResponse) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.Uint8(&pk.Status)
+}
@@ -0,0 +1,26 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// FootprintRequest is sent by a connection to request the number of entries the proxy tracks for its sessions,
+// such as entities and boss bars, so that sessions of which the footprint keeps growing can be spotted.
+type FootprintRequest struct {
+	// Limit is the maximum number of sessions to return, starting at the session with the largest footprint.
+	// If zero, all sessions are returned.
+	Limit int32
+}
+
+// ID ...
+func (*FootprintRequest) ID() uint16 {
+	return IDFootprintRequest
+}
+
+// Marshal ...
+func (pk *FootprintRequest) Marshal(w *protocol.Writer) {
+	w.Int32(&pk.Limit)
+}
+
+// Unmarshal ...
+func (pk *FootprintRequest) Unmarshal(r *protocol.Reader) {
+	r.Int32(&pk.Limit)
+}
@@ -0,0 +1,83 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// FootprintResponse is sent by the proxy in response to FootprintRequest.
+type FootprintResponse struct {
+	// Total is the sum of the footprints of all sessions on the proxy.
+	Total Footprint
+	// Sessions holds the footprints of the sessions on the proxy, ordered from the largest to the smallest.
+	Sessions []SessionFootprint
+}
+
+// Footprint holds the number of entries the proxy tracks for one or more sessions.
+type Footprint struct {
+	Entities    int32
+	PlayerList  int32
+	Effects     int32
+	BossBars    int32
+	Scoreboards int32
+	Sounds      int32
+	BlobHashes  int32
+	Dimensions  int32
+}
+
+// SessionFootprint is the footprint of a single session.
+type SessionFootprint struct {
+	// PlayerUUID and PlayerName are the UUID and name of the player of the session.
+	PlayerUUID uuid.UUID
+	PlayerName string
+	// Server is the name of the server the player is on.
+	Server string
+	// Footprint is the footprint of the session.
+	Footprint Footprint
+}
+
+// ID ...
+func (*FootprintResponse) ID() uint16 {
+	return IDFootprintResponse
+}
+
+// Marshal ...
+func (pk *FootprintResponse) Marshal(w *protocol.Writer) {
+	pk.Total.marshal(w)
+	l := uint32(len(pk.Sessions))
+	w.Uint32(&l)
+	for _, s := range pk.Sessions {
+		w.UUID(&s.PlayerUUID)
+		w.String(&s.PlayerName)
+		w.String(&s.Server)
+		s.Footprint.marshal(w)
+	}
+}
+
+// Unmarshal ...
+func (pk *FootprintResponse) Unmarshal(r *protocol.Reader) {
+	pk.Total.unmarshal(r)
+	var l uint32
+	r.Uint32(&l)
+	pk.Sessions = make([]SessionFootprint, l)
+	for i := uint32(0); i < l; i++ {
+		r.UUID(&pk.Sessions[i].PlayerUUID)
+		r.String(&pk.Sessions[i].PlayerName)
+		r.String(&pk.Sessions[i].Server)
+		pk.Sessions[i].Footprint.unmarshal(r)
+	}
+}
+
+// marshal writes the footprint to the writer passed.
+func (f *Footprint) marshal(w *protocol.Writer) {
+	for _, v := range []*int32{&f.Entities, &f.PlayerList, &f.Effects, &f.BossBars, &f.Scoreboards, &f.Sounds, &f.BlobHashes, &f.Dimensions} {
+		w.Int32(v)
+	}
+}
+
+// unmarshal reads the footprint from the reader passed.
+func (f *Footprint) unmarshal(r *protocol.Reader) {
+	for _, v := range []*int32{&f.Entities, &f.PlayerList, &f.Effects, &f.BossBars, &f.Scoreboards, &f.Sounds, &f.BlobHashes, &f.Dimensions} {
+		r.Int32(v)
+	}
+}
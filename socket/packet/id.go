@@ -2,7 +2,7 @@ package packet
 
 // ProtocolVersion is the protocol version supported by the proxy. It will only accept clients that match this version,
 // and it should be incremented every time the protocol changes.
-const ProtocolVersion = 1
+const ProtocolVersion = 2
 
 const (
 	IDAuthRequest uint16 = iota
@@ -17,4 +17,47 @@ const (
 	IDFindPlayerRequest
 	IDFindPlayerResponse
 	IDUpdatePlayerLatency
+	IDQueueInfoRequest
+	IDQueueInfoResponse
+	IDAuditRequest
+	IDAuditResponse
+	IDUpdateServerCapacity
+	IDRestartRequest
+	IDRestartResponse
+	IDTransferStart
+	IDTransferSuccess
+	IDTransferFailure
+	IDPluginMessage
+	IDReleasePlayer
+	IDBroadcastRequest
+	IDBroadcastResponse
+	IDStatsRequest
+	IDStatsResponse
+	IDPlayerQuit
+	IDPlayerListRequest
+	IDPlayerListResponse
+	IDKickRequest
+	IDKickResponse
+	IDMaintenanceRequest
+	IDMaintenanceResponse
+	IDUpdateServerMetadata
+	IDCanaryRequest
+	IDCanaryResponse
+	IDDebugRequest
+	IDDebugResponse
+	IDDebugPacket
+	IDReconnectRequest
+	IDReconnectResponse
+	IDProxyInfoRequest
+	IDProxyInfoResponse
+	IDSlowModeRequest
+	IDSlowModeResponse
+	IDShadowTransferRequest
+	IDShadowTransferResponse
+	IDStateRequest
+	IDStateResponse
+	IDFootprintRequest
+	IDFootprintResponse
+	IDServerPacksRequest
+	IDServerPacksResponse
 )
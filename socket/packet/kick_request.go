@@ -0,0 +1,35 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// KickRequest is sent by a connection to disconnect a player from the proxy.
+type KickRequest struct {
+	// PlayerUUID is the UUID of the player to kick.
+	PlayerUUID uuid.UUID
+	// PlayerName is the name of the player to kick. It is used if no player with the UUID is found.
+	PlayerName string
+	// Message is the message shown to the player on their disconnection screen.
+	Message string
+}
+
+// ID ...
+func (*KickRequest) ID() uint16 {
+	return IDKickRequest
+}
+
+// Marshal ...
+func (pk *KickRequest) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.PlayerName)
+	w.String(&pk.Message)
+}
+
+// Unmarshal ...
+func (pk *KickRequest) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.PlayerName)
+	r.String(&pk.Message)
+}
@@ -0,0 +1,36 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	KickResponseSuccess byte = iota
+	KickResponsePlayerNotFound
+)
+
+// KickResponse is sent by the proxy in response to KickRequest.
+type KickResponse struct {
+	// PlayerUUID is the UUID of the player that was kicked, or the UUID from the request if no player was found.
+	PlayerUUID uuid.UUID
+	// Status is the response status from kicking the player. The possible values for this can be found above.
+	Status byte
+}
+
+// ID ...
+func (*KickResponse) ID() uint16 {
+	return IDKickResponse
+}
+
+// Marshal ...
+func (pk *KickResponse) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.Uint8(&pk.Status)
+}
+
+// Unmarshal ...
+func (pk *KickResponse) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.Uint8(&pk.Status)
+}
@@ -0,0 +1,29 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// MaintenanceRequest is sent by a connection to put a server in or out of maintenance. Players are not sent
+// to a server in maintenance by load balancers, but players already on it are not affected.
+type MaintenanceRequest struct {
+	// Server is the name of the server.
+	Server string
+	// Enabled is true if the server should be put in maintenance, and false if it should be taken out of it.
+	Enabled bool
+}
+
+// ID ...
+func (*MaintenanceRequest) ID() uint16 {
+	return IDMaintenanceRequest
+}
+
+// Marshal ...
+func (pk *MaintenanceRequest) Marshal(w *protocol.Writer) {
+	w.String(&pk.Server)
+	w.Bool(&pk.Enabled)
+}
+
+// Unmarshal ...
+func (pk *MaintenanceRequest) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Server)
+	r.Bool(&pk.Enabled)
+}
@@ -0,0 +1,33 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+const (
+	MaintenanceResponseSuccess byte = iota
+	MaintenanceResponseServerNotFound
+)
+
+// MaintenanceResponse is sent by the proxy in response to MaintenanceRequest.
+type MaintenanceResponse struct {
+	// Server is the name of the server from the request.
+	Server string
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+}
+
+// ID ...
+func (*MaintenanceResponse) ID() uint16 {
+	return IDMaintenanceResponse
+}
+
+// Marshal ...
+func (pk *MaintenanceResponse) Marshal(w *protocol.Writer) {
+	w.String(&pk.Server)
+	w.Uint8(&pk.Status)
+}
+
+// Unmarshal ...
+func (pk *MaintenanceResponse) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Server)
+	r.Uint8(&pk.Status)
+}
@@ -0,0 +1,24 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// PlayerListRequest is sent by a connection to request a list of the players connected to the proxy.
+type PlayerListRequest struct {
+	// Server is the name of the server to list the players of. If empty, all players on the proxy are listed.
+	Server string
+}
+
+// ID ...
+func (*PlayerListRequest) ID() uint16 {
+	return IDPlayerListRequest
+}
+
+// Marshal ...
+func (pk *PlayerListRequest) Marshal(w *protocol.Writer) {
+	w.String(&pk.Server)
+}
+
+// Unmarshal ...
+func (pk *PlayerListRequest) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Server)
+}
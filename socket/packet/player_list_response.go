@@ -0,0 +1,52 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// PlayerListResponse is sent by the proxy in response to PlayerListRequest.
+type PlayerListResponse struct {
+	// Players holds the players that were requested.
+	Players []PlayerEntry
+}
+
+// PlayerEntry represents a single player connected to the proxy.
+type PlayerEntry struct {
+	// UUID is the UUID of the player.
+	UUID uuid.UUID
+	// Name is the name of the player.
+	Name string
+	// Server is the name of the server the player is on. It is empty if the player is still logging in.
+	Server string
+}
+
+// ID ...
+func (*PlayerListResponse) ID() uint16 {
+	return IDPlayerListResponse
+}
+
+// Marshal ...
+func (pk *PlayerListResponse) Marshal(w *protocol.Writer) {
+	l := uint32(len(pk.Players))
+	w.Uint32(&l)
+
+	for _, p := range pk.Players {
+		w.UUID(&p.UUID)
+		w.String(&p.Name)
+		w.String(&p.Server)
+	}
+}
+
+// Unmarshal ...
+func (pk *PlayerListResponse) Unmarshal(r *protocol.Reader) {
+	var l uint32
+	r.Uint32(&l)
+
+	pk.Players = make([]PlayerEntry, l)
+	for i := uint32(0); i < l; i++ {
+		r.UUID(&pk.Players[i].UUID)
+		r.String(&pk.Players[i].Name)
+		r.String(&pk.Players[i].Server)
+	}
+}
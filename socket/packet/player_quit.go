@@ -0,0 +1,46 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	PlayerQuitKicked byte = iota
+	PlayerQuitClientQuit
+	PlayerQuitServerLost
+	PlayerQuitTransferFailed
+	PlayerQuitProxyShutdown
+	PlayerQuitReconnected
+)
+
+// PlayerQuit is sent by the proxy to the server a player was connected to when the player leaves the proxy.
+// It holds the reason the player left, so that the server can tell a player that left apart from one that
+// was kicked or lost their server.
+type PlayerQuit struct {
+	// PlayerUUID is the UUID of the player that left.
+	PlayerUUID uuid.UUID
+	// Server is the name of the server the player was connected to.
+	Server string
+	// Reason is the reason the player left. It is one of the constants above.
+	Reason byte
+}
+
+// ID ...
+func (*PlayerQuit) ID() uint16 {
+	return IDPlayerQuit
+}
+
+// Marshal ...
+func (pk *PlayerQuit) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.Server)
+	w.Uint8(&pk.Reason)
+}
+
+// Unmarshal ...
+func (pk *PlayerQuit) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.Server)
+	r.Uint8(&pk.Reason)
+}
@@ -0,0 +1,43 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// PluginMessage is sent by both the proxy and connections to exchange custom data on a named channel. The proxy
+// passes messages it receives to the handlers registered for the channel, or forwards them to another server if
+// a target is set.
+type PluginMessage struct {
+	// Channel is the name of the channel the message is sent on, for example "myplugin:sync".
+	Channel string
+	// PlayerUUID is the UUID of the player the message relates to. It is uuid.Nil if the message does not
+	// relate to a specific player.
+	PlayerUUID uuid.UUID
+	// Target is the name of the server the message should be forwarded to. If empty, the message is handled by
+	// the proxy. It is always empty for messages sent by the proxy.
+	Target string
+	// Payload is the custom data of the message.
+	Payload []byte
+}
+
+// ID ...
+func (*PluginMessage) ID() uint16 {
+	return IDPluginMessage
+}
+
+// Marshal ...
+func (pk *PluginMessage) Marshal(w *protocol.Writer) {
+	w.String(&pk.Channel)
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.Target)
+	w.ByteSlice(&pk.Payload)
+}
+
+// Unmarshal ...
+func (pk *PluginMessage) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Channel)
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.Target)
+	r.ByteSlice(&pk.Payload)
+}
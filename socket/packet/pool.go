@@ -24,18 +24,61 @@ func NewPool() Pool {
 
 func init() {
 	packets := map[uint16]func() Packet{
-		IDAuthRequest:         func() Packet { return &AuthRequest{} },
-		IDAuthResponse:        func() Packet { return &AuthResponse{} },
-		IDRegisterServer:      func() Packet { return &RegisterServer{} },
-		IDTransferRequest:     func() Packet { return &TransferRequest{} },
-		IDTransferResponse:    func() Packet { return &TransferResponse{} },
-		IDPlayerInfoRequest:   func() Packet { return &PlayerInfoRequest{} },
-		IDPlayerInfoResponse:  func() Packet { return &PlayerInfoResponse{} },
-		IDServerListRequest:   func() Packet { return &ServerListRequest{} },
-		IDServerListResponse:  func() Packet { return &ServerListResponse{} },
-		IDFindPlayerRequest:   func() Packet { return &FindPlayerRequest{} },
-		IDFindPlayerResponse:  func() Packet { return &FindPlayerResponse{} },
-		IDUpdatePlayerLatency: func() Packet { return &UpdatePlayerLatency{} },
+		IDAuthRequest:            func() Packet { return &AuthRequest{} },
+		IDAuthResponse:           func() Packet { return &AuthResponse{} },
+		IDRegisterServer:         func() Packet { return &RegisterServer{} },
+		IDTransferRequest:        func() Packet { return &TransferRequest{} },
+		IDTransferResponse:       func() Packet { return &TransferResponse{} },
+		IDPlayerInfoRequest:      func() Packet { return &PlayerInfoRequest{} },
+		IDPlayerInfoResponse:     func() Packet { return &PlayerInfoResponse{} },
+		IDServerListRequest:      func() Packet { return &ServerListRequest{} },
+		IDServerListResponse:     func() Packet { return &ServerListResponse{} },
+		IDFindPlayerRequest:      func() Packet { return &FindPlayerRequest{} },
+		IDFindPlayerResponse:     func() Packet { return &FindPlayerResponse{} },
+		IDUpdatePlayerLatency:    func() Packet { return &UpdatePlayerLatency{} },
+		IDQueueInfoRequest:       func() Packet { return &QueueInfoRequest{} },
+		IDQueueInfoResponse:      func() Packet { return &QueueInfoResponse{} },
+		IDAuditRequest:           func() Packet { return &AuditRequest{} },
+		IDAuditResponse:          func() Packet { return &AuditResponse{} },
+		IDUpdateServerCapacity:   func() Packet { return &UpdateServerCapacity{} },
+		IDRestartRequest:         func() Packet { return &RestartRequest{} },
+		IDRestartResponse:        func() Packet { return &RestartResponse{} },
+		IDTransferStart:          func() Packet { return &TransferStart{} },
+		IDTransferSuccess:        func() Packet { return &TransferSuccess{} },
+		IDTransferFailure:        func() Packet { return &TransferFailure{} },
+		IDPluginMessage:          func() Packet { return &PluginMessage{} },
+		IDReleasePlayer:          func() Packet { return &ReleasePlayer{} },
+		IDBroadcastRequest:       func() Packet { return &BroadcastRequest{} },
+		IDBroadcastResponse:      func() Packet { return &BroadcastResponse{} },
+		IDStatsRequest:           func() Packet { return &StatsRequest{} },
+		IDStatsResponse:          func() Packet { return &StatsResponse{} },
+		IDPlayerQuit:             func() Packet { return &PlayerQuit{} },
+		IDPlayerListRequest:      func() Packet { return &PlayerListRequest{} },
+		IDPlayerListResponse:     func() Packet { return &PlayerListResponse{} },
+		IDKickRequest:            func() Packet { return &KickRequest{} },
+		IDKickResponse:           func() Packet { return &KickResponse{} },
+		IDMaintenanceRequest:     func() Packet { return &MaintenanceRequest{} },
+		IDMaintenanceResponse:    func() Packet { return &MaintenanceResponse{} },
+		IDUpdateServerMetadata:   func() Packet { return &UpdateServerMetadata{} },
+		IDCanaryRequest:          func() Packet { return &CanaryRequest{} },
+		IDCanaryResponse:         func() Packet { return &CanaryResponse{} },
+		IDDebugRequest:           func() Packet { return &DebugRequest{} },
+		IDDebugResponse:          func() Packet { return &DebugResponse{} },
+		IDDebugPacket:            func() Packet { return &DebugPacket{} },
+		IDReconnectRequest:       func() Packet { return &ReconnectRequest{} },
+		IDReconnectResponse:      func() Packet { return &ReconnectResponse{} },
+		IDProxyInfoRequest:       func() Packet { return &ProxyInfoRequest{} },
+		IDProxyInfoResponse:      func() Packet { return &ProxyInfoResponse{} },
+		IDSlowModeRequest:        func() Packet { return &SlowModeRequest{} },
+		IDSlowModeResponse:       func() Packet { return &SlowModeResponse{} },
+		IDShadowTransferRequest:  func() Packet { return &ShadowTransferRequest{} },
+		IDShadowTransferResponse: func() Packet { return &ShadowTransferResponse{} },
+		IDStateRequest:           func() Packet { return &StateRequest{} },
+		IDStateResponse:          func() Packet { return &StateResponse{} },
+		IDFootprintRequest:       func() Packet { return &FootprintRequest{} },
+		IDFootprintResponse:      func() Packet { return &FootprintResponse{} },
+		IDServerPacksRequest:     func() Packet { return &ServerPacksRequest{} },
+		IDServerPacksResponse:    func() Packet { return &ServerPacksResponse{} },
 	}
 	for id, pk := range packets {
 		Register(id, pk)
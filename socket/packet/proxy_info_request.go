@@ -0,0 +1,20 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// ProxyInfoRequest is sent by a connection to request information about the proxy, such as its version and
+// uptime.
+type ProxyInfoRequest struct{}
+
+// ID ...
+func (*ProxyInfoRequest) ID() uint16 {
+	return IDProxyInfoRequest
+}
+
+// Marshal ...
+func (pk *ProxyInfoRequest) Marshal(*protocol.Writer) {}
+
+// Unmarshal ...
+func (pk *ProxyInfoRequest) Unmarshal(*protocol.Reader) {}
@@ -0,0 +1,54 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// ProxyInfoResponse is sent by the proxy in response to ProxyInfoRequest. It holds information about the proxy.
+type ProxyInfoResponse struct {
+	// Version is the version of the proxy, and Commit the git commit it was built from. Either may be empty if
+	// unknown.
+	Version string
+	Commit  string
+	// GoVersion is the version of Go the proxy was built with.
+	GoVersion string
+	// StartTime is the Unix time in seconds at which the proxy was started.
+	StartTime int64
+	// Protocol is the protocol version of Minecraft supported by the proxy, and MinecraftVersion the version of
+	// Minecraft it belongs to.
+	Protocol         int32
+	MinecraftVersion string
+	// Players is the number of players connected to the proxy.
+	Players int32
+	// Servers is the number of servers registered on the proxy.
+	Servers int32
+}
+
+// ID ...
+func (*ProxyInfoResponse) ID() uint16 {
+	return IDProxyInfoResponse
+}
+
+// Marshal ...
+func (pk *ProxyInfoResponse) Marshal(w *protocol.Writer) {
+	w.String(&pk.Version)
+	w.String(&pk.Commit)
+	w.String(&pk.GoVersion)
+	w.Int64(&pk.StartTime)
+	w.Int32(&pk.Protocol)
+	w.String(&pk.MinecraftVersion)
+	w.Int32(&pk.Players)
+	w.Int32(&pk.Servers)
+}
+
+// Unmarshal ...
+func (pk *ProxyInfoResponse) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Version)
+	r.String(&pk.Commit)
+	r.String(&pk.GoVersion)
+	r.Int64(&pk.StartTime)
+	r.Int32(&pk.Protocol)
+	r.String(&pk.MinecraftVersion)
+	r.Int32(&pk.Players)
+	r.Int32(&pk.Servers)
+}
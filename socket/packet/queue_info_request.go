@@ -0,0 +1,20 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// QueueInfoRequest is sent by a connection to request the players currently waiting in the login queue of the
+// proxy.
+type QueueInfoRequest struct{}
+
+// ID ...
+func (*QueueInfoRequest) ID() uint16 {
+	return IDQueueInfoRequest
+}
+
+// Marshal ...
+func (*QueueInfoRequest) Marshal(*protocol.Writer) {}
+
+// Unmarshal ...
+func (*QueueInfoRequest) Unmarshal(*protocol.Reader) {}
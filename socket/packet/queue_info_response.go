@@ -0,0 +1,61 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// QueueInfoResponse is sent by the proxy in response to QueueInfoRequest. It holds all the players waiting in
+// the login queue, ordered by their position.
+type QueueInfoResponse struct {
+	// Enabled is if the proxy has a login queue. If false, Entries is always empty.
+	Enabled bool
+	// Entries holds the players waiting in the queue.
+	Entries []QueueEntry
+}
+
+// QueueEntry represents a player waiting in the login queue.
+type QueueEntry struct {
+	// PlayerUUID is the UUID of the player.
+	PlayerUUID uuid.UUID
+	// PlayerName is the name of the player.
+	PlayerName string
+	// Priority is the priority tier of the player.
+	Priority int32
+	// Position is the position of the player in the queue, starting at 1.
+	Position int32
+}
+
+// ID ...
+func (*QueueInfoResponse) ID() uint16 {
+	return IDQueueInfoResponse
+}
+
+// Marshal ...
+func (pk *QueueInfoResponse) Marshal(w *protocol.Writer) {
+	w.Bool(&pk.Enabled)
+	l := uint32(len(pk.Entries))
+	w.Uint32(&l)
+
+	for _, e := range pk.Entries {
+		w.UUID(&e.PlayerUUID)
+		w.String(&e.PlayerName)
+		w.Int32(&e.Priority)
+		w.Int32(&e.Position)
+	}
+}
+
+// Unmarshal ...
+func (pk *QueueInfoResponse) Unmarshal(r *protocol.Reader) {
+	r.Bool(&pk.Enabled)
+	var l uint32
+	r.Uint32(&l)
+
+	pk.Entries = make([]QueueEntry, l)
+	for i := uint32(0); i < l; i++ {
+		r.UUID(&pk.Entries[i].PlayerUUID)
+		r.String(&pk.Entries[i].PlayerName)
+		r.Int32(&pk.Entries[i].Priority)
+		r.Int32(&pk.Entries[i].Position)
+	}
+}
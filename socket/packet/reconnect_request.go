@@ -0,0 +1,32 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// ReconnectRequest is sent by a connection to reconnect a player to the server they are on using a fresh
+// connection, such as after the server restarted without closing its connections.
+type ReconnectRequest struct {
+	// PlayerUUID is the UUID of the player to reconnect.
+	PlayerUUID uuid.UUID
+	// PlayerName is the name of the player to reconnect. It is used if no player with the UUID is found.
+	PlayerName string
+}
+
+// ID ...
+func (*ReconnectRequest) ID() uint16 {
+	return IDReconnectRequest
+}
+
+// Marshal ...
+func (pk *ReconnectRequest) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.PlayerName)
+}
+
+// Unmarshal ...
+func (pk *ReconnectRequest) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.PlayerName)
+}
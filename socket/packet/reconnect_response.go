@@ -0,0 +1,45 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	ReconnectResponseSuccess byte = iota
+	ReconnectResponsePlayerNotFound
+	ReconnectResponseError
+)
+
+// ReconnectResponse is sent by the proxy in response to ReconnectRequest.
+type ReconnectResponse struct {
+	// PlayerUUID is the UUID of the player being reconnected, or the UUID from the request if no player was found.
+	PlayerUUID uuid.UUID
+	// Status is the response status from reconnecting the player. The possible values for this can be found above.
+	Status byte
+	// Error is the error message when the Status field is ReconnectResponseError.
+	Error string
+}
+
+// ID ...
+func (*ReconnectResponse) ID() uint16 {
+	return IDReconnectResponse
+}
+
+// Marshal ...
+func (pk *ReconnectResponse) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.Uint8(&pk.Status)
+	if pk.Status == ReconnectResponseError {
+		w.String(&pk.Error)
+	}
+}
+
+// Unmarshal ...
+func (pk *ReconnectResponse) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.Uint8(&pk.Status)
+	if pk.Status == ReconnectResponseError {
+		r.String(&pk.Error)
+	}
+}
@@ -6,6 +6,9 @@ import "github.com/sandertv/gophertunnel/minecraft/protocol"
 type RegisterServer struct {
 	// Address is the address of the server in the format ip:port.
 	Address string
+	// Network is the network the proxy connects to the server over, such as "raknet" or "tcp". If empty,
+	// "raknet" is used.
+	Network string
 }
 
 // ID ...
@@ -16,9 +19,11 @@ func (pk *RegisterServer) ID() uint16 {
 // Marshal ...
 func (pk *RegisterServer) Marshal(w *protocol.Writer) {
 	w.String(&pk.Address)
+	w.String(&pk.Network)
 }
 
 // Unmarshal ...
 func (pk *RegisterServer) Unmarshal(r *protocol.Reader) {
 	r.String(&pk.Address)
+	r.String(&pk.Network)
 }
@@ -0,0 +1,29 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// ReleasePlayer is sent by a server once it has finished loading the data of a player that was transferred to it.
+// If the proxy holds players on a loading screen after a transfer, the player is released and able to play. Only
+// the server the player is being transferred to is able to release them.
+type ReleasePlayer struct {
+	// PlayerUUID is the UUID of the player to release.
+	PlayerUUID uuid.UUID
+}
+
+// ID ...
+func (*ReleasePlayer) ID() uint16 {
+	return IDReleasePlayer
+}
+
+// Marshal ...
+func (pk *ReleasePlayer) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+}
+
+// Unmarshal ...
+func (pk *ReleasePlayer) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+}
@@ -0,0 +1,43 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// RestartRequest is sent by a connection to schedule or cancel the restart of a server. The players on the
+// server are warned of the restart and moved to the fallback once the countdown has passed.
+type RestartRequest struct {
+	// Server is the name of the server to restart.
+	Server string
+	// Cancel is true if the restart of the server should be cancelled instead of scheduled.
+	Cancel bool
+	// Countdown is the time in seconds after which the players are moved off the server.
+	Countdown int32
+	// Fallback is the name of the server or group the players are moved to.
+	Fallback string
+}
+
+// ID ...
+func (*RestartRequest) ID() uint16 {
+	return IDRestartRequest
+}
+
+// Marshal ...
+func (pk *RestartRequest) Marshal(w *protocol.Writer) {
+	w.String(&pk.Server)
+	w.Bool(&pk.Cancel)
+	if !pk.Cancel {
+		w.Int32(&pk.Countdown)
+		w.String(&pk.Fallback)
+	}
+}
+
+// Unmarshal ...
+func (pk *RestartRequest) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Server)
+	r.Bool(&pk.Cancel)
+	if !pk.Cancel {
+		r.Int32(&pk.Countdown)
+		r.String(&pk.Fallback)
+	}
+}
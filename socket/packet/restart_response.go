@@ -0,0 +1,45 @@
+package packet
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	RestartResponseSuccess byte = iota
+	RestartResponseServerNotFound
+	RestartResponseUnavailable
+	RestartResponseError
+)
+
+// RestartResponse is sent by the proxy in response to a restart request.
+type RestartResponse struct {
+	// Server is the name of the server from the request.
+	Server string
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+	// Error is the error message when the Status field is RestartResponseError.
+	Error string
+}
+
+// ID ...
+func (*RestartResponse) ID() uint16 {
+	return IDRestartResponse
+}
+
+// Marshal ...
+func (pk *RestartResponse) Marshal(w *protocol.Writer) {
+	w.String(&pk.Server)
+	w.Uint8(&pk.Status)
+	if pk.Status == RestartResponseError {
+		w.String(&pk.Error)
+	}
+}
+
+// Unmarshal ...
+func (pk *RestartResponse) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Server)
+	r.Uint8(&pk.Status)
+	if pk.Status == RestartResponseError {
+		r.String(&pk.Error)
+	}
+}
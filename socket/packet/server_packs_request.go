@@ -0,0 +1,25 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// ServerPacksRequest is sent by a connection to request the resource and behaviour packs that servers send to
+// players when they join them, so that servers requiring different packs can be spotted.
+type ServerPacksRequest struct {
+	// Server is the name of the server to return the packs of. If empty, the packs of all servers are returned.
+	Server string
+}
+
+// ID ...
+func (*ServerPacksRequest) ID() uint16 {
+	return IDServerPacksRequest
+}
+
+// Marshal ...
+func (pk *ServerPacksRequest) Marshal(w *protocol.Writer) {
+	w.String(&pk.Server)
+}
+
+// Unmarshal ...
+func (pk *ServerPacksRequest) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Server)
+}
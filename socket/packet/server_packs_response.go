@@ -0,0 +1,84 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+const (
+	ServerPacksResponseSuccess byte = iota
+	ServerPacksResponseServerNotFound
+)
+
+// ServerPacksResponse is sent by the proxy in response to ServerPacksRequest.
+type ServerPacksResponse struct {
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+	// Servers holds the packs of the servers requested, ordered by the name of the server.
+	Servers []ServerPacks
+}
+
+// ServerPacks holds the packs a server sends to players when they join it.
+type ServerPacks struct {
+	// Server is the name of the server.
+	Server string
+	// Known is false if no player connected to the server yet, in which case its packs are unknown.
+	Known bool
+	// Packs holds the packs the server sent the last time a player connected to it.
+	Packs []PackEntry
+}
+
+// PackEntry is a resource or behaviour pack sent by a server.
+type PackEntry struct {
+	UUID       string
+	Version    string
+	Name       string
+	Size       int64
+	Behaviours bool
+}
+
+// ID ...
+func (*ServerPacksResponse) ID() uint16 {
+	return IDServerPacksResponse
+}
+
+// Marshal ...
+func (pk *ServerPacksResponse) Marshal(w *protocol.Writer) {
+	w.Uint8(&pk.Status)
+	l := uint32(len(pk.Servers))
+	w.Uint32(&l)
+	for _, s := range pk.Servers {
+		w.String(&s.Server)
+		w.Bool(&s.Known)
+		packs := uint32(len(s.Packs))
+		w.Uint32(&packs)
+		for _, p := range s.Packs {
+			w.String(&p.UUID)
+			w.String(&p.Version)
+			w.String(&p.Name)
+			w.Int64(&p.Size)
+			w.Bool(&p.Behaviours)
+		}
+	}
+}
+
+// Unmarshal ...
+func (pk *ServerPacksResponse) Unmarshal(r *protocol.Reader) {
+	r.Uint8(&pk.Status)
+	var l uint32
+	r.Uint32(&l)
+	pk.Servers = make([]ServerPacks, l)
+	for i := range pk.Servers {
+		s := &pk.Servers[i]
+		r.String(&s.Server)
+		r.Bool(&s.Known)
+		var packs uint32
+		r.Uint32(&packs)
+		s.Packs = make([]PackEntry, packs)
+		for j := range s.Packs {
+			p := &s.Packs[j]
+			r.String(&p.UUID)
+			r.String(&p.Version)
+			r.String(&p.Name)
+			r.Int64(&p.Size)
+			r.Bool(&p.Behaviours)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// ShadowTransferRequest is sent by a connection to test if a server accepts a player, by dialing it and spawning
+// on it as the player without moving them.
+type ShadowTransferRequest struct {
+	// PlayerUUID is the UUID of the player to test the server with.
+	PlayerUUID uuid.UUID
+	// PlayerName is the name of the player to test the server with. It is used if no player with the UUID is
+	// found.
+	PlayerName string
+	// Server is the name of the server to test, or the name of a group to test one of its servers.
+	Server string
+}
+
+// ID ...
+func (*ShadowTransferRequest) ID() uint16 {
+	return IDShadowTransferRequest
+}
+
+// Marshal ...
+func (pk *ShadowTransferRequest) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.PlayerName)
+	w.String(&pk.Server)
+}
+
+// Unmarshal ...
+func (pk *ShadowTransferRequest) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.PlayerName)
+	r.String(&pk.Server)
+}
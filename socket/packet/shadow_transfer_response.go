@@ -0,0 +1,55 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	ShadowTransferResponseSuccess byte = iota
+	ShadowTransferResponsePlayerNotFound
+	ShadowTransferResponseServerNotFound
+	ShadowTransferResponseError
+)
+
+// ShadowTransferResponse is sent by the proxy in response to ShadowTransferRequest, once the player spawned on
+// the server or the test failed.
+type ShadowTransferResponse struct {
+	// PlayerUUID is the UUID of the player tested with, or the UUID from the request if no player was found.
+	PlayerUUID uuid.UUID
+	// Server is the name of the server tested, or the name from the request if no server was found.
+	Server string
+	// Status is the response status from the test. The possible values for this can be found above.
+	Status byte
+	// Error is the error message when the Status field is ShadowTransferResponseError.
+	Error string
+	// DialTime is the time in milliseconds it took to dial the server and log in to it.
+	DialTime int64
+	// SpawnTime is the time in milliseconds it took to spawn on the server once logged in.
+	SpawnTime int64
+}
+
+// ID ...
+func (*ShadowTransferResponse) ID() uint16 {
+	return IDShadowTransferResponse
+}
+
+// Marshal ...
+func (pk *ShadowTransferResponse) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.Server)
+	w.Uint8(&pk.Status)
+	w.String(&pk.Error)
+	w.Int64(&pk.DialTime)
+	w.Int64(&pk.SpawnTime)
+}
+
+// Unmarshal ...
+func (pk *ShadowTransferResponse) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.Server)
+	r.Uint8(&pk.Status)
+	r.String(&pk.Error)
+	r.Int64(&pk.DialTime)
+	r.Int64(&pk.SpawnTime)
+}
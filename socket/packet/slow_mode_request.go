@@ -0,0 +1,29 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// SlowModeRequest is sent by a connection to set the slow mode of a group of servers, which limits players in
+// the group to one chat message per interval.
+type SlowModeRequest struct {
+	// Group is the name of the group, or the name of a server without a group.
+	Group string
+	// Interval is the interval in seconds between the chat messages of players. If zero, slow mode is disabled.
+	Interval int32
+}
+
+// ID ...
+func (*SlowModeRequest) ID() uint16 {
+	return IDSlowModeRequest
+}
+
+// Marshal ...
+func (pk *SlowModeRequest) Marshal(w *protocol.Writer) {
+	w.String(&pk.Group)
+	w.Int32(&pk.Interval)
+}
+
+// Unmarshal ...
+func (pk *SlowModeRequest) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Group)
+	r.Int32(&pk.Interval)
+}
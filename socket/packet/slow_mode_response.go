@@ -0,0 +1,34 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+const (
+	SlowModeResponseSuccess byte = iota
+	SlowModeResponseUnavailable
+	SlowModeResponseInvalidInterval
+)
+
+// SlowModeResponse is sent by the proxy in response to SlowModeRequest.
+type SlowModeResponse struct {
+	// Group is the name of the group from the request.
+	Group string
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+}
+
+// ID ...
+func (*SlowModeResponse) ID() uint16 {
+	return IDSlowModeResponse
+}
+
+// Marshal ...
+func (pk *SlowModeResponse) Marshal(w *protocol.Writer) {
+	w.String(&pk.Group)
+	w.Uint8(&pk.Status)
+}
+
+// Unmarshal ...
+func (pk *SlowModeResponse) Unmarshal(r *protocol.Reader) {
+	r.String(&pk.Group)
+	r.Uint8(&pk.Status)
+}
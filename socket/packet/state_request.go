@@ -0,0 +1,34 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// StateRequest is sent by a connection to export the state of the proxy, or to import a state, so that
+// orchestration tooling is able to reconcile the servers, canaries and whitelist of the proxy with the state it
+// desires.
+type StateRequest struct {
+	// Import is true if the State should be imported instead of the state of the proxy being exported.
+	Import bool
+	// State is the state to import, encoded as JSON. It is only used if Import is true.
+	State []byte
+}
+
+// ID ...
+func (*StateRequest) ID() uint16 {
+	return IDStateRequest
+}
+
+// Marshal ...
+func (pk *StateRequest) Marshal(w *protocol.Writer) {
+	w.Bool(&pk.Import)
+	if pk.Import {
+		w.ByteSlice(&pk.State)
+	}
+}
+
+// Unmarshal ...
+func (pk *StateRequest) Unmarshal(r *protocol.Reader) {
+	r.Bool(&pk.Import)
+	if pk.Import {
+		r.ByteSlice(&pk.State)
+	}
+}
@@ -0,0 +1,40 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+const (
+	StateResponseSuccess byte = iota
+	StateResponseUnavailable
+	StateResponseError
+)
+
+// StateResponse is sent by the proxy in response to StateRequest.
+type StateResponse struct {
+	// Status is the response status from the request. The possible values for this can be found above.
+	Status byte
+	// State is the state of the proxy encoded as JSON, after the state from the request was imported if it was
+	// an import.
+	State []byte
+	// Error is the error message when the Status field is StateResponseError, such as why a state could not be
+	// imported.
+	Error string
+}
+
+// ID ...
+func (*StateResponse) ID() uint16 {
+	return IDStateResponse
+}
+
+// Marshal ...
+func (pk *StateResponse) Marshal(w *protocol.Writer) {
+	w.Uint8(&pk.Status)
+	w.ByteSlice(&pk.State)
+	w.String(&pk.Error)
+}
+
+// Unmarshal ...
+func (pk *StateResponse) Unmarshal(r *protocol.Reader) {
+	r.Uint8(&pk.Status)
+	r.ByteSlice(&pk.State)
+	r.String(&pk.Error)
+}
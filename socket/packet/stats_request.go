@@ -0,0 +1,27 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// StatsRequest is sent by a connection to request the statistics of a player, such as their playtime.
+type StatsRequest struct {
+	// PlayerUUID is the UUID of the player to request the statistics of.
+	PlayerUUID uuid.UUID
+}
+
+// ID ...
+func (*StatsRequest) ID() uint16 {
+	return IDStatsRequest
+}
+
+// Marshal ...
+func (pk *StatsRequest) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+}
+
+// Unmarshal ...
+func (pk *StatsRequest) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+}
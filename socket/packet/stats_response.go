@@ -0,0 +1,65 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// StatsResponse is sent by the proxy in response to StatsRequest. It holds the statistics of the requested
+// player.
+type StatsResponse struct {
+	// PlayerUUID is the UUID of the player from the request.
+	PlayerUUID uuid.UUID
+	// Found is if the proxy has statistics of the player. If false, the other fields are empty.
+	Found bool
+	// Joins is the number of times the player joined the proxy.
+	Joins int32
+	// Playtime is the total time the player spent on the proxy in seconds.
+	Playtime int64
+	// Servers holds the time the player spent on each server.
+	Servers []ServerPlaytime
+}
+
+// ServerPlaytime represents the time a player spent on a single server.
+type ServerPlaytime struct {
+	// Server is the name of the server.
+	Server string
+	// Playtime is the time the player spent on the server in seconds.
+	Playtime int64
+}
+
+// ID ...
+func (*StatsResponse) ID() uint16 {
+	return IDStatsResponse
+}
+
+// Marshal ...
+func (pk *StatsResponse) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.Bool(&pk.Found)
+	w.Int32(&pk.Joins)
+	w.Int64(&pk.Playtime)
+	l := uint32(len(pk.Servers))
+	w.Uint32(&l)
+
+	for _, s := range pk.Servers {
+		w.String(&s.Server)
+		w.Int64(&s.Playtime)
+	}
+}
+
+// Unmarshal ...
+func (pk *StatsResponse) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.Bool(&pk.Found)
+	r.Int32(&pk.Joins)
+	r.Int64(&pk.Playtime)
+	var l uint32
+	r.Uint32(&l)
+
+	pk.Servers = make([]ServerPlaytime, l)
+	for i := uint32(0); i < l; i++ {
+		r.String(&pk.Servers[i].Server)
+		r.Int64(&pk.Servers[i].Playtime)
+	}
+}
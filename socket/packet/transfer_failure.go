@@ -0,0 +1,40 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TransferFailure is sent by the proxy to the source and destination servers of a transfer when the transfer of a
+// player failed. The player stays on the source server.
+type TransferFailure struct {
+	// PlayerUUID is the UUID of the player being transferred.
+	PlayerUUID uuid.UUID
+	// From is the name of the server the player is transferred from.
+	From string
+	// To is the name of the server the player is transferred to.
+	To string
+	// Reason is the reason the transfer failed.
+	Reason string
+}
+
+// ID ...
+func (*TransferFailure) ID() uint16 {
+	return IDTransferFailure
+}
+
+// Marshal ...
+func (pk *TransferFailure) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.From)
+	w.String(&pk.To)
+	w.String(&pk.Reason)
+}
+
+// Unmarshal ...
+func (pk *TransferFailure) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.From)
+	r.String(&pk.To)
+	r.String(&pk.Reason)
+}
@@ -0,0 +1,37 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TransferStart is sent by the proxy to the source and destination servers of a transfer when a player starts being
+// transferred. The source server may use it to save the data of the player before the player arrives on the
+// destination server.
+type TransferStart struct {
+	// PlayerUUID is the UUID of the player being transferred.
+	PlayerUUID uuid.UUID
+	// From is the name of the server the player is transferred from.
+	From string
+	// To is the name of the server the player is transferred to.
+	To string
+}
+
+// ID ...
+func (*TransferStart) ID() uint16 {
+	return IDTransferStart
+}
+
+// Marshal ...
+func (pk *TransferStart) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.From)
+	w.String(&pk.To)
+}
+
+// Unmarshal ...
+func (pk *TransferStart) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.From)
+	r.String(&pk.To)
+}
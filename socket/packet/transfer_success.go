@@ -0,0 +1,36 @@
+package packet
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TransferSuccess is sent by the proxy to the source and destination servers of a transfer when a player has
+// finished being transferred and is now playing on the destination server.
+type TransferSuccess struct {
+	// PlayerUUID is the UUID of the player being transferred.
+	PlayerUUID uuid.UUID
+	// From is the name of the server the player is transferred from.
+	From string
+	// To is the name of the server the player is transferred to.
+	To string
+}
+
+// ID ...
+func (*TransferSuccess) ID() uint16 {
+	return IDTransferSuccess
+}
+
+// Marshal ...
+func (pk *TransferSuccess) Marshal(w *protocol.Writer) {
+	w.UUID(&pk.PlayerUUID)
+	w.String(&pk.From)
+	w.String(&pk.To)
+}
+
+// Unmarshal ...
+func (pk *TransferSuccess) Unmarshal(r *protocol.Reader) {
+	r.UUID(&pk.PlayerUUID)
+	r.String(&pk.From)
+	r.String(&pk.To)
+}
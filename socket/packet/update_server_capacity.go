@@ -0,0 +1,31 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// UpdateServerCapacity is sent by a connection that registered itself as a server to declare the soft cap and
+// maximum player count of the server. Load balancers prefer servers below their soft cap and never send players
+// to servers that have reached their maximum player count.
+type UpdateServerCapacity struct {
+	// SoftCap is the player count from which the server is only chosen if no other server is below its soft
+	// cap. Zero means that there is no soft cap.
+	SoftCap int32
+	// MaxPlayers is the maximum player count of the server. Zero means that there is no maximum.
+	MaxPlayers int32
+}
+
+// ID ...
+func (pk *UpdateServerCapacity) ID() uint16 {
+	return IDUpdateServerCapacity
+}
+
+// Marshal ...
+func (pk *UpdateServerCapacity) Marshal(w *protocol.Writer) {
+	w.Int32(&pk.SoftCap)
+	w.Int32(&pk.MaxPlayers)
+}
+
+// Unmarshal ...
+func (pk *UpdateServerCapacity) Unmarshal(r *protocol.Reader) {
+	r.Int32(&pk.SoftCap)
+	r.Int32(&pk.MaxPlayers)
+}
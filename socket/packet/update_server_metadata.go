@@ -0,0 +1,39 @@
+package packet
+
+import "github.com/sandertv/gophertunnel/minecraft/protocol"
+
+// UpdateServerMetadata is sent by a server to replace its metadata, which holds arbitrary attributes of the
+// server such as its region, that load balancers and handlers may use to decide where to send players. The
+// connection must have registered itself as a server using RegisterServer.
+type UpdateServerMetadata struct {
+	// Metadata holds the new metadata of the server.
+	Metadata map[string]string
+}
+
+// ID ...
+func (*UpdateServerMetadata) ID() uint16 {
+	return IDUpdateServerMetadata
+}
+
+// Marshal ...
+func (pk *UpdateServerMetadata) Marshal(w *protocol.Writer) {
+	l := uint32(len(pk.Metadata))
+	w.Uint32(&l)
+	for k, v := range pk.Metadata {
+		w.String(&k)
+		w.String(&v)
+	}
+}
+
+// Unmarshal ...
+func (pk *UpdateServerMetadata) Unmarshal(r *protocol.Reader) {
+	var l uint32
+	r.Uint32(&l)
+	pk.Metadata = make(map[string]string, l)
+	for i := uint32(0); i < l; i++ {
+		var k, v string
+		r.String(&k)
+		r.String(&v)
+		pk.Metadata[k] = v
+	}
+}
@@ -0,0 +1,38 @@
+package socket
+
+import (
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/socket/packet"
+)
+
+// QuitEvents returns a function that creates a session.Handler which sends a PlayerQuit packet to the server
+// of a session when it is closed, holding the reason it was closed. The function returned may be passed to
+// portal.Handle directly.
+func (s *DefaultServer) QuitEvents() func(*session.Session) session.Handler {
+	return func(sess *session.Session) session.Handler {
+		return &quitEventHandler{srv: s, s: sess}
+	}
+}
+
+// quitEventHandler is the session.Handler returned by QuitEvents.
+type quitEventHandler struct {
+	session.NopHandler
+
+	srv *DefaultServer
+	s   *session.Session
+}
+
+// HandleQuit ...
+func (h *quitEventHandler) HandleQuit(reason session.CloseReason) {
+	srv, ok := h.s.TryServer()
+	if !ok || srv == nil {
+		return
+	}
+	conn, ok := h.srv.Client(srv.Name())
+	if !ok {
+		return
+	}
+	if err := conn.WritePacket(&packet.PlayerQuit{PlayerUUID: h.s.UUID(), Server: srv.Name(), Reason: byte(reason)}); err != nil {
+		h.srv.Logger().Errorf("failed to send packet: %v", err)
+	}
+}
@@ -1,15 +1,30 @@
 package socket
 
 import (
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/audit"
+	"github.com/paroxity/portal/broadcast"
+	"github.com/paroxity/portal/chat"
 	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/restart"
 	"github.com/paroxity/portal/server"
 	"github.com/paroxity/portal/session"
 	"github.com/paroxity/portal/socket/packet"
+	"github.com/paroxity/portal/stats"
 	"net"
 	"strings"
 	"sync"
 )
 
+// StateStore exports and imports the state of the proxy, such as the servers registered with it. It is
+// implemented by *portal.Portal.
+type StateStore interface {
+	// ExportState returns the state of the proxy encoded as JSON.
+	ExportState() ([]byte, error)
+	// ImportState changes the state of the proxy to the state encoded as JSON passed.
+	ImportState(data []byte) error
+}
+
 type Server interface {
 	// Listen starts listening for connections on an address.
 	Listen() error
@@ -32,6 +47,28 @@ type Server interface {
 	SessionStore() *session.Store
 	// ServerRegistry returns the registry used to store available servers on the proxy.
 	ServerRegistry() *server.Registry
+	// Queue returns the login queue of the proxy, or nil if the proxy has no login queue.
+	Queue() *session.Queue
+	// Audit returns the audit log of the proxy, or nil if the proxy has no audit log.
+	Audit() *audit.Log
+	// Restarts returns the restart scheduler of the proxy, or nil if the proxy has no restart scheduler.
+	Restarts() *restart.Scheduler
+	// Broadcaster returns the broadcaster used to send announcements to players, or nil if the proxy has none.
+	Broadcaster() *broadcast.Broadcaster
+	// Stats returns the statistics tracker of the proxy, or nil if the proxy does not track statistics.
+	Stats() *stats.Tracker
+	// Canary returns the load balancer used to route players to canary servers, or nil if the proxy has none.
+	Canary() *session.CanaryLoadBalancer
+	// Debugger returns the debugger used to attach connections to sessions to inspect their packets.
+	Debugger() *Debugger
+	// Messenger returns the messenger used to exchange plugin messages with the connected servers.
+	Messenger() *Messenger
+	// ProxyInfo returns information about the proxy, such as its version and uptime.
+	ProxyInfo() *packet.ProxyInfoResponse
+	// SlowMode returns the slow mode applied to the chat of players, or nil if the proxy has none.
+	SlowMode() *chat.SlowMode
+	// State returns the store used to export and import the state of the proxy, or nil if the proxy has none.
+	State() StateStore
 }
 
 // DefaultServer represents a basic TCP socket server implementation. It allows external connections to
@@ -50,11 +87,27 @@ type DefaultServer struct {
 
 	sessionStore   *session.Store
 	serverRegistry *server.Registry
+	queue          *session.Queue
+	audit          *audit.Log
+	restarts       *restart.Scheduler
+	broadcaster    *broadcast.Broadcaster
+	stats          *stats.Tracker
+	canary         *session.CanaryLoadBalancer
+	messenger      *Messenger
+	debugger       *Debugger
+	info           func() *packet.ProxyInfoResponse
+	slowMode       *chat.SlowMode
+	state          StateStore
+
+	// syncs holds the transfers in progress of which the data attached to the player is delivered to the
+	// destination, by the UUIDs of the players.
+	syncsMu sync.Mutex
+	syncs   map[uuid.UUID]*syncTransfer
 }
 
 // NewDefaultServer creates a new default server to be used for accepting socket connections.
 func NewDefaultServer(addr, secret string, sessionStore *session.Store, serverRegistry *server.Registry, log internal.Logger, readerLimits bool) *DefaultServer {
-	return &DefaultServer{
+	s := &DefaultServer{
 		log: log,
 
 		addr:         addr,
@@ -66,7 +119,13 @@ func NewDefaultServer(addr, secret string, sessionStore *session.Store, serverRe
 
 		sessionStore:   sessionStore,
 		serverRegistry: serverRegistry,
+
+		syncs: make(map[uuid.UUID]*syncTransfer),
 	}
+	s.messenger = NewMessenger(s)
+	s.messenger.Handle(SyncChannel, s.handleSync)
+	s.debugger = NewDebugger(s)
+	return s
 }
 
 // Listen ...
@@ -132,6 +191,7 @@ func (s *DefaultServer) handleClient(c *Client) {
 
 // handleClientDisconnect handles a client that has been disconnected from the socket server.
 func (s *DefaultServer) handleClientDisconnect(c *Client) {
+	s.debugger.DetachAll(c)
 	s.clientsMu.Lock()
 	defer s.clientsMu.Unlock()
 	delete(s.clients, c.Name())
@@ -191,6 +251,118 @@ func (s *DefaultServer) ServerRegistry() *server.Registry {
 	return s.serverRegistry
 }
 
+// Queue ...
+func (s *DefaultServer) Queue() *session.Queue {
+	return s.queue
+}
+
+// SetQueue sets the login queue of the proxy, so that socket connections are able to request the players
+// waiting in it.
+func (s *DefaultServer) SetQueue(q *session.Queue) {
+	s.queue = q
+}
+
+// Audit ...
+func (s *DefaultServer) Audit() *audit.Log {
+	return s.audit
+}
+
+// SetAudit sets the audit log of the proxy, so that socket connections are able to query the commands recorded
+// in it.
+func (s *DefaultServer) SetAudit(l *audit.Log) {
+	s.audit = l
+}
+
+// Restarts ...
+func (s *DefaultServer) Restarts() *restart.Scheduler {
+	return s.restarts
+}
+
+// SetRestarts sets the restart scheduler of the proxy, so that socket connections are able to schedule restarts
+// of servers.
+func (s *DefaultServer) SetRestarts(r *restart.Scheduler) {
+	s.restarts = r
+}
+
+// Broadcaster ...
+func (s *DefaultServer) Broadcaster() *broadcast.Broadcaster {
+	return s.broadcaster
+}
+
+// SetBroadcaster sets the broadcaster of the proxy, so that socket connections are able to send announcements to
+// players.
+func (s *DefaultServer) SetBroadcaster(b *broadcast.Broadcaster) {
+	s.broadcaster = b
+}
+
+// Stats ...
+func (s *DefaultServer) Stats() *stats.Tracker {
+	return s.stats
+}
+
+// SetStats sets the statistics tracker of the proxy, so that socket connections are able to request the
+// statistics of players.
+func (s *DefaultServer) SetStats(t *stats.Tracker) {
+	s.stats = t
+}
+
+// Canary ...
+func (s *DefaultServer) Canary() *session.CanaryLoadBalancer {
+	return s.canary
+}
+
+// SetCanary sets the canary load balancer of the proxy, so that socket connections are able to change the
+// canary servers of groups.
+func (s *DefaultServer) SetCanary(b *session.CanaryLoadBalancer) {
+	s.canary = b
+}
+
+// Debugger ...
+func (s *DefaultServer) Debugger() *Debugger {
+	return s.debugger
+}
+
+// Messenger ...
+func (s *DefaultServer) Messenger() *Messenger {
+	return s.messenger
+}
+
+// ProxyInfo ...
+func (s *DefaultServer) ProxyInfo() *packet.ProxyInfoResponse {
+	if s.info == nil {
+		return &packet.ProxyInfoResponse{Players: int32(len(s.sessionStore.All())), Servers: int32(len(s.serverRegistry.Servers()))}
+	}
+	return s.info()
+}
+
+// SlowMode ...
+func (s *DefaultServer) SlowMode() *chat.SlowMode {
+	return s.slowMode
+}
+
+// SetSlowMode sets the slow mode applied to the chat of players, so that socket connections are able to change
+// it, such as to handle a raid.
+func (s *DefaultServer) SetSlowMode(m *chat.SlowMode) {
+	s.slowMode = m
+}
+
+// SetProxyInfo sets the function used to get information about the proxy, so that socket connections are able
+// to request it. If not set, only the number of players and servers are sent.
+func (s *DefaultServer) SetProxyInfo(f func() *packet.ProxyInfoResponse) {
+	s.info = f
+}
+
+// State ...
+func (s *DefaultServer) State() StateStore {
+	return s.state
+}
+
+// SetState sets the store used to export and import the state of the proxy, so that socket connections are able
+// to reconcile it with the state they desire.
+func (s *DefaultServer) SetState(st StateStore) {
+	s.state = st
+}
+
 // containsAny checks if the string contains any of the provided sub strings.
 func containsAny(s string, subs ...string) bool {
 	for _, sub := range subs {
@@ -0,0 +1,111 @@
+package socket
+
+import (
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/server"
+)
+
+// SyncChannel is the plugin message channel used by servers to attach data to a player being transferred. A
+// server that receives a TransferStart packet may send a message on this channel with the data of the player,
+// which the proxy delivers on the same channel to the destination server once the player has spawned on it,
+// before the player is held on the loading screen until the destination releases them.
+// This prevents the destination server from loading stale data that the source server has not yet saved.
+const SyncChannel = "portal:sync"
+
+// envelope holds the data attached to a player by the server it is being transferred from.
+type envelope struct {
+	from    string
+	payload []byte
+}
+
+// syncTransfer is a transfer of a player to the destination server, of which the data attached by the source
+// server is held until it is delivered to the destination.
+type syncTransfer struct {
+	to       *server.Server
+	envelope *envelope
+	// connected is true once the player spawned on the destination, after which data is delivered right away.
+	connected bool
+}
+
+// handleSync handles a message on the SyncChannel sent by the client passed.
+func (s *DefaultServer) handleSync(c *Client, player uuid.UUID, payload []byte) {
+	sess, ok := s.sessionStore.Load(player)
+	if !ok {
+		s.log.Debugf("server \"%s\" sent sync data for player %s who is not online", c.Name(), player)
+		return
+	}
+	e := envelope{from: c.Name(), payload: payload}
+	s.syncsMu.Lock()
+	if t, ok := s.syncs[player]; ok {
+		if !t.connected {
+			t.envelope = &e
+			s.syncsMu.Unlock()
+			return
+		}
+		s.syncsMu.Unlock()
+		s.sendEnvelope(player, t.to, e)
+		return
+	}
+	s.syncsMu.Unlock()
+
+	// The transfer may have finished before the data arrived, in which case the data is delivered to the
+	// server the player is on now.
+	if srv, ok := sess.TryServer(); ok && srv.Name() != c.Name() {
+		s.sendEnvelope(player, srv, e)
+		return
+	}
+	s.log.Debugf("server \"%s\" sent sync data for player %s who is not being transferred", c.Name(), player)
+}
+
+// startSync starts holding the data attached to the player passed for the transfer of the player to the server
+// passed, replacing any transfer started before.
+func (s *DefaultServer) startSync(player uuid.UUID, to *server.Server) {
+	s.syncsMu.Lock()
+	defer s.syncsMu.Unlock()
+	s.syncs[player] = &syncTransfer{to: to}
+}
+
+// connectSync marks the player passed as spawned on the server passed and delivers the data attached to the
+// player, if any, if the server is the destination of the transfer of the player. Data attached afterwards is
+// delivered right away.
+func (s *DefaultServer) connectSync(player uuid.UUID, srv *server.Server) {
+	s.syncsMu.Lock()
+	t, ok := s.syncs[player]
+	if !ok || t.to != srv {
+		s.syncsMu.Unlock()
+		return
+	}
+	t.connected = true
+	e := t.envelope
+	t.envelope = nil
+	s.syncsMu.Unlock()
+
+	if e != nil {
+		s.sendEnvelope(player, srv, *e)
+	}
+}
+
+// finishSync ends the transfer of the player passed and delivers the data attached to the player, if any, to the
+// destination of the transfer.
+func (s *DefaultServer) finishSync(player uuid.UUID) {
+	t, ok := s.endSync(player)
+	if ok && t.envelope != nil {
+		s.sendEnvelope(player, t.to, *t.envelope)
+	}
+}
+
+// endSync ends the transfer of the player passed and returns it, discarding any data attached to the player.
+func (s *DefaultServer) endSync(player uuid.UUID) (*syncTransfer, bool) {
+	s.syncsMu.Lock()
+	defer s.syncsMu.Unlock()
+	t, ok := s.syncs[player]
+	delete(s.syncs, player)
+	return t, ok
+}
+
+// sendEnvelope sends the envelope passed to the server passed on the SyncChannel.
+func (s *DefaultServer) sendEnvelope(player uuid.UUID, srv *server.Server, e envelope) {
+	if err := s.messenger.Send(srv.Name(), SyncChannel, player, e.payload); err != nil {
+		s.log.Errorf("failed to deliver sync data of %s from %s to %s: %v", player, e.from, srv.Name(), err)
+	}
+}
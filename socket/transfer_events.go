@@ -0,0 +1,106 @@
+package socket
+
+import (
+	"sync"
+
+	"github.com/paroxity/portal/event"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/paroxity/portal/socket/packet"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// TransferEvents returns a function that creates a session.Handler which notifies the source and destination
+// servers of every transfer of the session through the TransferStart, TransferSuccess and TransferFailure
+// packets. This allows servers to save the data of a player before it leaves and load it once it arrives,
+// instead of relying on join and quit events. Data sent by the source server on the SyncChannel is delivered
+// to the destination server once the player spawned on it, before the player is held until the destination
+// releases them. The function returned may be passed to portal.Handle directly,
+// and should be added after any handlers that cancel or redirect transfers.
+func (s *DefaultServer) TransferEvents() func(*session.Session) session.Handler {
+	return func(sess *session.Session) session.Handler {
+		return &transferEventHandler{srv: s, s: sess}
+	}
+}
+
+// transferEventHandler is the session.Handler returned by TransferEvents.
+type transferEventHandler struct {
+	session.NopHandler
+
+	srv *DefaultServer
+	s   *session.Session
+
+	mu       sync.Mutex
+	from, to *server.Server
+}
+
+// HandleTransfer ...
+func (h *transferEventHandler) HandleTransfer(ctx *event.Context, srv *server.Server) {
+	if ctx.Cancelled() {
+		return
+	}
+	if dst, ok := event.Load(ctx, session.TransferServer); ok && dst != nil {
+		srv = dst
+	}
+	from := h.s.Server()
+
+	h.mu.Lock()
+	h.from, h.to = from, srv
+	h.mu.Unlock()
+	h.srv.startSync(h.s.UUID(), srv)
+
+	h.send(&packet.TransferStart{PlayerUUID: h.s.UUID(), From: from.Name(), To: srv.Name()}, from, srv)
+}
+
+// HandleServerConnect ...
+func (h *transferEventHandler) HandleServerConnect(srv *server.Server, _ *minecraft.Conn) {
+	h.srv.connectSync(h.s.UUID(), srv)
+}
+
+// HandleTransferFailure ...
+func (h *transferEventHandler) HandleTransferFailure(srv *server.Server, err error) {
+	from, _, ok := h.pending()
+	if !ok {
+		return
+	}
+	h.srv.endSync(h.s.UUID())
+	h.send(&packet.TransferFailure{PlayerUUID: h.s.UUID(), From: from.Name(), To: srv.Name(), Reason: err.Error()}, from, srv)
+}
+
+// HandleChangeConn ...
+func (h *transferEventHandler) HandleChangeConn(*minecraft.Conn) {
+	from, to, ok := h.pending()
+	if !ok {
+		return
+	}
+	h.send(&packet.TransferSuccess{PlayerUUID: h.s.UUID(), From: from.Name(), To: to.Name()}, from, to)
+	h.srv.finishSync(h.s.UUID())
+}
+
+// HandleQuit ...
+func (h *transferEventHandler) HandleQuit(session.CloseReason) {
+	h.srv.endSync(h.s.UUID())
+}
+
+// pending returns the source and destination of the transfer currently in progress and clears them. If no
+// transfer is in progress, false is returned.
+func (h *transferEventHandler) pending() (from, to *server.Server, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	from, to = h.from, h.to
+	h.from, h.to = nil, nil
+	return from, to, from != nil
+}
+
+// send writes the packet passed to the socket clients of each of the servers passed that are connected.
+func (h *transferEventHandler) send(pk packet.Packet, servers ...*server.Server) {
+	for _, srv := range servers {
+		conn, ok := h.srv.Client(srv.Name())
+		if !ok {
+			continue
+		}
+		if err := conn.WritePacket(pk); err != nil {
+			h.srv.Logger().Errorf("failed to send packet: %v", err)
+		}
+	}
+}
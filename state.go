@@ -0,0 +1,195 @@
+package portal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+)
+
+// State is the state of the servers registered with the proxy, the canaries of their groups and the whitelist,
+// as exported by ExportState. Orchestration tooling may compare it to the state it desires and import the
+// desired state using ImportState.
+type State struct {
+	// Servers holds the servers registered with the proxy, ordered by their name.
+	Servers []ServerState `json:"servers"`
+	// Canaries holds the canaries of the groups that have one, ordered by their group.
+	Canaries []CanaryState `json:"canaries,omitempty"`
+	// Whitelist is the whitelist of the proxy. It is nil if the whitelist of the proxy can not be listed.
+	Whitelist *WhitelistState `json:"whitelist,omitempty"`
+}
+
+// ServerState is the state of a server registered with the proxy.
+type ServerState struct {
+	// Name is the name the server is registered with.
+	Name string `json:"name"`
+	// Network is the network the server is connected to over, such as "raknet".
+	Network string `json:"network,omitempty"`
+	// Address is the address of the server.
+	Address string `json:"address"`
+	// Group is the group the server is part of, if any.
+	Group string `json:"group,omitempty"`
+	// SoftCap and MaxPlayers are the capacity of the server. Zero means that there is no limit.
+	SoftCap    int `json:"soft_cap,omitempty"`
+	MaxPlayers int `json:"max_players,omitempty"`
+	// Maintenance is true if the server is out of rotation, so that load balancers do not send players to it.
+	Maintenance bool `json:"maintenance,omitempty"`
+	// Metadata holds the metadata of the server, such as "region".
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// CanaryState is the canary of a group of servers.
+type CanaryState struct {
+	// Group is the group the canary is part of.
+	Group string `json:"group"`
+	// Server is the name of the canary server.
+	Server string `json:"server"`
+	// Percentage is the percentage of players, between 0 and 100, sent to the group that are sent to the canary.
+	Percentage float64 `json:"percentage"`
+	// XUIDs holds the XUIDs of players that are always sent to the canary.
+	XUIDs []string `json:"xuids,omitempty"`
+}
+
+// WhitelistState is the state of the whitelist of the proxy.
+type WhitelistState struct {
+	// Enabled is true if only the players on the whitelist may join the proxy.
+	Enabled bool `json:"enabled"`
+	// Players holds the names of the players on the whitelist, ordered by name.
+	Players []string `json:"players"`
+}
+
+// ExportState returns the State of the proxy encoded as JSON. The canaries of groups are only included if the
+// load balancer of the proxy is a session.CanaryLoadBalancer, and the whitelist only if it is a
+// session.EditableWhitelist.
+func (p *Portal) ExportState() ([]byte, error) {
+	var st State
+	for _, srv := range p.serverRegistry.Servers() {
+		softCap, maxPlayers := srv.Capacity()
+		st.Servers = append(st.Servers, ServerState{
+			Name:        srv.Name(),
+			Network:     srv.Network(),
+			Address:     srv.Address(),
+			Group:       srv.Group(),
+			SoftCap:     softCap,
+			MaxPlayers:  maxPlayers,
+			Maintenance: !srv.InRotation(),
+			Metadata:    srv.AllMetadata(),
+		})
+	}
+	sort.Slice(st.Servers, func(i, j int) bool {
+		return strings.ToLower(st.Servers[i].Name) < strings.ToLower(st.Servers[j].Name)
+	})
+	if canary, ok := p.LoadBalancer().(*session.CanaryLoadBalancer); ok {
+		for group, c := range canary.Canaries() {
+			st.Canaries = append(st.Canaries, CanaryState{Group: group, Server: c.Server, Percentage: c.Percentage, XUIDs: c.XUIDs})
+		}
+		sort.Slice(st.Canaries, func(i, j int) bool {
+			return st.Canaries[i].Group < st.Canaries[j].Group
+		})
+	}
+	if w, ok := p.whitelist.(session.EditableWhitelist); ok {
+		players, err := w.Players()
+		if err != nil {
+			return nil, fmt.Errorf("list whitelist: %w", err)
+		}
+		sort.Strings(players)
+		st.Whitelist = &WhitelistState{Enabled: w.Enabled(), Players: players}
+	}
+	return json.MarshalIndent(st, "", "  ")
+}
+
+// ImportState changes the state of the proxy to the State encoded as JSON passed, as exported by ExportState.
+// Servers that are not registered are registered, servers of which the address changed are replaced, and
+// servers that are not in the state are removed, while players already on them stay. Canaries of groups not in
+// the state are removed, and the whitelist is left as it is if the state has none. The state is validated as a
+// whole before any of it is applied.
+func (p *Portal) ImportState(data []byte) error {
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("decode state: %w", err)
+	}
+	canary, hasCanary := p.LoadBalancer().(*session.CanaryLoadBalancer)
+	whitelist, hasWhitelist := p.whitelist.(session.EditableWhitelist)
+	if err := st.validate(hasCanary, hasWhitelist); err != nil {
+		return err
+	}
+
+	desired := make(map[string]struct{}, len(st.Servers))
+	for _, s := range st.Servers {
+		desired[strings.ToLower(s.Name)] = struct{}{}
+		srv, ok := p.serverRegistry.Server(s.Name)
+		if !ok || srv.Address() != s.Address || (s.Network != "" && srv.Network() != s.Network) {
+			srv = server.NewWithNetwork(s.Name, s.Network, s.Address)
+			p.serverRegistry.AddServer(srv)
+		}
+		srv.SetGroup(s.Group)
+		srv.SetCapacity(s.SoftCap, s.MaxPlayers)
+		srv.SetInRotation(!s.Maintenance)
+		srv.ReplaceMetadata(s.Metadata)
+	}
+	for _, srv := range p.serverRegistry.Servers() {
+		if _, ok := desired[strings.ToLower(srv.Name())]; !ok {
+			p.serverRegistry.RemoveServer(srv)
+		}
+	}
+
+	if hasCanary {
+		groups := make(map[string]struct{}, len(st.Canaries))
+		for _, c := range st.Canaries {
+			groups[strings.ToLower(c.Group)] = struct{}{}
+			canary.SetCanary(c.Group, session.Canary{Server: c.Server, Percentage: c.Percentage, XUIDs: c.XUIDs})
+		}
+		for group := range canary.Canaries() {
+			if _, ok := groups[group]; !ok {
+				canary.RemoveCanary(group)
+			}
+		}
+	}
+	if st.Whitelist != nil {
+		if err := whitelist.SetPlayers(st.Whitelist.Players); err != nil {
+			return fmt.Errorf("set whitelist: %w", err)
+		}
+		whitelist.SetEnabled(st.Whitelist.Enabled)
+	}
+	p.log.Infof("imported state with %d servers and %d canaries", len(st.Servers), len(st.Canaries))
+	return nil
+}
+
+// validate checks if the state is valid and may be imported by a proxy which has a canary load balancer and an
+// editable whitelist as passed.
+func (st State) validate(hasCanary, hasWhitelist bool) error {
+	groups := make(map[string]string, len(st.Servers))
+	for i, s := range st.Servers {
+		if s.Name == "" || s.Address == "" {
+			return fmt.Errorf("server %d must have a name and an address", i+1)
+		}
+		if _, ok := groups[strings.ToLower(s.Name)]; ok {
+			return fmt.Errorf("server %s is in the state more than once", s.Name)
+		}
+		if s.SoftCap < 0 || s.MaxPlayers < 0 {
+			return fmt.Errorf("server %s has a negative capacity", s.Name)
+		}
+		groups[strings.ToLower(s.Name)] = s.Group
+	}
+	if len(st.Canaries) > 0 && !hasCanary {
+		return fmt.Errorf("state has canaries, but the proxy has no canary load balancer")
+	}
+	for _, c := range st.Canaries {
+		group, ok := groups[strings.ToLower(c.Server)]
+		switch {
+		case !ok:
+			return fmt.Errorf("canary server %s of group %s is not in the state", c.Server, c.Group)
+		case c.Group == "" || !strings.EqualFold(group, c.Group):
+			return fmt.Errorf("canary server %s is not part of group %s", c.Server, c.Group)
+		case c.Percentage < 0 || c.Percentage > 100:
+			return fmt.Errorf("canary of group %s has a percentage of %g, which is not between 0 and 100", c.Group, c.Percentage)
+		}
+	}
+	if st.Whitelist != nil && !hasWhitelist {
+		return fmt.Errorf("state has a whitelist, but the whitelist of the proxy can not be changed")
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+// Package stats implements tracking of statistics of players across the network, such as their playtime and
+// the number of times they joined.
+package stats
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Record holds the statistics of a single player.
+type Record struct {
+	// UUID is the UUID of the player.
+	UUID uuid.UUID `json:"uuid"`
+	// Name is the name the player last joined with.
+	Name string `json:"name"`
+	// Joins is the number of times the player joined the proxy.
+	Joins int `json:"joins"`
+	// Playtime is the total time the player spent on the proxy.
+	Playtime time.Duration `json:"playtime"`
+	// Servers holds the time the player spent on each server, by the names of the servers.
+	Servers map[string]time.Duration `json:"servers"`
+	// LastSeen is the time at which the player last left the proxy.
+	LastSeen time.Time `json:"last_seen"`
+}
@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/storage"
+)
+
+// Store persists the statistics of players.
+type Store interface {
+	// Load returns the record of the player with the UUID passed. If the player has no record, false is returned.
+	Load(id uuid.UUID) (Record, bool, error)
+	// Save stores the record passed, replacing any record of the same player stored before.
+	Save(r Record) error
+}
+
+// FileStore is a Store that keeps records in memory and persists them to a JSON file on every change.
+type FileStore struct {
+	path string
+
+	mu      sync.Mutex
+	records map[uuid.UUID]Record
+}
+
+// fileData is the data stored in the file of a FileStore.
+type fileData struct {
+	Records []Record `json:"records"`
+}
+
+// NewFileStore creates a FileStore that persists records to the file at the path passed. Records that are
+// already stored in the file are loaded.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, records: make(map[uuid.UUID]Record)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var d fileData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	for _, r := range d.Records {
+		s.records[r.UUID] = r
+	}
+	return s, nil
+}
+
+// Load ...
+func (s *FileStore) Load(id uuid.UUID) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[id]
+	return r, ok, nil
+}
+
+// Save ...
+func (s *FileStore) Save(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[r.UUID] = r
+
+	d := fileData{Records: make([]Record, 0, len(s.records))}
+	for _, r := range s.records {
+		d.Records = append(d.Records, r)
+	}
+	data, err := json.MarshalIndent(d, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// ProviderStore is a Store that stores records using a storage.Provider, so that they may be shared by
+// multiple proxies using the same database.
+type ProviderStore struct {
+	p storage.Provider
+}
+
+// recordBucket is the bucket of the storage.Provider in which records are stored.
+const recordBucket = "stats"
+
+// NewProviderStore creates a ProviderStore that stores records using the provider passed.
+func NewProviderStore(p storage.Provider) *ProviderStore {
+	return &ProviderStore{p: p}
+}
+
+// Load ...
+func (s *ProviderStore) Load(id uuid.UUID) (Record, bool, error) {
+	var r Record
+	ok, err := storage.Load(s.p, recordBucket, id.String(), &r)
+	return r, ok, err
+}
+
+// Save ...
+func (s *ProviderStore) Save(r Record) error {
+	return storage.Save(s.p, recordBucket, r.UUID.String(), r)
+}
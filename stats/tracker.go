@@ -0,0 +1,183 @@
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"go.uber.org/atomic"
+)
+
+// Tracker tracks the statistics of the players on the proxy and persists them to a Store when they leave. Its
+// handler must be added to sessions using portal.Handle.
+type Tracker struct {
+	store Store
+	log   internal.Logger
+
+	mu     sync.Mutex
+	active map[uuid.UUID]*activity
+}
+
+// activity holds the statistics of a player that is currently online.
+type activity struct {
+	s      *session.Session
+	record Record
+	// server is the name of the server the player is on, and since is the time at which the player joined it.
+	server string
+	since  time.Time
+}
+
+// NewTracker returns a new Tracker which persists statistics to the store passed. Errors that occur while
+// persisting statistics are logged to the logger passed.
+func NewTracker(store Store, log internal.Logger) *Tracker {
+	return &Tracker{store: store, log: log, active: make(map[uuid.UUID]*activity)}
+}
+
+// Record returns the statistics of the player with the UUID passed. If the player is online, the time spent on
+// the proxy so far is included. If the player has no statistics, false is returned.
+func (t *Tracker) Record(id uuid.UUID) (Record, bool, error) {
+	t.mu.Lock()
+	a, ok := t.active[id]
+	if ok {
+		r := a.current(time.Now())
+		t.mu.Unlock()
+		return r, true, nil
+	}
+	t.mu.Unlock()
+	return t.store.Load(id)
+}
+
+// Playtime returns the total time the player with the UUID passed spent on the proxy.
+func (t *Tracker) Playtime(id uuid.UUID) (time.Duration, error) {
+	r, _, err := t.Record(id)
+	return r.Playtime, err
+}
+
+// Handler returns a function that creates a session.Handler which tracks the statistics of the session. The
+// function returned may be passed to portal.Handle directly.
+func (t *Tracker) Handler() func(s *session.Session) session.Handler {
+	return func(s *session.Session) session.Handler {
+		return &handler{t: t, s: s}
+	}
+}
+
+// join starts tracking the session passed, which joined the server passed.
+func (t *Tracker) join(s *session.Session, srv *server.Server) {
+	r, _, err := t.store.Load(s.UUID())
+	if err != nil {
+		t.log.Errorf("failed to load statistics of %s: %v", s.IdentityData().DisplayName, err)
+	}
+	r.UUID, r.Name = s.UUID(), s.IdentityData().DisplayName
+	r.Joins++
+	if r.Servers == nil {
+		r.Servers = make(map[string]time.Duration)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active[s.UUID()] = &activity{s: s, record: r, server: srv.Name(), since: time.Now()}
+}
+
+// move records the time the session passed spent on its previous server, as it moved to the server passed.
+func (t *Tracker) move(s *session.Session, srv *server.Server) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if a, ok := t.active[s.UUID()]; ok && a.s == s {
+		now := time.Now()
+		a.record = a.current(now)
+		a.server, a.since = srv.Name(), now
+	}
+}
+
+// quit stops tracking the session passed and persists its statistics.
+func (t *Tracker) quit(s *session.Session) {
+	t.mu.Lock()
+	a, ok := t.active[s.UUID()]
+	if !ok || a.s != s {
+		// The player may have joined again with a new session before this one was closed.
+		t.mu.Unlock()
+		return
+	}
+	delete(t.active, s.UUID())
+	t.mu.Unlock()
+	now := time.Now()
+	r := a.current(now)
+	r.LastSeen = now
+	if err := t.store.Save(r); err != nil {
+		t.log.Errorf("failed to save statistics of %s: %v", s.IdentityData().DisplayName, err)
+	}
+}
+
+// Close persists the statistics of the players that are still online and stops tracking them. It should be
+// called when the proxy shuts down, so that the statistics of players whose sessions were not closed are kept.
+func (t *Tracker) Close() error {
+	t.mu.Lock()
+	active := t.active
+	t.active = make(map[uuid.UUID]*activity)
+	t.mu.Unlock()
+
+	now := time.Now()
+	var err error
+	for _, a := range active {
+		r := a.current(now)
+		r.LastSeen = now
+		if saveErr := t.store.Save(r); saveErr != nil && err == nil {
+			err = fmt.Errorf("save statistics of %s: %w", r.Name, saveErr)
+		}
+	}
+	return err
+}
+
+// current returns the record of the activity with the time spent on the current server up to the time passed
+// added to it.
+func (a *activity) current(now time.Time) Record {
+	r := a.record
+	elapsed := now.Sub(a.since)
+	r.Playtime += elapsed
+	r.Servers = make(map[string]time.Duration, len(a.record.Servers)+1)
+	for name, d := range a.record.Servers {
+		r.Servers[name] = d
+	}
+	r.Servers[a.server] += elapsed
+	return r
+}
+
+// handler is the session.Handler of a Tracker for a single session.
+type handler struct {
+	session.NopHandler
+	t *Tracker
+	s *session.Session
+
+	joined atomic.Bool
+	// pending is the server the session last connected to. The session moves to it once the connection is
+	// changed.
+	pending atomic.Value
+}
+
+// HandleServerConnect ...
+func (h *handler) HandleServerConnect(srv *server.Server, _ *minecraft.Conn) {
+	if h.joined.CAS(false, true) {
+		h.t.join(h.s, srv)
+		return
+	}
+	h.pending.Store(srv)
+}
+
+// HandleChangeConn ...
+func (h *handler) HandleChangeConn(*minecraft.Conn) {
+	// The server of the session may not be retrieved here, as the session holds its lock while changing its
+	// connection.
+	if srv, ok := h.pending.Load().(*server.Server); ok {
+		h.t.move(h.s, srv)
+	}
+}
+
+// HandleQuit ...
+func (h *handler) HandleQuit(session.CloseReason) {
+	h.t.quit(h.s)
+}
@@ -7,7 +7,8 @@ import (
 
 // MOTDStatusProvider represents a status provider that shows a custom MOTD which can be changed at any time.
 type MOTDStatusProvider struct {
-	motd atomic.String
+	motd    atomic.String
+	network atomic.Pointer[NetworkPlayerCount]
 }
 
 // NewMOTDStatusProvider creates a new server status provider which shows a custom message in the server list.
@@ -22,8 +23,20 @@ func (p *MOTDStatusProvider) MOTD(v string) {
 	p.motd.Store(v)
 }
 
+// SetNetworkPlayerCount sets the NetworkPlayerCount of which the player count is shown instead of that of the
+// proxy, so that the server list shows the player count of the whole network. If nil, the player count of the
+// proxy is shown.
+func (p *MOTDStatusProvider) SetNetworkPlayerCount(n *NetworkPlayerCount) {
+	p.network.Store(n)
+}
+
 // ServerStatus ...
 func (p *MOTDStatusProvider) ServerStatus(playerCount, maxPlayers int) minecraft.ServerStatus {
+	if n := p.network.Load(); n != nil {
+		if players, networkMax, ok := n.Counts(); ok {
+			playerCount, maxPlayers = players, networkMax
+		}
+	}
 	return minecraft.ServerStatus{
 		ServerName:  p.motd.Load(),
 		PlayerCount: playerCount,
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect holds the differences in the SQL used by the databases supported by SQL.
+type Dialect struct {
+	// Name is the name of the dialect.
+	Name string
+	// BlobType is the column type used to store values.
+	BlobType string
+	// NumberedParams is if parameters are written as $1, $2 and so on, instead of ?.
+	NumberedParams bool
+}
+
+var (
+	// SQLite is the dialect of SQLite databases.
+	SQLite = Dialect{Name: "sqlite", BlobType: "BLOB"}
+	// Postgres is the dialect of PostgreSQL databases.
+	Postgres = Dialect{Name: "postgres", BlobType: "BYTEA", NumberedParams: true}
+)
+
+// DialectOf returns the dialect used by the database/sql driver with the name passed. If the driver is not
+// known, false is returned.
+func DialectOf(driver string) (Dialect, bool) {
+	switch driver {
+	case "sqlite", "sqlite3":
+		return SQLite, true
+	case "postgres", "pgx":
+		return Postgres, true
+	}
+	return Dialect{}, false
+}
+
+// table is the name of the table in which values are stored.
+const table = "portal_storage"
+
+// SQL is a Provider that stores values in a single table of an SQL database.
+type SQL struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// Open opens the database with the data source name passed using the database/sql driver passed, and returns
+// an SQL provider storing values in it. Portal does not import any drivers itself, so the driver must be
+// registered by importing it, for example modernc.org/sqlite for "sqlite" or github.com/jackc/pgx/v5/stdlib for
+// "pgx".
+func Open(driver, dsn string) (*SQL, error) {
+	dialect, ok := DialectOf(driver)
+	if !ok {
+		return nil, fmt.Errorf("storage: unsupported driver %q", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewSQL(db, dialect)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewSQL returns an SQL provider storing values in the database passed, which uses the dialect passed. The
+// table in which values are stored is created if it does not yet exist.
+func NewSQL(db *sql.DB, dialect Dialect) (*SQL, error) {
+	p := &SQL{db: db, dialect: dialect}
+	_, err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	bucket TEXT NOT NULL,
+	id TEXT NOT NULL,
+	data %s NOT NULL,
+	PRIMARY KEY (bucket, id)
+)`, table, dialect.BlobType))
+	if err != nil {
+		return nil, fmt.Errorf("storage: create table: %w", err)
+	}
+	return p, nil
+}
+
+// Get ...
+func (p *SQL) Get(bucket, key string) ([]byte, bool, error) {
+	var data []byte
+	err := p.db.QueryRow(p.query("SELECT data FROM "+table+" WHERE bucket = ? AND id = ?"), bucket, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Put ...
+func (p *SQL) Put(bucket, key string, value []byte) error {
+	_, err := p.db.Exec(p.query("INSERT INTO "+table+" (bucket, id, data) VALUES (?, ?, ?) ON CONFLICT (bucket, id) DO UPDATE SET data = excluded.data"), bucket, key, value)
+	return err
+}
+
+// Delete ...
+func (p *SQL) Delete(bucket, key string) error {
+	_, err := p.db.Exec(p.query("DELETE FROM "+table+" WHERE bucket = ? AND id = ?"), bucket, key)
+	return err
+}
+
+// Keys ...
+func (p *SQL) Keys(bucket string) ([]string, error) {
+	rows, err := p.db.Query(p.query("SELECT id FROM "+table+" WHERE bucket = ? ORDER BY id"), bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Clear ...
+func (p *SQL) Clear(bucket string) error {
+	_, err := p.db.Exec(p.query("DELETE FROM "+table+" WHERE bucket = ?"), bucket)
+	return err
+}
+
+// Close ...
+func (p *SQL) Close() error {
+	return p.db.Close()
+}
+
+// query rewrites the parameters of the query passed to the form used by the dialect of the provider.
+func (p *SQL) query(q string) string {
+	if !p.dialect.NumberedParams {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/paroxity/portal/storage"
+	_ "modernc.org/sqlite"
+)
+
+// TestSQLite tests that values are stored, listed and removed in an SQLite database, and that they are kept when
+// the database is opened again.
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portal.db")
+	p, err := storage.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	type value struct {
+		Name string `json:"name"`
+	}
+	for _, key := range []string{"b", "a", "c"} {
+		if err := storage.Save(p, "players", key, value{Name: key}); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+	if err := storage.Save(p, "players", "a", value{Name: "replaced"}); err != nil {
+		t.Fatalf("replace a: %v", err)
+	}
+	if err := storage.Save(p, "servers", "lobby", value{Name: "lobby"}); err != nil {
+		t.Fatalf("save lobby: %v", err)
+	}
+	if err := p.Delete("players", "c"); err != nil {
+		t.Fatalf("delete c: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if p, err = storage.Open("sqlite", path); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer p.Close()
+	keys, err := p.Keys("players")
+	if err != nil {
+		t.Fatalf("keys: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Fatalf("expected keys [a b], got %v", keys)
+	}
+	var v value
+	if ok, err := storage.Load(p, "players", "a", &v); err != nil || !ok {
+		t.Fatalf("load a: %v, %v", ok, err)
+	}
+	if v.Name != "replaced" {
+		t.Fatalf("expected a to be replaced, got %q", v.Name)
+	}
+	if ok, err := storage.Load(p, "players", "c", &v); err != nil || ok {
+		t.Fatalf("expected c to be deleted, got %v, %v", ok, err)
+	}
+
+	if err := p.Clear("players"); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if keys, err := p.Keys("players"); err != nil || len(keys) != 0 {
+		t.Fatalf("expected no keys after clear, got %v, %v", keys, err)
+	}
+	if ok, err := storage.Load(p, "servers", "lobby", &v); err != nil || !ok {
+		t.Fatalf("expected other buckets to be kept after clear, got %v, %v", ok, err)
+	}
+}
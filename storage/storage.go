@@ -0,0 +1,44 @@
+// Package storage implements a shared storage backend for the subsystems of the proxy that persist data, such
+// as the whitelist, punishments, statistics and the servers players were last on. A single Provider is
+// configured once and passed to each of them, instead of every subsystem using a file of its own.
+package storage
+
+import (
+	"encoding/json"
+)
+
+// Provider stores values by key in named buckets. Implementations must be safe for concurrent use.
+type Provider interface {
+	// Get returns the value stored under the key passed in the bucket passed. If no value is stored, false is
+	// returned.
+	Get(bucket, key string) ([]byte, bool, error)
+	// Put stores the value passed under the key passed in the bucket passed, replacing any value stored before.
+	Put(bucket, key string, value []byte) error
+	// Delete removes the value stored under the key passed in the bucket passed, if any.
+	Delete(bucket, key string) error
+	// Keys returns the keys of all values stored in the bucket passed.
+	Keys(bucket string) ([]string, error)
+	// Clear removes every value stored in the bucket passed.
+	Clear(bucket string) error
+	// Close closes the provider. It must not be used after it is closed.
+	Close() error
+}
+
+// Load decodes the JSON value stored under the key passed in the bucket passed into v. If no value is stored,
+// false is returned and v is left unchanged.
+func Load(p Provider, bucket, key string, v any) (bool, error) {
+	data, ok, err := p.Get(bucket, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// Save encodes v as JSON and stores it under the key passed in the bucket passed.
+func Save(p Provider, bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return p.Put(bucket, key, data)
+}
@@ -0,0 +1,85 @@
+package portal
+
+import (
+	"sync"
+
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+)
+
+// TransferOptions holds options that control how sessions are transferred by TransferAll.
+type TransferOptions struct {
+	// Concurrency is the maximum number of sessions transferred at the same time. If zero or less, one session
+	// is transferred at a time.
+	Concurrency int
+	// Progress is called after every transfer, successful or not, with the number of transfers done so far and
+	// the total number of transfers. The error passed is nil if the transfer succeeded. Progress may be called
+	// from multiple goroutines at the same time. It may be nil.
+	Progress func(s *session.Session, err error, done, total int)
+}
+
+// TransferFailure is a transfer of a session that failed during TransferAll.
+type TransferFailure struct {
+	// Session is the session that failed to be transferred.
+	Session *session.Session
+	// Err is the error that caused the transfer to fail.
+	Err error
+}
+
+// TransferSummary is a summary of the transfers performed by TransferAll.
+type TransferSummary struct {
+	// Total is the number of sessions that were on the source server when TransferAll was called.
+	Total int
+	// Succeeded is the number of sessions that were transferred successfully.
+	Succeeded int
+	// Failures holds all the transfers that failed.
+	Failures []TransferFailure
+}
+
+// TransferAll transfers every session connected to the server from to the server to, for example to evacuate a
+// server before restarting it. It blocks until all transfers have either succeeded or failed, and returns a
+// summary of the transfers.
+func (p *Portal) TransferAll(from, to *server.Server, opts TransferOptions) TransferSummary {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	var sessions []*session.Session
+	for _, s := range p.sessionStore.All() {
+		if srv, ok := s.TryServer(); ok && srv == from {
+			sessions = append(sessions, s)
+		}
+	}
+
+	summary := TransferSummary{Total: len(sessions)}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, opts.Concurrency)
+	)
+	for _, s := range sessions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(s *session.Session) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := s.Transfer(to)
+
+			mu.Lock()
+			if err != nil {
+				summary.Failures = append(summary.Failures, TransferFailure{Session: s, Err: err})
+			} else {
+				summary.Succeeded++
+			}
+			done := summary.Succeeded + len(summary.Failures)
+			mu.Unlock()
+
+			if opts.Progress != nil {
+				opts.Progress(s, err, done, summary.Total)
+			}
+		}(s)
+	}
+	wg.Wait()
+	return summary
+}
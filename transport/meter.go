@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"go.uber.org/atomic"
+)
+
+// CapPolicy is the policy applied by a Meter when a connection exceeds its bandwidth cap.
+type CapPolicy string
+
+const (
+	// CapThrottle delays reads and writes of the connection until it is within its cap again.
+	CapThrottle CapPolicy = "throttle"
+	// CapKick reports the connection as exceeding its cap, so that the player is disconnected.
+	CapKick CapPolicy = "kick"
+)
+
+// MeterConfig holds the settings of a Meter.
+type MeterConfig struct {
+	// In is the maximum number of bytes per second a connection may send to the proxy. If zero, it is not capped.
+	In int
+	// Out is the maximum number of bytes per second the proxy may send to a connection. If zero, it is not capped.
+	Out int
+	// Policy is the policy applied when a connection exceeds its cap. If empty, CapThrottle is used.
+	Policy CapPolicy
+}
+
+// Counter counts the bytes sent over a single connection, after compression and encryption.
+type Counter struct {
+	in, out atomic.Uint64
+}
+
+// In returns the number of bytes received from the connection.
+func (c *Counter) In() uint64 {
+	return c.in.Load()
+}
+
+// Out returns the number of bytes sent to the connection.
+func (c *Counter) Out() uint64 {
+	return c.out.Load()
+}
+
+// Meter counts the bytes sent over connections accepted by the networks it wraps, and caps their bandwidth.
+// This contains clients flooding the proxy, for example with blob cache misses, and servers flooding a single
+// client. Connections from loopback addresses are neither counted nor capped.
+type Meter struct {
+	conf MeterConfig
+
+	exceeded atomic.Value
+	conns    sync.Map
+}
+
+// NewMeter returns a Meter using the settings passed.
+func NewMeter(conf MeterConfig) *Meter {
+	if conf.Policy == "" {
+		conf.Policy = CapThrottle
+	}
+	return &Meter{conf: conf}
+}
+
+// OnExceeded sets the function called when a connection exceeds its cap while the policy of the meter is
+// CapKick. It is called at most once for every connection.
+func (m *Meter) OnExceeded(f func(addr net.Addr)) {
+	m.exceeded.Store(f)
+}
+
+// Counter returns the Counter of the connection with the remote address passed. If the connection was not
+// accepted by a network wrapped by the meter, or if it is closed, false is returned.
+func (m *Meter) Counter(addr net.Addr) (*Counter, bool) {
+	c, ok := m.conns.Load(addr.String())
+	if !ok {
+		return nil, false
+	}
+	return c.(*meteredConn).c, true
+}
+
+// Wrap returns a minecraft.Network wrapping around the network passed, whose listeners count and cap the
+// bandwidth of the connections they accept. The network returned should be registered using
+// minecraft.RegisterNetwork under the name of the network the proxy listens on.
+func (m *Meter) Wrap(n minecraft.Network) minecraft.Network {
+	return meteredNetwork{Network: n, m: m}
+}
+
+// meteredNetwork is a minecraft.Network whose listeners are wrapped by a Meter.
+type meteredNetwork struct {
+	minecraft.Network
+	m *Meter
+}
+
+// Listen ...
+func (n meteredNetwork) Listen(address string) (minecraft.NetworkListener, error) {
+	l, err := n.Network.Listen(address)
+	if err != nil {
+		return nil, err
+	}
+	return meteredListener{NetworkListener: l, m: n.m}, nil
+}
+
+// meteredListener is a minecraft.NetworkListener that wraps the connections it accepts so that they are metered.
+type meteredListener struct {
+	minecraft.NetworkListener
+	m *Meter
+}
+
+// Accept ...
+func (l meteredListener) Accept() (net.Conn, error) {
+	conn, err := l.NetworkListener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if host, _, err := net.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return conn, nil
+		}
+	}
+	c := &meteredConn{Conn: conn, m: l.m, c: &Counter{}, in: newBucket(l.m.conf.In), out: newBucket(l.m.conf.Out)}
+	l.m.conns.Store(conn.RemoteAddr().String(), c)
+	return c, nil
+}
+
+// meteredConn is a connection whose bandwidth is counted and capped by a Meter. It forwards the methods that
+// gophertunnel looks for on connections of the network it wraps.
+type meteredConn struct {
+	net.Conn
+	m       *Meter
+	c       *Counter
+	in, out *bucket
+
+	exceeded sync.Once
+}
+
+// ReadPacket reads a packet from the connection. If the connection wrapped does not read full packets, a
+// single read is done instead.
+func (c *meteredConn) ReadPacket() ([]byte, error) {
+	var data []byte
+	if r, ok := c.Conn.(interface{ ReadPacket() ([]byte, error) }); ok {
+		b, err := r.ReadPacket()
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	} else {
+		b := make([]byte, maxFrameSize)
+		n, err := c.Conn.Read(b)
+		if err != nil {
+			return nil, err
+		}
+		data = b[:n]
+	}
+	c.c.in.Add(uint64(len(data)))
+	c.limit(c.in, len(data))
+	return data, nil
+}
+
+// Read ...
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.c.in.Add(uint64(n))
+	c.limit(c.in, n)
+	return n, err
+}
+
+// Write ...
+func (c *meteredConn) Write(b []byte) (int, error) {
+	c.limit(c.out, len(b))
+	n, err := c.Conn.Write(b)
+	c.c.out.Add(uint64(n))
+	return n, err
+}
+
+// Latency returns the latency of the connection wrapped, or zero if it does not measure its latency.
+func (c *meteredConn) Latency() time.Duration {
+	if l, ok := c.Conn.(interface{ Latency() time.Duration }); ok {
+		return l.Latency()
+	}
+	return 0
+}
+
+// Close ...
+func (c *meteredConn) Close() error {
+	c.m.conns.Delete(c.RemoteAddr().String())
+	return c.Conn.Close()
+}
+
+// limit takes n bytes from the bucket passed and applies the policy of the meter if the bucket is exhausted.
+func (c *meteredConn) limit(b *bucket, n int) {
+	if b == nil {
+		return
+	}
+	wait := b.take(n)
+	if wait <= 0 {
+		return
+	}
+	if c.m.conf.Policy == CapKick {
+		c.exceeded.Do(func() {
+			if f, ok := c.m.exceeded.Load().(func(addr net.Addr)); ok {
+				go f(c.RemoteAddr())
+			}
+		})
+		return
+	}
+	time.Sleep(wait)
+}
+
+// bucket is a token bucket holding at most one second worth of bytes.
+type bucket struct {
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newBucket returns a bucket filling up at the rate passed in bytes per second. If the rate is zero or less,
+// nil is returned.
+func newBucket(rate int) *bucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &bucket{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// take takes n bytes from the bucket and returns the time to wait before the bucket is no longer exhausted.
+func (b *bucket) take(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,201 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sandertv/go-raknet"
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// Pool is a minecraft.Network that keeps a pool of pre-established RakNet connections for every address dialed
+// through it. RakNet connections are not authenticated until the Minecraft login sequence starts, so a pooled
+// connection may be used for any player. Dialing through the pool skips the RakNet handshake, which otherwise
+// dominates the time it takes to transfer a player under load.
+type Pool struct {
+	size    int
+	maxIdle time.Duration
+
+	mu     sync.Mutex
+	pools  map[string]*addressPool
+	closed bool
+	stop   chan struct{}
+}
+
+// pooledConn is an idle connection in a Pool.
+type pooledConn struct {
+	conn    *raknet.Conn
+	created time.Time
+}
+
+// addressPool holds the idle connections of a Pool to a single address.
+type addressPool struct {
+	mu     sync.Mutex
+	conns  []pooledConn
+	closed bool
+	wake   chan struct{}
+}
+
+// NewPool creates a new Pool that keeps size idle connections for every address dialed. Idle connections that
+// are older than maxIdle are closed and replaced, as servers may drop connections that do not log in. If maxIdle
+// is zero or less, it defaults to ten seconds.
+func NewPool(size int, maxIdle time.Duration) *Pool {
+	if maxIdle <= 0 {
+		maxIdle = time.Second * 10
+	}
+	return &Pool{size: size, maxIdle: maxIdle, pools: make(map[string]*addressPool), stop: make(chan struct{})}
+}
+
+// Close stops filling the pools of connections and closes all idle connections. Dialing through the Pool after
+// closing it dials new connections without pooling them.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.stop)
+	for _, a := range p.pools {
+		a.close()
+	}
+	return nil
+}
+
+// Warm starts keeping a pool of connections for the address passed, so that even the first dial to the address
+// is able to use a pooled connection.
+func (p *Pool) Warm(address string) {
+	p.pool(address)
+}
+
+// DialContext ...
+func (p *Pool) DialContext(ctx context.Context, address string) (net.Conn, error) {
+	if conn, ok := p.pool(address).take(p.maxIdle); ok {
+		return conn, nil
+	}
+	return raknet.DialContext(ctx, address)
+}
+
+// PingContext ...
+func (p *Pool) PingContext(ctx context.Context, address string) ([]byte, error) {
+	return raknet.PingContext(ctx, address)
+}
+
+// Listen ...
+func (p *Pool) Listen(address string) (minecraft.NetworkListener, error) {
+	return raknet.Listen(address)
+}
+
+// pool returns the pool of connections for the address passed, creating and starting to fill it if it did not
+// yet exist. If the Pool is closed, an empty pool is returned.
+func (p *Pool) pool(address string) *addressPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return &addressPool{closed: true}
+	}
+	a, ok := p.pools[address]
+	if !ok {
+		a = &addressPool{wake: make(chan struct{}, 1)}
+		p.pools[address] = a
+		go p.fill(address, a)
+	}
+	return a
+}
+
+// fill keeps the pool of connections passed filled with fresh connections to the address passed, until the Pool
+// is closed.
+func (p *Pool) fill(address string, a *addressPool) {
+	t := time.NewTicker(p.maxIdle / 2)
+	defer t.Stop()
+	for {
+		a.expire(p.maxIdle)
+		for a.len() < p.size {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			conn, err := raknet.DialContext(ctx, address)
+			cancel()
+			if err != nil {
+				// The server is probably down. We try again on the next tick instead of hammering it.
+				break
+			}
+			if !a.put(conn) {
+				// The Pool was closed while dialing.
+				return
+			}
+		}
+		select {
+		case <-t.C:
+		case <-a.wake:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// take takes a connection that is not older than maxIdle out of the pool. If no such connection is available,
+// false is returned.
+func (a *addressPool) take(maxIdle time.Duration) (*raknet.Conn, bool) {
+	a.expire(maxIdle)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.conns) == 0 {
+		return nil, false
+	}
+	c := a.conns[0]
+	a.conns = a.conns[1:]
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return c.conn, true
+}
+
+// put adds a connection to the pool. If the pool is closed, the connection is closed instead and false is
+// returned.
+func (a *addressPool) put(conn *raknet.Conn) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		_ = conn.Close()
+		return false
+	}
+	a.conns = append(a.conns, pooledConn{conn: conn, created: time.Now()})
+	return true
+}
+
+// close closes all idle connections in the pool, and any connection put in it afterwards.
+func (a *addressPool) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	for _, c := range a.conns {
+		_ = c.conn.Close()
+	}
+	a.conns = nil
+}
+
+// len returns the number of idle connections in the pool.
+func (a *addressPool) len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.conns)
+}
+
+// expire closes and removes all connections in the pool that are older than maxIdle.
+func (a *addressPool) expire(maxIdle time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for _, c := range a.conns {
+		if time.Since(c.created) > maxIdle {
+			_ = c.conn.Close()
+			continue
+		}
+		a.conns[n] = c
+		n++
+	}
+	a.conns = a.conns[:n]
+}
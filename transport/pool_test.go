@@ -0,0 +1,66 @@
+package transport_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/paroxity/portal/transport"
+	"github.com/sandertv/go-raknet"
+)
+
+// TestPoolClose tests that a closed Pool stops dialing connections to keep its pools filled, and dials new
+// connections without pooling them.
+func TestPoolClose(t *testing.T) {
+	l, err := raknet.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+	accepted := make(chan net.Conn, 16)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	pool := transport.NewPool(2, time.Minute)
+	pool.Warm(l.Addr().String())
+	var conns []net.Conn
+	for len(conns) < 2 {
+		select {
+		case conn := <-accepted:
+			conns = append(conns, conn)
+		case <-time.After(time.Second * 5):
+			t.Fatalf("expected pool to dial 2 connections, got %d", len(conns))
+		}
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	select {
+	case <-accepted:
+		t.Fatalf("expected closed pool not to dial new connections")
+	case <-time.After(time.Millisecond * 200):
+	}
+
+	// Dialing through a closed pool still works, but dials a new connection.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	conn, err := pool.DialContext(ctx, l.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	select {
+	case <-accepted:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("expected closed pool to dial a new connection")
+	}
+}
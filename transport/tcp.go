@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// maxFrameSize is the maximum size of a single frame read from a TCP connection. Frames exceeding this size
+// result in the connection being closed.
+const maxFrameSize = 1024 * 1024 * 8
+
+// TCP is a minecraft.Network implementation that carries Minecraft packets over a plain TCP connection. It
+// is intended for links between the proxy and servers running in the same datacenter, where the reliability
+// layer of RakNet only adds overhead. Each batch written to the connection is prefixed with its length so
+// that it can be read back as a single packet.
+type TCP struct{}
+
+// DialContext ...
+func (TCP) DialContext(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return newFramedConn(conn), nil
+}
+
+// PingContext ...
+func (TCP) PingContext(context.Context, string) ([]byte, error) {
+	return nil, errors.New("tcp: pinging is not supported")
+}
+
+// Listen ...
+func (TCP) Listen(address string) (minecraft.NetworkListener, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpListener{Listener: l, id: rand.Int63()}, nil
+}
+
+// tcpListener is a minecraft.NetworkListener which wraps accepted TCP connections so that they are framed.
+type tcpListener struct {
+	net.Listener
+	id int64
+}
+
+// Accept ...
+func (l *tcpListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newFramedConn(conn), nil
+}
+
+// Addr returns the address of the listener as a *net.UDPAddr, as gophertunnel expects the address of every
+// listener to be one when building its pong data.
+func (l *tcpListener) Addr() net.Addr {
+	addr := l.Listener.Addr().(*net.TCPAddr)
+	return &net.UDPAddr{IP: addr.IP, Port: addr.Port, Zone: addr.Zone}
+}
+
+// ID ...
+func (l *tcpListener) ID() int64 {
+	return l.id
+}
+
+// PongData ...
+func (l *tcpListener) PongData([]byte) {}
+
+// framedConn wraps around a net.Conn to prefix every write with its length, and to read back exactly one
+// frame per read. This makes a stream connection behave like the packet based connections gophertunnel
+// expects.
+type framedConn struct {
+	net.Conn
+
+	readMu sync.Mutex
+
+	writeMu sync.Mutex
+	buf     bytes.Buffer
+}
+
+// newFramedConn returns a framedConn wrapping around the net.Conn passed.
+func newFramedConn(conn net.Conn) *framedConn {
+	return &framedConn{Conn: conn}
+}
+
+// ReadPacket reads a full frame from the connection and returns it.
+func (c *framedConn) ReadPacket() ([]byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	var l uint32
+	if err := binary.Read(c.Conn, binary.LittleEndian, &l); err != nil {
+		return nil, err
+	}
+	if l > maxFrameSize {
+		_ = c.Conn.Close()
+		return nil, fmt.Errorf("tcp: frame size %v exceeds maximum of %v", l, maxFrameSize)
+	}
+	data := make([]byte, l)
+	if _, err := io.ReadFull(c.Conn, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Read reads a full frame into b. If b is too small to hold the frame, the error io.ErrShortBuffer is
+// returned.
+func (c *framedConn) Read(b []byte) (int, error) {
+	data, err := c.ReadPacket()
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(b) {
+		return 0, io.ErrShortBuffer
+	}
+	return copy(b, data), nil
+}
+
+// Write writes b to the connection as a single frame.
+func (c *framedConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.buf.Reset()
+	_ = binary.Write(&c.buf, binary.LittleEndian, uint32(len(b)))
+	c.buf.Write(b)
+	if _, err := c.Conn.Write(c.buf.Bytes()); err != nil {
+		// gophertunnel panics on write errors other than those of closed connections, such as a connection
+		// reset by the peer, so a connection that failed to write is closed and reported as such.
+		_ = c.Conn.Close()
+		return 0, fmt.Errorf("tcp: %w: %v", net.ErrClosed, err)
+	}
+	return len(b), nil
+}
@@ -0,0 +1,47 @@
+package transport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paroxity/portal/transport"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+)
+
+// TestTCPLogin tests that a client is able to log in and spawn on a listener over TCP.
+func TestTCPLogin(t *testing.T) {
+	l, err := minecraft.ListenConfig{AuthenticationDisabled: true}.Listen(transport.NetworkTCP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+
+	spawned := make(chan error, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			spawned <- err
+			return
+		}
+		defer conn.Close()
+		spawned <- conn.(*minecraft.Conn).StartGameTimeout(minecraft.GameData{WorldName: "tcp"}, time.Second*10)
+	}()
+
+	conn, err := minecraft.Dialer{
+		IdentityData: login.IdentityData{DisplayName: "Steve"},
+	}.DialTimeout(transport.NetworkTCP, l.Addr().String(), time.Second*10)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.DoSpawnTimeout(time.Second * 10); err != nil {
+		t.Fatalf("spawn: %v", err)
+	}
+	if err := <-spawned; err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	if name := conn.GameData().WorldName; name != "tcp" {
+		t.Fatalf("expected world name %q, got %q", "tcp", name)
+	}
+}
@@ -0,0 +1,23 @@
+// Package transport implements additional networks that may be used by the proxy to connect to servers or
+// to accept connections from clients, next to the RakNet network provided by gophertunnel. The TCP network is
+// registered with gophertunnel when the package is imported, so that it may be selected by its name. A Pool
+// keeps goroutines running, so it is only registered by programs that create one.
+package transport
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+const (
+	// NetworkRakNet is the name of the default network used by Minecraft clients and servers.
+	NetworkRakNet = "raknet"
+	// NetworkTCP is the name under which the TCP network is registered.
+	NetworkTCP = "tcp"
+	// NetworkPooledRakNet is the name under which a Pool of RakNet connections should be registered using
+	// minecraft.RegisterNetwork.
+	NetworkPooledRakNet = "raknet_pooled"
+)
+
+func init() {
+	minecraft.RegisterNetwork(NetworkTCP, TCP{})
+}
@@ -0,0 +1,114 @@
+package portal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DefaultVersionMessage is the message shown to players of which the version is rejected by a VersionGate, if
+// the gate has no message set.
+const DefaultVersionMessage = "{{if .Outdated}}Please update Minecraft to {{.Min}} or newer to join.{{else}}Minecraft {{.Version}} is not supported yet, please use {{.Max}} to join.{{end}}"
+
+// VersionGate rejects players of which the version of the client is outside a range, so that they are shown a
+// clear message before any server is dialed instead of being rejected by the server they are sent to.
+type VersionGate struct {
+	min, max string
+	message  *template.Template
+}
+
+// VersionData is the data passed to the message template of a VersionGate.
+type VersionData struct {
+	// Version is the version of the client of the player, such as "1.20.40".
+	Version string
+	// Min and Max are the minimum and maximum versions allowed by the gate. Either may be empty if the gate has
+	// no bound on that side.
+	Min, Max string
+	// Outdated is true if the version of the player is below the minimum version, and false if it is above the
+	// maximum version.
+	Outdated bool
+}
+
+// NewVersionGate creates a VersionGate which allows versions from min to max. Either may be empty to have no
+// bound on that side. Components of the maximum version that are left out or set to "x" match any value, so
+// that a maximum of "1.21.x" allows every version of 1.21. The message passed is a text/template executed with
+// VersionData. If it is empty, DefaultVersionMessage is used.
+func NewVersionGate(min, max, message string) (*VersionGate, error) {
+	for _, v := range []string{min, max} {
+		if _, err := parseVersion(v); err != nil {
+			return nil, err
+		}
+	}
+	if message == "" {
+		message = DefaultVersionMessage
+	}
+	t, err := template.New("version").Parse(message)
+	if err != nil {
+		return nil, fmt.Errorf("parse version message: %w", err)
+	}
+	return &VersionGate{min: min, max: max, message: t}, nil
+}
+
+// Allow returns if the version of a client passed is allowed by the gate. If it is not, the message shown to the
+// player is returned. Versions that cannot be parsed are allowed, as they are rejected by servers if needed.
+func (g *VersionGate) Allow(version string) (bool, string) {
+	v, err := parseVersion(version)
+	if err != nil || v == nil {
+		return true, ""
+	}
+	data := VersionData{Version: version, Min: g.min, Max: g.max}
+	if minimum, _ := parseVersion(g.min); minimum != nil && compareVersion(v, minimum) < 0 {
+		data.Outdated = true
+	} else if maximum, _ := parseVersion(g.max); maximum == nil || compareVersion(v, maximum) <= 0 {
+		return true, ""
+	}
+	var b strings.Builder
+	if err := g.message.Execute(&b, data); err != nil {
+		return false, DefaultVersionMessage
+	}
+	return false, b.String()
+}
+
+// parseVersion parses a version such as "1.20.40" into its components. Components set to "x" are returned as -1.
+// An empty version is returned as nil.
+func parseVersion(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ".")
+	v := make([]int, len(parts))
+	for i, p := range parts {
+		if p == "x" || p == "*" {
+			v[i] = -1
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// compareVersion compares the version a to the bound b, returning -1 if a is lower, 1 if a is higher and 0 if
+// they are equal. Components of b that are left out or set to -1 are equal to any component of a.
+func compareVersion(a, b []int) int {
+	for i, n := range b {
+		if n == -1 {
+			return 0
+		}
+		var c int
+		if i < len(a) {
+			c = a[i]
+		}
+		if c < n {
+			return -1
+		}
+		if c > n {
+			return 1
+		}
+	}
+	return 0
+}
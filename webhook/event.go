@@ -0,0 +1,33 @@
+package webhook
+
+import "time"
+
+// EventType is the type of operational event that a webhook may be notified of.
+type EventType string
+
+const (
+	// EventStart is sent when the proxy starts listening for players.
+	EventStart EventType = "start"
+	// EventStop is sent when the proxy is shutting down.
+	EventStop EventType = "stop"
+	// EventServerHealth is sent when a server becomes healthy or unhealthy.
+	EventServerHealth EventType = "server_health"
+	// EventPlayerThreshold is sent when the player count of the proxy rises above or drops below a threshold.
+	EventPlayerThreshold EventType = "player_threshold"
+	// EventTransferFailures is sent when transfers to a server fail repeatedly within a short time.
+	EventTransferFailures EventType = "transfer_failures"
+)
+
+// Event is an operational event sent to webhooks. It is passed to the templates of webhooks as data, so all
+// of its fields may be used in templates.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType `json:"type"`
+	// Message is a short human-readable description of the event.
+	Message string `json:"message"`
+	// Time is the time at which the event occurred.
+	Time time.Time `json:"time"`
+	// Fields holds additional data specific to the type of the event, such as the name of the server for
+	// EventServerHealth.
+	Fields map[string]any `json:"fields,omitempty"`
+}
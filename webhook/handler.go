@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/server"
+	"github.com/paroxity/portal/session"
+)
+
+// TransferFailures keeps track of failed transfers to each server and sends an EventTransferFailures event once
+// the transfers to a server fail a certain number of times within a window.
+type TransferFailures struct {
+	n      *Notifier
+	limit  int
+	window time.Duration
+
+	mu       sync.Mutex
+	failures map[string][]time.Time
+}
+
+// NewTransferFailures creates a new TransferFailures that notifies the Notifier passed once limit transfers to
+// the same server have failed within the window passed.
+func NewTransferFailures(n *Notifier, limit int, window time.Duration) *TransferFailures {
+	return &TransferFailures{n: n, limit: limit, window: window, failures: make(map[string][]time.Time)}
+}
+
+// Handler returns a function that creates a session.Handler which reports failed transfers of the session. The
+// function returned may be passed to portal.Handle directly.
+func (t *TransferFailures) Handler() func(s *session.Session) session.Handler {
+	return func(*session.Session) session.Handler {
+		return &transferHandler{t: t}
+	}
+}
+
+// add records a failed transfer to the server passed.
+func (t *TransferFailures) add(srv *server.Server, err error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	failures := t.failures[srv.Name()]
+	n := 0
+	for _, f := range failures {
+		if now.Sub(f) < t.window {
+			failures[n] = f
+			n++
+		}
+	}
+	failures = append(failures[:n], now)
+	reached := len(failures) >= t.limit
+	if reached {
+		failures = failures[:0]
+	}
+	t.failures[srv.Name()] = failures
+	t.mu.Unlock()
+
+	if reached {
+		t.n.Notify(EventTransferFailures, fmt.Sprintf("%d transfers to %s failed within %s, last error: %v", t.limit, srv.Name(), t.window, err), map[string]any{
+			"server": srv.Name(),
+			"count":  t.limit,
+			"window": t.window.String(),
+			"error":  err.Error(),
+		})
+	}
+}
+
+// transferHandler is a session.Handler that reports failed transfers to a TransferFailures.
+type transferHandler struct {
+	session.NopHandler
+	t *TransferFailures
+}
+
+// HandleTransferFailure ...
+func (h *transferHandler) HandleTransferFailure(srv *server.Server, err error) {
+	h.t.add(srv, err)
+}
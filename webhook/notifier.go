@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/paroxity/portal/internal"
+	"github.com/paroxity/portal/server"
+)
+
+// Notifier sends operational events to a set of webhooks. Events are sent asynchronously, so notifying does
+// not block the caller.
+type Notifier struct {
+	log    internal.Logger
+	hooks  []*Webhook
+	client *http.Client
+
+	wg sync.WaitGroup
+}
+
+// NewNotifier creates a new Notifier that sends events to the webhooks passed. Errors that occur while sending
+// events are logged to the logger passed.
+func NewNotifier(log internal.Logger, hooks ...*Webhook) *Notifier {
+	return &Notifier{
+		log:    log,
+		hooks:  hooks,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+// Notify sends an event of the type passed with the message and fields passed to all webhooks that accept it.
+func (n *Notifier) Notify(t EventType, message string, fields map[string]any) {
+	e := Event{Type: t, Message: message, Time: time.Now(), Fields: fields}
+	for _, w := range n.hooks {
+		if !w.Accepts(t) {
+			continue
+		}
+		n.wg.Add(1)
+		go func(w *Webhook) {
+			defer n.wg.Done()
+			if err := n.send(w, e); err != nil {
+				n.log.Errorf("failed to send %s event to webhook: %v", t, err)
+			}
+		}(w)
+	}
+}
+
+// Wait blocks until all events that are currently being sent have been sent. It should be called before the
+// program exits to make sure events such as EventStop are delivered.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// send sends the event passed to a single webhook.
+func (n *Notifier) send(w *Webhook, e Event) error {
+	body, err := w.Payload(e)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(w.URL(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// ServerHealthChanged may be registered to a server.HealthChecker using OnChange to send an EventServerHealth
+// event when the health of a server changes.
+func (n *Notifier) ServerHealthChanged(srv *server.Server, healthy bool, err error) {
+	fields := map[string]any{"server": srv.Name(), "address": srv.Address(), "healthy": healthy}
+	if healthy {
+		n.Notify(EventServerHealth, fmt.Sprintf("Server %s is healthy again", srv.Name()), fields)
+		return
+	}
+	fields["error"] = err.Error()
+	n.Notify(EventServerHealth, fmt.Sprintf("Server %s is unhealthy: %v", srv.Name(), err), fields)
+}
+
+// WatchPlayerCount checks the player count returned by the function passed every interval, and sends an
+// EventPlayerThreshold event every time it rises to or above, or drops below, one of the thresholds passed.
+// It blocks forever, so it should generally be called in a separate goroutine.
+func (n *Notifier) WatchPlayerCount(count func() int, thresholds []int, interval time.Duration) {
+	thresholds = append([]int(nil), thresholds...)
+	sort.Ints(thresholds)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	last := count()
+	for range t.C {
+		current := count()
+		for _, threshold := range thresholds {
+			fields := map[string]any{"threshold": threshold, "count": current}
+			if last < threshold && current >= threshold {
+				n.Notify(EventPlayerThreshold, fmt.Sprintf("Player count reached %d (%d online)", threshold, current), fields)
+			} else if last >= threshold && current < threshold {
+				n.Notify(EventPlayerThreshold, fmt.Sprintf("Player count dropped below %d (%d online)", threshold, current), fields)
+			}
+		}
+		last = current
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+const (
+	// FormatJSON is the format of webhooks that receive the event as a generic JSON object.
+	FormatJSON = "json"
+	// FormatDiscord is the format of webhooks that are Discord webhooks, which receive the message of the
+	// event as the content of a Discord message.
+	FormatDiscord = "discord"
+)
+
+// defaultTemplates holds the templates used for each format if a webhook has no template set.
+var defaultTemplates = map[string]string{
+	FormatJSON:    `{{json .}}`,
+	FormatDiscord: `{"content": {{json .Message}}}`,
+}
+
+// Webhook is an HTTP endpoint that is notified of operational events. The body sent to the endpoint is
+// produced by executing a template with the Event as data.
+type Webhook struct {
+	url    string
+	events map[EventType]struct{}
+	tmpl   *template.Template
+}
+
+// New creates a new Webhook that sends events to the URL passed. The format passed decides the default
+// payload and must be either FormatJSON or FormatDiscord. If events is empty, the webhook is notified of all
+// events. If tmpl is not empty, it is used as text/template to produce the body instead of the default payload
+// of the format. The function "json" is available in templates to encode a value as JSON.
+func New(url, format string, events []EventType, tmpl string) (*Webhook, error) {
+	if tmpl == "" {
+		var ok bool
+		if tmpl, ok = defaultTemplates[format]; !ok {
+			return nil, fmt.Errorf("unknown webhook format %q", format)
+		}
+	}
+	t, err := template.New(url).Funcs(template.FuncMap{"json": marshal}).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("parse webhook template: %w", err)
+	}
+	w := &Webhook{url: url, tmpl: t}
+	if len(events) > 0 {
+		w.events = make(map[EventType]struct{}, len(events))
+		for _, e := range events {
+			w.events[e] = struct{}{}
+		}
+	}
+	return w, nil
+}
+
+// URL returns the URL that the webhook sends events to.
+func (w *Webhook) URL() string {
+	return w.url
+}
+
+// Accepts returns if the webhook should be notified of events of the type passed.
+func (w *Webhook) Accepts(t EventType) bool {
+	if w.events == nil {
+		return true
+	}
+	_, ok := w.events[t]
+	return ok
+}
+
+// Payload executes the template of the webhook for the event passed and returns the body to send.
+func (w *Webhook) Payload(e Event) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.tmpl.Execute(&buf, e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// marshal encodes v as JSON for use in templates.
+func marshal(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}